@@ -0,0 +1,374 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dynamicroutingconnector // import "github.com/elastic/opentelemetry-collector-components/connector/dynamicroutingconnector"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/pipeline"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/sharedcomponent"
+)
+
+// adminServers holds the admin servers per connector ID, so that the routers
+// of all signals of a connector share the same server.
+var adminServers = sharedcomponent.NewMap[component.ID, *adminServer]()
+
+var errUnknownCardinalityBucket = errors.New("unknown cardinality bucket")
+
+// adminRouter is the signal independent view of a router used by the admin
+// server.
+type adminRouter interface {
+	status() routerStatus
+	pin(pk, cardinalityBucket string, ttl time.Duration) error
+	unpin(pk string) bool
+}
+
+// routerStatus is the JSON representation of the state of a router.
+type routerStatus struct {
+	Thresholds []thresholdStatus `json:"thresholds"`
+	Decisions  []decisionStatus  `json:"decisions"`
+	// Estimates holds the cardinality estimates of the current recording
+	// window, per partition key.
+	Estimates map[string]uint64 `json:"estimates"`
+}
+
+// thresholdStatus is the JSON representation of a routing pipeline.
+// Thresholds without a limit are omitted.
+type thresholdStatus struct {
+	CardinalityBucket   string   `json:"cardinality_bucket"`
+	Pipelines           []string `json:"pipelines"`
+	MaxCardinality      *float64 `json:"max_cardinality,omitempty"`
+	MaxRecordsPerSecond *float64 `json:"max_records_per_second,omitempty"`
+	MaxBytesPerSecond   *float64 `json:"max_bytes_per_second,omitempty"`
+}
+
+// decisionStatus is the JSON representation of a cached routing decision.
+type decisionStatus struct {
+	PartitionKey      string    `json:"partition_key"`
+	CardinalityBucket string    `json:"cardinality_bucket"`
+	Pipelines         []string  `json:"pipelines"`
+	Reason            string    `json:"reason"`
+	Pinned            bool      `json:"pinned"`
+	Since             time.Time `json:"since"`
+	// TTLRemaining is empty for decisions without expiry.
+	TTLRemaining string `json:"ttl_remaining,omitempty"`
+}
+
+// pinRequest is the body of a request pinning a partition key to the
+// routing pipeline identified by its cardinality bucket. An empty TTL pins
+// the partition key until it is unpinned.
+type pinRequest struct {
+	PartitionKey      string `json:"partition_key"`
+	CardinalityBucket string `json:"cardinality_bucket"`
+	TTL               string `json:"ttl"`
+}
+
+// adminServer is an HTTP server exposing the routing decisions of the routers
+// registered for each signal.
+type adminServer struct {
+	config   *confighttp.ServerConfig
+	settings component.TelemetrySettings
+
+	mu      sync.RWMutex
+	routers map[pipeline.Signal]adminRouter
+
+	server     *http.Server
+	shutdownWG sync.WaitGroup
+}
+
+func newAdminServer(config *confighttp.ServerConfig, settings component.TelemetrySettings) *adminServer {
+	return &adminServer{
+		config:   config,
+		settings: settings,
+		routers:  make(map[pipeline.Signal]adminRouter),
+	}
+}
+
+func (s *adminServer) Start(ctx context.Context, host component.Host) error {
+	var err error
+	s.server, err = s.config.ToServer(ctx, host.GetExtensions(), s.settings, s.handler())
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP server: %w", err)
+	}
+
+	var listener net.Listener
+	if listener, err = s.config.ToListener(ctx); err != nil {
+		return fmt.Errorf("failed to create listener: %w", err)
+	}
+	s.settings.Logger.Info("Starting dynamic routing admin server",
+		zap.String("endpoint", s.config.NetAddr.Endpoint))
+
+	s.shutdownWG.Go(func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			componentstatus.ReportStatus(host, componentstatus.NewFatalErrorEvent(err))
+			s.settings.Logger.Error("HTTP server error", zap.Error(err))
+		}
+	})
+	return nil
+}
+
+func (s *adminServer) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Shutdown(ctx)
+	if err == nil {
+		s.shutdownWG.Wait()
+	}
+	return err
+}
+
+func (s *adminServer) register(signal pipeline.Signal, r adminRouter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routers[signal] = r
+}
+
+func (s *adminServer) unregister(signal pipeline.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.routers, signal)
+}
+
+func (s *adminServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /decisions", s.handleDecisions)
+	mux.HandleFunc("PUT /pins", s.handlePin)
+	mux.HandleFunc("DELETE /pins", s.handleUnpin)
+	return mux
+}
+
+// selectRouters returns the routers selected by the optional signal query
+// parameter, defaulting to all registered routers.
+func (s *adminServer) selectRouters(req *http.Request) (map[pipeline.Signal]adminRouter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name := req.URL.Query().Get("signal")
+	if name == "" {
+		routers := make(map[pipeline.Signal]adminRouter, len(s.routers))
+		for signal, r := range s.routers {
+			routers[signal] = r
+		}
+		return routers, nil
+	}
+	for signal, r := range s.routers {
+		if signal.String() == name {
+			return map[pipeline.Signal]adminRouter{signal: r}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signal %q", name)
+}
+
+func (s *adminServer) handleDecisions(w http.ResponseWriter, req *http.Request) {
+	routers, err := s.selectRouters(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := make(map[string]routerStatus, len(routers))
+	for signal, r := range routers {
+		resp[signal.String()] = r.status()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.settings.Logger.Warn("failed to write admin response", zap.Error(err))
+	}
+}
+
+func (s *adminServer) handlePin(w http.ResponseWriter, req *http.Request) {
+	routers, err := s.selectRouters(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var pr pinRequest
+	if err := json.NewDecoder(req.Body).Decode(&pr); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if pr.PartitionKey == "" || pr.CardinalityBucket == "" {
+		http.Error(w, "partition_key and cardinality_bucket must be specified", http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if pr.TTL != "" {
+		if ttl, err = time.ParseDuration(pr.TTL); err != nil || ttl <= 0 {
+			http.Error(w, fmt.Sprintf("invalid ttl %q", pr.TTL), http.StatusBadRequest)
+			return
+		}
+	}
+	// Validate against all selected routers before pinning, so that a pin
+	// is either applied to all of them or to none.
+	for signal, r := range routers {
+		if !slices.ContainsFunc(r.status().Thresholds, func(t thresholdStatus) bool {
+			return t.CardinalityBucket == pr.CardinalityBucket
+		}) {
+			http.Error(w, fmt.Sprintf("%s for signal %s: %q", errUnknownCardinalityBucket, signal, pr.CardinalityBucket), http.StatusBadRequest)
+			return
+		}
+	}
+	for signal, r := range routers {
+		if err := r.pin(pr.PartitionKey, pr.CardinalityBucket, ttl); err != nil {
+			http.Error(w, fmt.Sprintf("failed to pin partition key for signal %s: %v", signal, err), http.StatusBadRequest)
+			return
+		}
+	}
+	s.settings.Logger.Info("pinned partition key",
+		zap.String("partition_key", pr.PartitionKey),
+		zap.String("cardinality_bucket", pr.CardinalityBucket),
+		zap.Duration("ttl", ttl),
+	)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *adminServer) handleUnpin(w http.ResponseWriter, req *http.Request) {
+	routers, err := s.selectRouters(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pk := req.URL.Query().Get("partition_key")
+	if pk == "" {
+		http.Error(w, "partition_key must be specified", http.StatusBadRequest)
+		return
+	}
+	var unpinned bool
+	for _, r := range routers {
+		unpinned = r.unpin(pk) || unpinned
+	}
+	if !unpinned {
+		http.Error(w, fmt.Sprintf("partition key %q is not pinned", pk), http.StatusNotFound)
+		return
+	}
+	s.settings.Logger.Info("unpinned partition key", zap.String("partition_key", pk))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *router[C]) status() routerStatus {
+	status := routerStatus{
+		Thresholds: make([]thresholdStatus, 0, len(r.consumers)),
+		Estimates:  make(map[string]uint64),
+	}
+	for _, c := range r.consumers {
+		status.Thresholds = append(status.Thresholds, thresholdStatus{
+			CardinalityBucket:   c.cardinalityBucket,
+			Pipelines:           pipelineNames(c.pipelines),
+			MaxCardinality:      finiteOrNil(c.maxCount),
+			MaxRecordsPerSecond: finiteOrNil(c.maxRecordsPerSecond),
+			MaxBytesPerSecond:   finiteOrNil(c.maxBytesPerSecond),
+		})
+	}
+
+	now := time.Now()
+	for pk, item := range r.decision.Items() {
+		expiresAt := item.ExpiresAt()
+		if !expiresAt.IsZero() && expiresAt.Before(now) {
+			continue
+		}
+		d := item.Value()
+		ds := decisionStatus{
+			PartitionKey:      pk,
+			CardinalityBucket: d.cardinalityBucket,
+			Pipelines:         pipelineNames(r.consumers[d.index].pipelines),
+			Reason:            d.reason,
+			Pinned:            d.pinned,
+			Since:             d.since,
+		}
+		if !expiresAt.IsZero() {
+			ds.TTLRemaining = expiresAt.Sub(now).String()
+		}
+		status.Decisions = append(status.Decisions, ds)
+	}
+	slices.SortFunc(status.Decisions, func(a, b decisionStatus) int {
+		return strings.Compare(a.PartitionKey, b.PartitionKey)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for pk, hll := range r.m {
+		status.Estimates[pk] = hll.Estimate()
+	}
+	return status
+}
+
+// pin pins the partition key to the routing pipeline of the given cardinality
+// bucket. A zero ttl pins the partition key until it is unpinned.
+func (r *router[C]) pin(pk, cardinalityBucket string, ttl time.Duration) error {
+	i := slices.IndexFunc(r.consumers, func(c consumerThreshold[C]) bool {
+		return c.cardinalityBucket == cardinalityBucket
+	})
+	if i < 0 {
+		return fmt.Errorf("%w: %q", errUnknownCardinalityBucket, cardinalityBucket)
+	}
+	if ttl == 0 {
+		ttl = ttlcache.NoTTL
+	}
+	decision := r.newRoutingDecision(pk, i, reasonPinned, time.Now())
+	decision.pinned = true
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decision.Set(pk, decision, ttl)
+	return nil
+}
+
+// unpin removes the pinned decision of the partition key, if any. The
+// partition key is routed to the default pipelines until a new decision
+// is made from the recorded measurements.
+func (r *router[C]) unpin(pk string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	item := r.decision.Get(pk)
+	if item == nil || !item.Value().pinned {
+		return false
+	}
+	r.decision.Delete(pk)
+	return true
+}
+
+func pipelineNames(ids []pipeline.ID) []string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, id.String())
+	}
+	return names
+}
+
+// finiteOrNil returns nil for an infinite threshold, as it cannot be
+// represented in JSON.
+func finiteOrNil(v float64) *float64 {
+	if math.IsInf(v, 1) {
+		return nil
+	}
+	return &v
+}
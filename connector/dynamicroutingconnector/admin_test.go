@@ -0,0 +1,205 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dynamicroutingconnector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+func TestAdminServer(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		const (
+			interval = time.Second
+			ttl      = 5 * time.Second
+		)
+		_, sinkBucket, conn := newTestMetricsConnector(t, interval, ttl)
+		require.NoError(t, conn.Start(context.Background(), nil))
+		defer func() { require.NoError(t, conn.Shutdown(context.Background())) }()
+
+		s := newAdminServer(&confighttp.ServerConfig{}, componenttest.NewNopTelemetrySettings())
+		s.register(pipeline.SignalMetrics, conn.(*metricsConnector).router)
+		handler := s.handler()
+		do := func(method, target, body string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+			return rec
+		}
+
+		ctx := contextWithMetadata(map[string][]string{
+			"x-tenant-id":     {"tenant-1"},
+			"x-forwarded-for": {"10.2.4.2"},
+		})
+		md := newTestMetrics("1", "1", "1", "1")
+		require.NoError(t, conn.ConsumeMetrics(ctx, md))
+		time.Sleep(interval)
+		synctest.Wait()
+
+		// The decision is listed with the configured thresholds.
+		rec := do(http.MethodGet, "/decisions", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var status map[string]routerStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		require.Contains(t, status, "metrics")
+		maxCardinality := float64(2)
+		assert.Equal(t, []thresholdStatus{
+			{CardinalityBucket: "0_2", Pipelines: []string{"metrics/bucket_0_2"}, MaxCardinality: &maxCardinality},
+			{CardinalityBucket: "2_inf", Pipelines: []string{"metrics/bucket_inf"}},
+		}, status["metrics"].Thresholds)
+		require.Len(t, status["metrics"].Decisions, 1)
+		decision := status["metrics"].Decisions[0]
+		assert.Equal(t, "tenant-1:;", decision.PartitionKey)
+		assert.Equal(t, "0_2", decision.CardinalityBucket)
+		assert.Equal(t, reasonWithinLimits, decision.Reason)
+		assert.False(t, decision.Pinned)
+		assert.Equal(t, ttl.String(), decision.TTLRemaining)
+
+		// Pin the partition key to the last routing pipeline.
+		rec = do(http.MethodPut, "/pins?signal=metrics", `{"partition_key":"tenant-1:;","cardinality_bucket":"2_inf"}`)
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+		require.NoError(t, conn.ConsumeMetrics(ctx, md))
+		assert.Empty(t, sinkBucket.AllMetrics())
+
+		// Pinned decisions are not refreshed from the recorded measurements.
+		time.Sleep(2 * interval)
+		synctest.Wait()
+		require.NoError(t, json.Unmarshal(do(http.MethodGet, "/decisions?signal=metrics", "").Body.Bytes(), &status))
+		require.Len(t, status["metrics"].Decisions, 1)
+		decision = status["metrics"].Decisions[0]
+		assert.Equal(t, "2_inf", decision.CardinalityBucket)
+		assert.Equal(t, reasonPinned, decision.Reason)
+		assert.True(t, decision.Pinned)
+		assert.Empty(t, decision.TTLRemaining)
+
+		// Unpin the partition key.
+		unpin := "/pins?" + url.Values{"partition_key": {"tenant-1:;"}}.Encode()
+		rec = do(http.MethodDelete, unpin, "")
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+		rec = do(http.MethodDelete, unpin, "")
+		require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	})
+}
+
+func TestAdminServer_InvalidRequests(t *testing.T) {
+	_, _, conn := newTestMetricsConnector(t, time.Second, time.Minute)
+	s := newAdminServer(&confighttp.ServerConfig{}, componenttest.NewNopTelemetrySettings())
+	s.register(pipeline.SignalMetrics, conn.(*metricsConnector).router)
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "unknown_signal",
+			method:   http.MethodGet,
+			target:   "/decisions?signal=logs",
+			wantCode: http.StatusBadRequest,
+			wantBody: `unknown signal "logs"`,
+		},
+		{
+			name:     "invalid_body",
+			method:   http.MethodPut,
+			target:   "/pins",
+			body:     "{",
+			wantCode: http.StatusBadRequest,
+			wantBody: "failed to decode request body",
+		},
+		{
+			name:     "missing_partition_key",
+			method:   http.MethodPut,
+			target:   "/pins",
+			body:     `{"cardinality_bucket":"0_2"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: "partition_key and cardinality_bucket must be specified",
+		},
+		{
+			name:     "unknown_cardinality_bucket",
+			method:   http.MethodPut,
+			target:   "/pins",
+			body:     `{"partition_key":"tenant-1:;","cardinality_bucket":"0_5"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `unknown cardinality bucket for signal metrics: "0_5"`,
+		},
+		{
+			name:     "invalid_ttl",
+			method:   http.MethodPut,
+			target:   "/pins",
+			body:     `{"partition_key":"tenant-1:;","cardinality_bucket":"0_2","ttl":"-1m"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `invalid ttl "-1m"`,
+		},
+		{
+			name:     "unpin_missing_partition_key",
+			method:   http.MethodDelete,
+			target:   "/pins",
+			wantCode: http.StatusBadRequest,
+			wantBody: "partition_key must be specified",
+		},
+		{
+			name:     "method_not_allowed",
+			method:   http.MethodPost,
+			target:   "/decisions",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handler().ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestAdminServer_Lifecycle(t *testing.T) {
+	admin := confighttp.NewDefaultServerConfig()
+	admin.NetAddr.Endpoint = "localhost:0"
+	_, _, conn := newTestMetricsConnector(t, time.Second, time.Minute, func(cfg *Config) {
+		cfg.Admin = configoptional.Some(admin)
+	})
+	require.NoError(t, conn.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, conn.Shutdown(context.Background()))
+
+	// The shared admin server is removed once all routers are shut down.
+	var created bool
+	c, err := adminServers.LoadOrStore(conn.(*metricsConnector).router.id, func() (*adminServer, error) {
+		created = true
+		return newAdminServer(&admin, componenttest.NewNopTelemetrySettings()), nil
+	})
+	require.NoError(t, err)
+	assert.True(t, created)
+	require.NoError(t, c.Shutdown(context.Background()))
+}
@@ -24,6 +24,9 @@ import (
 	"slices"
 	"time"
 
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configoptional"
 	"go.opentelemetry.io/collector/pipeline"
 )
 
@@ -33,11 +36,58 @@ type Config struct {
 	RecordingInterval time.Duration     `mapstructure:"recording_interval"`
 	TTL               time.Duration     `mapstructure:"ttl"`
 	RoutingPipelines  []RoutingPipeline `mapstructure:"routing_pipelines"`
+
+	// Hysteresis is the fraction by which the measurements of a partition
+	// key must fall below the thresholds of a lower routing pipeline before
+	// the key is demoted to it. For example, with a hysteresis of 0.2 and a
+	// max_cardinality of 1000, a key is promoted above 1000 but demoted only
+	// below 800. Must be in the range [0, 1). Defaults to 0.
+	Hysteresis float64 `mapstructure:"hysteresis"`
+	// MinDwellTime is the minimum duration a partition key keeps its routing
+	// pipeline before it can be moved to another one. Defaults to 0.
+	MinDwellTime time.Duration `mapstructure:"min_dwell_time"`
+
+	// StorageID references a storage extension used to persist the
+	// cardinality sketches and routing decisions across restarts. If nil,
+	// the state is only kept in memory.
+	StorageID *component.ID `mapstructure:"storage"`
+
+	// Admin configures an optional HTTP server listing the current routing
+	// decisions and allowing partition keys to be pinned to a routing
+	// pipeline. The server is shared by all signals of the connector.
+	Admin configoptional.Optional[confighttp.ServerConfig] `mapstructure:"admin"`
 }
 
 type RoutingPipeline struct {
 	Pipelines      []pipeline.ID `mapstructure:"pipelines"`
 	MaxCardinality float64       `mapstructure:"max_cardinality"`
+
+	// MaxRecordsPerSecond is the maximum observed throughput, in records
+	// per second, of a partition key routed to the pipelines. Zero means
+	// no limit.
+	MaxRecordsPerSecond float64 `mapstructure:"max_records_per_second"`
+	// MaxBytesPerSecond is the maximum observed throughput, in bytes per
+	// second of the serialized OTLP payload, of a partition key routed to
+	// the pipelines. Zero means no limit.
+	MaxBytesPerSecond float64 `mapstructure:"max_bytes_per_second"`
+}
+
+// maxRecordsPerSecond returns the records per second threshold, treating
+// zero as no limit.
+func (p RoutingPipeline) maxRecordsPerSecond() float64 {
+	if p.MaxRecordsPerSecond == 0 {
+		return math.Inf(1)
+	}
+	return p.MaxRecordsPerSecond
+}
+
+// maxBytesPerSecond returns the bytes per second threshold, treating zero
+// as no limit.
+func (p RoutingPipeline) maxBytesPerSecond() float64 {
+	if p.MaxBytesPerSecond == 0 {
+		return math.Inf(1)
+	}
+	return p.MaxBytesPerSecond
 }
 
 type RoutingKeys struct {
@@ -64,13 +114,38 @@ func (c *Config) Validate() error {
 	if c.TTL < c.RecordingInterval {
 		return errors.New("ttl must be greater than or equal to recording_interval")
 	}
-	if !math.IsInf(c.RoutingPipelines[len(c.RoutingPipelines)-1].MaxCardinality, 1) {
+	if c.Admin.HasValue() && c.Admin.Get().NetAddr.Endpoint == "" {
+		return errors.New("admin.endpoint must be specified")
+	}
+	if c.Hysteresis < 0 || c.Hysteresis >= 1 {
+		return errors.New("hysteresis must be in the range [0, 1)")
+	}
+	if c.MinDwellTime < 0 {
+		return errors.New("min_dwell_time must not be negative")
+	}
+	for _, p := range c.RoutingPipelines {
+		if p.MaxRecordsPerSecond < 0 || p.MaxBytesPerSecond < 0 {
+			return errors.New("max_records_per_second and max_bytes_per_second must not be negative")
+		}
+	}
+	last := c.RoutingPipelines[len(c.RoutingPipelines)-1]
+	if !math.IsInf(last.MaxCardinality, 1) {
 		return errors.New("last dynamic pipeline must have max count set to positive infinity (.inf)")
 	}
+	if !math.IsInf(last.maxRecordsPerSecond(), 1) || !math.IsInf(last.maxBytesPerSecond(), 1) {
+		return errors.New("last dynamic pipeline must not limit max_records_per_second or max_bytes_per_second")
+	}
 	if !slices.IsSortedFunc(c.RoutingPipelines, func(a, b RoutingPipeline) int {
 		return cmp.Compare(a.MaxCardinality, b.MaxCardinality)
 	}) {
 		return errors.New("pipelines must be defined in ascending order of max_cardinality")
 	}
+	// The cardinality bucket of a pipeline, used in telemetry and to pin
+	// partition keys, is derived from max_cardinality, so it must be unique.
+	for i := 1; i < len(c.RoutingPipelines); i++ {
+		if c.RoutingPipelines[i].MaxCardinality == c.RoutingPipelines[i-1].MaxCardinality {
+			return errors.New("max_cardinality must be unique across routing_pipelines")
+		}
+	}
 	return nil
 }
@@ -27,12 +27,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configoptional"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
 	"go.opentelemetry.io/collector/confmap/xconfmap"
 	"go.opentelemetry.io/collector/pipeline"
 )
 
 func TestConfig(t *testing.T) {
+	defaultAdmin := configoptional.Default(confighttp.NewDefaultServerConfig())
+	admin := confighttp.NewDefaultServerConfig()
+	admin.NetAddr.Endpoint = "localhost:8090"
+	storageID := component.MustNewID("file_storage")
 	for _, tc := range []struct {
 		name     string
 		expected component.Config
@@ -54,6 +60,10 @@ func TestConfig(t *testing.T) {
 			name:   "invalid-dynamic-pipelines/out-of-order",
 			errMsg: "pipelines must be defined in ascending order of max_cardinality",
 		},
+		{
+			name:   "invalid-dynamic-pipelines/duplicate",
+			errMsg: "max_cardinality must be unique across routing_pipelines",
+		},
 		{
 			name:   "invalid-recording-interval",
 			errMsg: "recording_interval must be greater than zero",
@@ -78,6 +88,7 @@ func TestConfig(t *testing.T) {
 				},
 				RecordingInterval: time.Minute,
 				TTL:               5 * time.Minute,
+				Admin:             defaultAdmin,
 				RoutingPipelines: []RoutingPipeline{
 					{
 						Pipelines: []pipeline.ID{
@@ -100,6 +111,125 @@ func TestConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "storage",
+			expected: &Config{
+				RoutingKeys: RoutingKeys{
+					PartitionBy: []string{"x-tenant"},
+				},
+				DefaultPipelines: []pipeline.ID{
+					pipeline.NewIDWithName(pipeline.SignalLogs, "default"),
+				},
+				RecordingInterval: time.Minute,
+				TTL:               5 * time.Minute,
+				Admin:             defaultAdmin,
+				StorageID:         &storageID,
+				RoutingPipelines: []RoutingPipeline{
+					{
+						Pipelines: []pipeline.ID{
+							pipeline.NewIDWithName(pipeline.SignalLogs, "final"),
+						},
+						MaxCardinality: math.Inf(1),
+					},
+				},
+			},
+		},
+		{
+			name:   "invalid-throughput-negative",
+			errMsg: "max_records_per_second and max_bytes_per_second must not be negative",
+		},
+		{
+			name:   "invalid-throughput-last",
+			errMsg: "last dynamic pipeline must not limit max_records_per_second or max_bytes_per_second",
+		},
+		{
+			name: "throughput",
+			expected: &Config{
+				RoutingKeys: RoutingKeys{
+					PartitionBy: []string{"x-tenant"},
+				},
+				DefaultPipelines: []pipeline.ID{
+					pipeline.NewIDWithName(pipeline.SignalLogs, "default"),
+				},
+				RecordingInterval: time.Minute,
+				TTL:               5 * time.Minute,
+				Admin:             defaultAdmin,
+				RoutingPipelines: []RoutingPipeline{
+					{
+						Pipelines: []pipeline.ID{
+							pipeline.NewIDWithName(pipeline.SignalLogs, "shared"),
+						},
+						MaxCardinality:      100,
+						MaxRecordsPerSecond: 1000,
+						MaxBytesPerSecond:   1048576,
+					},
+					{
+						Pipelines: []pipeline.ID{
+							pipeline.NewIDWithName(pipeline.SignalLogs, "dedicated"),
+						},
+						MaxCardinality: math.Inf(1),
+					},
+				},
+			},
+		},
+		{
+			name:   "invalid-hysteresis",
+			errMsg: "hysteresis must be in the range [0, 1)",
+		},
+		{
+			name:   "invalid-min-dwell-time",
+			errMsg: "min_dwell_time must not be negative",
+		},
+		{
+			name: "hysteresis",
+			expected: &Config{
+				RoutingKeys: RoutingKeys{
+					PartitionBy: []string{"x-tenant"},
+				},
+				DefaultPipelines: []pipeline.ID{
+					pipeline.NewIDWithName(pipeline.SignalLogs, "default"),
+				},
+				RecordingInterval: time.Minute,
+				TTL:               5 * time.Minute,
+				Admin:             defaultAdmin,
+				Hysteresis:        0.2,
+				MinDwellTime:      10 * time.Minute,
+				RoutingPipelines: []RoutingPipeline{
+					{
+						Pipelines: []pipeline.ID{
+							pipeline.NewIDWithName(pipeline.SignalLogs, "final"),
+						},
+						MaxCardinality: math.Inf(1),
+					},
+				},
+			},
+		},
+		{
+			name:   "invalid-admin",
+			errMsg: "admin.endpoint must be specified",
+		},
+		{
+			name: "admin",
+			expected: &Config{
+				RoutingKeys: RoutingKeys{
+					PartitionBy: []string{"x-tenant"},
+				},
+				DefaultPipelines: []pipeline.ID{
+					pipeline.NewIDWithName(pipeline.SignalLogs, "default"),
+				},
+				RecordingInterval: time.Minute,
+				TTL:               5 * time.Minute,
+				Admin:             configoptional.Some(admin),
+				RoutingPipelines: []RoutingPipeline{
+					{
+						Pipelines: []pipeline.ID{
+							pipeline.NewIDWithName(pipeline.SignalLogs, "final"),
+						},
+						MaxCardinality: math.Inf(1),
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			configPath := filepath.Join("testdata", "configs", "config.yaml")
@@ -21,6 +21,8 @@ import (
 	"context"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configoptional"
 	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/connector/xconnector"
 	"go.opentelemetry.io/collector/consumer"
@@ -78,5 +80,7 @@ func createProfilesToProfiles(
 }
 
 func createDefaultConfig() component.Config {
-	return &Config{}
+	return &Config{
+		Admin: configoptional.Default(confighttp.NewDefaultServerConfig()),
+	}
 }
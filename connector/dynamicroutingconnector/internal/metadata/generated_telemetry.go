@@ -40,10 +40,11 @@ func Tracer(settings component.TelemetrySettings) trace.Tracer {
 // TelemetryBuilder provides an interface for components to report telemetry
 // as defined in metadata and user config.
 type TelemetryBuilder struct {
-	meter                metric.Meter
-	mu                   sync.Mutex
-	registrations        []metric.Registration
-	DynamicroutingRouted metric.Int64Counter
+	meter                     metric.Meter
+	mu                        sync.Mutex
+	registrations             []metric.Registration
+	DynamicroutingRouted      metric.Int64Counter
+	DynamicroutingTransitions metric.Int64Counter
 }
 
 // TelemetryBuilderOption applies changes to default builder.
@@ -81,5 +82,11 @@ func NewTelemetryBuilder(settings component.TelemetrySettings, options ...Teleme
 		metric.WithUnit("1"),
 	)
 	errs = errors.Join(errs, err)
+	builder.DynamicroutingTransitions, err = builder.meter.Int64Counter(
+		"otelcol.dynamicrouting.transitions",
+		metric.WithDescription("Number of times a partition key with a cached routing decision was moved to another routing pipeline. [Development]"),
+		metric.WithUnit("1"),
+	)
+	errs = errors.Join(errs, err)
 	return &builder, errs
 }
@@ -26,9 +26,12 @@ import (
 	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pipeline"
 	"go.uber.org/zap"
 )
 
+var logsSizer = &plog.ProtoMarshaler{}
+
 type logsConnector struct {
 	logger *zap.Logger
 	cfg    *Config
@@ -46,7 +49,7 @@ func newLogsConnector(
 		return nil, errors.New("expected connector to be a router and consumer")
 	}
 
-	router, err := newRouter(cfg, set.TelemetrySettings, lr.Consumer)
+	router, err := newRouter(cfg, set, pipeline.SignalLogs, lr.Consumer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create router: %w", err)
 	}
@@ -71,5 +74,13 @@ func (c *logsConnector) Capabilities() consumer.Capabilities {
 }
 
 func (c *logsConnector) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
-	return c.router.Process(ctx).ConsumeLogs(ctx, ld)
+	return c.router.Process(ctx, c.batchSize(ld)).ConsumeLogs(ctx, ld)
+}
+
+func (c *logsConnector) batchSize(ld plog.Logs) batchSize {
+	size := batchSize{records: ld.LogRecordCount()}
+	if c.router.measureBytes {
+		size.bytes = logsSizer.LogsSize(ld)
+	}
+	return size
 }
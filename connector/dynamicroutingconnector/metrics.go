@@ -26,9 +26,12 @@ import (
 	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pipeline"
 	"go.uber.org/zap"
 )
 
+var metricsSizer = &pmetric.ProtoMarshaler{}
+
 type metricsConnector struct {
 	logger *zap.Logger
 	cfg    *Config
@@ -46,7 +49,7 @@ func newMetricsConnector(
 		return nil, errors.New("expected connector to be a router and consumer")
 	}
 
-	router, err := newRouter(cfg, set.TelemetrySettings, mr.Consumer)
+	router, err := newRouter(cfg, set, pipeline.SignalMetrics, mr.Consumer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create router: %w", err)
 	}
@@ -71,5 +74,13 @@ func (c *metricsConnector) Capabilities() consumer.Capabilities {
 }
 
 func (c *metricsConnector) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
-	return c.router.Process(ctx).ConsumeMetrics(ctx, md)
+	return c.router.Process(ctx, c.batchSize(md)).ConsumeMetrics(ctx, md)
+}
+
+func (c *metricsConnector) batchSize(md pmetric.Metrics) batchSize {
+	size := batchSize{records: md.DataPointCount()}
+	if c.router.measureBytes {
+		size.bytes = metricsSizer.MetricsSize(md)
+	}
+	return size
 }
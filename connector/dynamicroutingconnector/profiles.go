@@ -28,9 +28,12 @@ import (
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/xconsumer"
 	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pipeline/xpipeline"
 	"go.uber.org/zap"
 )
 
+var profilesSizer = &pprofile.ProtoMarshaler{}
+
 type profilesConnector struct {
 	logger *zap.Logger
 	cfg    *Config
@@ -48,7 +51,7 @@ func newProfilesConnector(
 		return nil, errors.New("expected connector to be a router and consumer")
 	}
 
-	router, err := newRouter(cfg, set.TelemetrySettings, pr.Consumer)
+	router, err := newRouter(cfg, set, xpipeline.SignalProfiles, pr.Consumer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create router: %w", err)
 	}
@@ -73,5 +76,13 @@ func (c *profilesConnector) Capabilities() consumer.Capabilities {
 }
 
 func (c *profilesConnector) ConsumeProfiles(ctx context.Context, pd pprofile.Profiles) error {
-	return c.router.Process(ctx).ConsumeProfiles(ctx, pd)
+	return c.router.Process(ctx, c.batchSize(pd)).ConsumeProfiles(ctx, pd)
+}
+
+func (c *profilesConnector) batchSize(pd pprofile.Profiles) batchSize {
+	size := batchSize{records: pd.SampleCount()}
+	if c.router.measureBytes {
+		size.bytes = profilesSizer.ProfilesSize(pd)
+	}
+	return size
 }
@@ -31,12 +31,15 @@ import (
 	"github.com/jellydator/ttlcache/v3"
 	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
 	"go.opentelemetry.io/collector/pipeline"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 
 	"github.com/elastic/opentelemetry-collector-components/connector/dynamicroutingconnector/internal/metadata"
+	"github.com/elastic/opentelemetry-collector-components/internal/sharedcomponent"
 )
 
 var _ component.Component = (*router[any])(nil)
@@ -44,11 +47,26 @@ var _ component.Component = (*router[any])(nil)
 const defaultCardinalityBucket = "default"
 const maxPkCapacity = 256
 
+// Reasons reported with routing decisions. reasonDefault is used when no
+// decision is available, reasonWithinLimits when the first routing pipeline
+// is selected and reasonPinned when the partition key was pinned through the
+// admin server. Otherwise the reason is the criterion that exceeded the
+// thresholds of the preceding routing pipeline.
+const (
+	reasonDefault          = "default"
+	reasonWithinLimits     = "within_limits"
+	reasonCardinality      = "cardinality"
+	reasonRecordsPerSecond = "records_per_second"
+	reasonBytesPerSecond   = "bytes_per_second"
+	reasonPinned           = "pinned"
+)
+
 // defaultRoutedOpt is a cached MeasurementOption for the default routing path
 // (empty partition key, default bucket) to avoid per-call allocations.
 var defaultRoutedOpt = metric.WithAttributeSet(attribute.NewSet(
 	attribute.String("cardinality_bucket", defaultCardinalityBucket),
 	attribute.String("partition_key", ""),
+	attribute.String("reason", reasonDefault),
 ))
 
 // consumerProvider is a function with a type parameter C (expected to be one
@@ -63,30 +81,85 @@ type router[C any] struct {
 	sortedMetadataKeys []string
 	defaultConsumer    C
 	consumers          []consumerThreshold[C]
+	// measureThroughput and measureBytes are set if any routing pipeline
+	// has a throughput threshold, respectively a bytes per second threshold.
+	measureThroughput bool
+	measureBytes      bool
+	hysteresis        float64
+	minDwellTime      time.Duration
 
 	logger           *zap.Logger
 	telemetryBuilder *metadata.TelemetryBuilder
 
-	mu       sync.Mutex
-	m        map[string]*hyperloglog.Sketch
-	stop     chan struct{}
-	stopped  chan struct{}
-	decision *ttlcache.Cache[string, routingDecision[C]]
+	id        component.ID
+	signal    pipeline.Signal
+	storageID *component.ID
+	storage   storage.Client
+	admin     *sharedcomponent.Component[*adminServer]
+
+	mu          sync.Mutex
+	m           map[string]*hyperloglog.Sketch
+	throughput  map[string]*throughput
+	windowStart time.Time
+	stop        chan struct{}
+	stopped     chan struct{}
+	decision    *ttlcache.Cache[string, routingDecision[C]]
 }
 
 // consumerThreshold is a config-time structure mapping a consumer to its
 // cardinality threshold and bucket label.
 type consumerThreshold[C any] struct {
-	consumer          C
-	maxCount          float64
-	cardinalityBucket string
+	consumer            C
+	pipelines           []pipeline.ID
+	maxCount            float64
+	maxRecordsPerSecond float64
+	maxBytesPerSecond   float64
+	cardinalityBucket   string
+}
+
+// exceeded returns the reason for the first threshold, multiplied by scale,
+// exceeded by the given measurements, or an empty string if all of them are
+// within the thresholds.
+func (c consumerThreshold[C]) exceeded(scale, cardinality, recordsPerSecond, bytesPerSecond float64) string {
+	switch {
+	case cardinality > c.maxCount*scale:
+		return reasonCardinality
+	case recordsPerSecond > c.maxRecordsPerSecond*scale:
+		return reasonRecordsPerSecond
+	case bytesPerSecond > c.maxBytesPerSecond*scale:
+		return reasonBytesPerSecond
+	}
+	return ""
+}
+
+// batchSize is the size of a batch, used to measure the throughput of a
+// partition key. bytes is only computed if the router measures bytes.
+type batchSize struct {
+	records int
+	bytes   int
+}
+
+// throughput accumulates the records and bytes of a partition key over a
+// recording window.
+type throughput struct {
+	records int64
+	bytes   int64
 }
 
 // routingDecision is a cached per-partition-key entry holding the resolved
 // consumer and a pre-built metric option.
 type routingDecision[C any] struct {
-	consumer C
-	maxCount float64
+	consumer          C
+	index             int
+	maxCount          float64
+	cardinalityBucket string
+	reason            string
+	// since is the time the partition key was moved to the consumer, used
+	// to enforce the minimum dwell time.
+	since time.Time
+	// pinned is set for decisions pinned through the admin server, which
+	// are not refreshed from the recorded measurements.
+	pinned bool
 	// routedOpt is a pre-built metric.MeasurementOption containing the
 	// partition key and cardinality bucket attributes for this decision.
 	// It is constructed once when the decision is cached (in updateDecisions)
@@ -97,7 +170,8 @@ type routingDecision[C any] struct {
 
 func newRouter[C any](
 	cfg *Config,
-	settings component.TelemetrySettings,
+	set connector.Settings,
+	signal pipeline.Signal,
 	provider consumerProvider[C],
 ) (*router[C], error) {
 	sortedMetadataKeys := slices.Clone(cfg.RoutingKeys.MeasureBy)
@@ -107,18 +181,26 @@ func newRouter[C any](
 		ttlcache.WithDisableTouchOnHit[string, routingDecision[C]](),
 	)
 	consumers := make([]consumerThreshold[C], 0, len(cfg.RoutingPipelines))
-	var prevMax float64
+	var (
+		prevMax                         float64
+		measureThroughput, measureBytes bool
+	)
 	for i, p := range cfg.RoutingPipelines {
 		c, err := provider(p.Pipelines...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create consumer from provided pipelines at idx %d: %w", i, err)
 		}
 		consumers = append(consumers, consumerThreshold[C]{
-			consumer:          c,
-			maxCount:          p.MaxCardinality,
-			cardinalityBucket: formatCardinalityBucket(prevMax, p.MaxCardinality),
+			consumer:            c,
+			pipelines:           p.Pipelines,
+			maxCount:            p.MaxCardinality,
+			maxRecordsPerSecond: p.maxRecordsPerSecond(),
+			maxBytesPerSecond:   p.maxBytesPerSecond(),
+			cardinalityBucket:   formatCardinalityBucket(prevMax, p.MaxCardinality),
 		})
 		prevMax = p.MaxCardinality
+		measureBytes = measureBytes || p.MaxBytesPerSecond > 0
+		measureThroughput = measureThroughput || measureBytes || p.MaxRecordsPerSecond > 0
 	}
 
 	var (
@@ -132,26 +214,61 @@ func newRouter[C any](
 		}
 	}
 
-	telemetryBuilder, err := metadata.NewTelemetryBuilder(settings)
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(set.TelemetrySettings)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create telemetry builder: %w", err)
 	}
-	return &router[C]{
+	r := &router[C]{
 		recordingInterval:  cfg.RecordingInterval,
 		ttl:                cfg.TTL,
 		partitionKeys:      cfg.RoutingKeys.PartitionBy,
 		sortedMetadataKeys: sortedMetadataKeys,
 		defaultConsumer:    defaultConsumer,
 		consumers:          consumers,
-		logger:             settings.Logger,
+		measureThroughput:  measureThroughput,
+		measureBytes:       measureBytes,
+		hysteresis:         cfg.Hysteresis,
+		minDwellTime:       cfg.MinDwellTime,
+		logger:             set.Logger,
 		telemetryBuilder:   telemetryBuilder,
+		id:                 set.ID,
+		signal:             signal,
+		storageID:          cfg.StorageID,
 		stop:               make(chan struct{}),
 		decision:           decisionCache,
 		m:                  make(map[string]*hyperloglog.Sketch),
-	}, nil
+		throughput:         make(map[string]*throughput),
+		windowStart:        time.Now(),
+	}
+	if cfg.Admin.HasValue() {
+		r.admin, err = adminServers.LoadOrStore(set.ID, func() (*adminServer, error) {
+			return newAdminServer(cfg.Admin.Get(), set.TelemetrySettings), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create admin server: %w", err)
+		}
+	}
+	return r, nil
 }
 
-func (r *router[C]) Start(ctx context.Context, _ component.Host) error {
+func (r *router[C]) Start(ctx context.Context, host component.Host) error {
+	if r.storageID != nil {
+		client, err := getStorageClient(ctx, host, *r.storageID, r.id, r.signal)
+		if err != nil {
+			return fmt.Errorf("failed to get storage client: %w", err)
+		}
+		r.storage = client
+		if err := r.restoreState(ctx); err != nil {
+			r.logger.Warn("failed to restore routing state, starting from an empty state", zap.Error(err))
+		}
+	}
+	if r.admin != nil {
+		r.admin.Unwrap().register(r.signal, r)
+		if err := r.admin.Start(ctx, host); err != nil {
+			return fmt.Errorf("failed to start admin server: %w", err)
+		}
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -169,6 +286,7 @@ func (r *router[C]) Start(ctx context.Context, _ component.Host) error {
 	default:
 	}
 
+	r.windowStart = time.Now()
 	go func() {
 		defer close(r.stopped)
 		// Use timers to ensure that decision updates are always at least
@@ -187,6 +305,11 @@ func (r *router[C]) Start(ctx context.Context, _ component.Host) error {
 			}
 
 			r.updateDecisions()
+			if r.storage != nil {
+				if err := r.persistState(context.Background()); err != nil {
+					r.logger.Warn("failed to persist routing state", zap.Error(err))
+				}
+			}
 			timer.Reset(r.recordingInterval)
 		}
 	}()
@@ -218,12 +341,26 @@ func (r *router[C]) Shutdown(ctx context.Context) error {
 	if r.telemetryBuilder != nil {
 		r.telemetryBuilder.Shutdown()
 	}
+	if r.admin != nil {
+		r.admin.Unwrap().unregister(r.signal)
+		if err := r.admin.Shutdown(ctx); err != nil {
+			return fmt.Errorf("failed to shutdown admin server: %w", err)
+		}
+	}
+	if r.storage != nil {
+		if err := r.persistState(ctx); err != nil {
+			r.logger.Warn("failed to persist routing state", zap.Error(err))
+		}
+		if err := r.storage.Close(ctx); err != nil {
+			return fmt.Errorf("failed to close storage client: %w", err)
+		}
+	}
 	return nil
 }
 
-func (r *router[C]) Process(ctx context.Context) C {
+func (r *router[C]) Process(ctx context.Context, size batchSize) C {
 	pk := r.partitionKey(ctx)
-	return r.getNextConsumerAndMaybeRecord(ctx, pk)
+	return r.getNextConsumerAndMaybeRecord(ctx, pk, size)
 }
 
 func (r *router[C]) partitionKey(ctx context.Context) string {
@@ -296,7 +433,20 @@ func (r *router[C]) recordCardinality(pk string, hashSum uint64) {
 	hll.InsertHash(hashSum)
 }
 
-func (r *router[C]) getNextConsumerAndMaybeRecord(ctx context.Context, pk string) C {
+func (r *router[C]) recordThroughput(pk string, size batchSize) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.throughput[pk]
+	if !ok {
+		t = &throughput{}
+		r.throughput[pk] = t
+	}
+	t.records += int64(size.records)
+	t.bytes += int64(size.bytes)
+}
+
+func (r *router[C]) getNextConsumerAndMaybeRecord(ctx context.Context, pk string, size batchSize) C {
 	if pk == "" {
 		if ce := r.logger.Check(zap.DebugLevel, "returning default consumer due to empty primary key"); ce != nil {
 			ce.Write(zap.String("primary_key", pk))
@@ -305,8 +455,13 @@ func (r *router[C]) getNextConsumerAndMaybeRecord(ctx context.Context, pk string
 		return r.defaultConsumer
 	}
 
+	// Throughput is recorded for every batch, rather than only when the
+	// cardinality is recorded, so that rates cover the whole window.
+	if r.measureThroughput {
+		r.recordThroughput(pk, size)
+	}
 	item := r.decision.Get(pk)
-	if item == nil || time.Until(item.ExpiresAt()) <= r.recordingInterval {
+	if item == nil || !item.Value().pinned && time.Until(item.ExpiresAt()) <= r.recordingInterval {
 		r.recordCardinality(pk, r.hashSum(ctx))
 	}
 
@@ -318,48 +473,127 @@ func (r *router[C]) getNextConsumerAndMaybeRecord(ctx context.Context, pk string
 		r.telemetryBuilder.DynamicroutingRouted.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
 			attribute.String("cardinality_bucket", defaultCardinalityBucket),
 			attribute.String("partition_key", pk),
+			attribute.String("reason", reasonDefault),
 		)))
 		return r.defaultConsumer
 	}
 	next := item.Value()
 	if ce := r.logger.Check(zap.DebugLevel, "returning non-default consumer"); ce != nil {
-		ce.Write(zap.String("primary_key", pk), zap.Float64("max_count", next.maxCount))
+		ce.Write(
+			zap.String("primary_key", pk),
+			zap.Float64("max_count", next.maxCount),
+			zap.String("reason", next.reason),
+		)
 	}
 	r.telemetryBuilder.DynamicroutingRouted.Add(ctx, 1, next.routedOpt)
 	return next.consumer
 }
 
 // updateDecisions refreshes decision cache entries from the most recently
-// recorded HLL window.
+// recorded HLL window and the throughput observed during that window.
+// Partition keys with a cached decision are demoted to a lower routing
+// pipeline only once the measurements fall below its thresholds lowered by
+// the hysteresis, and keep their routing pipeline for at least the minimum
+// dwell time.
 func (r *router[C]) updateDecisions() {
 	r.mu.Lock()
 	oldM := r.m
+	oldThroughput := r.throughput
 	r.m = make(map[string]*hyperloglog.Sketch)
+	r.throughput = make(map[string]*throughput)
+	now := time.Now()
+	elapsed := now.Sub(r.windowStart).Seconds()
+	r.windowStart = now
 	r.mu.Unlock()
 
 	newDecision := make(map[string]routingDecision[C], len(oldM))
 	for k, hll := range oldM {
-		estimate := hll.Estimate()
-		for _, c := range r.consumers {
-			if float64(estimate) <= c.maxCount {
-				newDecision[k] = routingDecision[C]{
-					consumer: c.consumer,
-					maxCount: c.maxCount,
-					routedOpt: metric.WithAttributeSet(attribute.NewSet(
-						attribute.String("cardinality_bucket", c.cardinalityBucket),
-						attribute.String("partition_key", k),
-					)),
-				}
-				break
+		estimate := float64(hll.Estimate())
+		var recordsPerSecond, bytesPerSecond float64
+		if t, ok := oldThroughput[k]; ok && elapsed > 0 {
+			recordsPerSecond = float64(t.records) / elapsed
+			bytesPerSecond = float64(t.bytes) / elapsed
+		}
+		i, reason := r.selectConsumer(1, estimate, recordsPerSecond, bytesPerSecond)
+		if i < 0 {
+			continue
+		}
+		item := r.decision.Get(k)
+		if item == nil {
+			newDecision[k] = r.newRoutingDecision(k, i, reason, now)
+			continue
+		}
+		prev := item.Value()
+		if prev.pinned {
+			continue
+		}
+		if i < prev.index && r.hysteresis > 0 {
+			i, reason = r.selectConsumer(1-r.hysteresis, estimate, recordsPerSecond, bytesPerSecond)
+			if i >= prev.index {
+				i, reason = prev.index, prev.reason
 			}
 		}
+		switch {
+		case i == prev.index:
+			newDecision[k] = r.newRoutingDecision(k, i, reason, prev.since)
+		case now.Sub(prev.since) < r.minDwellTime:
+			newDecision[k] = prev
+		default:
+			next := r.newRoutingDecision(k, i, reason, now)
+			newDecision[k] = next
+			r.telemetryBuilder.DynamicroutingTransitions.Add(context.Background(), 1, metric.WithAttributeSet(attribute.NewSet(
+				attribute.String("previous_cardinality_bucket", prev.cardinalityBucket),
+				attribute.String("cardinality_bucket", next.cardinalityBucket),
+				attribute.String("partition_key", k),
+				attribute.String("reason", reason),
+			)))
+		}
 	}
 	r.decision.DeleteExpired()
+	// Partition keys may have been pinned since their decision was read,
+	// the lock serializes with pins made through the admin server.
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	for k, c := range newDecision {
+		if item := r.decision.Get(k); item != nil && item.Value().pinned {
+			continue
+		}
 		r.decision.Set(k, c, r.ttl)
 	}
 }
 
+// selectConsumer returns the index of the first consumer whose thresholds,
+// multiplied by scale, are not exceeded by the given measurements, along
+// with the reason for the selection. It returns -1 if there is none.
+func (r *router[C]) selectConsumer(scale, cardinality, recordsPerSecond, bytesPerSecond float64) (int, string) {
+	reason := reasonWithinLimits
+	for i, c := range r.consumers {
+		exceeded := c.exceeded(scale, cardinality, recordsPerSecond, bytesPerSecond)
+		if exceeded == "" {
+			return i, reason
+		}
+		reason = exceeded
+	}
+	return -1, ""
+}
+
+func (r *router[C]) newRoutingDecision(pk string, i int, reason string, since time.Time) routingDecision[C] {
+	c := r.consumers[i]
+	return routingDecision[C]{
+		consumer:          c.consumer,
+		index:             i,
+		maxCount:          c.maxCount,
+		cardinalityBucket: c.cardinalityBucket,
+		reason:            reason,
+		since:             since,
+		routedOpt: metric.WithAttributeSet(attribute.NewSet(
+			attribute.String("cardinality_bucket", c.cardinalityBucket),
+			attribute.String("partition_key", pk),
+			attribute.String("reason", reason),
+		)),
+	}
+}
+
 func formatCardinalityBucket(prevMax float64, max float64) string {
 	return formatCardinality(prevMax) + "_" + formatCardinality(max)
 }
@@ -19,11 +19,13 @@ package dynamicroutingconnector
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"testing"
 	"testing/synctest"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component/componenttest"
@@ -177,6 +179,7 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 					Attributes: attribute.NewSet(
 						attribute.String("cardinality_bucket", defaultCardinalityBucket),
 						attribute.String("partition_key", ""),
+						attribute.String("reason", reasonDefault),
 					),
 				},
 			},
@@ -191,6 +194,7 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 					Attributes: attribute.NewSet(
 						attribute.String("cardinality_bucket", defaultCardinalityBucket),
 						attribute.String("partition_key", "tenant-1:;"),
+						attribute.String("reason", reasonDefault),
 					),
 				},
 				{
@@ -198,6 +202,7 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 					Attributes: attribute.NewSet(
 						attribute.String("cardinality_bucket", "0_2"),
 						attribute.String("partition_key", "tenant-1:;"),
+						attribute.String("reason", reasonWithinLimits),
 					),
 				},
 			},
@@ -212,6 +217,7 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 					Attributes: attribute.NewSet(
 						attribute.String("cardinality_bucket", defaultCardinalityBucket),
 						attribute.String("partition_key", "tenant-1:;"),
+						attribute.String("reason", reasonDefault),
 					),
 				},
 				{
@@ -219,6 +225,7 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 					Attributes: attribute.NewSet(
 						attribute.String("cardinality_bucket", defaultCardinalityBucket),
 						attribute.String("partition_key", "tenant-2:;"),
+						attribute.String("reason", reasonDefault),
 					),
 				},
 				{
@@ -226,6 +233,7 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 					Attributes: attribute.NewSet(
 						attribute.String("cardinality_bucket", "0_2"),
 						attribute.String("partition_key", "tenant-1:;"),
+						attribute.String("reason", reasonWithinLimits),
 					),
 				},
 				{
@@ -233,6 +241,7 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 					Attributes: attribute.NewSet(
 						attribute.String("cardinality_bucket", "0_2"),
 						attribute.String("partition_key", "tenant-2:;"),
+						attribute.String("reason", reasonWithinLimits),
 					),
 				},
 			},
@@ -287,9 +296,205 @@ func TestDynamicroutingRoutedTelemetry(t *testing.T) {
 	}
 }
 
+func TestThroughputRouting(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		pipeline   RoutingPipeline
+		clientIPs  []string
+		batches    int
+		wantBucket bool
+		wantReason string
+	}{
+		{
+			name:       "within_limits",
+			pipeline:   RoutingPipeline{MaxCardinality: 2, MaxRecordsPerSecond: 100},
+			clientIPs:  []string{"10.2.4.2"},
+			batches:    10,
+			wantBucket: true,
+			wantReason: reasonWithinLimits,
+		},
+		{
+			name:       "cardinality",
+			pipeline:   RoutingPipeline{MaxCardinality: 2, MaxRecordsPerSecond: 100},
+			clientIPs:  []string{"10.2.4.2", "10.2.4.3", "10.2.4.4"},
+			batches:    1,
+			wantReason: reasonCardinality,
+		},
+		{
+			name:       "records_per_second",
+			pipeline:   RoutingPipeline{MaxCardinality: 2, MaxRecordsPerSecond: 5},
+			clientIPs:  []string{"10.2.4.2"},
+			batches:    10,
+			wantReason: reasonRecordsPerSecond,
+		},
+		{
+			name:       "bytes_per_second",
+			pipeline:   RoutingPipeline{MaxCardinality: 2, MaxBytesPerSecond: 10},
+			clientIPs:  []string{"10.2.4.2"},
+			batches:    1,
+			wantReason: reasonBytesPerSecond,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				const interval = time.Second
+				_, sinkBucket, conn := newTestMetricsConnector(t, interval, 5*interval, func(cfg *Config) {
+					tc.pipeline.Pipelines = cfg.RoutingPipelines[0].Pipelines
+					cfg.RoutingPipelines[0] = tc.pipeline
+				})
+				require.NoError(t, conn.Start(context.Background(), nil))
+				defer func() { require.NoError(t, conn.Shutdown(context.Background())) }()
+
+				md := newTestMetrics("1", "1", "1", "1")
+				for i := 0; i < tc.batches; i++ {
+					for _, ip := range tc.clientIPs {
+						require.NoError(t, conn.ConsumeMetrics(contextWithMetadata(map[string][]string{
+							"x-tenant-id":     {"tenant-1"},
+							"x-forwarded-for": {ip},
+						}), md))
+					}
+				}
+				time.Sleep(interval)
+				synctest.Wait()
+
+				require.NoError(t, conn.ConsumeMetrics(contextWithMetadata(map[string][]string{
+					"x-tenant-id":     {"tenant-1"},
+					"x-forwarded-for": {"10.2.4.2"},
+				}), md))
+				if tc.wantBucket {
+					require.Len(t, sinkBucket.AllMetrics(), 1)
+				} else {
+					require.Empty(t, sinkBucket.AllMetrics())
+				}
+
+				item := conn.(*metricsConnector).router.decision.Get("tenant-1:;")
+				require.NotNil(t, item)
+				require.Equal(t, tc.wantReason, item.Value().reason)
+			})
+		})
+	}
+}
+
+func TestDecisionTransitions(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		hysteresis   float64
+		minDwellTime time.Duration
+		// windows holds the number of distinct clients sent in each
+		// recording window.
+		windows         []int
+		wantBuckets     []string
+		wantTransitions int64
+	}{
+		{
+			name:            "no_hysteresis",
+			windows:         []int{11, 9, 7},
+			wantBuckets:     []string{"10_inf", "0_10", "0_10"},
+			wantTransitions: 1,
+		},
+		{
+			name:            "hysteresis",
+			hysteresis:      0.2,
+			windows:         []int{11, 9, 7},
+			wantBuckets:     []string{"10_inf", "10_inf", "0_10"},
+			wantTransitions: 1,
+		},
+		{
+			name:            "hysteresis_promotion",
+			hysteresis:      0.2,
+			windows:         []int{7, 9, 11},
+			wantBuckets:     []string{"0_10", "0_10", "10_inf"},
+			wantTransitions: 1,
+		},
+		{
+			name:            "flapping",
+			windows:         []int{11, 9, 11, 9},
+			wantBuckets:     []string{"10_inf", "0_10", "10_inf", "0_10"},
+			wantTransitions: 3,
+		},
+		{
+			name:            "min_dwell_time",
+			minDwellTime:    3 * time.Second,
+			windows:         []int{11, 9, 11, 9, 9},
+			wantBuckets:     []string{"10_inf", "10_inf", "10_inf", "0_10", "0_10"},
+			wantTransitions: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				const interval = time.Second
+				testTel := componenttest.NewTelemetry()
+				defer func() { require.NoError(t, testTel.Shutdown(context.Background())) }()
+
+				pipelineDefault := pipeline.NewIDWithName(pipeline.SignalMetrics, "default")
+				pipelineBucket := pipeline.NewIDWithName(pipeline.SignalMetrics, "bucket_0_10")
+				pipelineInf := pipeline.NewIDWithName(pipeline.SignalMetrics, "bucket_10_inf")
+				routerAndConsumer := connector.NewMetricsRouter(map[pipeline.ID]consumer.Metrics{
+					pipelineDefault: consumertest.NewNop(),
+					pipelineBucket:  consumertest.NewNop(),
+					pipelineInf:     consumertest.NewNop(),
+				})
+				cfg := &Config{
+					RoutingKeys: RoutingKeys{
+						PartitionBy: []string{"x-tenant-id"},
+						MeasureBy:   []string{"x-forwarded-for"},
+					},
+					DefaultPipelines: []pipeline.ID{pipelineDefault},
+					RoutingPipelines: []RoutingPipeline{
+						{Pipelines: []pipeline.ID{pipelineBucket}, MaxCardinality: 10},
+						{Pipelines: []pipeline.ID{pipelineInf}, MaxCardinality: math.Inf(1)},
+					},
+					// A TTL equal to the recording interval records every
+					// batch, so that every window refreshes the decision.
+					RecordingInterval: interval,
+					TTL:               interval,
+					Hysteresis:        tc.hysteresis,
+					MinDwellTime:      tc.minDwellTime,
+				}
+				connSet := connectortest.NewNopSettings(metadata.Type)
+				connSet.TelemetrySettings = testTel.NewTelemetrySettings()
+				connSet.Logger = zaptest.NewLogger(t)
+				conn, err := NewFactory().CreateMetricsToMetrics(
+					context.Background(),
+					connSet,
+					cfg,
+					routerAndConsumer.(consumer.Metrics),
+				)
+				require.NoError(t, err)
+				require.NoError(t, conn.Start(context.Background(), nil))
+				defer func() { require.NoError(t, conn.Shutdown(context.Background())) }()
+
+				md := newTestMetrics("1", "1", "1", "1")
+				for i, clients := range tc.windows {
+					for c := range clients {
+						require.NoError(t, conn.ConsumeMetrics(contextWithMetadata(map[string][]string{
+							"x-tenant-id":     {"tenant-1"},
+							"x-forwarded-for": {fmt.Sprintf("10.2.4.%d", c)},
+						}), md))
+					}
+					time.Sleep(interval)
+					synctest.Wait()
+
+					item := conn.(*metricsConnector).router.decision.Get("tenant-1:;")
+					require.NotNil(t, item)
+					assert.Equal(t, tc.wantBuckets[i], item.Value().cardinalityBucket, "window %d", i)
+				}
+
+				got, err := testTel.GetMetric("otelcol.dynamicrouting.transitions")
+				require.NoError(t, err)
+				var total int64
+				for _, dp := range got.Data.(metricdata.Sum[int64]).DataPoints {
+					total += dp.Value
+				}
+				assert.Equal(t, tc.wantTransitions, total)
+			})
+		})
+	}
+}
+
 // Helpers
 
-func newTestMetricsConnector(t *testing.T, recordingInterval, ttl time.Duration) (
+func newTestMetricsConnector(t *testing.T, recordingInterval, ttl time.Duration, opts ...func(*Config)) (
 	*consumertest.MetricsSink, *consumertest.MetricsSink, connector.Metrics,
 ) {
 	t.Helper()
@@ -317,6 +522,9 @@ func newTestMetricsConnector(t *testing.T, recordingInterval, ttl time.Duration)
 		RecordingInterval: recordingInterval,
 		TTL:               ttl,
 	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 
 	connSet := connectortest.NewNopSettings(metadata.Type)
 	connSet.Logger = zaptest.NewLogger(t)
@@ -0,0 +1,168 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dynamicroutingconnector // import "github.com/elastic/opentelemetry-collector-components/connector/dynamicroutingconnector"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/jellydator/ttlcache/v3"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+// stateKey is the storage key under which the router state is persisted.
+const stateKey = "routing_state"
+
+// persistedState is the router state persisted through the storage
+// extension, so that routing decisions survive restarts.
+type persistedState struct {
+	// Sketches holds the binary encoded sketches of the current recording
+	// window, per partition key.
+	Sketches map[string][]byte `json:"sketches,omitempty"`
+	// Decisions holds the cached routing decisions, per partition key.
+	Decisions map[string]persistedDecision `json:"decisions,omitempty"`
+}
+
+// persistedDecision identifies the routing pipeline of a decision by its
+// cardinality bucket rather than its index, so that decisions for buckets
+// removed from the configuration are dropped on restore.
+type persistedDecision struct {
+	CardinalityBucket string    `json:"cardinality_bucket"`
+	Reason            string    `json:"reason"`
+	Since             time.Time `json:"since"`
+	Pinned            bool      `json:"pinned,omitempty"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// persistState writes the current sketches and routing decisions to the
+// storage client.
+func (r *router[C]) persistState(ctx context.Context) error {
+	state := persistedState{
+		Sketches:  make(map[string][]byte),
+		Decisions: make(map[string]persistedDecision),
+	}
+	r.mu.Lock()
+	for pk, hll := range r.m {
+		data, err := hll.MarshalBinary()
+		if err != nil {
+			r.mu.Unlock()
+			return fmt.Errorf("failed to marshal sketch for partition key %q: %w", pk, err)
+		}
+		state.Sketches[pk] = data
+	}
+	r.mu.Unlock()
+	for pk, item := range r.decision.Items() {
+		state.Decisions[pk] = persistedDecision{
+			CardinalityBucket: item.Value().cardinalityBucket,
+			Reason:            item.Value().reason,
+			Since:             item.Value().since,
+			Pinned:            item.Value().pinned,
+			ExpiresAt:         item.ExpiresAt(),
+		}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal routing state: %w", err)
+	}
+	return r.storage.Set(ctx, stateKey, data)
+}
+
+// restoreState reads the sketches and routing decisions from the storage
+// client. Expired decisions and decisions for unknown cardinality buckets
+// are skipped, and restored sketches are merged into the current ones.
+// Pinned decisions keep their own expiry.
+func (r *router[C]) restoreState(ctx context.Context) error {
+	data, err := r.storage.Get(ctx, stateKey)
+	if err != nil {
+		return fmt.Errorf("failed to read routing state: %w", err)
+	}
+	if data == nil {
+		return nil
+	}
+	var state persistedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to unmarshal routing state: %w", err)
+	}
+
+	now := time.Now()
+	for pk, d := range state.Decisions {
+		ttl := d.ExpiresAt.Sub(now)
+		switch {
+		case d.Pinned && d.ExpiresAt.IsZero():
+			// Pinned without expiry.
+			ttl = ttlcache.NoTTL
+		case ttl <= 0:
+			continue
+		case !d.Pinned:
+			ttl = min(ttl, r.ttl)
+		}
+		i := slices.IndexFunc(r.consumers, func(c consumerThreshold[C]) bool {
+			return c.cardinalityBucket == d.CardinalityBucket
+		})
+		if i < 0 {
+			continue
+		}
+		decision := r.newRoutingDecision(pk, i, d.Reason, d.Since)
+		decision.pinned = d.Pinned
+		r.decision.Set(pk, decision, ttl)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for pk, data := range state.Sketches {
+		hll := hyperloglog.New()
+		if err := hll.UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("failed to unmarshal sketch for partition key %q: %w", pk, err)
+		}
+		if existing, ok := r.m[pk]; ok {
+			if err := existing.Merge(hll); err != nil {
+				return fmt.Errorf("failed to merge sketch for partition key %q: %w", pk, err)
+			}
+			continue
+		}
+		r.m[pk] = hll
+	}
+	return nil
+}
+
+// getStorageClient retrieves a storage.Client from the configured storage
+// extension. Each signal gets its own client, as each has its own router.
+func getStorageClient(
+	ctx context.Context,
+	host component.Host,
+	id component.ID,
+	componentID component.ID,
+	signal pipeline.Signal,
+) (storage.Client, error) {
+	ext, ok := host.GetExtensions()[id]
+	if !ok {
+		return nil, fmt.Errorf("storage extension %q not found", id)
+	}
+	se, ok := ext.(storage.Extension)
+	if !ok {
+		return nil, fmt.Errorf("extension %q is not a storage extension", id)
+	}
+	return se.GetClient(ctx, component.KindConnector, componentID, signal.String())
+}
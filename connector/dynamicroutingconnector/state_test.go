@@ -0,0 +1,202 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dynamicroutingconnector
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+)
+
+func TestPersistentState(t *testing.T) {
+	const (
+		interval = 10 * time.Millisecond
+		ttl      = 40 * time.Millisecond
+	)
+	storageID := component.MustNewID("file_storage")
+	withStorage := func(cfg *Config) { cfg.StorageID = &storageID }
+	ctx := contextWithMetadata(map[string][]string{
+		"x-tenant-id":     {"tenant-1"},
+		"x-forwarded-for": {"10.2.4.2"},
+	})
+	md := newTestMetrics("1", "1", "1", "1")
+
+	t.Run("decisions", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			client := newMemStorageClient()
+			host := newStorageHost(storageID, client)
+
+			sinkDefault, sinkBucket, conn := newTestMetricsConnector(t, interval, ttl, withStorage)
+			require.NoError(t, conn.Start(context.Background(), host))
+			require.NoError(t, conn.ConsumeMetrics(ctx, md))
+			time.Sleep(interval)
+			synctest.Wait()
+			require.NoError(t, conn.ConsumeMetrics(ctx, md))
+			require.Len(t, sinkDefault.AllMetrics(), 1)
+			require.Len(t, sinkBucket.AllMetrics(), 1)
+			require.NoError(t, conn.Shutdown(context.Background()))
+
+			// The restarted connector routes to the bucket right away.
+			sinkDefault, sinkBucket, conn = newTestMetricsConnector(t, interval, ttl, withStorage)
+			require.NoError(t, conn.Start(context.Background(), host))
+			defer func() { require.NoError(t, conn.Shutdown(context.Background())) }()
+			require.NoError(t, conn.ConsumeMetrics(ctx, md))
+			assert.Empty(t, sinkDefault.AllMetrics())
+			assert.Len(t, sinkBucket.AllMetrics(), 1)
+		})
+	})
+
+	t.Run("sketches", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			client := newMemStorageClient()
+			host := newStorageHost(storageID, client)
+
+			// Shutdown before a decision is made, leaving only a sketch.
+			sinkDefault, _, conn := newTestMetricsConnector(t, interval, ttl, withStorage)
+			require.NoError(t, conn.Start(context.Background(), host))
+			require.NoError(t, conn.ConsumeMetrics(ctx, md))
+			require.Len(t, sinkDefault.AllMetrics(), 1)
+			require.NoError(t, conn.Shutdown(context.Background()))
+
+			// The restored sketch forms a decision at the next interval,
+			// without recording new data.
+			sinkDefault, sinkBucket, conn := newTestMetricsConnector(t, interval, ttl, withStorage)
+			require.NoError(t, conn.Start(context.Background(), host))
+			defer func() { require.NoError(t, conn.Shutdown(context.Background())) }()
+			time.Sleep(interval)
+			synctest.Wait()
+			require.NoError(t, conn.ConsumeMetrics(ctx, md))
+			assert.Empty(t, sinkDefault.AllMetrics())
+			assert.Len(t, sinkBucket.AllMetrics(), 1)
+		})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			client := newMemStorageClient()
+			host := newStorageHost(storageID, client)
+
+			_, _, conn := newTestMetricsConnector(t, interval, ttl, withStorage)
+			require.NoError(t, conn.Start(context.Background(), host))
+			require.NoError(t, conn.ConsumeMetrics(ctx, md))
+			time.Sleep(interval)
+			synctest.Wait()
+			require.NoError(t, conn.Shutdown(context.Background()))
+
+			// The decision expires while the collector is down.
+			time.Sleep(ttl)
+
+			sinkDefault, sinkBucket, conn := newTestMetricsConnector(t, interval, ttl, withStorage)
+			require.NoError(t, conn.Start(context.Background(), host))
+			defer func() { require.NoError(t, conn.Shutdown(context.Background())) }()
+			require.NoError(t, conn.ConsumeMetrics(ctx, md))
+			assert.Len(t, sinkDefault.AllMetrics(), 1)
+			assert.Empty(t, sinkBucket.AllMetrics())
+		})
+	})
+
+	t.Run("missing_extension", func(t *testing.T) {
+		_, _, conn := newTestMetricsConnector(t, interval, ttl, withStorage)
+		err := conn.Start(context.Background(), newStorageHost(component.MustNewID("other"), nil))
+		assert.ErrorContains(t, err, `storage extension "file_storage" not found`)
+	})
+}
+
+// storageHost is a component.Host holding a single storage extension.
+type storageHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func newStorageHost(id component.ID, client *memStorageClient) component.Host {
+	return &storageHost{extensions: map[component.ID]component.Component{
+		id: &memStorageExtension{client: client},
+	}}
+}
+
+func (h *storageHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+// memStorageExtension implements storage.Extension for tests.
+type memStorageExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+	client *memStorageClient
+}
+
+func (m *memStorageExtension) GetClient(context.Context, component.Kind, component.ID, string) (storage.Client, error) {
+	return m.client, nil
+}
+
+// memStorageClient is an in-memory storage.Client.
+type memStorageClient struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemStorageClient() *memStorageClient {
+	return &memStorageClient{data: make(map[string][]byte)}
+}
+
+func (m *memStorageClient) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[key], nil
+}
+
+func (m *memStorageClient) Set(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *memStorageClient) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memStorageClient) Batch(ctx context.Context, ops ...*storage.Operation) error {
+	for _, op := range ops {
+		var err error
+		switch op.Type {
+		case storage.Get:
+			op.Value, err = m.Get(ctx, op.Key)
+		case storage.Set:
+			err = m.Set(ctx, op.Key, op.Value)
+		case storage.Delete:
+			err = m.Delete(ctx, op.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*memStorageClient) Close(context.Context) error { return nil }
@@ -26,9 +26,12 @@ import (
 	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pipeline"
 	"go.uber.org/zap"
 )
 
+var tracesSizer = &ptrace.ProtoMarshaler{}
+
 type tracesConnector struct {
 	logger *zap.Logger
 	cfg    *Config
@@ -46,7 +49,7 @@ func newTracesConnector(
 		return nil, errors.New("expected connector to be a router and consumer")
 	}
 
-	router, err := newRouter(cfg, set.TelemetrySettings, tr.Consumer)
+	router, err := newRouter(cfg, set, pipeline.SignalTraces, tr.Consumer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create router: %w", err)
 	}
@@ -71,5 +74,13 @@ func (c *tracesConnector) Capabilities() consumer.Capabilities {
 }
 
 func (c *tracesConnector) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
-	return c.router.Process(ctx).ConsumeTraces(ctx, td)
+	return c.router.Process(ctx, c.batchSize(td)).ConsumeTraces(ctx, td)
+}
+
+func (c *tracesConnector) batchSize(td ptrace.Traces) batchSize {
+	size := batchSize{records: td.SpanCount()}
+	if c.router.measureBytes {
+		size.bytes = tracesSizer.TracesSize(td)
+	}
+	return size
 }
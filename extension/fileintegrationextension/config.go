@@ -29,6 +29,10 @@ import (
 type Config struct {
 	// Path is the directory containing integrations.
 	Path string `mapstructure:"path"`
+
+	// Watch enables watching the directory for changes, so components using the integrations
+	// can reload them. Enabled by default.
+	Watch bool `mapstructure:"watch"`
 }
 
 var _ xconfmap.Validator = &Config{}
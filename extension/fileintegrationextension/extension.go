@@ -19,35 +19,142 @@ package fileintegrationextension // import "github.com/elastic/opentelemetry-col
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
 
 	"go.opentelemetry.io/collector/component"
 
 	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
 )
 
+const (
+	integrationExtension = ".yaml"
+
+	defaultReloadDelay = 100 * time.Millisecond
+)
+
 type fileTemplateExtension struct {
 	config *Config
+	logger *zap.Logger
+
+	watcher *fsnotify.Watcher
+	wg      sync.WaitGroup
+	// reloadDelay is the time without events from the watcher after which the integrations are
+	// reloaded.
+	reloadDelay time.Duration
+
+	mu          sync.Mutex
+	subscribers map[int]func(string)
+	nextID      int
+	// checksums holds the checksum of the last seen content of each integration file, so that
+	// unrelated events in the watched directory don't notify changes.
+	checksums map[string][sha256.Size]byte
 }
 
 var (
-	_ component.Component = &fileTemplateExtension{}
-	_ integrations.Finder = &fileTemplateExtension{}
+	_ component.Component         = &fileTemplateExtension{}
+	_ integrations.Finder         = &fileTemplateExtension{}
+	_ integrations.ChangeNotifier = &fileTemplateExtension{}
 )
 
-func newFileTemplateExtension(config *Config) *fileTemplateExtension {
+func newFileTemplateExtension(config *Config, logger *zap.Logger) *fileTemplateExtension {
 	return &fileTemplateExtension{
-		config: config,
+		config:      config,
+		logger:      logger,
+		subscribers: make(map[int]func(string)),
+		reloadDelay: defaultReloadDelay,
 	}
 }
 
-// FindIntegration looks for integrations in the configured directory, the name of the integration is used as the name
-// of the file in the directory, without extension.
+// FindIntegration looks for the newest version of an integration in the configured directory. See
+// FindIntegrationVersion.
 func (e *fileTemplateExtension) FindIntegration(ctx context.Context, name string) (integrations.Integration, error) {
-	path := filepath.Join(e.config.Path, name+".yaml")
+	return e.FindIntegrationVersion(ctx, name, nil)
+}
+
+// FindIntegrationVersion looks for integrations in the configured directory. Multiple versions of an
+// integration can be installed in a directory with the name of the integration, with a file for each
+// version, named after it. The newest version that satisfies the constraints is returned. If there is
+// no version satisfying them, it looks for a file with the name of the integration, that is only used
+// with constraints if the version in its manifest satisfies them.
+func (e *fileTemplateExtension) FindIntegrationVersion(_ context.Context, name string, constraints *semver.Constraints) (integrations.Integration, error) {
+	versions, err := e.listVersions(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if constraints != nil && !constraints.Check(version.version) {
+			continue
+		}
+		integration, err := loadIntegration(filepath.Join(e.config.Path, name, version.file))
+		if err != nil {
+			return nil, err
+		}
+		if manifest := integration.Manifest(); manifest != nil {
+			if manifestVersion, _ := manifest.SemVer(); !manifestVersion.Equal(version.version) {
+				return nil, fmt.Errorf("version in manifest of integration %q (%s) does not match version in file name (%s)",
+					name, manifest.Version, version.version)
+			}
+		}
+		return integration, nil
+	}
+
+	integration, err := loadIntegration(filepath.Join(e.config.Path, name+integrationExtension))
+	if err != nil {
+		return nil, err
+	}
+	if constraints != nil && !integrations.MatchesVersion(integration, constraints) {
+		return nil, fmt.Errorf("%w: no version of integration %q satisfies %q", integrations.ErrNotFound, name, constraints)
+	}
+	return integration, nil
+}
+
+type integrationVersion struct {
+	version *semver.Version
+	file    string
+}
+
+// listVersions lists the versions of the integration found in its directory, from newest to oldest.
+func (e *fileTemplateExtension) listVersions(name string) ([]integrationVersion, error) {
+	entries, err := os.ReadDir(filepath.Join(e.config.Path, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []integrationVersion
+	for _, entry := range entries {
+		v, found := strings.CutSuffix(entry.Name(), integrationExtension)
+		if !found || entry.IsDir() {
+			continue
+		}
+		version, err := semver.StrictNewVersion(v)
+		if err != nil {
+			e.logger.Debug("ignoring file with invalid version in integration directory",
+				zap.String("integration", name),
+				zap.String("file", entry.Name()))
+			continue
+		}
+		versions = append(versions, integrationVersion{version: version, file: entry.Name()})
+	}
+	slices.SortFunc(versions, func(a, b integrationVersion) int { return b.version.Compare(a.version) })
+	return versions, nil
+}
+
+func loadIntegration(path string) (*integrations.RawTemplate, error) {
 	raw, err := os.ReadFile(path)
 	if errors.Is(err, fs.ErrNotExist) {
 		return nil, integrations.ErrNotFound
@@ -59,10 +166,202 @@ func (e *fileTemplateExtension) FindIntegration(ctx context.Context, name string
 	return integrations.NewRawTemplate(raw)
 }
 
-func (*fileTemplateExtension) Start(context.Context, component.Host) error {
+// SubscribeChanges registers fn to be called with the name of an integration every time its file
+// is created, modified or removed. Changes are only detected if watch is enabled.
+func (e *fileTemplateExtension) SubscribeChanges(fn func(name string)) func() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	id := e.nextID
+	e.nextID++
+	e.subscribers[id] = fn
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subscribers, id)
+	}
+}
+
+func (e *fileTemplateExtension) Start(context.Context, component.Host) error {
+	if !e.config.Watch {
+		return nil
+	}
+
+	checksums, dirs, err := e.scan()
+	if err != nil {
+		return err
+	}
+	e.checksums = checksums
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create integrations watcher: %w", err)
+	}
+	if err := watcher.Add(e.config.Path); err != nil {
+		return errors.Join(
+			fmt.Errorf("failed to watch integrations directory: %w", err),
+			watcher.Close(),
+		)
+	}
+	e.watcher = watcher
+	e.watchDirs(dirs)
+
+	// Reloads run in their own goroutine, so slow subscribers don't block the watcher.
+	reloads := make(chan struct{}, 1)
+	e.wg.Add(2)
+	go func() {
+		defer e.wg.Done()
+		defer close(reloads)
+		e.watch(watcher, reloads)
+	}()
+	go func() {
+		defer e.wg.Done()
+		for range reloads {
+			if err := e.reload(); err != nil {
+				e.logger.Error("failed to reload integrations", zap.Error(err))
+			}
+		}
+	}()
 	return nil
 }
 
-func (*fileTemplateExtension) Shutdown(context.Context) error {
+// watch requests a reload once no events have been received from the watcher for reloadDelay,
+// so that a burst of events, such as those of a file being written, causes a single reload.
+func (e *fileTemplateExtension) watch(watcher *fsnotify.Watcher, reloads chan<- struct{}) {
+	var delay <-chan time.Time
+	for {
+		select {
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			delay = time.After(e.reloadDelay)
+		case <-delay:
+			delay = nil
+			select {
+			case reloads <- struct{}{}:
+			default:
+				// A reload is already pending, and it will see this change.
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			e.logger.Warn("integrations watcher error", zap.Error(err))
+		}
+	}
+}
+
+func (e *fileTemplateExtension) Shutdown(context.Context) error {
+	var err error
+	if e.watcher != nil {
+		err = e.watcher.Close()
+		e.wg.Wait()
+		e.watcher = nil
+	}
+	return err
+}
+
+// watchDirs watches the directories of integrations with multiple versions. Watches of removed
+// directories are removed by the watcher.
+func (e *fileTemplateExtension) watchDirs(dirs []string) {
+	for _, dir := range dirs {
+		if err := e.watcher.Add(filepath.Join(e.config.Path, dir)); err != nil {
+			e.logger.Warn("failed to watch integration directory", zap.String("integration", dir), zap.Error(err))
+		}
+	}
+}
+
+// reload scans the directory and notifies the subscribers about the integrations that changed
+// since the last scan.
+func (e *fileTemplateExtension) reload() error {
+	checksums, dirs, err := e.scan()
+	if err != nil {
+		return err
+	}
+	e.watchDirs(dirs)
+
+	var changed []string
+	for file, checksum := range checksums {
+		if previous, found := e.checksums[file]; !found || previous != checksum {
+			changed = append(changed, integrationName(file))
+		}
+	}
+	for file := range e.checksums {
+		if _, found := checksums[file]; !found {
+			changed = append(changed, integrationName(file))
+		}
+	}
+	e.checksums = checksums
+	if len(changed) == 0 {
+		return nil
+	}
+	slices.Sort(changed)
+	changed = slices.Compact(changed)
+
+	// Subscribers are called without holding the lock, so they can unsubscribe.
+	e.mu.Lock()
+	subscribers := make([]func(string), 0, len(e.subscribers))
+	for _, fn := range e.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	e.mu.Unlock()
+
+	for _, name := range changed {
+		e.logger.Info("integration changed", zap.String("integration", name))
+		for _, fn := range subscribers {
+			fn(name)
+		}
+	}
 	return nil
 }
+
+// scan returns the checksums of the integration files found in the directory and in the
+// directories of integrations with multiple versions, by their relative paths. It also returns
+// the list of these directories.
+func (e *fileTemplateExtension) scan() (map[string][sha256.Size]byte, []string, error) {
+	entries, err := os.ReadDir(e.config.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read integrations directory: %w", err)
+	}
+	checksums := make(map[string][sha256.Size]byte, len(entries))
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			versions, err := os.ReadDir(filepath.Join(e.config.Path, entry.Name()))
+			if err != nil {
+				// The directory may have been removed after listing the parent directory.
+				e.logger.Debug("failed to read integration directory", zap.String("integration", entry.Name()), zap.Error(err))
+				continue
+			}
+			dirs = append(dirs, entry.Name())
+			for _, version := range versions {
+				e.addChecksum(checksums, filepath.Join(entry.Name(), version.Name()), version)
+			}
+			continue
+		}
+		e.addChecksum(checksums, entry.Name(), entry)
+	}
+	return checksums, dirs, nil
+}
+
+func (e *fileTemplateExtension) addChecksum(checksums map[string][sha256.Size]byte, file string, entry fs.DirEntry) {
+	if !strings.HasSuffix(file, integrationExtension) || entry.IsDir() {
+		return
+	}
+	raw, err := os.ReadFile(filepath.Join(e.config.Path, file))
+	if err != nil {
+		// The file may have been removed after listing the directory.
+		e.logger.Debug("failed to read integration", zap.String("file", file), zap.Error(err))
+		return
+	}
+	checksums[file] = sha256.Sum256(raw)
+}
+
+// integrationName returns the name of the integration defined in the file, given its path relative to
+// the integrations directory.
+func integrationName(file string) string {
+	if dir := filepath.Dir(file); dir != "." {
+		return dir
+	}
+	return strings.TrimSuffix(file, integrationExtension)
+}
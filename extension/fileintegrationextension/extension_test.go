@@ -18,11 +18,19 @@
 package fileintegrationextension // import "github.com/elastic/opentelemetry-collector-components/extension/fileintegrationextension"
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
 )
 
 func TestFindIntegration(t *testing.T) {
@@ -47,7 +55,7 @@ func TestFindIntegration(t *testing.T) {
 		},
 	}
 
-	extension := newFileTemplateExtension(&Config{Path: "testdata/templates"})
+	extension := newFileTemplateExtension(&Config{Path: "testdata/templates"}, zap.NewNop())
 
 	// Start and Stop have empty implementations, checking just in case.
 	err := extension.Start(context.Background(), nil)
@@ -70,3 +78,166 @@ func TestFindIntegration(t *testing.T) {
 		})
 	}
 }
+
+func TestFindIntegrationVersion(t *testing.T) {
+	cases := []struct {
+		title           string
+		template        string
+		constraints     string
+		expectedVersion string
+		expectedErr     string
+	}{
+		{
+			title:           "newest version",
+			template:        "versioned",
+			expectedVersion: "2.0.0",
+		},
+		{
+			title:           "newest version satisfying constraints",
+			template:        "versioned",
+			constraints:     "^1.0.0",
+			expectedVersion: "1.1.0",
+		},
+		{
+			title:           "exact version",
+			template:        "versioned",
+			constraints:     "1.0.0",
+			expectedVersion: "1.0.0",
+		},
+		{
+			title:       "no version satisfying constraints",
+			template:    "versioned",
+			constraints: "^3.0.0",
+			expectedErr: "not found",
+		},
+		{
+			title:       "unversioned file without manifest",
+			template:    "valid",
+			constraints: "^1.0.0",
+			expectedErr: "not found",
+		},
+		{
+			title:       "version in manifest does not match file name",
+			template:    "mismatch",
+			expectedErr: "does not match version in file name",
+		},
+	}
+
+	extension := newFileTemplateExtension(&Config{Path: "testdata/templates"}, zap.NewNop())
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			var constraints *semver.Constraints
+			if c.constraints != "" {
+				var err error
+				constraints, err = semver.NewConstraint(c.constraints)
+				require.NoError(t, err)
+			}
+
+			integration, err := extension.FindIntegrationVersion(context.Background(), c.template, constraints)
+			if c.expectedErr != "" {
+				assert.ErrorContains(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			manifest := integration.(integrations.ManifestGetter).Manifest()
+			require.NotNil(t, manifest)
+			assert.Equal(t, c.expectedVersion, manifest.Version)
+		})
+	}
+}
+
+func TestSubscribeChanges(t *testing.T) {
+	dir := t.TempDir()
+	valid, err := os.ReadFile(filepath.Join("testdata", "templates", "valid.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "valid.yaml"), valid, 0o600))
+
+	extension := newFileTemplateExtension(&Config{Path: dir, Watch: true}, zap.NewNop())
+	require.NoError(t, extension.Start(context.Background(), nil))
+	defer func() {
+		require.NoError(t, extension.Shutdown(context.Background()))
+	}()
+
+	changes := make(chan string, 10)
+	unsubscribe := extension.SubscribeChanges(func(name string) {
+		changes <- name
+	})
+	defer unsubscribe()
+
+	waitChange := func(t *testing.T, expected string) {
+		t.Helper()
+		select {
+		case name := <-changes:
+			assert.Equal(t, expected, name)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout while waiting for changes in %q", expected)
+		}
+	}
+
+	// Files that are not integrations are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o600))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), valid, 0o600))
+	waitChange(t, "other")
+
+	modified := append(valid, []byte("\n# modified\n")...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "valid.yaml"), modified, 0o600))
+	waitChange(t, "valid")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "other.yaml")))
+	waitChange(t, "other")
+
+	// Versions of integrations in their own directories.
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "versioned"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "versioned", "1.0.0.yaml"), valid, 0o600))
+	waitChange(t, "versioned")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "versioned", "1.1.0.yaml"), valid, 0o600))
+	waitChange(t, "versioned")
+
+	select {
+	case name := <-changes:
+		t.Fatalf("unexpected change notified for %q", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubscribeChanges_Debounce(t *testing.T) {
+	dir := t.TempDir()
+	valid, err := os.ReadFile(filepath.Join("testdata", "templates", "valid.yaml"))
+	require.NoError(t, err)
+
+	extension := newFileTemplateExtension(&Config{Path: dir, Watch: true}, zap.NewNop())
+	extension.reloadDelay = 500 * time.Millisecond
+	require.NoError(t, extension.Start(context.Background(), nil))
+	defer func() {
+		require.NoError(t, extension.Shutdown(context.Background()))
+	}()
+
+	changes := make(chan string, 10)
+	unsubscribe := extension.SubscribeChanges(func(name string) {
+		changes <- name
+	})
+	defer unsubscribe()
+
+	// A file written in several steps is notified once.
+	f, err := os.Create(filepath.Join(dir, "valid.yaml"))
+	require.NoError(t, err)
+	for _, line := range bytes.SplitAfter(valid, []byte("\n")) {
+		_, err := f.Write(line)
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	select {
+	case name := <-changes:
+		assert.Equal(t, "valid", name)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout while waiting for changes")
+	}
+	select {
+	case name := <-changes:
+		t.Fatalf("unexpected change notified for %q", name)
+	case <-time.After(time.Second):
+	}
+}
@@ -38,10 +38,11 @@ func NewFactory() extension.Factory {
 
 func createDefaultConfig() component.Config {
 	return &Config{
-		Path: "integrations",
+		Path:  "integrations",
+		Watch: true,
 	}
 }
 
-func createExtension(_ context.Context, _ extension.Settings, cfg component.Config) (extension.Extension, error) {
-	return newFileTemplateExtension(cfg.(*Config)), nil
+func createExtension(_ context.Context, set extension.Settings, cfg component.Config) (extension.Extension, error) {
+	return newFileTemplateExtension(cfg.(*Config), set.Logger), nil
 }
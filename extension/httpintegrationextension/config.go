@@ -0,0 +1,97 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpintegrationextension // import "github.com/elastic/opentelemetry-collector-components/extension/httpintegrationextension"
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/confmap/xconfmap"
+)
+
+// Config is the structured configuration of the extension.
+type Config struct {
+	// ClientConfig configures the client used to connect to the registry. Its endpoint is the
+	// URL of the index document.
+	confighttp.ClientConfig `mapstructure:",squash"`
+
+	// CacheDir is the directory where the index and the packages downloaded from the registry are
+	// stored, so they can be used if the registry is not available on start. Cache is disabled if empty.
+	CacheDir string `mapstructure:"cache_dir"`
+
+	// RefreshInterval is the interval between refreshes of the integrations from the registry,
+	// so components using them can reload them. Refresh is disabled if zero.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+
+	// PublicKey is the PEM-encoded Ed25519 public key used to verify the signatures of the
+	// packages. If set, all packages must be signed.
+	PublicKey string `mapstructure:"public_key"`
+
+	// MaxResponseSize is the maximum size in bytes of the index, its signature and each of
+	// the packages obtained from the registry.
+	MaxResponseSize int64 `mapstructure:"max_response_size"`
+}
+
+var _ xconfmap.Validator = &Config{}
+
+// Validate validates the configuration, it checks that the endpoint is a valid URL and that the
+// public key, if any, can be parsed.
+func (c *Config) Validate() error {
+	if c.Endpoint == "" {
+		return errors.New("endpoint is required")
+	}
+	if _, err := url.Parse(c.Endpoint); err != nil {
+		return fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if c.RefreshInterval < 0 {
+		return errors.New("refresh_interval must not be negative (0 disables refresh)")
+	}
+	if c.MaxResponseSize <= 0 {
+		return errors.New("max_response_size must be positive")
+	}
+	if _, err := c.parsePublicKey(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parsePublicKey returns the configured public key, or nil if none is configured.
+func (c *Config) parsePublicKey() (ed25519.PublicKey, error) {
+	if c.PublicKey == "" {
+		return nil, nil
+	}
+	block, _ := pem.Decode([]byte(c.PublicKey))
+	if block == nil {
+		return nil, errors.New("invalid public_key: no PEM data found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public_key: %w", err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid public_key: unsupported key type %T, only Ed25519 keys are supported", key)
+	}
+	return publicKey, nil
+}
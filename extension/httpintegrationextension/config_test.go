@@ -0,0 +1,95 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpintegrationextension // import "github.com/elastic/opentelemetry-collector-components/extension/httpintegrationextension"
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cases := []struct {
+		title       string
+		modify      func(*Config)
+		expectedErr string
+	}{
+		{
+			title:       "missing endpoint",
+			modify:      func(*Config) {},
+			expectedErr: "endpoint is required",
+		},
+		{
+			title: "valid",
+			modify: func(c *Config) {
+				c.Endpoint = "https://integrations.example.com/index.yaml"
+			},
+		},
+		{
+			title: "negative refresh interval",
+			modify: func(c *Config) {
+				c.Endpoint = "https://integrations.example.com/index.yaml"
+				c.RefreshInterval = -time.Second
+			},
+			expectedErr: "refresh_interval must not be negative",
+		},
+		{
+			title: "zero max response size",
+			modify: func(c *Config) {
+				c.Endpoint = "https://integrations.example.com/index.yaml"
+				c.MaxResponseSize = 0
+			},
+			expectedErr: "max_response_size must be positive",
+		},
+		{
+			title: "valid public key",
+			modify: func(c *Config) {
+				c.Endpoint = "https://integrations.example.com/index.yaml"
+				c.PublicKey = encodePublicKey(t, publicKey)
+			},
+		},
+		{
+			title: "invalid public key",
+			modify: func(c *Config) {
+				c.Endpoint = "https://integrations.example.com/index.yaml"
+				c.PublicKey = "not a key"
+			},
+			expectedErr: "invalid public_key: no PEM data found",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			config := createDefaultConfig().(*Config)
+			c.modify(config)
+			err := config.Validate()
+			if c.expectedErr != "" {
+				assert.ErrorContains(t, err, c.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,268 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpintegrationextension // import "github.com/elastic/opentelemetry-collector-components/extension/httpintegrationextension"
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+
+	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
+)
+
+type httpIntegrationExtension struct {
+	config    *Config
+	telemetry component.TelemetrySettings
+	logger    *zap.Logger
+	publicKey ed25519.PublicKey
+
+	client *http.Client
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu          sync.Mutex
+	catalog     catalog
+	subscribers map[int]func(string)
+	nextID      int
+}
+
+var (
+	_ component.Component          = &httpIntegrationExtension{}
+	_ integrations.VersionedFinder = &httpIntegrationExtension{}
+	_ integrations.ChangeNotifier  = &httpIntegrationExtension{}
+)
+
+func newHTTPIntegrationExtension(config *Config, telemetry component.TelemetrySettings) (*httpIntegrationExtension, error) {
+	publicKey, err := config.parsePublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &httpIntegrationExtension{
+		config:      config,
+		telemetry:   telemetry,
+		logger:      telemetry.Logger,
+		publicKey:   publicKey,
+		subscribers: make(map[int]func(string)),
+	}, nil
+}
+
+// FindIntegration looks for the newest version of an integration in the registry. See
+// FindIntegrationVersion.
+func (e *httpIntegrationExtension) FindIntegration(ctx context.Context, name string) (integrations.Integration, error) {
+	return e.FindIntegrationVersion(ctx, name, nil)
+}
+
+// FindIntegrationVersion returns the newest version of the integration that satisfies the constraints,
+// among the ones obtained from the registry in the last successful refresh.
+func (e *httpIntegrationExtension) FindIntegrationVersion(_ context.Context, name string, constraints *semver.Constraints) (integrations.Integration, error) {
+	e.mu.Lock()
+	packages := e.catalog[name]
+	e.mu.Unlock()
+
+	if len(packages) == 0 {
+		return nil, integrations.ErrNotFound
+	}
+	for _, p := range packages {
+		if constraints != nil && !constraints.Check(p.version) {
+			continue
+		}
+		return integrations.NewRawTemplate(p.raw)
+	}
+	return nil, fmt.Errorf("%w: no version of integration %q satisfies %q", integrations.ErrNotFound, name, constraints)
+}
+
+// SubscribeChanges registers fn to be called with the name of an integration every time a refresh
+// finds that its packages were added, modified or removed in the registry.
+func (e *httpIntegrationExtension) SubscribeChanges(fn func(name string)) func() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	id := e.nextID
+	e.nextID++
+	e.subscribers[id] = fn
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subscribers, id)
+	}
+}
+
+// Start obtains the integrations from the registry, or from the cache if the registry is not available,
+// and starts refreshing them periodically.
+func (e *httpIntegrationExtension) Start(ctx context.Context, host component.Host) error {
+	client, err := e.config.ToClient(ctx, host.GetExtensions(), e.telemetry)
+	if err != nil {
+		return fmt.Errorf("failed to create registry client: %w", err)
+	}
+	e.client = client
+
+	if err := e.refresh(ctx); err != nil {
+		if e.config.CacheDir == "" {
+			return err
+		}
+		e.logger.Warn("failed to obtain integrations from registry, using cache", zap.Error(err))
+		cached, cacheErr := e.readCache(ctx)
+		if cacheErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to read integrations cache: %w", cacheErr))
+		}
+		e.update(cached)
+	}
+
+	if e.config.RefreshInterval <= 0 {
+		return nil
+	}
+	refreshCtx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.config.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-refreshCtx.Done():
+				return
+			case <-ticker.C:
+				if err := e.refresh(refreshCtx); err != nil {
+					e.logger.Error("failed to refresh integrations", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (e *httpIntegrationExtension) Shutdown(context.Context) error {
+	if e.cancel != nil {
+		e.cancel()
+		e.wg.Wait()
+		e.cancel = nil
+	}
+	if e.client != nil {
+		e.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// refresh obtains the index and the packages that changed from the registry. The current integrations
+// are kept if the index cannot be obtained or verified, packages failing on their own are skipped.
+func (e *httpIntegrationExtension) refresh(ctx context.Context) error {
+	rawIndex, err := e.get(ctx, e.config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to fetch integrations index: %w", err)
+	}
+	var signature []byte
+	if e.publicKey != nil {
+		signatureURL, err := url.Parse(e.config.Endpoint)
+		if err != nil {
+			return err
+		}
+		signatureURL.Path += signatureExtension
+		signature, err = e.get(ctx, signatureURL.String())
+		if err != nil {
+			return fmt.Errorf("failed to fetch integrations index signature: %w", err)
+		}
+		if err := e.verifyIndex(rawIndex, signature); err != nil {
+			return err
+		}
+	}
+	index, err := parseIndex(rawIndex)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	previous := e.catalog
+	e.mu.Unlock()
+
+	current := e.buildCatalog(ctx, index, previous, e.download)
+	if e.config.CacheDir != "" {
+		if err := e.writeCache(rawIndex, signature, index, current); err != nil {
+			e.logger.Warn("failed to write integrations cache", zap.Error(err))
+		}
+	}
+	e.update(current)
+	return nil
+}
+
+// update replaces the current integrations and notifies the subscribers about the ones that changed.
+func (e *httpIntegrationExtension) update(current catalog) {
+	e.mu.Lock()
+	changed := changedIntegrations(e.catalog, current)
+	e.catalog = current
+	// Subscribers are called without holding the lock, so they can unsubscribe or find integrations.
+	subscribers := make([]func(string), 0, len(e.subscribers))
+	for _, fn := range e.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	e.mu.Unlock()
+
+	for _, name := range changed {
+		e.logger.Info("integration changed", zap.String("integration", name))
+		for _, fn := range subscribers {
+			fn(name)
+		}
+	}
+}
+
+// download fetches a package from the registry, its URL is resolved relative to the URL of the index.
+func (e *httpIntegrationExtension) download(ctx context.Context, _ string, entry packageEntry) ([]byte, error) {
+	base, err := url.Parse(e.config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(entry.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	return e.get(ctx, base.ResolveReference(ref).String())
+}
+
+func (e *httpIntegrationExtension) get(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, location)
+	}
+	// One more byte than allowed is read to detect bodies exceeding the limit.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, e.config.MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > e.config.MaxResponseSize {
+		return nil, fmt.Errorf("response from %s exceeds max_response_size of %d bytes", location, e.config.MaxResponseSize)
+	}
+	return body, nil
+}
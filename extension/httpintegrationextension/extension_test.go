@@ -0,0 +1,508 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpintegrationextension // import "github.com/elastic/opentelemetry-collector-components/extension/httpintegrationextension"
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
+)
+
+func TestFindIntegrationVersion(t *testing.T) {
+	cases := []struct {
+		title           string
+		template        string
+		constraints     string
+		expectedVersion string
+		expectedErr     string
+	}{
+		{
+			title:           "newest version",
+			template:        "versioned",
+			expectedVersion: "2.0.0",
+		},
+		{
+			title:           "newest version satisfying constraints",
+			template:        "versioned",
+			constraints:     "^1.0.0",
+			expectedVersion: "1.1.0",
+		},
+		{
+			title:           "exact version",
+			template:        "versioned",
+			constraints:     "1.0.0",
+			expectedVersion: "1.0.0",
+		},
+		{
+			title:       "no version satisfying constraints",
+			template:    "versioned",
+			constraints: "^3.0.0",
+			expectedErr: "not found",
+		},
+		{
+			title:       "integration does not exist",
+			template:    "unavailable",
+			expectedErr: "not found",
+		},
+	}
+
+	registry := newTestRegistry(t)
+	registry.publishFile("versioned", "1.0.0", "versioned/1.0.0.yaml")
+	registry.publishFile("versioned", "1.1.0", "versioned/1.1.0.yaml")
+	registry.publishFile("versioned", "2.0.0", "versioned/2.0.0.yaml")
+
+	extension := startExtension(t, registry.config())
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			var constraints *semver.Constraints
+			if c.constraints != "" {
+				var err error
+				constraints, err = semver.NewConstraint(c.constraints)
+				require.NoError(t, err)
+			}
+
+			integration, err := extension.FindIntegrationVersion(context.Background(), c.template, constraints)
+			if c.expectedErr != "" {
+				assert.ErrorContains(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			manifest := integration.(integrations.ManifestGetter).Manifest()
+			require.NotNil(t, manifest)
+			assert.Equal(t, c.expectedVersion, manifest.Version)
+		})
+	}
+}
+
+func TestVerification(t *testing.T) {
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cases := []struct {
+		title         string
+		signed        bool
+		publicKey     bool
+		unsignedIndex bool
+		publish       func(r *testRegistry)
+		expectedErr   string
+		skippedErr    string
+	}{
+		{
+			title:   "unsigned package",
+			publish: func(r *testRegistry) { r.publishFile("valid", "1.0.0", "valid.yaml") },
+		},
+		{
+			title:     "signed package",
+			signed:    true,
+			publicKey: true,
+			publish:   func(r *testRegistry) { r.publishFile("valid", "1.0.0", "valid.yaml") },
+		},
+		{
+			title: "checksum mismatch",
+			publish: func(r *testRegistry) {
+				r.publishFile("valid", "1.0.0", "valid.yaml")
+				r.files["/valid/1.0.0.yaml"] = append(r.files["/valid/1.0.0.yaml"], []byte("\n# modified\n")...)
+			},
+			skippedErr: "checksum mismatch",
+		},
+		{
+			title:      "unsigned package with public key",
+			publicKey:  true,
+			publish:    func(r *testRegistry) { r.publishFile("valid", "1.0.0", "valid.yaml") },
+			skippedErr: "package is not signed",
+		},
+		{
+			title:     "package signed with other key",
+			signed:    true,
+			publicKey: true,
+			publish: func(r *testRegistry) {
+				r.publishFile("valid", "1.0.0", "valid.yaml")
+				signature := ed25519.Sign(otherKey, r.files["/valid/1.0.0.yaml"])
+				r.index.Integrations["valid"][0].Signature = base64.StdEncoding.EncodeToString(signature)
+			},
+			skippedErr: "invalid signature",
+		},
+		{
+			title:         "unsigned index with public key",
+			signed:        true,
+			publicKey:     true,
+			unsignedIndex: true,
+			publish:       func(r *testRegistry) { r.publishFile("valid", "1.0.0", "valid.yaml") },
+			expectedErr:   "failed to fetch integrations index signature",
+		},
+		{
+			title:     "index signed with other key",
+			signed:    true,
+			publicKey: true,
+			publish: func(r *testRegistry) {
+				r.publishFile("valid", "1.0.0", "valid.yaml")
+				r.indexKey = otherKey
+			},
+			expectedErr: "invalid index signature",
+		},
+		{
+			title:      "invalid integration",
+			publish:    func(r *testRegistry) { r.publishFile("invalid", "1.0.0", "invalid.yaml") },
+			skippedErr: "invalid integration template format",
+		},
+		{
+			title:      "version in manifest does not match index",
+			publish:    func(r *testRegistry) { r.publishFile("versioned", "1.1.0", "versioned/1.0.0.yaml") },
+			skippedErr: "does not match version in index",
+		},
+		{
+			title: "invalid name",
+			publish: func(r *testRegistry) {
+				r.publishFile("valid", "1.0.0", "valid.yaml")
+				r.index.Integrations["../valid"] = r.index.Integrations["valid"]
+			},
+			expectedErr: `invalid integration name "../valid"`,
+		},
+		{
+			title: "package not available",
+			publish: func(r *testRegistry) {
+				r.publishFile("valid", "1.0.0", "valid.yaml")
+				delete(r.files, "/valid/1.0.0.yaml")
+			},
+			skippedErr: "unexpected status code 404",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			require.NoError(t, err)
+			registry := newTestRegistry(t)
+			if c.signed {
+				registry.key = key
+			}
+			if c.publicKey && !c.unsignedIndex {
+				registry.indexKey = key
+			}
+			c.publish(registry)
+
+			config := registry.config()
+			if c.publicKey {
+				config.PublicKey = encodePublicKey(t, key.Public().(ed25519.PublicKey))
+			}
+			core, logs := observer.New(zapcore.WarnLevel)
+			telemetry := componenttest.NewNopTelemetrySettings()
+			telemetry.Logger = zap.New(core)
+			extension, err := newHTTPIntegrationExtension(config, telemetry)
+			require.NoError(t, err)
+			err = extension.Start(context.Background(), componenttest.NewNopHost())
+			defer func() {
+				require.NoError(t, extension.Shutdown(context.Background()))
+			}()
+			if c.expectedErr != "" {
+				assert.ErrorContains(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			// Packages failing verification are skipped without failing the refresh.
+			skipped := logs.FilterMessage("skipping integration package").All()
+			if c.skippedErr == "" {
+				assert.Empty(t, skipped)
+				assert.NotEmpty(t, extension.catalog)
+				return
+			}
+			require.Len(t, skipped, 1)
+			assert.Contains(t, skipped[0].ContextMap()["error"], c.skippedErr)
+			assert.Empty(t, extension.catalog)
+		})
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	registry := newTestRegistry(t)
+	registry.publishFile("valid", "1.0.0", "valid.yaml")
+
+	config := registry.config()
+	config.MaxResponseSize = 10
+	extension, err := newHTTPIntegrationExtension(config, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	err = extension.Start(context.Background(), componenttest.NewNopHost())
+	assert.ErrorContains(t, err, "exceeds max_response_size of 10 bytes")
+	require.NoError(t, extension.Shutdown(context.Background()))
+}
+
+func TestCache(t *testing.T) {
+	registry := newTestRegistry(t)
+	registry.publishFile("versioned", "1.0.0", "versioned/1.0.0.yaml")
+	registry.publishFile("versioned", "1.1.0", "versioned/1.1.0.yaml")
+
+	config := registry.config()
+	config.CacheDir = t.TempDir()
+	extension := startExtension(t, config)
+	require.NoError(t, extension.Shutdown(context.Background()))
+
+	// Integrations are obtained from the cache when the registry is not available.
+	registry.server.Close()
+	extension = startExtension(t, config)
+	integration, err := extension.FindIntegration(context.Background(), "versioned")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", integration.(integrations.ManifestGetter).Manifest().Version)
+	require.NoError(t, extension.Shutdown(context.Background()))
+
+	// Packages in the cache are verified too, modified ones are skipped.
+	cached := filepath.Join(config.CacheDir, "versioned", "1.1.0.yaml")
+	raw, err := os.ReadFile(cached)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cached, append(raw, []byte("\n# modified\n")...), 0o600))
+	extension = startExtension(t, config)
+	integration, err = extension.FindIntegration(context.Background(), "versioned")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", integration.(integrations.ManifestGetter).Manifest().Version)
+	require.NoError(t, extension.Shutdown(context.Background()))
+
+	// Start fails without cache if the registry is not available.
+	config.CacheDir = ""
+	extension, err = newHTTPIntegrationExtension(config, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	err = extension.Start(context.Background(), componenttest.NewNopHost())
+	assert.ErrorContains(t, err, "failed to fetch integrations index")
+	require.NoError(t, extension.Shutdown(context.Background()))
+}
+
+func TestCacheIndexSignature(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	registry := newTestRegistry(t)
+	registry.key = key
+	registry.indexKey = key
+	registry.publishFile("valid", "1.0.0", "valid.yaml")
+
+	config := registry.config()
+	config.CacheDir = t.TempDir()
+	config.PublicKey = encodePublicKey(t, key.Public().(ed25519.PublicKey))
+	extension := startExtension(t, config)
+	require.NoError(t, extension.Shutdown(context.Background()))
+
+	registry.server.Close()
+	extension = startExtension(t, config)
+	_, err = extension.FindIntegration(context.Background(), "valid")
+	require.NoError(t, err)
+	require.NoError(t, extension.Shutdown(context.Background()))
+
+	// The signature of the cached index is verified too.
+	cached := filepath.Join(config.CacheDir, indexFile)
+	raw, err := os.ReadFile(cached)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cached, append(raw, []byte("\n# modified\n")...), 0o600))
+	extension, err = newHTTPIntegrationExtension(config, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	err = extension.Start(context.Background(), componenttest.NewNopHost())
+	assert.ErrorContains(t, err, "invalid index signature")
+	require.NoError(t, extension.Shutdown(context.Background()))
+}
+
+func TestSubscribeChanges(t *testing.T) {
+	registry := newTestRegistry(t)
+	registry.publishFile("valid", "1.0.0", "valid.yaml")
+
+	config := registry.config()
+	config.RefreshInterval = 10 * time.Millisecond
+	extension := startExtension(t, config)
+
+	changes := make(chan string, 10)
+	unsubscribe := extension.SubscribeChanges(func(name string) {
+		changes <- name
+	})
+	defer unsubscribe()
+
+	waitChange := func(t *testing.T, expected string) {
+		t.Helper()
+		select {
+		case name := <-changes:
+			assert.Equal(t, expected, name)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout while waiting for changes in %q", expected)
+		}
+	}
+
+	registry.publishFile("versioned", "1.0.0", "versioned/1.0.0.yaml")
+	waitChange(t, "versioned")
+
+	registry.publishFile("versioned", "1.1.0", "versioned/1.1.0.yaml")
+	waitChange(t, "versioned")
+	integration, err := extension.FindIntegration(context.Background(), "versioned")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", integration.(integrations.ManifestGetter).Manifest().Version)
+
+	// Current integrations are kept if the registry fails.
+	registry.setFailing(true)
+	select {
+	case name := <-changes:
+		t.Fatalf("unexpected change notified for %q", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+	_, err = extension.FindIntegration(context.Background(), "versioned")
+	require.NoError(t, err)
+	registry.setFailing(false)
+
+	// Broken packages don't prevent updating other integrations.
+	registry.publishFile("invalid", "1.0.0", "invalid.yaml")
+	registry.remove("valid")
+	waitChange(t, "valid")
+	_, err = extension.FindIntegration(context.Background(), "valid")
+	assert.ErrorIs(t, err, integrations.ErrNotFound)
+
+	select {
+	case name := <-changes:
+		t.Fatalf("unexpected change notified for %q", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func startExtension(t *testing.T, config *Config) *httpIntegrationExtension {
+	t.Helper()
+	extension, err := newHTTPIntegrationExtension(config, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	require.NoError(t, extension.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		require.NoError(t, extension.Shutdown(context.Background()))
+	})
+	return extension
+}
+
+// testRegistry is an HTTP registry serving the index in /index.yaml, and the packages it publishes.
+// The index is signed in /index.yaml.sig if it has an index key.
+type testRegistry struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      ed25519.PrivateKey
+	indexKey ed25519.PrivateKey
+
+	mu      sync.Mutex
+	index   registryIndex
+	files   map[string][]byte
+	failing bool
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{
+		t:     t,
+		index: registryIndex{Integrations: make(map[string][]packageEntry)},
+		files: make(map[string][]byte),
+	}
+	r.server = httptest.NewServer(r)
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *testRegistry) config() *Config {
+	config := createDefaultConfig().(*Config)
+	config.ClientConfig = confighttp.ClientConfig{Endpoint: r.server.URL + "/" + indexFile}
+	config.RefreshInterval = 0
+	return config
+}
+
+// publishFile publishes a file from the testdata directory as a version of an integration.
+func (r *testRegistry) publishFile(name, version, file string) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "registry", file))
+	require.NoError(r.t, err)
+
+	checksum := sha256.Sum256(raw)
+	entry := packageEntry{
+		Version: version,
+		URL:     path.Join(name, version+integrationExtension),
+		SHA256:  hex.EncodeToString(checksum[:]),
+	}
+	if r.key != nil {
+		entry.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(r.key, raw))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.index.Integrations[name] = append(r.index.Integrations[name], entry)
+	r.files["/"+entry.URL] = raw
+}
+
+func (r *testRegistry) remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.index.Integrations, name)
+}
+
+func (r *testRegistry) setFailing(failing bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failing = failing
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failing {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	switch req.URL.Path {
+	case "/" + indexFile:
+		raw, err := yaml.Marshal(r.index)
+		require.NoError(r.t, err)
+		_, _ = w.Write(raw)
+		return
+	case "/" + indexFile + signatureExtension:
+		if r.indexKey == nil {
+			break
+		}
+		raw, err := yaml.Marshal(r.index)
+		require.NoError(r.t, err)
+		_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(r.indexKey, raw))))
+		return
+	}
+	raw, found := r.files[req.URL.Path]
+	if !found {
+		http.NotFound(w, req)
+		return
+	}
+	_, _ = w.Write(raw)
+}
+
+func encodePublicKey(t *testing.T, key ed25519.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
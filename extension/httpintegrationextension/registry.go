@@ -0,0 +1,301 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpintegrationextension // import "github.com/elastic/opentelemetry-collector-components/extension/httpintegrationextension"
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
+)
+
+const (
+	indexFile            = "index.yaml"
+	integrationExtension = ".yaml"
+	signatureExtension   = ".sig"
+)
+
+// validName matches the names of integrations that can be used in paths of the cache.
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+// registryIndex is the document published by the registry, it lists the packages available for
+// each integration.
+type registryIndex struct {
+	Integrations map[string][]packageEntry `yaml:"integrations"`
+}
+
+// packageEntry describes a package containing a version of an integration.
+type packageEntry struct {
+	// Version is the version of the integration in the package.
+	Version string `yaml:"version"`
+
+	// URL is the location of the package, it can be relative to the URL of the index.
+	URL string `yaml:"url"`
+
+	// SHA256 is the hex-encoded SHA-256 checksum of the package.
+	SHA256 string `yaml:"sha256"`
+
+	// Signature is the base64-encoded Ed25519 signature of the package.
+	Signature string `yaml:"signature,omitempty"`
+}
+
+// integrationPackage is a package whose content has been verified.
+type integrationPackage struct {
+	version  *semver.Version
+	checksum string
+	raw      []byte
+}
+
+// catalog contains the verified packages of each integration, sorted from newest to oldest version.
+type catalog map[string][]integrationPackage
+
+// fetchFunc obtains the content of a package.
+type fetchFunc func(ctx context.Context, name string, entry packageEntry) ([]byte, error)
+
+func parseIndex(raw []byte) (*registryIndex, error) {
+	var index registryIndex
+	if err := yaml.Unmarshal(raw, &index); err != nil {
+		return nil, fmt.Errorf("invalid index format: %w", err)
+	}
+	for name, entries := range index.Integrations {
+		if !validName.MatchString(name) {
+			return nil, fmt.Errorf("invalid integration name %q in index", name)
+		}
+		for _, entry := range entries {
+			if _, err := semver.StrictNewVersion(entry.Version); err != nil {
+				return nil, fmt.Errorf("invalid version %q of integration %q in index: %w", entry.Version, name, err)
+			}
+			if entry.URL == "" {
+				return nil, fmt.Errorf("missing url for version %s of integration %q in index", entry.Version, name)
+			}
+			if entry.SHA256 == "" {
+				return nil, fmt.Errorf("missing sha256 for version %s of integration %q in index", entry.Version, name)
+			}
+		}
+	}
+	return &index, nil
+}
+
+// buildCatalog obtains and verifies the packages listed in the index. Packages found in the previous
+// catalog with the same checksum are not fetched again. Packages that cannot be obtained or verified
+// are logged and skipped, keeping the previous package of the same version if there is one, so a
+// broken package doesn't prevent updating the rest of integrations.
+func (e *httpIntegrationExtension) buildCatalog(ctx context.Context, index *registryIndex, previous catalog, fetch fetchFunc) catalog {
+	result := make(catalog, len(index.Integrations))
+	for name, entries := range index.Integrations {
+		packages := make([]integrationPackage, 0, len(entries))
+		for _, entry := range entries {
+			version, _ := semver.StrictNewVersion(entry.Version)
+			if found := slices.IndexFunc(previous[name], func(p integrationPackage) bool {
+				return p.version.Equal(version) && p.checksum == entry.SHA256
+			}); found >= 0 {
+				packages = append(packages, previous[name][found])
+				continue
+			}
+
+			p, err := e.loadPackage(ctx, name, entry, version, fetch)
+			if err != nil {
+				found := slices.IndexFunc(previous[name], func(p integrationPackage) bool { return p.version.Equal(version) })
+				e.logger.Warn("skipping integration package",
+					zap.String("integration", name),
+					zap.String("version", entry.Version),
+					zap.Bool("keeping_previous", found >= 0),
+					zap.Error(err))
+				if found < 0 {
+					continue
+				}
+				p = previous[name][found]
+			}
+			packages = append(packages, p)
+		}
+		if len(packages) == 0 {
+			continue
+		}
+		slices.SortFunc(packages, func(a, b integrationPackage) int { return b.version.Compare(a.version) })
+		result[name] = packages
+	}
+	return result
+}
+
+// loadPackage fetches and verifies a package of the index.
+func (e *httpIntegrationExtension) loadPackage(ctx context.Context, name string, entry packageEntry, version *semver.Version, fetch fetchFunc) (integrationPackage, error) {
+	raw, err := fetch(ctx, name, entry)
+	if err != nil {
+		return integrationPackage{}, fmt.Errorf("failed to fetch package: %w", err)
+	}
+	if err := e.verify(entry, raw); err != nil {
+		return integrationPackage{}, fmt.Errorf("failed to verify package: %w", err)
+	}
+	integration, err := integrations.NewRawTemplate(raw)
+	if err != nil {
+		return integrationPackage{}, fmt.Errorf("invalid package: %w", err)
+	}
+	if manifest := integration.Manifest(); manifest != nil {
+		if manifestVersion, _ := manifest.SemVer(); !manifestVersion.Equal(version) {
+			return integrationPackage{}, fmt.Errorf("version in manifest (%s) does not match version in index (%s)",
+				manifest.Version, version)
+		}
+	}
+	return integrationPackage{version: version, checksum: entry.SHA256, raw: raw}, nil
+}
+
+// verifyIndex checks the detached signature of the index, if a public key is configured.
+func (e *httpIntegrationExtension) verifyIndex(raw, signature []byte) error {
+	if e.publicKey == nil {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid index signature: %w", err)
+	}
+	if !ed25519.Verify(e.publicKey, raw, decoded) {
+		return errors.New("invalid index signature")
+	}
+	return nil
+}
+
+// verify checks that the content of a package matches its checksum and, if a public key is
+// configured, its signature.
+func (e *httpIntegrationExtension) verify(entry packageEntry, raw []byte) error {
+	expected, err := hex.DecodeString(entry.SHA256)
+	if err != nil {
+		return fmt.Errorf("invalid sha256: %w", err)
+	}
+	checksum := sha256.Sum256(raw)
+	if !bytes.Equal(expected, checksum[:]) {
+		return fmt.Errorf("checksum mismatch, expected %s, found %x", entry.SHA256, checksum)
+	}
+
+	if e.publicKey == nil {
+		return nil
+	}
+	if entry.Signature == "" {
+		return errors.New("package is not signed")
+	}
+	signature, err := base64.StdEncoding.DecodeString(entry.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if !ed25519.Verify(e.publicKey, raw, signature) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// changedIntegrations returns the sorted names of the integrations whose packages differ between
+// both catalogs.
+func changedIntegrations(previous, current catalog) []string {
+	samePackage := func(a, b integrationPackage) bool {
+		return a.version.Equal(b.version) && a.checksum == b.checksum
+	}
+	var changed []string
+	for name, packages := range current {
+		if !slices.EqualFunc(previous[name], packages, samePackage) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, found := current[name]; !found {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// cachePath returns the path of a package in the cache directory.
+func (e *httpIntegrationExtension) cachePath(name string, entry packageEntry) string {
+	return filepath.Join(e.config.CacheDir, name, entry.Version+integrationExtension)
+}
+
+// readCache loads the index and the packages stored in the cache directory. They are verified
+// again, as they could have been modified since they were stored.
+func (e *httpIntegrationExtension) readCache(ctx context.Context) (catalog, error) {
+	indexPath := filepath.Join(e.config.CacheDir, indexFile)
+	raw, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	if e.publicKey != nil {
+		signature, err := os.ReadFile(indexPath + signatureExtension)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.verifyIndex(raw, signature); err != nil {
+			return nil, err
+		}
+	}
+	index, err := parseIndex(raw)
+	if err != nil {
+		return nil, err
+	}
+	return e.buildCatalog(ctx, index, nil, func(_ context.Context, name string, entry packageEntry) ([]byte, error) {
+		return os.ReadFile(e.cachePath(name, entry))
+	}), nil
+}
+
+// writeCache stores the index, its signature and its packages in the cache directory. The index is
+// written last, so it only references packages already stored.
+func (e *httpIntegrationExtension) writeCache(rawIndex, signature []byte, index *registryIndex, current catalog) error {
+	for name, entries := range index.Integrations {
+		for _, entry := range entries {
+			i := slices.IndexFunc(current[name], func(p integrationPackage) bool { return p.checksum == entry.SHA256 })
+			if i < 0 {
+				continue
+			}
+			if err := writeFile(e.cachePath(name, entry), current[name][i].raw); err != nil {
+				return err
+			}
+		}
+	}
+	indexPath := filepath.Join(e.config.CacheDir, indexFile)
+	if signature != nil {
+		if err := writeFile(indexPath+signatureExtension, signature); err != nil {
+			return err
+		}
+	}
+	return writeFile(indexPath, rawIndex)
+}
+
+// writeFile replaces the content of a file, writing it first to a temporary file so readers never
+// find it partially written.
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -23,6 +23,10 @@ const (
 	AgentVersion           = "agent.version"
 	AgentEphemeralID       = "agent.ephemeral_id"
 	AgentActivationMethod  = "agent.activation_method"
+	ClientGeoCityName      = "client.geo.city_name"
+	ClientGeoCountryISO    = "client.geo.country_iso_code"
+	ClientGeoRegionISO     = "client.geo.region_iso_code"
+	ClientGeoRegionName    = "client.geo.region_name"
 	CloudOriginAccountID   = "cloud.origin.account.id"
 	CloudOriginProvider    = "cloud.origin.provider"
 	CloudOriginRegion      = "cloud.origin.region"
@@ -91,6 +95,7 @@ const (
 	SpanSubtype                                    = "span.subtype"
 	SpanMessageQueueName                           = "span.message.queue.name"
 	SpanDBLink                                     = "span.db.link"
+	SpanLinks                                      = "span.links"
 	SpanDBRowsAffected                             = "span.db.rows_affected"
 	SpanDBUserName                                 = "span.db.user.name"
 	SpanCompositeSumUs                             = "span.composite.sum.us"
@@ -171,6 +176,17 @@ const (
 	// For top-level error.exception.handled, use the constant above (value: "error.exception.handled")
 	ErrorExceptionHandledField = "handled"
 
+	// Stack frame attributes set when a frame is mapped to its original
+	// source location using a source map
+	StacktraceFrameSourcemapUpdated   = "sourcemap.updated"
+	StacktraceFrameSourcemapError     = "sourcemap.error"
+	StacktraceFrameOriginalAbsPath    = "original.abs_path"
+	StacktraceFrameOriginalFilename   = "original.filename"
+	StacktraceFrameOriginalClassname  = "original.classname"
+	StacktraceFrameOriginalFunction   = "original.function"
+	StacktraceFrameOriginalLineNumber = "original.lineno"
+	StacktraceFrameOriginalLineColumn = "original.colno"
+
 	// HTTP attributes
 	HTTPVersion                 = "http.version"
 	HTTPRequestHeaders          = "http.request.headers"
@@ -185,6 +201,10 @@ const (
 	HTTPResponseDecodedBodySize = "http.response.decoded_body_size"
 	HTTPResponseTransferSize    = "http.response.transfer_size"
 
+	// Span link attributes, within the objects of SpanLinks
+	SpanLinkTraceID = "trace.id"
+	SpanLinkSpanID  = "span.id"
+
 	// Message attributes
 	MessageRoutingKey    = "message.routing_key"
 	MessageBody          = "message.body"
@@ -203,4 +223,8 @@ const (
 	// Session attributes
 	SessionID       = "session.id"
 	SessionSequence = "session.sequence"
+	// SessionCrashed is set on crash and ANR events that carry a session.id.
+	// It is only set on these events, not on the other events of the
+	// session: a session crashed if any of its events has it set.
+	SessionCrashed = "session.crashed"
 )
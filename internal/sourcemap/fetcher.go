@@ -0,0 +1,167 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap // import "github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+// contextLines is the number of source lines included before and after the
+// mapped line, matching apm-server.
+const contextLines = 5
+
+// Fetcher fetches and parses source maps from a Store, caching the parsed
+// source maps. Misses are cached too, so the store is not queried for every
+// frame of bundles without a source map; a source map uploaded after a miss
+// is picked up once the cache entry expires.
+type Fetcher struct {
+	store      Store
+	size       int
+	expiration time.Duration
+
+	mu      sync.Mutex
+	lru     *list.List // of *cacheEntry, most recently used first
+	entries map[Key]*list.Element
+}
+
+type cacheEntry struct {
+	key      Key
+	consumer *sourcemap.Consumer
+	expires  time.Time
+}
+
+// NewFetcher returns a Fetcher caching up to size source maps for the
+// expiration duration.
+func NewFetcher(store Store, size int, expiration time.Duration) *Fetcher {
+	return &Fetcher{
+		store:      store,
+		size:       size,
+		expiration: expiration,
+		lru:        list.New(),
+		entries:    make(map[Key]*list.Element),
+	}
+}
+
+// Fetch returns the source map for the bundle at bundleFilepath, or nil if
+// none was uploaded. Source maps uploaded for the full bundle URL take
+// precedence over those uploaded for its path only.
+func (f *Fetcher) Fetch(ctx context.Context, serviceName, serviceVersion, bundleFilepath string) (*sourcemap.Consumer, error) {
+	key := NewKey(serviceName, serviceVersion, bundleFilepath)
+	consumer, err := f.fetch(ctx, key)
+	if consumer != nil || err != nil {
+		return consumer, err
+	}
+	if u, err := url.Parse(key.BundleFilepath); err == nil && u.Host != "" {
+		key.BundleFilepath = u.Path
+		return f.fetch(ctx, key)
+	}
+	return nil, nil
+}
+
+func (f *Fetcher) fetch(ctx context.Context, key Key) (*sourcemap.Consumer, error) {
+	if err := key.Validate(); err != nil {
+		return nil, nil
+	}
+	now := time.Now()
+	f.mu.Lock()
+	if elem, ok := f.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if now.Before(entry.expires) {
+			f.lru.MoveToFront(elem)
+			f.mu.Unlock()
+			return entry.consumer, nil
+		}
+		f.lru.Remove(elem)
+		delete(f.entries, key)
+	}
+	f.mu.Unlock()
+
+	data, err := f.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch source map: %w", err)
+	}
+	var consumer *sourcemap.Consumer
+	if data != nil {
+		if consumer, err = sourcemap.Parse("", data); err != nil {
+			return nil, fmt.Errorf("failed to parse source map: %w", err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if elem, ok := f.entries[key]; ok {
+		// Fetched concurrently; keep the cached entry.
+		return elem.Value.(*cacheEntry).consumer, nil
+	}
+	if f.lru.Len() >= f.size {
+		delete(f.entries, f.lru.Remove(f.lru.Back()).(*cacheEntry).key)
+	}
+	f.entries[key] = f.lru.PushFront(&cacheEntry{key: key, consumer: consumer, expires: now.Add(f.expiration)})
+	return consumer, nil
+}
+
+// Location is the original source location of a minified stack frame.
+type Location struct {
+	Filename string
+	// Function is the name of the function called at this location,
+	// which is the function of the calling frame, not of this one.
+	Function    string
+	Line        int
+	Column      int
+	ContextLine string
+	PreContext  []string
+	PostContext []string
+}
+
+// Map returns the original location of the 1-based line and column in the
+// minified bundle described by consumer.
+func Map(consumer *sourcemap.Consumer, line, column int) (Location, bool) {
+	file, function, srcLine, srcColumn, ok := consumer.Source(line, column-1)
+	if !ok {
+		return Location{}, false
+	}
+	loc := Location{
+		Filename: file,
+		Function: function,
+		Line:     srcLine,
+		Column:   srcColumn + 1,
+	}
+	if content := consumer.SourceContent(file); content != "" {
+		lines := strings.Split(content, "\n")
+		if i := srcLine - 1; i >= 0 && i < len(lines) {
+			loc.ContextLine = lines[i]
+			loc.PreContext = lines[max(0, i-contextLines):i]
+			loc.PostContext = lines[i+1 : min(len(lines), i+1+contextLines)]
+		}
+	}
+	return loc, true
+}
+
+// Validate checks data is a source map that can be parsed.
+func Validate(data []byte) error {
+	_, err := sourcemap.Parse("", data)
+	return err
+}
@@ -0,0 +1,114 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the source map lookups reaching the store.
+type countingStore struct {
+	Store
+	gets int
+}
+
+func (s *countingStore) Get(ctx context.Context, key Key) ([]byte, error) {
+	s.gets++
+	return s.Store.Get(ctx, key)
+}
+
+func newTestStore(t *testing.T, bundleFilepath string) *countingStore {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "bundle.min.js.map"))
+	require.NoError(t, err)
+	store := &countingStore{Store: NewDirectoryStore(t.TempDir())}
+	require.NoError(t, store.Put(context.Background(), NewKey("frontend", "1.0.0", bundleFilepath), data))
+	return store
+}
+
+func TestFetcher(t *testing.T) {
+	store := newTestStore(t, "http://localhost:8000/js/bundle.min.js")
+	fetcher := NewFetcher(store, 10, time.Minute)
+
+	consumer, err := fetcher.Fetch(context.Background(), "frontend", "1.0.0", "http://localhost:8000/js/bundle.min.js?v=2")
+	require.NoError(t, err)
+	require.NotNil(t, consumer)
+
+	// Cached, including misses.
+	_, err = fetcher.Fetch(context.Background(), "frontend", "1.0.0", "http://localhost:8000/js/bundle.min.js")
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.gets)
+	for range 2 {
+		consumer, err = fetcher.Fetch(context.Background(), "frontend", "2.0.0", "http://localhost:8000/js/bundle.min.js")
+		require.NoError(t, err)
+		assert.Nil(t, consumer)
+	}
+	// The full URL and the path only are both looked up.
+	assert.Equal(t, 3, store.gets)
+}
+
+func TestFetcherPathOnly(t *testing.T) {
+	fetcher := NewFetcher(newTestStore(t, "/js/bundle.min.js"), 10, time.Minute)
+	consumer, err := fetcher.Fetch(context.Background(), "frontend", "1.0.0", "https://cdn.example.com/js/bundle.min.js")
+	require.NoError(t, err)
+	assert.NotNil(t, consumer)
+}
+
+func TestFetcherExpiration(t *testing.T) {
+	store := newTestStore(t, "/js/bundle.min.js")
+	fetcher := NewFetcher(store, 1, 0)
+	for range 2 {
+		_, err := fetcher.Fetch(context.Background(), "frontend", "1.0.0", "/js/bundle.min.js")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, store.gets)
+}
+
+func TestMap(t *testing.T) {
+	fetcher := NewFetcher(newTestStore(t, "/js/bundle.min.js"), 10, time.Minute)
+	consumer, err := fetcher.Fetch(context.Background(), "frontend", "1.0.0", "/js/bundle.min.js")
+	require.NoError(t, err)
+	require.NotNil(t, consumer)
+
+	loc, ok := Map(consumer, 1, 15)
+	require.True(t, ok)
+	assert.Equal(t, Location{
+		Filename:    "webpack:///./src/app.js",
+		Line:        2,
+		Column:      3,
+		ContextLine: "  throw new Error(name);",
+		PreContext:  []string{"function greet(name) {"},
+		PostContext: []string{"}", `greet("x");`, ""},
+	}, loc)
+
+	loc, ok = Map(consumer, 1, 34)
+	require.True(t, ok)
+	assert.Equal(t, "greet", loc.Function)
+	assert.Equal(t, 4, loc.Line)
+	assert.Equal(t, 1, loc.Column)
+
+	_, ok = Map(consumer, 2, 1)
+	assert.False(t, ok)
+}
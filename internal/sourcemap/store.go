@@ -0,0 +1,239 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package sourcemap stores JavaScript source maps uploaded for RUM services
+// and maps minified stack frames back to their original source.
+package sourcemap // import "github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+)
+
+// storageName is the name of the storage extension client holding source
+// maps. The receiver uploading source maps and the processors reading them
+// must use the same client, so it is not tied to a component ID.
+const storageName = "sourcemaps"
+
+// Key identifies a source map by the service and bundle it belongs to.
+type Key struct {
+	ServiceName    string
+	ServiceVersion string
+	BundleFilepath string
+}
+
+// NewKey returns the key of the source map for the bundle at bundleFilepath,
+// which is an absolute URL or path as reported in stack frames.
+func NewKey(serviceName, serviceVersion, bundleFilepath string) Key {
+	return Key{
+		ServiceName:    serviceName,
+		ServiceVersion: serviceVersion,
+		BundleFilepath: CleanBundleFilepath(bundleFilepath),
+	}
+}
+
+// Validate checks all parts of the key are set and safe to use as path
+// elements.
+func (k Key) Validate() error {
+	for name, v := range map[string]string{
+		"service_name":    k.ServiceName,
+		"service_version": k.ServiceVersion,
+		"bundle_filepath": k.BundleFilepath,
+	} {
+		switch v {
+		case "":
+			return fmt.Errorf("%s must be set", name)
+		case ".", "..":
+			return fmt.Errorf("invalid %s %q", name, v)
+		}
+	}
+	return nil
+}
+
+// String returns the key as a slash-separated path of escaped elements.
+func (k Key) String() string {
+	return path.Join(
+		url.PathEscape(k.ServiceName),
+		url.PathEscape(k.ServiceVersion),
+		url.PathEscape(k.BundleFilepath),
+	)
+}
+
+// CleanBundleFilepath removes the query and fragment from a bundle URL and
+// cleans its path, so that frames referencing the bundle with cache-busting
+// parameters still match the uploaded source map.
+func CleanBundleFilepath(bundleFilepath string) string {
+	u, err := url.Parse(bundleFilepath)
+	if err != nil {
+		return bundleFilepath
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.Path = path.Clean(u.Path)
+	u.RawPath = ""
+	return u.String()
+}
+
+// Store persists source maps.
+type Store interface {
+	// Get returns the source map stored under key, or nil if there is none.
+	Get(ctx context.Context, key Key) ([]byte, error)
+	// Put stores a source map under key, replacing any existing one.
+	Put(ctx context.Context, key Key, data []byte) error
+	// Close releases the resources held by the store.
+	Close(ctx context.Context) error
+}
+
+// Config configures where source maps are stored. Exactly one of Directory
+// and StorageID may be set; source maps are disabled if neither is.
+type Config struct {
+	// Directory is a local directory source maps are written to.
+	Directory string `mapstructure:"directory"`
+
+	// StorageID is the ID of a storage extension source maps are written to.
+	StorageID *component.ID `mapstructure:"storage"`
+}
+
+// Enabled reports whether a source map store is configured.
+func (cfg *Config) Enabled() bool {
+	return cfg.Directory != "" || cfg.StorageID != nil
+}
+
+// Validate checks the store configuration is valid.
+func (cfg *Config) Validate() error {
+	if cfg.Directory != "" && cfg.StorageID != nil {
+		return errors.New("only one of directory and storage may be set")
+	}
+	return nil
+}
+
+// NewStore creates the store described by cfg, looking up the storage
+// extension in host if one is configured.
+func NewStore(ctx context.Context, host component.Host, cfg Config) (Store, error) {
+	if cfg.StorageID == nil {
+		return NewDirectoryStore(cfg.Directory), nil
+	}
+	ext, ok := host.GetExtensions()[*cfg.StorageID]
+	if !ok {
+		return nil, fmt.Errorf("storage extension %q not found", cfg.StorageID)
+	}
+	se, ok := ext.(storage.Extension)
+	if !ok {
+		return nil, fmt.Errorf("extension %q is not a storage extension", cfg.StorageID)
+	}
+	return NewStorageStore(se, *cfg.StorageID), nil
+}
+
+type directoryStore struct {
+	dir string
+}
+
+// NewDirectoryStore returns a Store writing each source map to a file under
+// dir, at <service name>/<service version>/<bundle filepath>.map.
+func NewDirectoryStore(dir string) Store {
+	return &directoryStore{dir: dir}
+}
+
+func (s *directoryStore) filename(key Key) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key.String())+".map")
+}
+
+func (s *directoryStore) Get(_ context.Context, key Key) ([]byte, error) {
+	data, err := os.ReadFile(s.filename(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *directoryStore) Put(_ context.Context, key Key, data []byte) error {
+	filename := s.filename(key)
+	if err := os.MkdirAll(filepath.Dir(filename), 0o750); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that readers never observe a
+	// partially written source map.
+	f, err := os.CreateTemp(filepath.Dir(filename), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+func (*directoryStore) Close(context.Context) error {
+	return nil
+}
+
+type storageStore struct {
+	extension storage.Extension
+	id        component.ID
+}
+
+// NewStorageStore returns a Store backed by the storage extension ext with
+// ID id. A client is opened for each operation rather than held open: storage
+// extensions such as file_storage lock the underlying file while a client is
+// open, which would keep the receiver and the processors from sharing it.
+// Reads are infrequent as the Fetcher caches them.
+func NewStorageStore(ext storage.Extension, id component.ID) Store {
+	return &storageStore{extension: ext, id: id}
+}
+
+func (s *storageStore) withClient(ctx context.Context, f func(storage.Client) error) (err error) {
+	client, err := s.extension.GetClient(ctx, component.KindExtension, s.id, storageName)
+	if err != nil {
+		return fmt.Errorf("failed to get storage client: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, client.Close(ctx))
+	}()
+	return f(client)
+}
+
+func (s *storageStore) Get(ctx context.Context, key Key) (data []byte, err error) {
+	err = s.withClient(ctx, func(client storage.Client) error {
+		data, err = client.Get(ctx, key.String())
+		return err
+	})
+	return data, err
+}
+
+func (s *storageStore) Put(ctx context.Context, key Key, data []byte) error {
+	return s.withClient(ctx, func(client storage.Client) error {
+		return client.Set(ctx, key.String(), data)
+	})
+}
+
+func (*storageStore) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sourcemap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+)
+
+func TestNewKey(t *testing.T) {
+	for bundle, expected := range map[string]string{
+		"http://localhost:8000/js/bundle.min.js":             "http://localhost:8000/js/bundle.min.js",
+		"http://localhost:8000/js/../js/bundle.min.js?v=1#x": "http://localhost:8000/js/bundle.min.js",
+		"/js/./bundle.min.js":                                "/js/bundle.min.js",
+	} {
+		assert.Equal(t, expected, NewKey("frontend", "1.0.0", bundle).BundleFilepath, bundle)
+	}
+}
+
+func TestKeyValidate(t *testing.T) {
+	assert.NoError(t, NewKey("frontend", "1.0.0", "/bundle.js").Validate())
+	assert.EqualError(t, NewKey("", "1.0.0", "/bundle.js").Validate(), "service_name must be set")
+	assert.EqualError(t, NewKey("frontend", "..", "/bundle.js").Validate(), `invalid service_version ".."`)
+	assert.EqualError(t, NewKey("frontend", "1.0.0", "").Validate(), `invalid bundle_filepath "."`)
+}
+
+func TestDirectoryStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewDirectoryStore(dir)
+	key := NewKey("frontend", "1.0.0", "http://localhost:8000/js/bundle.min.js")
+
+	data, err := store.Get(context.Background(), key)
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	require.NoError(t, store.Put(context.Background(), key, []byte("first")))
+	require.NoError(t, store.Put(context.Background(), key, []byte("second")))
+	data, err = store.Get(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	// The bundle path is escaped into a single file name.
+	entries, err := os.ReadDir(filepath.Join(dir, "frontend", "1.0.0"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "http:%2F%2Flocalhost:8000%2Fjs%2Fbundle.min.js.map", entries[0].Name())
+	require.NoError(t, store.Close(context.Background()))
+}
+
+// memStorageExtension implements storage.Extension for tests, recording the
+// clients it hands out.
+type memStorageExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+	data    map[string][]byte
+	clients []string
+	open    int
+}
+
+func (m *memStorageExtension) GetClient(_ context.Context, kind component.Kind, id component.ID, name string) (storage.Client, error) {
+	m.clients = append(m.clients, kind.String()+"/"+id.String()+"/"+name)
+	m.open++
+	return &memStorageClient{ext: m}, nil
+}
+
+// memStorageClient implements the storage.Client methods used by the store.
+type memStorageClient struct {
+	storage.Client
+	ext *memStorageExtension
+}
+
+func (c *memStorageClient) Get(_ context.Context, key string) ([]byte, error) {
+	return c.ext.data[key], nil
+}
+
+func (c *memStorageClient) Set(_ context.Context, key string, value []byte) error {
+	c.ext.data[key] = value
+	return nil
+}
+
+func (c *memStorageClient) Close(context.Context) error {
+	c.ext.open--
+	return nil
+}
+
+// storageHost is a component.Host holding a single storage extension.
+type storageHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func (h *storageHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+func TestStorageStore(t *testing.T) {
+	id := component.MustNewID("file_storage")
+	ext := &memStorageExtension{data: make(map[string][]byte)}
+	host := &storageHost{extensions: map[component.ID]component.Component{id: ext}}
+	store, err := NewStore(context.Background(), host, Config{StorageID: &id})
+	require.NoError(t, err)
+
+	key := NewKey("frontend", "1.0.0", "/js/bundle.min.js")
+	require.NoError(t, store.Put(context.Background(), key, []byte("data")))
+	data, err := store.Get(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+	assert.Equal(t, map[string][]byte{"frontend/1.0.0/%2Fjs%2Fbundle.min.js": []byte("data")}, ext.data)
+
+	// Clients are shared by all components and closed after each operation.
+	assert.Equal(t, []string{"Extension/file_storage/sourcemaps", "Extension/file_storage/sourcemaps"}, ext.clients)
+	assert.Zero(t, ext.open)
+	require.NoError(t, store.Close(context.Background()))
+}
+
+func TestNewStoreErrors(t *testing.T) {
+	id := component.MustNewID("file_storage")
+	_, err := NewStore(context.Background(), componenttest.NewNopHost(), Config{StorageID: &id})
+	assert.EqualError(t, err, `storage extension "file_storage" not found`)
+
+	host := &storageHost{extensions: map[component.ID]component.Component{id: &struct {
+		component.StartFunc
+		component.ShutdownFunc
+	}{}}}
+	_, err = NewStore(context.Background(), host, Config{StorageID: &id})
+	assert.EqualError(t, err, `extension "file_storage" is not a storage extension`)
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{}
+	assert.NoError(t, cfg.Validate())
+	assert.False(t, cfg.Enabled())
+
+	cfg.Directory = t.TempDir()
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.Enabled())
+
+	id := component.MustNewID("file_storage")
+	cfg.StorageID = &id
+	assert.EqualError(t, cfg.Validate(), "only one of directory and storage may be set")
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Masterminds/semver/v3"
 	"go.uber.org/zap"
 
 	"go.opentelemetry.io/collector/component"
@@ -47,6 +48,23 @@ type Finder interface {
 	FindIntegration(ctx context.Context, name string) (Integration, error)
 }
 
+// VersionedFinder is the interface for finders that can provide multiple versions of the same integration.
+type VersionedFinder interface {
+	Finder
+
+	// FindIntegrationVersion returns the newest version of the integration that satisfies the constraints.
+	// All versions satisfy nil constraints.
+	FindIntegrationVersion(ctx context.Context, name string, constraints *semver.Constraints) (Integration, error)
+}
+
+// ChangeNotifier is an optional interface for finders that can notify when the integrations they
+// provide change, so components using them can resolve them again.
+type ChangeNotifier interface {
+	// SubscribeChanges registers fn to be called with the name of an integration every time it
+	// changes. The returned function unregisters fn.
+	SubscribeChanges(fn func(name string)) (unsubscribe func())
+}
+
 // ExtensionGetter is the interface a host must implement to be used to locate finders
 // along extensions.
 // It must be a subset of the component.Host interface.
@@ -65,6 +83,22 @@ var _ ExtensionGetter = (component.Host)(nil)
 // It returns ErrNotFound if the integration cannot be found, or ErrNotConfigured if there are no
 // configured finder extensions. ErrNotConfigured also wraps ErrNotFound.
 func Find(ctx context.Context, logger *zap.Logger, host ExtensionGetter, integrationName string) (Integration, error) {
+	return FindVersion(ctx, logger, host, integrationName, "")
+}
+
+// FindVersion looks for integrations as Find does, but only returns integrations whose version satisfies
+// the version constraints, such as ">= 1.2.0, < 2.0.0". Integrations found by finders that don't implement
+// VersionedFinder are checked against the version in their manifest. Empty constraints match any version.
+func FindVersion(ctx context.Context, logger *zap.Logger, host ExtensionGetter, integrationName string, constraints string) (Integration, error) {
+	var versionConstraints *semver.Constraints
+	if constraints != "" {
+		var err error
+		versionConstraints, err = semver.NewConstraint(constraints)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraints %q: %w", constraints, err)
+		}
+	}
+
 	if host == nil {
 		logger.Error("received nil host")
 		return nil, ErrNotConfigured
@@ -77,8 +111,8 @@ func Find(ctx context.Context, logger *zap.Logger, host ExtensionGetter, integra
 		}
 		anyExtension = true
 
-		integration, err := finder.FindIntegration(ctx, integrationName)
-		if errors.Is(ErrNotFound, err) {
+		integration, err := findIntegration(ctx, finder, integrationName, versionConstraints)
+		if errors.Is(err, ErrNotFound) {
 			continue
 		}
 		if err != nil {
@@ -97,32 +131,128 @@ func Find(ctx context.Context, logger *zap.Logger, host ExtensionGetter, integra
 	return nil, ErrNotFound
 }
 
+func findIntegration(ctx context.Context, finder Finder, name string, constraints *semver.Constraints) (Integration, error) {
+	if versioned, ok := finder.(VersionedFinder); ok {
+		return versioned.FindIntegrationVersion(ctx, name, constraints)
+	}
+	integration, err := finder.FindIntegration(ctx, name)
+	if err != nil || constraints == nil {
+		return integration, err
+	}
+	if !MatchesVersion(integration, constraints) {
+		return nil, fmt.Errorf("%w: no version satisfies %q", ErrNotFound, constraints)
+	}
+	return integration, nil
+}
+
+// MatchesVersion returns true if the integration has a manifest with a version that satisfies
+// the constraints.
+func MatchesVersion(integration Integration, constraints *semver.Constraints) bool {
+	getter, ok := integration.(ManifestGetter)
+	if !ok || getter.Manifest() == nil {
+		return false
+	}
+	version, err := getter.Manifest().SemVer()
+	return err == nil && constraints.Check(version)
+}
+
+// SubscribeChanges registers fn in all the extensions of the host that implement ChangeNotifier, to be
+// called when the integration with the given name changes. The returned function unregisters fn from
+// all of them.
+func SubscribeChanges(host ExtensionGetter, integrationName string, fn func()) (unsubscribe func()) {
+	if host == nil {
+		return func() {}
+	}
+	var unsubscribes []func()
+	for _, extension := range host.GetExtensions() {
+		notifier, ok := extension.(ChangeNotifier)
+		if !ok {
+			continue
+		}
+		unsubscribes = append(unsubscribes, notifier.SubscribeChanges(func(name string) {
+			if name == integrationName {
+				fn()
+			}
+		}))
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
 // Config contains an structured integration. At this point we only handle component IDs, and not
 // their specific configurations.
 type Config struct {
 	Receivers  map[component.ID]component.Config `mapstructure:"receivers"`
 	Processors map[component.ID]component.Config `mapstructure:"processors"`
+	Connectors map[component.ID]component.Config `mapstructure:"connectors"`
+	Exporters  map[component.ID]component.Config `mapstructure:"exporters"`
 	Pipelines  map[pipeline.ID]PipelineConfig    `mapstructure:"pipelines"`
 }
 
 // validate validates that the integration configuration is valid and all the components referenced in the
 // pipelines are defined.
 func (c *Config) validate() error {
-	if len(c.Pipelines) == 0 {
+	return validatePipelines(c.Pipelines, c.Receivers, c.Processors, c.Connectors, c.Exporters)
+}
+
+// validatePipelines validates that all the components referenced in the pipelines are defined, and that
+// connectors are used both as exporter and as receiver.
+func validatePipelines[C any](pipelines map[pipeline.ID]PipelineConfig, receivers, processors, connectors, exporters map[component.ID]C) error {
+	if len(pipelines) == 0 {
 		return errors.New("missing pipelines")
 	}
-	for _, pipeline := range c.Pipelines {
+	for id := range connectors {
+		if _, found := receivers[id]; found {
+			return fmt.Errorf("%q defined both as receiver and connector", id.String())
+		}
+		if _, found := exporters[id]; found {
+			return fmt.Errorf("%q defined both as exporter and connector", id.String())
+		}
+	}
+
+	connectorsAsReceiver := make(map[component.ID]bool)
+	connectorsAsExporter := make(map[component.ID]bool)
+	for _, pipeline := range pipelines {
 		if pipeline.Receiver != nil {
-			if _, found := c.Receivers[*pipeline.Receiver]; !found {
-				return fmt.Errorf("receiver %s not defined", pipeline.Receiver.String())
+			_, isReceiver := receivers[*pipeline.Receiver]
+			_, isConnector := connectors[*pipeline.Receiver]
+			if !isReceiver && !isConnector {
+				return fmt.Errorf("receiver %q not defined", pipeline.Receiver.String())
+			}
+			if isConnector {
+				connectorsAsReceiver[*pipeline.Receiver] = true
 			}
 		}
 
 		for _, processor := range pipeline.Processors {
-			if _, found := c.Processors[processor]; !found {
+			if _, found := processors[processor]; !found {
 				return fmt.Errorf("processor %q not defined", processor.String())
 			}
+		}
 
+		for _, exporter := range pipeline.Exporters {
+			_, isExporter := exporters[exporter]
+			_, isConnector := connectors[exporter]
+			if !isExporter && !isConnector {
+				return fmt.Errorf("exporter %q not defined", exporter.String())
+			}
+			if isConnector {
+				connectorsAsExporter[exporter] = true
+			}
+		}
+	}
+
+	for id := range connectorsAsReceiver {
+		if !connectorsAsExporter[id] {
+			return fmt.Errorf("connector %q used as receiver but not as exporter in any pipeline", id.String())
+		}
+	}
+	for id := range connectorsAsExporter {
+		if !connectorsAsReceiver[id] {
+			return fmt.Errorf("connector %q used as exporter but not as receiver in any pipeline", id.String())
 		}
 	}
 
@@ -132,10 +262,16 @@ func (c *Config) validate() error {
 // PipelineConfig contains the definition of a pipeline in the integration.
 type PipelineConfig struct {
 	// Receiver is the receiver to be used in the pipeline. It is optional, if a pipeline does not define a
-	// receiver it cannot be used to instantiate receivers.
+	// receiver it cannot be used to instantiate receivers. It can also be a connector, in which case the
+	// pipeline receives the data exported to this connector by other pipelines.
 	Receiver *component.ID `mapstructure:"receiver"`
 
 	// Processors is the chain of processors of the pipeline, to be used as part of the receiver in receiver
 	// components, or as a combined processor when the pipeline is used as processor.
 	Processors []component.ID `mapstructure:"processors"`
+
+	// Exporters is the list of connectors and exporters the pipeline sends its data to. It is optional, if a
+	// pipeline does not define exporters, its data is sent to the consumer of the component that
+	// instantiates it.
+	Exporters []component.ID `mapstructure:"exporters" yaml:"exporters,omitempty"`
 }
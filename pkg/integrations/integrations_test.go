@@ -22,6 +22,7 @@ import (
 	_ "embed"
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -36,6 +37,9 @@ var rawTemplate1 []byte
 //go:embed testdata/template-simple-2.yaml
 var rawTemplate2 []byte
 
+//go:embed testdata/template-manifest.yaml
+var rawTemplateManifest []byte
+
 func TestFind(t *testing.T) {
 	integration1 := mustNewIntegration(rawTemplate1)
 	integration2 := mustNewIntegration(rawTemplate2)
@@ -152,6 +156,96 @@ func TestErrNotConfigured(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNotConfigured)
 }
 
+func TestFindVersion(t *testing.T) {
+	withoutManifest := mustNewIntegration(rawTemplate1)
+	withManifest := mustNewIntegration(rawTemplateManifest)
+
+	cases := []struct {
+		title       string
+		finder      component.Component
+		constraints string
+		expected    Integration
+		expectedErr error
+	}{
+		{
+			title:       "any version",
+			finder:      newDummyFinder(map[string]Integration{"simple": withoutManifest}),
+			constraints: "",
+			expected:    withoutManifest,
+		},
+		{
+			title:       "version in manifest satisfies constraints",
+			finder:      newDummyFinder(map[string]Integration{"simple": withManifest}),
+			constraints: ">= 1.0.0, < 2.0.0",
+			expected:    withManifest,
+		},
+		{
+			title:       "version in manifest does not satisfy constraints",
+			finder:      newDummyFinder(map[string]Integration{"simple": withManifest}),
+			constraints: "^2.0.0",
+			expectedErr: ErrNotFound,
+		},
+		{
+			title:       "constraints without manifest",
+			finder:      newDummyFinder(map[string]Integration{"simple": withoutManifest}),
+			constraints: "^1.0.0",
+			expectedErr: ErrNotFound,
+		},
+		{
+			title: "versioned finder",
+			finder: &dummyVersionedFinder{versions: map[string]Integration{
+				"1.0.0": withoutManifest,
+				"1.2.0": withManifest,
+			}},
+			constraints: "~1.2",
+			expected:    withManifest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			host := newDummyHost(map[component.ID]component.Component{
+				component.MustNewID("finder"): c.finder,
+			})
+			integration, err := FindVersion(context.Background(), zap.NewNop(), host, "simple", c.constraints)
+			if c.expectedErr != nil {
+				require.ErrorIs(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, c.expected, integration)
+		})
+	}
+
+	_, err := FindVersion(context.Background(), zap.NewNop(), newDummyHost(nil), "simple", "not a version")
+	assert.ErrorContains(t, err, "invalid version constraints")
+}
+
+func TestSubscribeChanges(t *testing.T) {
+	notifier := &dummyNotifier{}
+	host := newDummyHost(map[component.ID]component.Component{
+		component.MustNewID("finder"):   newDummyFinder(nil),
+		component.MustNewID("notifier"): notifier,
+	})
+
+	var notified []string
+	unsubscribe := SubscribeChanges(host, "simple", func() {
+		notified = append(notified, "simple")
+	})
+	require.Len(t, notifier.subscribers, 1)
+
+	notifier.notify("other")
+	notifier.notify("simple")
+	assert.Equal(t, []string{"simple"}, notified)
+
+	unsubscribe()
+	notifier.notify("simple")
+	assert.Equal(t, []string{"simple"}, notified)
+
+	// Subscribing without host is a no-op.
+	SubscribeChanges(nil, "simple", func() {})()
+}
+
 type dummyHost struct {
 	extensions map[component.ID]component.Component
 }
@@ -195,3 +289,54 @@ func mustNewIntegration(raw []byte) Integration {
 	}
 	return i
 }
+
+type dummyNotifier struct {
+	dummyFinder
+
+	subscribers map[int]func(string)
+	next        int
+}
+
+func (n *dummyNotifier) SubscribeChanges(fn func(string)) func() {
+	if n.subscribers == nil {
+		n.subscribers = make(map[int]func(string))
+	}
+	id := n.next
+	n.next++
+	n.subscribers[id] = fn
+	return func() { delete(n.subscribers, id) }
+}
+
+func (n *dummyNotifier) notify(name string) {
+	for _, fn := range n.subscribers {
+		fn(name)
+	}
+}
+
+type dummyVersionedFinder struct {
+	dummyComponent
+
+	versions map[string]Integration
+}
+
+func (f *dummyVersionedFinder) FindIntegration(ctx context.Context, name string) (Integration, error) {
+	return f.FindIntegrationVersion(ctx, name, nil)
+}
+
+func (f *dummyVersionedFinder) FindIntegrationVersion(_ context.Context, _ string, constraints *semver.Constraints) (Integration, error) {
+	var found Integration
+	var foundVersion *semver.Version
+	for v, integration := range f.versions {
+		version := semver.MustParse(v)
+		if constraints != nil && !constraints.Check(version) {
+			continue
+		}
+		if foundVersion == nil || version.GreaterThan(foundVersion) {
+			found, foundVersion = integration, version
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
@@ -0,0 +1,233 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integrations // import "github.com/elastic/opentelemetry-collector-components/pkg/integrations"
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+// Manifest contains the metadata of an integration package.
+type Manifest struct {
+	// Name is the name of the integration.
+	Name string `yaml:"name"`
+
+	// Version is the semantic version of the integration.
+	Version string `yaml:"version"`
+
+	// Description is a human readable description of the integration.
+	Description string `yaml:"description,omitempty"`
+
+	// Parameters describe the parameters used to resolve the placeholders in the integration. If
+	// the manifest is defined, only these parameters can be used.
+	Parameters map[string]ParameterSchema `yaml:"parameters,omitempty"`
+
+	// Pipelines lists the pipelines that can be instantiated from the integration. If empty, all
+	// pipelines are supported.
+	Pipelines []pipeline.ID `yaml:"pipelines,omitempty"`
+}
+
+// ParameterType is the type of the value of a parameter.
+type ParameterType string
+
+const (
+	ParameterTypeString  ParameterType = "string"
+	ParameterTypeInteger ParameterType = "integer"
+	ParameterTypeNumber  ParameterType = "number"
+	ParameterTypeBoolean ParameterType = "boolean"
+	ParameterTypeList    ParameterType = "list"
+	ParameterTypeMap     ParameterType = "map"
+)
+
+// ParameterSchema describes a parameter of an integration.
+type ParameterSchema struct {
+	// Type is the type of the parameter. Parameters of any type are accepted if empty.
+	Type ParameterType `yaml:"type,omitempty"`
+
+	// Description is a human readable description of the parameter.
+	Description string `yaml:"description,omitempty"`
+
+	// Required parameters must be provided when resolving the integration.
+	Required bool `yaml:"required,omitempty"`
+
+	// Default is the value used when the parameter is not provided.
+	Default any `yaml:"default,omitempty"`
+}
+
+// ManifestGetter is the interface for integrations that can provide their manifest.
+type ManifestGetter interface {
+	// Manifest returns the manifest of the integration, or nil if it doesn't have one.
+	Manifest() *Manifest
+}
+
+// SemVer returns the parsed version of the integration.
+func (m *Manifest) SemVer() (*semver.Version, error) {
+	return semver.StrictNewVersion(m.Version)
+}
+
+// validate validates the manifest, and that the given pipelines and placeholders are consistent with it.
+func (m *Manifest) validate(pipelines map[pipeline.ID]PipelineConfig, placeholders []string) error {
+	if m.Name == "" {
+		return errors.New("manifest name is required")
+	}
+	if _, err := m.SemVer(); err != nil {
+		return fmt.Errorf("invalid manifest version %q: %w", m.Version, err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Parameters)) {
+		if err := m.Parameters[name].validate(); err != nil {
+			return fmt.Errorf("invalid parameter %q: %w", name, err)
+		}
+	}
+	for _, id := range m.Pipelines {
+		if _, found := pipelines[id]; !found {
+			return fmt.Errorf("supported pipeline %q not defined", id.String())
+		}
+	}
+	for _, name := range placeholders {
+		if _, found := m.Parameters[name]; !found {
+			return fmt.Errorf("parameter %q used but not defined in manifest", name)
+		}
+	}
+	return nil
+}
+
+// selectPipelines returns the pipelines to use from the requested ones, checking that they are supported.
+func (m *Manifest) selectPipelines(requested []pipeline.ID) ([]pipeline.ID, error) {
+	if len(m.Pipelines) == 0 {
+		return requested, nil
+	}
+	if len(requested) == 0 {
+		return m.Pipelines, nil
+	}
+	for _, id := range requested {
+		if !slices.Contains(m.Pipelines, id) {
+			return nil, fmt.Errorf("pipeline %q not supported by integration", id.String())
+		}
+	}
+	return requested, nil
+}
+
+// prepareParameters checks the given parameters against the schema, and returns them with the
+// default values of the parameters not provided.
+func (m *Manifest) prepareParameters(params map[string]any) (map[string]any, error) {
+	prepared := make(map[string]any, len(m.Parameters))
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		schema, found := m.Parameters[name]
+		if !found {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		if err := schema.Type.check(params[name]); err != nil {
+			return nil, fmt.Errorf("invalid value for parameter %q: %w", name, err)
+		}
+		prepared[name] = params[name]
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Parameters)) {
+		if _, found := prepared[name]; found {
+			continue
+		}
+		schema := m.Parameters[name]
+		if schema.Required {
+			return nil, fmt.Errorf("missing required parameter %q", name)
+		}
+		if schema.Default != nil {
+			prepared[name] = schema.Default
+		}
+	}
+	return prepared, nil
+}
+
+func (s ParameterSchema) validate() error {
+	switch s.Type {
+	case "", ParameterTypeString, ParameterTypeInteger, ParameterTypeNumber,
+		ParameterTypeBoolean, ParameterTypeList, ParameterTypeMap:
+	default:
+		return fmt.Errorf("unknown type %q", s.Type)
+	}
+	if s.Default == nil {
+		return nil
+	}
+	if s.Required {
+		return errors.New("required parameters cannot have a default value")
+	}
+	if err := s.Type.check(s.Default); err != nil {
+		return fmt.Errorf("invalid default value: %w", err)
+	}
+	return nil
+}
+
+// check checks that the value is of the type.
+func (t ParameterType) check(value any) error {
+	if t == "" {
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	var ok bool
+	switch t {
+	case ParameterTypeString:
+		ok = v.Kind() == reflect.String
+	case ParameterTypeInteger:
+		ok = v.CanInt() || v.CanUint() || (v.CanFloat() && v.Float() == float64(int64(v.Float())))
+	case ParameterTypeNumber:
+		ok = v.CanInt() || v.CanUint() || v.CanFloat()
+	case ParameterTypeBoolean:
+		ok = v.Kind() == reflect.Bool
+	case ParameterTypeList:
+		ok = v.Kind() == reflect.Slice || v.Kind() == reflect.Array
+	case ParameterTypeMap:
+		ok = v.Kind() == reflect.Map
+	}
+	if !ok {
+		return fmt.Errorf("expected %s, found %T", t, value)
+	}
+	return nil
+}
+
+// placeholderPattern matches the placeholders for parameters in integrations.
+var placeholderPattern = regexp.MustCompile(`\$\{` + varProviderScheme + `:([^}]+)\}`)
+
+// listPlaceholders lists the names of the parameters used in the placeholders of the nodes.
+func listPlaceholders[K comparable](nodes ...map[K]yaml.Node) []string {
+	var names []string
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.ScalarNode {
+			for _, match := range placeholderPattern.FindAllStringSubmatch(node.Value, -1) {
+				names = append(names, match[1])
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	for _, components := range nodes {
+		for _, node := range components {
+			walk(&node)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
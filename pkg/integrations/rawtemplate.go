@@ -20,7 +20,6 @@ package integrations // import "github.com/elastic/opentelemetry-collector-compo
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"slices"
 	"strings"
@@ -34,8 +33,11 @@ import (
 
 var _ Integration = &RawTemplate{}
 
+var _ ManifestGetter = &RawTemplate{}
+
 // RawTemplate implements the Template interface for raw YAML content.
 // Unused components are removed after resolving it, so the variables they contain are not required.
+// It can optionally include a manifest, used to validate the parameters and the selected pipelines.
 type RawTemplate struct {
 	source rawYAMLConfig
 }
@@ -52,11 +54,34 @@ func NewRawTemplate(raw []byte) (*RawTemplate, error) {
 	if err := source.validate(); err != nil {
 		return nil, fmt.Errorf("integration template validation failed: %w", err)
 	}
+	if source.Manifest != nil {
+		placeholders := listPlaceholders(source.Receivers, source.Processors, source.Connectors, source.Exporters)
+		if err := source.Manifest.validate(source.Pipelines, placeholders); err != nil {
+			return nil, fmt.Errorf("integration manifest validation failed: %w", err)
+		}
+	}
 	return &RawTemplate{source: source}, nil
 }
 
+// Manifest returns the manifest of the template, or nil if it doesn't have one.
+func (t *RawTemplate) Manifest() *Manifest {
+	return t.source.Manifest
+}
+
 // Resolve resolves the template using a confmap resolver.
 func (t *RawTemplate) Resolve(ctx context.Context, params map[string]any, pipelines []pipeline.ID) (*Config, error) {
+	if manifest := t.source.Manifest; manifest != nil {
+		var err error
+		pipelines, err = manifest.selectPipelines(pipelines)
+		if err != nil {
+			return nil, fmt.Errorf("selecting pipelines: %v", err)
+		}
+		params, err = manifest.prepareParameters(params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
 	selectedPipelines := t.source.Pipelines
 	if len(pipelines) > 0 {
 		var err error
@@ -65,7 +90,11 @@ func (t *RawTemplate) Resolve(ctx context.Context, params map[string]any, pipeli
 			return nil, fmt.Errorf("selecting pipelines: %v", err)
 		}
 	}
-	selectedReceivers, err := selectComponents(t.source.Receivers, listReceivers(selectedPipelines))
+	isConnector := func(id component.ID) bool {
+		_, found := t.source.Connectors[id]
+		return found
+	}
+	selectedReceivers, err := selectComponents(t.source.Receivers, listReceivers(selectedPipelines, isConnector))
 	if err != nil {
 		return nil, fmt.Errorf("selecting receivers: %v", err)
 	}
@@ -73,11 +102,21 @@ func (t *RawTemplate) Resolve(ctx context.Context, params map[string]any, pipeli
 	if err != nil {
 		return nil, fmt.Errorf("selecting processors: %v", err)
 	}
+	selectedConnectors, err := selectComponents(t.source.Connectors, listConnectors(selectedPipelines, isConnector))
+	if err != nil {
+		return nil, fmt.Errorf("selecting connectors: %v", err)
+	}
+	selectedExporters, err := selectComponents(t.source.Exporters, listExporters(selectedPipelines, isConnector))
+	if err != nil {
+		return nil, fmt.Errorf("selecting exporters: %v", err)
+	}
 
 	rawConfig := rawYAMLConfig{
 		Pipelines:  selectedPipelines,
 		Receivers:  selectedReceivers,
 		Processors: selectedProcessors,
+		Connectors: selectedConnectors,
+		Exporters:  selectedExporters,
 	}
 	resolver, err := newResolver(rawConfig, params)
 	if err != nil {
@@ -132,10 +171,10 @@ func listComponents(pipelines map[pipeline.ID]PipelineConfig, listIDs func(Pipel
 	return slices.Compact(list)
 }
 
-// listReceivers lists the IDs of all the receivers found in pipelines
-func listReceivers(pipelines map[pipeline.ID]PipelineConfig) []component.ID {
+// listReceivers lists the IDs of all the receivers found in pipelines, excluding connectors.
+func listReceivers(pipelines map[pipeline.ID]PipelineConfig, isConnector func(component.ID) bool) []component.ID {
 	return listComponents(pipelines, func(c PipelineConfig) []component.ID {
-		if c.Receiver == nil {
+		if c.Receiver == nil || isConnector(*c.Receiver) {
 			return []component.ID{}
 		}
 		return []component.ID{*c.Receiver}
@@ -149,32 +188,47 @@ func listProcessors(pipelines map[pipeline.ID]PipelineConfig) []component.ID {
 	})
 }
 
+// listConnectors lists the IDs of all the connectors found in pipelines, used either as receivers
+// or as exporters.
+func listConnectors(pipelines map[pipeline.ID]PipelineConfig, isConnector func(component.ID) bool) []component.ID {
+	return listComponents(pipelines, func(c PipelineConfig) []component.ID {
+		var connectors []component.ID
+		if c.Receiver != nil && isConnector(*c.Receiver) {
+			connectors = append(connectors, *c.Receiver)
+		}
+		for _, id := range c.Exporters {
+			if isConnector(id) {
+				connectors = append(connectors, id)
+			}
+		}
+		return connectors
+	})
+}
+
+// listExporters lists the IDs of all the exporters found in pipelines, excluding connectors.
+func listExporters(pipelines map[pipeline.ID]PipelineConfig, isConnector func(component.ID) bool) []component.ID {
+	return listComponents(pipelines, func(c PipelineConfig) []component.ID {
+		var exporters []component.ID
+		for _, id := range c.Exporters {
+			if !isConnector(id) {
+				exporters = append(exporters, id)
+			}
+		}
+		return exporters
+	})
+}
+
 type rawYAMLConfig struct {
+	Manifest   *Manifest                      `mapstructure:"manifest" yaml:"manifest,omitempty"`
 	Receivers  map[component.ID]yaml.Node     `mapstructure:"receivers" yaml:"receivers,omitempty"`
 	Processors map[component.ID]yaml.Node     `mapstructure:"processors" yaml:"processors,omitempty"`
+	Connectors map[component.ID]yaml.Node     `mapstructure:"connectors" yaml:"connectors,omitempty"`
+	Exporters  map[component.ID]yaml.Node     `mapstructure:"exporters" yaml:"exporters,omitempty"`
 	Pipelines  map[pipeline.ID]PipelineConfig `mapstructure:"pipelines" yaml:"pipelines,omitempty"`
 }
 
 func (c *rawYAMLConfig) validate() error {
-	if len(c.Pipelines) == 0 {
-		return errors.New("missing pipelines")
-	}
-	for _, pipeline := range c.Pipelines {
-		if pipeline.Receiver != nil {
-			if _, found := c.Receivers[*pipeline.Receiver]; !found {
-				return fmt.Errorf("receiver %q not defined", pipeline.Receiver.String())
-			}
-		}
-
-		for _, processor := range pipeline.Processors {
-			if _, found := c.Processors[processor]; !found {
-				return fmt.Errorf("processor %q not defined", processor.String())
-			}
-
-		}
-	}
-
-	return nil
+	return validatePipelines(c.Pipelines, c.Receivers, c.Processors, c.Connectors, c.Exporters)
 }
 
 const configProviderScheme = "config"
@@ -203,6 +203,179 @@ func TestRawTemplateResolve(t *testing.T) {
 			},
 			expectedErr: "selecting pipelines: component \"traces\" not found",
 		},
+		{
+			title: "connectors and exporters",
+			file:  "template-connectors.yaml",
+			pipelines: []pipeline.ID{
+				pipeline.NewID(pipeline.SignalTraces),
+				pipeline.NewIDWithName(pipeline.SignalMetrics, "count"),
+			},
+			params: map[string]any{
+				"somevalue": "foo",
+				"option":    "bar",
+				"verbosity": "basic",
+			},
+			expected: Config{
+				Receivers: map[component.ID]component.Config{
+					component.MustNewID("foo"): map[string]any{
+						"somesetting": "foo",
+					},
+				},
+				Processors: map[component.ID]component.Config{
+					component.MustNewID("someprocessor"): nil,
+				},
+				Connectors: map[component.ID]component.Config{
+					component.MustNewID("count"): map[string]any{
+						"option": "bar",
+					},
+				},
+				Exporters: map[component.ID]component.Config{
+					component.MustNewID("debug"): map[string]any{
+						"verbosity": "basic",
+					},
+				},
+				Pipelines: map[pipeline.ID]PipelineConfig{
+					pipeline.NewID(pipeline.SignalTraces): {
+						Receiver: idPtr(component.MustNewID("foo")),
+						Processors: []component.ID{
+							component.MustNewID("someprocessor"),
+						},
+						Exporters: []component.ID{
+							component.MustNewID("count"),
+							component.MustNewID("debug"),
+						},
+					},
+					pipeline.NewIDWithName(pipeline.SignalMetrics, "count"): {
+						Receiver:   idPtr(component.MustNewID("count")),
+						Processors: []component.ID{},
+					},
+				},
+			},
+		},
+		{
+			title: "connectors and exporters not selected",
+			file:  "template-connectors.yaml",
+			pipelines: []pipeline.ID{
+				pipeline.NewIDWithName(pipeline.SignalTraces, "raw"),
+			},
+			params: map[string]any{
+				"somevalue": "foo",
+			},
+			expected: Config{
+				Receivers: map[component.ID]component.Config{
+					component.MustNewID("foo"): map[string]any{
+						"somesetting": "foo",
+					},
+				},
+				Pipelines: map[pipeline.ID]PipelineConfig{
+					pipeline.NewIDWithName(pipeline.SignalTraces, "raw"): {
+						Receiver:   idPtr(component.MustNewID("foo")),
+						Processors: []component.ID{},
+					},
+				},
+			},
+		},
+		{
+			title: "connector without receiver pipeline selected",
+			file:  "template-connectors.yaml",
+			pipelines: []pipeline.ID{
+				pipeline.NewID(pipeline.SignalTraces),
+			},
+			params: map[string]any{
+				"somevalue": "foo",
+				"option":    "bar",
+				"verbosity": "basic",
+			},
+			expectedErr: "connector \"count\" used as exporter but not as receiver in any pipeline",
+		},
+		{
+			title:   "missing exporter",
+			file:    "template-missing-exporter.yaml",
+			fileErr: "exporter \"debug/missing\" not defined",
+		},
+		{
+			title:   "connector not used as receiver",
+			file:    "template-unused-connector.yaml",
+			fileErr: "connector \"count\" used as exporter but not as receiver in any pipeline",
+		},
+		{
+			title: "manifest with default parameters",
+			file:  "template-manifest.yaml",
+			params: map[string]any{
+				"somevalue": "foo",
+			},
+			expected: Config{
+				Receivers: map[component.ID]component.Config{
+					component.MustNewID("foo"): map[string]any{
+						"somesetting": "foo",
+						"port":        8080,
+					},
+				},
+				Processors: map[component.ID]component.Config{
+					component.MustNewID("someprocessor"): nil,
+				},
+				Pipelines: map[pipeline.ID]PipelineConfig{
+					pipeline.NewID(pipeline.SignalMetrics): {
+						Receiver: idPtr(component.MustNewID("foo")),
+						Processors: []component.ID{
+							component.MustNewID("someprocessor"),
+						},
+					},
+				},
+			},
+		},
+		{
+			title: "manifest with missing required parameter",
+			file:  "template-manifest.yaml",
+			params: map[string]any{
+				"port": 9090,
+			},
+			expectedErr: "missing required parameter \"somevalue\"",
+		},
+		{
+			title: "manifest with invalid parameter type",
+			file:  "template-manifest.yaml",
+			params: map[string]any{
+				"somevalue": "foo",
+				"port":      "http",
+			},
+			expectedErr: "invalid value for parameter \"port\": expected integer, found string",
+		},
+		{
+			title: "manifest with unknown parameter",
+			file:  "template-manifest.yaml",
+			params: map[string]any{
+				"somevalue": "foo",
+				"other":     "bar",
+			},
+			expectedErr: "unknown parameter \"other\"",
+		},
+		{
+			title: "manifest with unsupported pipeline",
+			file:  "template-manifest.yaml",
+			pipelines: []pipeline.ID{
+				pipeline.NewIDWithName(pipeline.SignalMetrics, "internal"),
+			},
+			params: map[string]any{
+				"somevalue": "foo",
+			},
+			expectedErr: "pipeline \"metrics/internal\" not supported by integration",
+		},
+		{
+			title:   "manifest with invalid version",
+			file:    "template-manifest-invalid-version.yaml",
+			fileErr: "invalid manifest version \"latest\"",
+		},
+		{
+			title:   "manifest with undefined parameter",
+			file:    "template-manifest-undefined-parameter.yaml",
+			fileErr: "parameter \"othervalue\" used but not defined in manifest",
+		},
+		{
+			title:   "manifest with invalid default",
+			file:    "template-manifest-invalid-default.yaml",
+			fileErr: "invalid parameter \"port\": invalid default value: expected integer, found string",
+		},
 		{
 			title:   "missing receiver",
 			file:    "template-missing-receiver.yaml",
@@ -17,7 +17,14 @@
 
 package elasticapmprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor"
 
-import "github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments/config"
+import (
+	"errors"
+	"time"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments/config"
+	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/routing"
+)
 
 type Config struct {
 	config.Config `mapstructure:",squash"`
@@ -33,8 +40,45 @@ type Config struct {
 	// in the format "apm.app.<service.name>". Defaults to false for backwards compatibility.
 	ServiceNameInDataStreamDataset bool `mapstructure:"service_name_in_datastream_dataset"`
 
+	// DataStreamRouting holds the rules selecting the data_stream.dataset and
+	// data_stream.namespace of resources in ECS mapping mode, evaluated in
+	// order. The first matching rule applies; resources matching no rule
+	// use the default data stream.
+	DataStreamRouting []routing.RuleConfig `mapstructure:"datastream_routing"`
+
 	// HostIPEnabled controls whether the `host.ip` resource attribute should be set using client info address.
 	// When true, the processor will set the `host.ip` attribute from the client address when
 	// the mapping mode is "ecs". Defaults to true.
 	HostIPEnabled bool `mapstructure:"host_ip_enabled"`
+
+	// SourceMap configures the de-minification of RUM stack traces using the
+	// source maps uploaded to the elasticapmintake receiver. It is enabled when
+	// the store the receiver writes source maps to is configured.
+	SourceMap SourceMapConfig `mapstructure:"sourcemap"`
+}
+
+// SourceMapConfig configures where source maps are read from and how they
+// are cached.
+type SourceMapConfig struct {
+	sourcemap.Config `mapstructure:",squash"`
+
+	// CacheSize is the number of parsed source maps kept in memory.
+	// Defaults to 128.
+	CacheSize int `mapstructure:"cache_size"`
+
+	// CacheExpiration is how long source maps, and the absence of a source
+	// map for a bundle, are cached. Source maps uploaded again are picked up
+	// once the cached entry expires. Defaults to 5 minutes.
+	CacheExpiration time.Duration `mapstructure:"cache_expiration"`
+}
+
+// Validate checks the source map cache configuration is valid.
+func (cfg *SourceMapConfig) Validate() error {
+	if cfg.CacheSize <= 0 {
+		return errors.New("cache_size must be positive")
+	}
+	if cfg.CacheExpiration < 0 {
+		return errors.New("cache_expiration must not be negative")
+	}
+	return nil
 }
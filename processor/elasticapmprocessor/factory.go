@@ -20,6 +20,7 @@ package elasticapmprocessor // import "github.com/elastic/opentelemetry-collecto
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
@@ -29,6 +30,11 @@ import (
 	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/metadata"
 )
 
+const (
+	defaultSourceMapCacheSize       = 128
+	defaultSourceMapCacheExpiration = 5 * time.Minute
+)
+
 // NewFactory returns a processor.Factory that constructs elastic
 // trace processor instances.
 func NewFactory() processor.Factory {
@@ -45,6 +51,10 @@ func NewFactory() processor.Factory {
 func NewDefaultConfig() component.Config {
 	return &Config{
 		Config: config.Enabled(),
+		SourceMap: SourceMapConfig{
+			CacheSize:       defaultSourceMapCacheSize,
+			CacheExpiration: defaultSourceMapCacheExpiration,
+		},
 	}
 }
 
@@ -55,7 +65,7 @@ func createLogs(
 	if !ok {
 		return nil, fmt.Errorf("configuration parsing error")
 	}
-	return newLogProcessor(processorCfg, next, set.Logger), nil
+	return newLogProcessor(processorCfg, next, set)
 }
 
 func createTraces(
@@ -65,7 +75,7 @@ func createTraces(
 	if !ok {
 		return nil, fmt.Errorf("configuration parsing error")
 	}
-	return NewTraceProcessor(processorCfg, next, set.Logger), nil
+	return NewTraceProcessor(processorCfg, next, set)
 }
 
 func createMetrics(
@@ -75,5 +85,5 @@ func createMetrics(
 	if !ok {
 		return nil, fmt.Errorf("configuration parsing error")
 	}
-	return newMetricProcessor(processorCfg, next, set.Logger), nil
+	return newMetricProcessor(processorCfg, next, set)
 }
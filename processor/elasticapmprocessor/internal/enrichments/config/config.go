@@ -17,6 +17,13 @@
 
 package config // import "github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments/config"
 
+// DefaultMessageTimestampAttribute is the default span link attribute
+// holding the time the linked message was sent. It is not defined by the
+// OTel semantic conventions; the name follows the messaging.message.*
+// namespace for instrumentations that record the send time of messages,
+// such as the Kafka record timestamp or the SQS SentTimestamp attribute.
+const DefaultMessageTimestampAttribute = "messaging.message.timestamp"
+
 // Config configures the enrichment attributes produced.
 type Config struct {
 	Resource    ResourceConfig           `mapstructure:"resource"`
@@ -26,6 +33,24 @@ type Config struct {
 	SpanEvent   SpanEventConfig          `mapstructure:"span_event"`
 	Log         ElasticLogConfig         `mapstructure:"elastic_log"`
 	Metric      ElasticMetricConfig      `mapstructure:"elastic_metric"`
+	Sampling    SamplingConfig           `mapstructure:"sampling"`
+}
+
+// SamplingConfig configures how the representative count of transactions
+// and spans accounts for sampling performed after head sampling, such as
+// tail-based sampling. The head sampling count is derived from the OTel
+// tracestate, whose "th" threshold already includes any later consistent
+// probability sampling which updated it.
+type SamplingConfig struct {
+	// AdjustedCountAttribute is the span attribute holding the adjusted
+	// count of a later sampling stage. The head sampling count is multiplied
+	// by its value.
+	AdjustedCountAttribute string `mapstructure:"adjusted_count_attribute"`
+	// ProbabilityAttribute is the span attribute holding the sampling
+	// probability, in (0, 1], of a later sampling stage. The head sampling
+	// count is divided by its value. It is ignored if the adjusted count
+	// attribute is set on the span.
+	ProbabilityAttribute string `mapstructure:"probability_attribute"`
 }
 
 // ResourceConfig configures the enrichment of resource attributes.
@@ -79,6 +104,11 @@ type ElasticTransactionConfig struct {
 	UserAgent           AttributeConfig `mapstructure:"user_agent"`
 	RemoveMessaging     AttributeConfig `mapstructure:"remove_messaging"`
 	MessageQueueName    AttributeConfig `mapstructure:"message_queue_name"`
+	SpanLinks           AttributeConfig `mapstructure:"span_links"`
+	// MessageAge sets message.age.ms of messaging consumer transactions
+	// from the timestamps of the messages they link to.
+	// Disabled by default.
+	MessageAge MessageAgeConfig `mapstructure:"message_age"`
 }
 
 // ElasticSpanConfig configures the enrichment attributes for the spans
@@ -100,6 +130,7 @@ type ElasticSpanConfig struct {
 	UserAgent           AttributeConfig `mapstructure:"user_agent"`
 	RemoveMessaging     AttributeConfig `mapstructure:"remove_messaging"`
 	MessageQueueName    AttributeConfig `mapstructure:"message_queue_name"`
+	SpanLinks           AttributeConfig `mapstructure:"span_links"`
 }
 
 // SpanEventConfig configures enrichment attributes for the span events.
@@ -127,6 +158,9 @@ type ElasticLogConfig struct {
 	EventConfig          EventConfig          `mapstructure:",squash"`
 	ErrorConfig          ErrorConfig          `mapstructure:",squash"`
 	ErrorExceptionConfig ErrorExceptionConfig `mapstructure:",squash"`
+	// SessionCrashed marks the session of mobile crash and ANR events
+	// as crashed.
+	SessionCrashed AttributeConfig `mapstructure:"session_crashed"`
 }
 
 type EventConfig struct {
@@ -142,6 +176,7 @@ type ErrorConfig struct {
 	ErrorGroupingKey  AttributeConfig `mapstructure:"error_grouping_key"`
 	ErrorGroupingName AttributeConfig `mapstructure:"error_grouping_name"`
 	ErrorType         AttributeConfig `mapstructure:"error_type"`
+	ErrorCulprit      AttributeConfig `mapstructure:"error_culprit"`
 	TimestampUs       AttributeConfig `mapstructure:"timestamp_us"`
 }
 
@@ -151,6 +186,17 @@ type ErrorExceptionConfig struct {
 	ErrorExceptionHandled AttributeConfig `mapstructure:"error_exception_handled"`
 }
 
+// MessageAgeConfig configures message.age.ms of messaging consumer
+// transactions.
+type MessageAgeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TimestampAttribute is the span link attribute holding the time, in
+	// milliseconds since the epoch, the linked message was sent. There is
+	// no semantic convention for it, so it must match the attribute set by
+	// the instrumentation. Defaults to DefaultMessageTimestampAttribute.
+	TimestampAttribute string `mapstructure:"timestamp_attribute"`
+}
+
 // AttributeConfig is the configuration options for each attribute.
 type AttributeConfig struct {
 	Enabled bool `mapstructure:"enabled"`
@@ -187,6 +233,8 @@ func Enabled() Config {
 			UserAgent:           AttributeConfig{Enabled: true},
 			RemoveMessaging:     AttributeConfig{Enabled: true},
 			MessageQueueName:    AttributeConfig{Enabled: true},
+			SpanLinks:           AttributeConfig{Enabled: true},
+			MessageAge:          MessageAgeConfig{TimestampAttribute: DefaultMessageTimestampAttribute},
 		},
 		Span: ElasticSpanConfig{
 			TimestampUs:         AttributeConfig{Enabled: true},
@@ -202,6 +250,7 @@ func Enabled() Config {
 			UserAgent:           AttributeConfig{Enabled: true},
 			RemoveMessaging:     AttributeConfig{Enabled: true},
 			MessageQueueName:    AttributeConfig{Enabled: true},
+			SpanLinks:           AttributeConfig{Enabled: true},
 		},
 		SpanEvent: SpanEventConfig{
 			TimestampUs:        AttributeConfig{Enabled: true},
@@ -233,6 +282,7 @@ func Enabled() Config {
 				ErrorStackTrace:  AttributeConfig{Enabled: true},
 				ErrorGroupingKey: AttributeConfig{Enabled: true},
 				ErrorType:        AttributeConfig{Enabled: true},
+				ErrorCulprit:     AttributeConfig{Enabled: true},
 				TimestampUs:      AttributeConfig{Enabled: true},
 			},
 			ErrorExceptionConfig: ErrorExceptionConfig{
@@ -240,6 +290,7 @@ func Enabled() Config {
 				ErrorExceptionMessage: AttributeConfig{Enabled: true},
 				ErrorExceptionHandled: AttributeConfig{Enabled: true},
 			},
+			SessionCrashed: AttributeConfig{Enabled: true},
 		},
 	}
 }
@@ -30,9 +30,11 @@ func TestEnabled(t *testing.T) {
 	assertAttributeConfigDefaults(t, reflect.ValueOf(config.Scope), nil)
 	assertAttributeConfigDefaults(t, reflect.ValueOf(config.Transaction), nil)
 	assertAttributeConfigDefaults(t, reflect.ValueOf(config.Span), nil)
-	assertAttributeConfigDefaults(t, reflect.ValueOf(config.SpanEvent), []string{"ErrorStackTrace", "ErrorExceptionType", "ErrorExceptionMessage", "ErrorType"})
+	assertAttributeConfigDefaults(t, reflect.ValueOf(config.SpanEvent), []string{"ErrorStackTrace", "ErrorExceptionType", "ErrorExceptionMessage", "ErrorType", "ErrorCulprit"})
 	assertAttributeConfigDefaults(t, reflect.ValueOf(config.Log), []string{"ErrorGroupingName"})
 	assertAttributeConfigDefaults(t, reflect.ValueOf(config.Metric), []string{"ProcessorEvent"})
+
+	require.Equal(t, MessageAgeConfig{TimestampAttribute: DefaultMessageTimestampAttribute}, config.Transaction.MessageAge)
 }
 
 func assertAttributeConfigDefaults(t *testing.T, cfg reflect.Value, expectDisabled []string) {
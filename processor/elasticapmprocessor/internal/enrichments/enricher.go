@@ -133,9 +133,25 @@ func ecsAPMConfig(cfg config.Config) config.Config {
 
 // ecsPreProcessResource runs the shared ECS pre-processing pipeline on a
 // resource. This is common across all signal types (traces, logs, metrics)
-// in ECS mode.
-func ecsPreProcessResource(ctx context.Context, resource pcommon.Resource, dataStreamType string, serviceNameInDataStreamDataset bool, hostIPEnabled bool, sanitizeExistingLabels bool) ecs.ResourceAttrContext {
+// in ECS mode. The data stream selected by router, if any, takes precedence
+// over the default one but not over data stream attributes already set on
+// the resource.
+func ecsPreProcessResource(ctx context.Context, resource pcommon.Resource, schemaURLItem routing.SchemaURLItem, dataStreamType string, serviceNameInDataStreamDataset bool, hostIPEnabled bool, sanitizeExistingLabels bool, router *routing.Router) ecs.ResourceAttrContext {
+	// Rules are evaluated on the attributes as received, before unsupported
+	// ones are moved to labels.
+	dataset, namespace, routed := router.Route(ctx, resource, schemaURLItem)
 	resourceContext := ecs.TranslateResourceMetadata(resource, sanitizeExistingLabels)
+	if routed {
+		attributes := resource.Attributes()
+		if resourceContext.DataStreamDataset == "" && dataset != "" {
+			attributes.PutStr(elasticattr.DataStreamDataset, dataset)
+			resourceContext.DataStreamDataset = dataset
+		}
+		if resourceContext.DataStreamNamespace == "" && namespace != "" {
+			attributes.PutStr(elasticattr.DataStreamNamespace, namespace)
+			resourceContext.DataStreamNamespace = namespace
+		}
+	}
 	routing.EncodeDataStream(resource, dataStreamType, serviceNameInDataStreamDataset, resourceContext)
 	if hostIPEnabled {
 		ecs.SetHostIP(ctx, resource.Attributes())
@@ -51,7 +51,7 @@ func EnrichLog(resourceAttrs map[string]any, log plog.LogRecord, cfg config.Conf
 		EnrichLogError(log, cfg)
 	}
 
-	if ctx.Action == "crash" || isErrorEvent {
+	if ctx.IsCrash() || isErrorEvent {
 		routing.EncodeErrorDataStream(log.Attributes(), routing.DataStreamTypeLogs, resourceNamespace)
 	}
 }
@@ -38,10 +38,11 @@ type ecsLogEnricher struct {
 	hostIPEnabled                  bool
 	serviceNameInDataStreamDataset bool
 	sanitizeExistingLabels         bool
+	router                         *routing.Router
 }
 
 func (e *ecsLogEnricher) enrichResourceLogs(ctx context.Context, rl plog.ResourceLogs) {
-	resCtx := ecsPreProcessResource(ctx, rl.Resource(), routing.DataStreamTypeLogs, e.serviceNameInDataStreamDataset, e.hostIPEnabled, e.sanitizeExistingLabels)
+	resCtx := ecsPreProcessResource(ctx, rl.Resource(), rl, routing.DataStreamTypeLogs, e.serviceNameInDataStreamDataset, e.hostIPEnabled, e.sanitizeExistingLabels, e.router)
 	e.enricher.EnrichResourceLogs(rl, resCtx.DataStreamNamespace)
 }
 
@@ -55,7 +56,7 @@ func (e *APMLogEnricher) EnrichResourceLogs(ctx context.Context, rl plog.Resourc
 }
 
 // NewAPMLogEnricher creates a LogEnricher for elastic APM intake events.
-func NewAPMLogEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool) *APMLogEnricher {
+func NewAPMLogEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool, router *routing.Router) *APMLogEnricher {
 	cfg := ecsAPMConfig(baseCfg)
 	// disable the transaction result enrichment to avoid deriving a value
 	// when the provided result is empty to match existing apm-data logic
@@ -66,6 +67,7 @@ func NewAPMLogEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInD
 			hostIPEnabled:                  hostIPEnabled,
 			serviceNameInDataStreamDataset: serviceNameInDataStreamDataset,
 			sanitizeExistingLabels:         true,
+			router:                         router,
 		},
 	}
 }
@@ -80,7 +82,7 @@ func (e *OTelLogEnricher) EnrichResourceLogs(ctx context.Context, rl plog.Resour
 }
 
 // NewOTelLogEnricher creates a LogEnricher for elastic OTel events.
-func NewOTelLogEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool) *OTelLogEnricher {
+func NewOTelLogEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool, router *routing.Router) *OTelLogEnricher {
 	cfg := ecsOTelConfig(baseCfg)
 	// disable the transaction result enrichment to avoid deriving a value
 	// when the provided result is empty to match existing apm-data logic
@@ -91,6 +93,7 @@ func NewOTelLogEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameIn
 			hostIPEnabled:                  hostIPEnabled,
 			serviceNameInDataStreamDataset: serviceNameInDataStreamDataset,
 			sanitizeExistingLabels:         false,
+			router:                         router,
 		},
 	}
 }
@@ -38,21 +38,23 @@ type ecsMetricEnricher struct {
 	hostIPEnabled                  bool
 	serviceNameInDataStreamDataset bool
 	sanitizeExistingLabels         bool
+	router                         *routing.Router
 }
 
 func (e *ecsMetricEnricher) enrichResourceMetrics(ctx context.Context, rm pmetric.ResourceMetrics) {
 	resource := rm.Resource()
-	resCtx := ecsPreProcessResource(ctx, resource, routing.DataStreamTypeMetrics, e.serviceNameInDataStreamDataset, e.hostIPEnabled, e.sanitizeExistingLabels)
+	resCtx := ecsPreProcessResource(ctx, resource, rm, routing.DataStreamTypeMetrics, e.serviceNameInDataStreamDataset, e.hostIPEnabled, e.sanitizeExistingLabels, e.router)
 	hasServiceName := resCtx.ServiceName != ""
 
 	// Route internal metrics to appropriate data streams if needed.
-	routeMetricsToDataStream(rm.ScopeMetrics(), hasServiceName)
+	routeMetricsToDataStream(rm.ScopeMetrics(), hasServiceName, resCtx.DataStreamNamespace)
 	e.enricher.EnrichResourceMetrics(rm)
 }
 
 // routeMetricsToDataStream routes individual metric data points to their
 // appropriate data streams based on metric name and service name presence.
-func routeMetricsToDataStream(scopeMetrics pmetric.ScopeMetricsSlice, hasServiceName bool) {
+// namespace is the resource namespace, propagated to internal data points.
+func routeMetricsToDataStream(scopeMetrics pmetric.ScopeMetricsSlice, hasServiceName bool, namespace string) {
 	for j := 0; j < scopeMetrics.Len(); j++ {
 		metrics := scopeMetrics.At(j).Metrics()
 		for k := 0; k < metrics.Len(); k++ {
@@ -67,35 +69,35 @@ func routeMetricsToDataStream(scopeMetrics pmetric.ScopeMetricsSlice, hasService
 			case pmetric.MetricTypeGauge:
 				dataPoints := metric.Gauge().DataPoints()
 				for l := 0; l < dataPoints.Len(); l++ {
-					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName) {
+					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName, namespace) {
 						isInternal = true
 					}
 				}
 			case pmetric.MetricTypeSum:
 				dataPoints := metric.Sum().DataPoints()
 				for l := 0; l < dataPoints.Len(); l++ {
-					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName) {
+					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName, namespace) {
 						isInternal = true
 					}
 				}
 			case pmetric.MetricTypeHistogram:
 				dataPoints := metric.Histogram().DataPoints()
 				for l := 0; l < dataPoints.Len(); l++ {
-					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName) {
+					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName, namespace) {
 						isInternal = true
 					}
 				}
 			case pmetric.MetricTypeExponentialHistogram:
 				dataPoints := metric.ExponentialHistogram().DataPoints()
 				for l := 0; l < dataPoints.Len(); l++ {
-					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName) {
+					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName, namespace) {
 						isInternal = true
 					}
 				}
 			case pmetric.MetricTypeSummary:
 				dataPoints := metric.Summary().DataPoints()
 				for l := 0; l < dataPoints.Len(); l++ {
-					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName) {
+					if routing.EncodeDataStreamMetricDataPoint(dataPoints.At(l).Attributes(), metricName, hasServiceName, namespace) {
 						isInternal = true
 					}
 				}
@@ -122,7 +124,7 @@ func (e *APMMetricEnricher) EnrichResourceMetrics(ctx context.Context, rm pmetri
 }
 
 // NewAPMMetricEnricher creates a MetricEnricher for elastic APM intake events.
-func NewAPMMetricEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool) *APMMetricEnricher {
+func NewAPMMetricEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool, router *routing.Router) *APMMetricEnricher {
 	cfg := ecsAPMConfig(baseCfg)
 	return &APMMetricEnricher{
 		ecsMetricEnricher: ecsMetricEnricher{
@@ -130,6 +132,7 @@ func NewAPMMetricEnricher(baseCfg config.Config, hostIPEnabled bool, serviceName
 			hostIPEnabled:                  hostIPEnabled,
 			serviceNameInDataStreamDataset: serviceNameInDataStreamDataset,
 			sanitizeExistingLabels:         true,
+			router:                         router,
 		},
 	}
 }
@@ -144,7 +147,7 @@ func (e *OTelMetricEnricher) EnrichResourceMetrics(ctx context.Context, rm pmetr
 }
 
 // NewOTelMetricEnricher creates a MetricEnricher for elastic OTel events.
-func NewOTelMetricEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool) *OTelMetricEnricher {
+func NewOTelMetricEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNameInDataStreamDataset bool, router *routing.Router) *OTelMetricEnricher {
 	cfg := ecsOTelConfig(baseCfg)
 	return &OTelMetricEnricher{
 		ecsMetricEnricher: ecsMetricEnricher{
@@ -152,6 +155,7 @@ func NewOTelMetricEnricher(baseCfg config.Config, hostIPEnabled bool, serviceNam
 			hostIPEnabled:                  hostIPEnabled,
 			serviceNameInDataStreamDataset: serviceNameInDataStreamDataset,
 			sanitizeExistingLabels:         false,
+			router:                         router,
 		},
 	}
 }
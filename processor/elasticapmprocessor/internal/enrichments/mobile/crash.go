@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mobile // import "github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments/mobile"
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// javaFramePattern matches the frames of java stack traces and ANR
+	// thread dumps, e.g. "at com.example.Main.run(Main.java:10)".
+	javaFramePattern = regexp.MustCompile(`(?m)^\s*at\s+([^\s(]+)\(([^:)]*)(?::\d+)?\)`)
+
+	// Patterns for the header fields and crashed thread frames of Apple
+	// crash reports, as produced by PLCrashReporter.
+	appleExceptionTypePattern  = regexp.MustCompile(`(?m)^Exception Type:\s+(.+?)\s*$`)
+	appleExceptionCodesPattern = regexp.MustCompile(`(?m)^Exception Codes:\s+(.+?)\s*$`)
+	appleFramePattern          = regexp.MustCompile(`^\d+\s+(\S+)\s+0x[0-9a-fA-F]+\s+(.+?)(?:\s+\+\s+\d+)?\s*$`)
+	// appleAddressPattern matches the symbol of unsymbolicated frames,
+	// which only hold the load address of their image.
+	appleAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+)
+
+// crashDetails contains the error fields derived from the stack trace of a
+// crash or ANR event.
+type crashDetails struct {
+	exceptionType    string
+	exceptionMessage string
+	culprit          string
+}
+
+// appleCulprit returns the culprit of the crashed thread frames of an
+// Apple crash report: the first symbolicated frame, in the same
+// "<image> in <symbol>" format as APM agents. Unsymbolicated frames are
+// skipped, and the image of the first frame is used if no frame is
+// symbolicated.
+func appleCulprit(frames string) string {
+	var image string
+	for _, frame := range strings.Split(frames, "\n") {
+		m := appleFramePattern.FindStringSubmatch(frame)
+		if m == nil {
+			continue
+		}
+		if !appleAddressPattern.MatchString(m[2]) {
+			return m[1] + " in " + m[2]
+		}
+		if image == "" {
+			image = m[1]
+		}
+	}
+	return image
+}
+
+// parseCrashStacktrace extracts the error fields from an Apple crash report
+// or a java stack trace. Fields that cannot be derived are left empty.
+func parseCrashStacktrace(stacktrace string) crashDetails {
+	if match := swiftCrashedThreadPattern.FindStringSubmatch(stacktrace); match != nil {
+		var details crashDetails
+		if m := appleExceptionTypePattern.FindStringSubmatch(stacktrace); m != nil {
+			details.exceptionType = m[1]
+		}
+		if m := appleExceptionCodesPattern.FindStringSubmatch(stacktrace); m != nil {
+			details.exceptionMessage = m[1]
+		}
+		details.culprit = appleCulprit(match[1])
+		return details
+	}
+	if m := javaFramePattern.FindStringSubmatch(stacktrace); m != nil {
+		// Use the same "<filename> in <function>" format as APM agents.
+		culprit := m[1]
+		if m[2] != "" && m[2] != "Unknown Source" && m[2] != "Native Method" {
+			culprit = m[2] + " in " + culprit
+		}
+		return crashDetails{culprit: culprit}
+	}
+	return crashDetails{}
+}
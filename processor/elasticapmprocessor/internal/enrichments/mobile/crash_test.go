@@ -0,0 +1,77 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mobile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCrashStacktrace(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		stacktrace string
+		expected   crashDetails
+	}{
+		{
+			name:       "java",
+			stacktrace: "java.lang.IllegalStateException: boom\n\tat com.example.MainActivity.onClick(MainActivity.kt:42)\n\tat android.view.View.performClick(View.java:7448)",
+			expected:   crashDetails{culprit: "MainActivity.kt in com.example.MainActivity.onClick"},
+		},
+		{
+			name:       "java_native_method",
+			stacktrace: "java.lang.UnsatisfiedLinkError: boom\n\tat java.lang.Runtime.nativeLoad(Native Method)",
+			expected:   crashDetails{culprit: "java.lang.Runtime.nativeLoad"},
+		},
+		{
+			name:       "anr_thread_dump",
+			stacktrace: "\"main\" prio=5 tid=1 Blocked\n  | group=\"main\" sCount=1\n  at com.example.Worker.await(Worker.java:12)\n  - waiting to lock <0x0a1b2c3d>\n  at com.example.MainActivity.onResume(MainActivity.java:30)",
+			expected:   crashDetails{culprit: "Worker.java in com.example.Worker.await"},
+		},
+		{
+			name:       "apple_crash_report",
+			stacktrace: readSwiftStacktraceFile(t, "thread-8-crash.txt"),
+			expected: crashDetails{
+				exceptionType:    "SIGTRAP",
+				exceptionMessage: "TRAP_BRKPT at 0x195712d2c",
+				culprit:          "libswiftCore.dylib",
+			},
+		},
+		{
+			name: "apple_crash_report_symbolicated",
+			stacktrace: "Exception Type:  EXC_BAD_ACCESS (SIGSEGV)\n\n" +
+				"Thread 0 Crashed:\n" +
+				"0   libswiftCore.dylib                  0x0000000195712d2c 0x1956e8000 + 175404\n" +
+				"1   opbeans-swift                       0x0000000107077048 ViewController.buttonTapped(_:) + 120\n" +
+				"2   UIKitCore                           0x0000000185d4c8a8 -[UIApplication sendAction:to:from:forEvent:] + 96\n\n",
+			expected: crashDetails{
+				exceptionType: "EXC_BAD_ACCESS (SIGSEGV)",
+				culprit:       "opbeans-swift in ViewController.buttonTapped(_:)",
+			},
+		},
+		{
+			name:       "unknown",
+			stacktrace: "something went wrong",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseCrashStacktrace(tc.stacktrace))
+		})
+	}
+}
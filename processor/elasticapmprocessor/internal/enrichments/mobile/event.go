@@ -20,6 +20,7 @@ package mobile // import "github.com/elastic/opentelemetry-collector-components/
 import (
 	"strings"
 
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 
 	"github.com/elastic/opentelemetry-collector-components/internal/elasticattr"
@@ -27,6 +28,14 @@ import (
 	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments/config"
 )
 
+const (
+	// actionCrash is the action of crash events, e.g. "device.crash" events
+	// of the OTel Android SDK or "crash" device events of the Swift SDK.
+	actionCrash = "crash"
+	// actionANR is the action of Application Not Responding events.
+	actionANR = "anr"
+)
+
 // EventContext contains contextual information for log event enrichment
 type EventContext struct {
 	ResourceAttributes map[string]any
@@ -62,6 +71,12 @@ func NewEventContext(resourceAttrs map[string]any, logRecord plog.LogRecord) Eve
 	return ctx
 }
 
+// IsCrash returns true if the event is a crash or ANR event, which are
+// stored as errors.
+func (ctx EventContext) IsCrash() bool {
+	return ctx.Action == actionCrash || ctx.Action == actionANR
+}
+
 func EnrichLogEvent(ctx EventContext, logRecord plog.LogRecord, cfg config.Config) {
 
 	if cfg.Log.EventConfig.EventKind.Enabled {
@@ -73,8 +88,8 @@ func EnrichLogEvent(ctx EventContext, logRecord plog.LogRecord, cfg config.Confi
 			attribute.PutStr(logRecord.Attributes(), elasticattr.EventCategory, "device")
 		}
 		action := strings.TrimPrefix(ctx.EventName, "device.")
-		if action == "crash" {
-			enrichCrashEvent(logRecord, ctx.ResourceAttributes, cfg)
+		if action == actionCrash || action == actionANR {
+			enrichCrashEvent(logRecord, ctx.ResourceAttributes, action, cfg)
 		} else if action != "" && cfg.Log.EventConfig.EventAction.Enabled {
 			attribute.PutStr(logRecord.Attributes(), elasticattr.EventAction, action)
 		}
@@ -103,34 +118,84 @@ func isDeviceEvent(logRecord plog.LogRecord, eventName string) bool {
 	return eventDomain == "device" && eventName != "" || strings.HasPrefix(eventName, "device.")
 }
 
-func enrichCrashEvent(logRecord plog.LogRecord, resourceAttrs map[string]any, cfg config.Config) {
+// enrichCrashEvent normalizes a crash or ANR event into an unhandled error.
+func enrichCrashEvent(logRecord plog.LogRecord, resourceAttrs map[string]any, action string, cfg config.Config) {
+	attributes := logRecord.Attributes()
 	timestamp := logRecord.Timestamp()
 	if timestamp == 0 {
 		timestamp = logRecord.ObservedTimestamp()
 	}
-	attribute.PutInt(logRecord.Attributes(), elasticattr.TimestampUs, attribute.ToTimestampUS(timestamp))
+	attribute.PutInt(attributes, elasticattr.TimestampUs, attribute.ToTimestampUS(timestamp))
 	if id, err := attribute.NewErrorID(); err == nil {
-		attribute.PutStr(logRecord.Attributes(), elasticattr.ErrorID, id)
+		attribute.PutStr(attributes, elasticattr.ErrorID, id)
+	}
+
+	var details crashDetails
+	stacktrace, ok := attributes.Get("exception.stacktrace")
+	if ok {
+		details = parseCrashStacktrace(stacktrace.AsString())
 	}
-	stacktrace, ok := logRecord.Attributes().Get("exception.stacktrace")
 	if ok && cfg.Log.ErrorConfig.ErrorGroupingKey.Enabled {
 		language, hasLanguage := resourceAttrs["telemetry.sdk.language"]
 		if hasLanguage {
 			switch language {
 			case "java":
-				attribute.PutStr(logRecord.Attributes(), elasticattr.ErrorGroupingKey, CreateJavaStacktraceGroupingKey(stacktrace.AsString()))
+				attribute.PutStr(attributes, elasticattr.ErrorGroupingKey, CreateJavaStacktraceGroupingKey(stacktrace.AsString()))
 			case "swift":
 				if key, err := CreateSwiftStacktraceGroupingKey(stacktrace.AsString()); err == nil {
-					attribute.PutStr(logRecord.Attributes(), elasticattr.ErrorGroupingKey, key)
+					attribute.PutStr(attributes, elasticattr.ErrorGroupingKey, key)
 				}
 			}
 		}
 	}
+	if ok && cfg.Log.ErrorConfig.ErrorStackTrace.Enabled {
+		attribute.PutStr(attributes, elasticattr.ErrorStackTrace, stacktrace.AsString())
+	}
+	if cfg.Log.ErrorConfig.ErrorCulprit.Enabled && details.culprit != "" {
+		attribute.PutStr(attributes, elasticattr.ErrorCulprit, details.culprit)
+	}
+
+	exceptionType := getStr(attributes, "exception.type")
+	if exceptionType == "" {
+		exceptionType = details.exceptionType
+	}
+	if exceptionType == "" && action == actionANR {
+		exceptionType = "ANR"
+	}
+	if cfg.Log.ErrorExceptionConfig.ErrorExceptionType.Enabled && exceptionType != "" {
+		attribute.PutStr(attributes, "error.exception.type", exceptionType)
+	}
+	exceptionMessage := getStr(attributes, "exception.message")
+	if exceptionMessage == "" {
+		exceptionMessage = details.exceptionMessage
+	}
+	if exceptionMessage == "" && action == actionANR {
+		exceptionMessage = "Application Not Responding"
+	}
+	if cfg.Log.ErrorExceptionConfig.ErrorExceptionMessage.Enabled && exceptionMessage != "" {
+		attribute.PutStr(attributes, "error.exception.message", exceptionMessage)
+	}
+	// Crashes and ANRs are never handled by the application.
+	if cfg.Log.ErrorExceptionConfig.ErrorExceptionHandled.Enabled {
+		attribute.PutBool(attributes, elasticattr.ErrorExceptionHandled, false)
+	}
+
 	if cfg.Log.ErrorConfig.ErrorType.Enabled {
-		attribute.PutStr(logRecord.Attributes(), elasticattr.ErrorType, "crash")
+		attribute.PutStr(attributes, elasticattr.ErrorType, action)
 	}
 
 	if cfg.Log.EventConfig.EventType.Enabled {
-		attribute.PutStr(logRecord.Attributes(), elasticattr.EventType, "error")
+		attribute.PutStr(attributes, elasticattr.EventType, "error")
+	}
+
+	if _, ok := attributes.Get(elasticattr.SessionID); ok && cfg.Log.SessionCrashed.Enabled {
+		attribute.PutBool(attributes, elasticattr.SessionCrashed, true)
+	}
+}
+
+func getStr(attributes pcommon.Map, key string) string {
+	if v, ok := attributes.Get(key); ok {
+		return v.AsString()
 	}
+	return ""
 }
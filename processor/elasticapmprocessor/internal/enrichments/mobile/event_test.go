@@ -62,12 +62,17 @@ func TestEnrichEvents(t *testing.T) {
 				return logRecord
 			},
 			expectedAttributes: map[string]any{
-				"timestamp.us":       timestamp.AsTime().UnixMicro(),
-				"error.grouping_key": javaStacktraceHash,
-				"error.type":         "crash",
-				"event.kind":         "event",
-				"event.category":     "device",
-				"event.type":         "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.grouping_key":      javaStacktraceHash,
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.culprit":           "GenerateTrace.java in com.example.GenerateTrace.methodB",
+				"error.exception.handled": false,
+				"error.exception.message": "Exception message",
+				"error.exception.type":    "java.lang.RuntimeException",
+				"error.stack_trace":       javaStacktrace,
 			},
 		},
 		{
@@ -86,12 +91,17 @@ func TestEnrichEvents(t *testing.T) {
 				return logRecord
 			},
 			expectedAttributes: map[string]any{
-				"timestamp.us":       timestamp.AsTime().UnixMicro(),
-				"error.grouping_key": javaStacktraceHash,
-				"error.type":         "crash",
-				"event.kind":         "event",
-				"event.category":     "device",
-				"event.type":         "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.grouping_key":      javaStacktraceHash,
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.culprit":           "GenerateTrace.java in com.example.GenerateTrace.methodB",
+				"error.exception.handled": false,
+				"error.exception.message": "Exception message",
+				"error.exception.type":    "java.lang.RuntimeException",
+				"error.stack_trace":       javaStacktrace,
 			},
 		},
 		{
@@ -110,11 +120,16 @@ func TestEnrichEvents(t *testing.T) {
 				return logRecord
 			},
 			expectedAttributes: map[string]any{
-				"timestamp.us":   timestamp.AsTime().UnixMicro(),
-				"error.type":     "crash",
-				"event.kind":     "event",
-				"event.category": "device",
-				"event.type":     "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.culprit":           "GenerateTrace.java in com.example.GenerateTrace.methodB",
+				"error.exception.handled": false,
+				"error.exception.message": "Exception message",
+				"error.exception.type":    "go.error",
+				"error.stack_trace":       javaStacktrace,
 			},
 		},
 		{
@@ -203,12 +218,17 @@ func TestEnrichEvents(t *testing.T) {
 				return logRecord
 			},
 			expectedAttributes: map[string]any{
-				"timestamp.us":       timestamp.AsTime().UnixMicro(),
-				"error.grouping_key": swiftStacktraceHash,
-				"error.type":         "crash",
-				"event.kind":         "event",
-				"event.category":     "device",
-				"event.type":         "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.grouping_key":      swiftStacktraceHash,
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.culprit":           "libswiftCore.dylib",
+				"error.exception.handled": false,
+				"error.exception.message": "TRAP_BRKPT at 0x195712d2c",
+				"error.exception.type":    "SIGTRAP",
+				"error.stack_trace":       swiftStacktrace,
 			},
 		},
 		{
@@ -235,14 +255,17 @@ func TestEnrichEvents(t *testing.T) {
 				"event.name":           "device.crash",
 				"exception.stacktrace": javaStacktrace,
 				// existing attributes that are not overridden
-				"event.kind":         "existing-event-kind",
-				"processor.event":    "existing-processor-event",
-				"timestamp.us":       int64(99999),
-				"error.id":           "0123456789abcdef0123456789abcdef",
-				"error.type":         "existing-error-type",
-				"error.grouping_key": "existing-grouping-key",
-				"event.category":     "device",
-				"event.type":         "error",
+				"event.kind":              "existing-event-kind",
+				"processor.event":         "existing-processor-event",
+				"timestamp.us":            int64(99999),
+				"error.id":                "0123456789abcdef0123456789abcdef",
+				"error.type":              "existing-error-type",
+				"error.grouping_key":      "existing-grouping-key",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.culprit":           "GenerateTrace.java in com.example.GenerateTrace.methodB",
+				"error.exception.handled": false,
+				"error.stack_trace":       javaStacktrace,
 			},
 		},
 		{
@@ -269,11 +292,14 @@ func TestEnrichEvents(t *testing.T) {
 				"event.kind":      "existing-event-kind",
 				"processor.event": "existing-processor-event",
 				// attributes that are added by enrichment
-				"timestamp.us":       timestamp.AsTime().UnixMicro(),
-				"error.grouping_key": javaStacktraceHash,
-				"error.type":         "crash",
-				"event.category":     "device",
-				"event.type":         "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.grouping_key":      javaStacktraceHash,
+				"error.type":              "crash",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.culprit":           "GenerateTrace.java in com.example.GenerateTrace.methodB",
+				"error.exception.handled": false,
+				"error.stack_trace":       javaStacktrace,
 			},
 		},
 		{
@@ -350,11 +376,13 @@ func TestEnrichEvents(t *testing.T) {
 				return logRecord
 			},
 			expectedAttributes: map[string]any{
-				"timestamp.us":   timestamp.AsTime().UnixMicro(),
-				"error.type":     "crash",
-				"event.kind":     "event",
-				"event.category": "device",
-				"event.type":     "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.exception.handled": false,
+				"error.exception.message": "Fatal error",
 			},
 		},
 		{
@@ -371,11 +399,13 @@ func TestEnrichEvents(t *testing.T) {
 				return logRecord
 			},
 			expectedAttributes: map[string]any{
-				"timestamp.us":   timestamp.AsTime().UnixMicro(),
-				"error.type":     "crash",
-				"event.kind":     "event",
-				"event.category": "device",
-				"event.type":     "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.exception.handled": false,
+				"error.stack_trace":       "invalid stacktrace without Thread N Crashed:",
 			},
 		},
 		{
@@ -390,11 +420,69 @@ func TestEnrichEvents(t *testing.T) {
 				return logRecord
 			},
 			expectedAttributes: map[string]any{
-				"timestamp.us":   timestamp.AsTime().UnixMicro(),
-				"error.type":     "crash",
-				"event.kind":     "event",
-				"event.category": "device",
-				"event.type":     "error",
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"error.culprit":           "GenerateTrace.java in com.example.GenerateTrace.methodB",
+				"error.exception.handled": false,
+				"error.stack_trace":       javaStacktrace,
+			},
+		},
+		{
+			name:      "anr_event_java",
+			eventName: "device.anr",
+			resourceAttrs: map[string]any{
+				"telemetry.sdk.language": "java",
+			},
+			input: func() plog.LogRecord {
+				logRecord := plog.NewLogRecord()
+				logRecord.SetTimestamp(timestamp)
+				logRecord.Attributes().PutStr("event.name", "device.anr")
+				logRecord.Attributes().PutStr("session.id", "session-1")
+				logRecord.Attributes().PutStr("exception.stacktrace", javaStacktrace)
+				return logRecord
+			},
+			expectedAttributes: map[string]any{
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.grouping_key":      javaStacktraceHash,
+				"error.culprit":           "GenerateTrace.java in com.example.GenerateTrace.methodB",
+				"error.exception.handled": false,
+				"error.exception.message": "Application Not Responding",
+				"error.exception.type":    "ANR",
+				"error.stack_trace":       javaStacktrace,
+				"error.type":              "anr",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"session.crashed":         true,
+			},
+		},
+		{
+			name:      "crash_event_with_session",
+			eventName: "crash",
+			resourceAttrs: map[string]any{
+				"telemetry.sdk.language": "swift",
+			},
+			input: func() plog.LogRecord {
+				logRecord := plog.NewLogRecord()
+				logRecord.SetTimestamp(timestamp)
+				logRecord.Attributes().PutStr("event.domain", "device")
+				logRecord.Attributes().PutStr("event.name", "crash")
+				logRecord.Attributes().PutStr("session.id", "session-1")
+				logRecord.Attributes().PutStr("exception.message", "Fatal error")
+				return logRecord
+			},
+			expectedAttributes: map[string]any{
+				"timestamp.us":            timestamp.AsTime().UnixMicro(),
+				"error.exception.handled": false,
+				"error.exception.message": "Fatal error",
+				"error.type":              "crash",
+				"event.kind":              "event",
+				"event.category":          "device",
+				"event.type":              "error",
+				"session.crashed":         true,
 			},
 		},
 	} {
@@ -411,11 +499,12 @@ func TestEnrichEvents(t *testing.T) {
 
 			assert.Empty(t, cmp.Diff(inputLogRecord.Attributes().AsRaw(), tc.expectedAttributes, ignoreMapKey("error.id")))
 			errorId, ok := inputLogRecord.Attributes().Get("error.id")
+			crashEventNames := []string{"device.crash", "device.anr", "crash"}
 			if ok {
-				assert.Equal(t, "device.crash", tc.eventName)
+				assert.Contains(t, crashEventNames, tc.eventName)
 				assert.Equal(t, 32, len(errorId.AsString()))
 			} else {
-				assert.NotEqual(t, "device.crash", tc.eventName)
+				assert.NotContains(t, crashEventNames, tc.eventName)
 			}
 		})
 	}
@@ -0,0 +1,325 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package enrichments // import "github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments"
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/elasticattr"
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+)
+
+// anonymousFunction is the function name of mapped frames whose caller
+// could not be mapped, matching apm-server.
+const anonymousFunction = "<anonymous>"
+
+var (
+	// v8StackFrameRegexp matches frames of V8 (Chrome, Node.js) stack traces:
+	// "    at fn (https://example.com/bundle.min.js:1:234)" or
+	// "    at https://example.com/bundle.min.js:1:234".
+	v8StackFrameRegexp = regexp.MustCompile(`^(\s*at )(?:(.+?) \()?(\S+):(\d+):(\d+)(\)?)$`)
+	// geckoStackFrameRegexp matches frames of SpiderMonkey (Firefox) and
+	// JavaScriptCore (Safari) stack traces: "fn@https://example.com/bundle.min.js:1:234".
+	geckoStackFrameRegexp = regexp.MustCompile(`^(\s*)(.*?)@(\S+):(\d+):(\d+)$`)
+)
+
+// SourcemapEnricher rewrites the minified stack frames of RUM events to their
+// original source location, using the source maps uploaded for the service.
+type SourcemapEnricher struct {
+	fetcher *sourcemap.Fetcher
+	logger  *zap.Logger
+}
+
+// NewSourcemapEnricher creates a SourcemapEnricher fetching source maps with
+// fetcher.
+func NewSourcemapEnricher(fetcher *sourcemap.Fetcher, logger *zap.Logger) *SourcemapEnricher {
+	return &SourcemapEnricher{fetcher: fetcher, logger: logger}
+}
+
+// rumService identifies the service source maps are looked up for.
+type rumService struct {
+	name    string
+	version string
+}
+
+// getRUMService returns the service of a resource if it was reported by a
+// browser agent. Source maps are keyed by service version, so resources
+// without one are skipped.
+func getRUMService(resource pcommon.Resource) (rumService, bool) {
+	attrs := resource.Attributes()
+	isRUM := false
+	if v, ok := attrs.Get(elasticattr.AgentName); ok {
+		switch v.Str() {
+		case "rum-js", "js-base":
+			isRUM = true
+		}
+	}
+	if v, ok := attrs.Get(string(semconv.TelemetrySDKLanguageKey)); ok && v.Str() == semconv.TelemetrySDKLanguageWebjs.Value.AsString() {
+		isRUM = true
+	}
+	if !isRUM {
+		return rumService{}, false
+	}
+	var svc rumService
+	if v, ok := attrs.Get(string(semconv.ServiceNameKey)); ok {
+		svc.name = v.Str()
+	}
+	if v, ok := attrs.Get(string(semconv.ServiceVersionKey)); ok {
+		svc.version = v.Str()
+	}
+	return svc, svc.name != "" && svc.version != ""
+}
+
+// EnrichResourceSpans maps the span stack traces and the stack traces of
+// exception events.
+func (e *SourcemapEnricher) EnrichResourceSpans(ctx context.Context, rs ptrace.ResourceSpans) {
+	svc, ok := getRUMService(rs.Resource())
+	if !ok {
+		return
+	}
+	scopeSpans := rs.ScopeSpans()
+	for i := 0; i < scopeSpans.Len(); i++ {
+		spans := scopeSpans.At(i).Spans()
+		for j := 0; j < spans.Len(); j++ {
+			span := spans.At(j)
+			if v, ok := span.Attributes().Get(elasticattr.SpanStacktrace); ok && v.Type() == pcommon.ValueTypeSlice {
+				e.mapFrames(ctx, svc, v.Slice())
+			}
+			events := span.Events()
+			for k := 0; k < events.Len(); k++ {
+				if events.At(k).Name() == "exception" {
+					e.mapExceptionStacktrace(ctx, svc, events.At(k).Attributes())
+				}
+			}
+		}
+	}
+}
+
+// EnrichResourceLogs maps the exception and log stack traces of error events.
+// It must run before the error enrichment, so that error.stack_trace and the
+// grouping key are derived from the original source locations.
+func (e *SourcemapEnricher) EnrichResourceLogs(ctx context.Context, rl plog.ResourceLogs) {
+	svc, ok := getRUMService(rl.Resource())
+	if !ok {
+		return
+	}
+	scopeLogs := rl.ScopeLogs()
+	for i := 0; i < scopeLogs.Len(); i++ {
+		logRecords := scopeLogs.At(i).LogRecords()
+		for j := 0; j < logRecords.Len(); j++ {
+			attributes := logRecords.At(j).Attributes()
+			if v, ok := attributes.Get(elasticattr.ErrorException); ok && v.Type() == pcommon.ValueTypeSlice {
+				exceptions := v.Slice()
+				for k := 0; k < exceptions.Len(); k++ {
+					if exceptions.At(k).Type() != pcommon.ValueTypeMap {
+						continue
+					}
+					if st, ok := exceptions.At(k).Map().Get(elasticattr.ErrorExceptionStacktrace); ok && st.Type() == pcommon.ValueTypeSlice {
+						e.mapFrames(ctx, svc, st.Slice())
+					}
+				}
+			}
+			if v, ok := attributes.Get(elasticattr.ErrorLogStackTrace); ok && v.Type() == pcommon.ValueTypeSlice {
+				e.mapFrames(ctx, svc, v.Slice())
+			}
+			e.mapExceptionStacktrace(ctx, svc, attributes)
+		}
+	}
+}
+
+// mapFrames maps a stack trace of frame maps, innermost frame first. The
+// name found at a location in a source map is that of the function being
+// called, so it becomes the function of the next inner frame.
+func (e *SourcemapEnricher) mapFrames(ctx context.Context, svc rumService, frames pcommon.Slice) {
+	function := anonymousFunction
+	for i := frames.Len() - 1; i >= 0; i-- {
+		if frames.At(i).Type() != pcommon.ValueTypeMap {
+			continue
+		}
+		if called, ok := e.mapFrame(ctx, svc, frames.At(i).Map(), function); ok {
+			function = called
+		}
+	}
+}
+
+// mapFrame rewrites a frame to its original source location, keeping the
+// minified location in the original.* attributes. It returns the name of the
+// function called from the frame.
+func (e *SourcemapEnricher) mapFrame(ctx context.Context, svc rumService, frame pcommon.Map, function string) (string, bool) {
+	absPath, ok := frame.Get(elasticattr.ErrorExceptionStacktraceAbsPath)
+	if !ok || absPath.Str() == "" {
+		return "", false
+	}
+	lineno, ok := frame.Get(elasticattr.ErrorExceptionStacktraceLineNumber)
+	if !ok || lineno.Type() != pcommon.ValueTypeInt {
+		return "", false
+	}
+	colno, ok := frame.Get(elasticattr.ErrorExceptionStacktraceLineColumn)
+	if !ok || colno.Type() != pcommon.ValueTypeInt {
+		return "", false
+	}
+	consumer, err := e.fetcher.Fetch(ctx, svc.name, svc.version, absPath.Str())
+	if err != nil {
+		e.logger.Debug("failed to fetch source map", zap.String("abs_path", absPath.Str()), zap.Error(err))
+		frame.PutBool(elasticattr.StacktraceFrameSourcemapUpdated, false)
+		frame.PutStr(elasticattr.StacktraceFrameSourcemapError, err.Error())
+		return "", false
+	}
+	if consumer == nil {
+		return "", false
+	}
+	loc, ok := sourcemap.Map(consumer, int(lineno.Int()), int(colno.Int()))
+	if !ok {
+		return "", false
+	}
+
+	for from, to := range map[string]string{
+		elasticattr.ErrorExceptionStacktraceAbsPath:    elasticattr.StacktraceFrameOriginalAbsPath,
+		elasticattr.ErrorExceptionStacktraceFilename:   elasticattr.StacktraceFrameOriginalFilename,
+		elasticattr.ErrorExceptionStacktraceClassname:  elasticattr.StacktraceFrameOriginalClassname,
+		elasticattr.ErrorExceptionStacktraceFunction:   elasticattr.StacktraceFrameOriginalFunction,
+		elasticattr.ErrorExceptionStacktraceLineNumber: elasticattr.StacktraceFrameOriginalLineNumber,
+		elasticattr.ErrorExceptionStacktraceLineColumn: elasticattr.StacktraceFrameOriginalLineColumn,
+	} {
+		if v, ok := frame.Get(from); ok {
+			v.CopyTo(frame.PutEmpty(to))
+		}
+	}
+	if loc.Filename != "" {
+		frame.PutStr(elasticattr.ErrorExceptionStacktraceAbsPath, loc.Filename)
+		frame.PutStr(elasticattr.ErrorExceptionStacktraceFilename, loc.Filename)
+	}
+	frame.PutStr(elasticattr.ErrorExceptionStacktraceFunction, function)
+	frame.PutInt(elasticattr.ErrorExceptionStacktraceLineNumber, int64(loc.Line))
+	frame.PutInt(elasticattr.ErrorExceptionStacktraceLineColumn, int64(loc.Column))
+	frame.Remove(elasticattr.ErrorExceptionStacktraceLineContext)
+	frame.Remove(elasticattr.ErrorExceptionStacktraceContextPre)
+	frame.Remove(elasticattr.ErrorExceptionStacktraceContextPost)
+	if loc.ContextLine != "" {
+		frame.PutStr(elasticattr.ErrorExceptionStacktraceLineContext, loc.ContextLine)
+	}
+	putStrSlice(frame, elasticattr.ErrorExceptionStacktraceContextPre, loc.PreContext)
+	putStrSlice(frame, elasticattr.ErrorExceptionStacktraceContextPost, loc.PostContext)
+	frame.PutBool(elasticattr.StacktraceFrameSourcemapUpdated, true)
+	return loc.Function, true
+}
+
+// mapExceptionStacktrace rewrites the frames of the exception.stacktrace
+// attribute, a stack trace string as formatted by the browser.
+func (e *SourcemapEnricher) mapExceptionStacktrace(ctx context.Context, svc rumService, attributes pcommon.Map) {
+	ec := getErrorEventContext(attributes)
+	if ec.exceptionStacktrace == "" {
+		return
+	}
+	lines := strings.Split(ec.exceptionStacktrace, "\n")
+	updated := false
+	function := anonymousFunction
+	for i := len(lines) - 1; i >= 0; i-- {
+		frame, ok := parseStackFrame(lines[i])
+		if !ok {
+			continue
+		}
+		consumer, err := e.fetcher.Fetch(ctx, svc.name, svc.version, frame.file)
+		if err != nil {
+			e.logger.Debug("failed to fetch source map", zap.String("abs_path", frame.file), zap.Error(err))
+			continue
+		}
+		if consumer == nil {
+			continue
+		}
+		loc, ok := sourcemap.Map(consumer, frame.line, frame.column)
+		if !ok {
+			continue
+		}
+		if loc.Filename != "" {
+			frame.file = loc.Filename
+		}
+		frame.function = function
+		frame.line = loc.Line
+		frame.column = loc.Column
+		lines[i] = frame.String()
+		function = loc.Function
+		updated = true
+	}
+	if updated {
+		attributes.PutStr(string(semconv.ExceptionStacktraceKey), strings.Join(lines, "\n"))
+	}
+}
+
+// stackFrame is a frame parsed from a stack trace string.
+type stackFrame struct {
+	v8       bool
+	indent   string
+	function string
+	file     string
+	line     int
+	column   int
+}
+
+func parseStackFrame(s string) (stackFrame, bool) {
+	var frame stackFrame
+	var line, column string
+	if m := v8StackFrameRegexp.FindStringSubmatch(s); m != nil && (m[2] == "") == (m[6] == "") {
+		frame = stackFrame{v8: true, indent: m[1], function: m[2], file: m[3]}
+		line, column = m[4], m[5]
+	} else if m := geckoStackFrameRegexp.FindStringSubmatch(s); m != nil {
+		frame = stackFrame{indent: m[1], function: m[2], file: m[3]}
+		line, column = m[4], m[5]
+	} else {
+		return stackFrame{}, false
+	}
+	var err error
+	if frame.line, err = strconv.Atoi(line); err != nil {
+		return stackFrame{}, false
+	}
+	if frame.column, err = strconv.Atoi(column); err != nil {
+		return stackFrame{}, false
+	}
+	return frame, true
+}
+
+func (f stackFrame) String() string {
+	location := f.file + ":" + strconv.Itoa(f.line) + ":" + strconv.Itoa(f.column)
+	if !f.v8 {
+		return f.indent + f.function + "@" + location
+	}
+	if f.function == "" {
+		return f.indent + location
+	}
+	return f.indent + f.function + " (" + location + ")"
+}
+
+func putStrSlice(m pcommon.Map, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	s := m.PutEmptySlice(key)
+	s.EnsureCapacity(len(values))
+	for _, v := range values {
+		s.AppendEmpty().SetStr(v)
+	}
+}
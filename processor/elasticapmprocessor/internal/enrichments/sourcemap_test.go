@@ -0,0 +1,218 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package enrichments
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+)
+
+const testBundle = "http://localhost:8000/js/bundle.min.js"
+
+func newTestSourcemapEnricher(t *testing.T) *SourcemapEnricher {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "bundle.min.js.map"))
+	require.NoError(t, err)
+	store := sourcemap.NewDirectoryStore(t.TempDir())
+	require.NoError(t, store.Put(context.Background(), sourcemap.NewKey("frontend", "1.0.0", testBundle), data))
+	return NewSourcemapEnricher(sourcemap.NewFetcher(store, 10, time.Minute), zap.NewNop())
+}
+
+func setRUMResource(resource pcommon.Resource) {
+	resource.Attributes().PutStr("agent.name", "rum-js")
+	resource.Attributes().PutStr("service.name", "frontend")
+	resource.Attributes().PutStr("service.version", "1.0.0")
+}
+
+// putMinifiedFrames puts the frames of an error thrown in the bundle,
+// innermost first.
+func putMinifiedFrames(s pcommon.Slice) {
+	s.FromRaw([]any{
+		map[string]any{"abs_path": testBundle + "?v=1", "filename": "js/bundle.min.js", "function": "a", "line.number": int64(1), "line.column": int64(15)},
+		map[string]any{"abs_path": testBundle, "filename": "js/bundle.min.js", "line.number": int64(1), "line.column": int64(34)},
+		map[string]any{"abs_path": "http://localhost:8000/js/vendor.min.js", "line.number": int64(1), "line.column": int64(1)},
+	})
+}
+
+var (
+	minifiedStacktrace = "Error: x\n" +
+		"    at a (" + testBundle + "?v=1:1:15)\n" +
+		"    at " + testBundle + ":1:34"
+	mappedStacktrace = "Error: x\n" +
+		"    at greet (webpack:///./src/app.js:2:3)\n" +
+		"    at <anonymous> (webpack:///./src/app.js:4:1)"
+
+	mappedFrames = []any{
+		map[string]any{
+			"abs_path":          "webpack:///./src/app.js",
+			"filename":          "webpack:///./src/app.js",
+			"function":          "greet",
+			"line.number":       int64(2),
+			"line.column":       int64(3),
+			"line.context":      "  throw new Error(name);",
+			"context.pre":       []any{"function greet(name) {"},
+			"context.post":      []any{"}", `greet("x");`, ""},
+			"original.abs_path": testBundle + "?v=1",
+			"original.filename": "js/bundle.min.js",
+			"original.function": "a",
+			"original.lineno":   int64(1),
+			"original.colno":    int64(15),
+			"sourcemap.updated": true,
+		},
+		map[string]any{
+			"abs_path":          "webpack:///./src/app.js",
+			"filename":          "webpack:///./src/app.js",
+			"function":          "<anonymous>",
+			"line.number":       int64(4),
+			"line.column":       int64(1),
+			"line.context":      `greet("x");`,
+			"context.pre":       []any{"function greet(name) {", "  throw new Error(name);", "}"},
+			"context.post":      []any{""},
+			"original.abs_path": testBundle,
+			"original.filename": "js/bundle.min.js",
+			"original.lineno":   int64(1),
+			"original.colno":    int64(34),
+			"sourcemap.updated": true,
+		},
+		// No source map was uploaded for the vendor bundle.
+		map[string]any{"abs_path": "http://localhost:8000/js/vendor.min.js", "line.number": int64(1), "line.column": int64(1)},
+	}
+)
+
+func TestSourcemapEnricherLogs(t *testing.T) {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	setRUMResource(rl.Resource())
+	attrs := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes()
+	exception := attrs.PutEmptySlice("error.exception").AppendEmpty().SetEmptyMap()
+	putMinifiedFrames(exception.PutEmptySlice("stacktrace"))
+	putMinifiedFrames(attrs.PutEmptySlice("error.log.stacktrace"))
+	attrs.PutStr("exception.stacktrace", minifiedStacktrace)
+
+	newTestSourcemapEnricher(t).EnrichResourceLogs(context.Background(), rl)
+
+	st, _ := exception.Get("stacktrace")
+	assert.Equal(t, mappedFrames, st.Slice().AsRaw())
+	st, _ = attrs.Get("error.log.stacktrace")
+	assert.Equal(t, mappedFrames, st.Slice().AsRaw())
+	st, _ = attrs.Get("exception.stacktrace")
+	assert.Equal(t, mappedStacktrace, st.Str())
+}
+
+func TestSourcemapEnricherSpans(t *testing.T) {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	setRUMResource(rs.Resource())
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	putMinifiedFrames(span.Attributes().PutEmptySlice("span.stacktrace"))
+	event := span.Events().AppendEmpty()
+	event.SetName("exception")
+	event.Attributes().PutStr("exception.stacktrace", minifiedStacktrace)
+
+	newTestSourcemapEnricher(t).EnrichResourceSpans(context.Background(), rs)
+
+	st, _ := span.Attributes().Get("span.stacktrace")
+	assert.Equal(t, mappedFrames, st.Slice().AsRaw())
+	st, _ = event.Attributes().Get("exception.stacktrace")
+	assert.Equal(t, mappedStacktrace, st.Str())
+}
+
+func TestSourcemapEnricherSkipped(t *testing.T) {
+	for name, resourceAttrs := range map[string]map[string]any{
+		"not rum":         {"agent.name": "nodejs", "service.name": "frontend", "service.version": "1.0.0"},
+		"no version":      {"agent.name": "rum-js", "service.name": "frontend"},
+		"unknown version": {"agent.name": "rum-js", "service.name": "frontend", "service.version": "2.0.0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			logs := plog.NewLogs()
+			rl := logs.ResourceLogs().AppendEmpty()
+			require.NoError(t, rl.Resource().Attributes().FromRaw(resourceAttrs))
+			attrs := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes()
+			putMinifiedFrames(attrs.PutEmptySlice("error.log.stacktrace"))
+			attrs.PutStr("exception.stacktrace", minifiedStacktrace)
+			expected := plog.NewLogs()
+			logs.CopyTo(expected)
+
+			newTestSourcemapEnricher(t).EnrichResourceLogs(context.Background(), rl)
+			assert.Equal(t, expected, logs)
+		})
+	}
+}
+
+// failingStore is a sourcemap.Store whose reads fail.
+type failingStore struct {
+	sourcemap.Store
+}
+
+func (failingStore) Get(context.Context, sourcemap.Key) ([]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestSourcemapEnricherFetchError(t *testing.T) {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("telemetry.sdk.language", "webjs")
+	rs.Resource().Attributes().PutStr("service.name", "frontend")
+	rs.Resource().Attributes().PutStr("service.version", "1.0.0")
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	frames := span.Attributes().PutEmptySlice("span.stacktrace")
+	frames.AppendEmpty().SetEmptyMap().FromRaw(map[string]any{"abs_path": testBundle, "line.number": int64(1), "line.column": int64(15)})
+
+	enricher := NewSourcemapEnricher(sourcemap.NewFetcher(failingStore{}, 10, time.Minute), zap.NewNop())
+	enricher.EnrichResourceSpans(context.Background(), rs)
+
+	assert.Equal(t, map[string]any{
+		"abs_path":          testBundle,
+		"line.number":       int64(1),
+		"line.column":       int64(15),
+		"sourcemap.updated": false,
+		"sourcemap.error":   "failed to fetch source map: unavailable",
+	}, frames.At(0).Map().AsRaw())
+}
+
+func TestParseStackFrame(t *testing.T) {
+	for input, expected := range map[string]stackFrame{
+		"    at a (https://example.com:8080/b.js:1:2)":  {v8: true, indent: "    at ", function: "a", file: "https://example.com:8080/b.js", line: 1, column: 2},
+		"    at new Foo (https://example.com/b.js:3:4)": {v8: true, indent: "    at ", function: "new Foo", file: "https://example.com/b.js", line: 3, column: 4},
+		"    at https://example.com/b.js:5:6":           {v8: true, indent: "    at ", file: "https://example.com/b.js", line: 5, column: 6},
+		"a@https://example.com/b.js:7:8":                {function: "a", file: "https://example.com/b.js", line: 7, column: 8},
+		"@https://example.com/b.js:9:10":                {file: "https://example.com/b.js", line: 9, column: 10},
+	} {
+		frame, ok := parseStackFrame(input)
+		require.True(t, ok, input)
+		assert.Equal(t, expected, frame, input)
+		assert.Equal(t, input, frame.String())
+	}
+	for _, input := range []string{"Error: boom", "    at a (https://example.com/b.js:1:2", "    at native"} {
+		_, ok := parseStackFrame(input)
+		assert.False(t, ok, input)
+	}
+}
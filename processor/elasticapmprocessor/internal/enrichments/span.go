@@ -119,9 +119,12 @@ type spanEnrichmentContext struct {
 
 	spanStatusCode ptrace.StatusCode
 
+	representativeCount float64
+
 	// TODO (lahsivjar): Refactor span enrichment to better utilize isTransaction
 	isTransaction            bool
 	isMessaging              bool
+	isConsumer               bool
 	isRPC                    bool
 	isHTTP                   bool
 	isDB                     bool
@@ -151,6 +154,7 @@ func (s *spanEnrichmentContext) Enrich(
 
 func (s *spanEnrichmentContext) capture(span ptrace.Span) {
 	s.spanStatusCode = span.Status().Code()
+	s.isConsumer = span.Kind() == ptrace.SpanKindConsumer
 
 	span.Attributes().Range(func(k string, v pcommon.Value) bool {
 		switch k {
@@ -293,6 +297,9 @@ func (s *spanEnrichmentContext) capture(span ptrace.Span) {
 		}
 		return true
 	})
+	if s.messagingDestinationName == "" {
+		s.messagingDestinationName = getLinksDestinationName(span.Links())
+	}
 }
 
 func (s *spanEnrichmentContext) enrichSpanEvents(span ptrace.Span, cfg config.SpanEventConfig) {
@@ -310,6 +317,9 @@ func (s *spanEnrichmentContext) enrich(span ptrace.Span, cfg config.Config) {
 	// with additional attributes that are specific to the outgoing call or producer span.
 	isExitRootSpan := s.isTransaction && (span.Kind() == ptrace.SpanKindClient || span.Kind() == ptrace.SpanKindProducer)
 
+	s.representativeCount = getRepresentativeCount(span.TraceState().AsRaw()) *
+		getSamplingAdjustment(span.Attributes(), cfg.Sampling)
+
 	if s.isTransaction {
 		s.enrichTransaction(span, cfg.Transaction)
 	}
@@ -350,8 +360,7 @@ func (s *spanEnrichmentContext) enrichTransaction(
 		attribute.PutStr(span.Attributes(), elasticattr.ProcessorEvent, "transaction")
 	}
 	if cfg.RepresentativeCount.Enabled {
-		repCount := getRepresentativeCount(span.TraceState().AsRaw())
-		attribute.PutDouble(span.Attributes(), elasticattr.TransactionRepresentativeCount, repCount)
+		attribute.PutDouble(span.Attributes(), elasticattr.TransactionRepresentativeCount, s.representativeCount)
 	}
 	if cfg.DurationUs.Enabled {
 		attribute.PutInt(span.Attributes(), elasticattr.TransactionDurationUs, getDurationUs(span))
@@ -374,6 +383,12 @@ func (s *spanEnrichmentContext) enrichTransaction(
 	if cfg.MessageQueueName.Enabled {
 		s.setMessageQueue(span)
 	}
+	if cfg.SpanLinks.Enabled {
+		s.setSpanLinks(span)
+	}
+	if cfg.MessageAge.Enabled {
+		s.setMessageAge(span, cfg.MessageAge.TimestampAttribute)
+	}
 	if cfg.RemoveMessaging.Enabled {
 		s.removeMessagingAttrs(span)
 	}
@@ -391,8 +406,7 @@ func (s *spanEnrichmentContext) enrichSpan(
 		attribute.PutInt(span.Attributes(), elasticattr.TimestampUs, attribute.ToTimestampUS(span.StartTimestamp()))
 	}
 	if cfg.RepresentativeCount.Enabled {
-		repCount := getRepresentativeCount(span.TraceState().AsRaw())
-		attribute.PutDouble(span.Attributes(), elasticattr.SpanRepresentativeCount, repCount)
+		attribute.PutDouble(span.Attributes(), elasticattr.SpanRepresentativeCount, s.representativeCount)
 	}
 	if cfg.Action.Enabled {
 		s.setSpanAction(span)
@@ -424,6 +438,9 @@ func (s *spanEnrichmentContext) enrichSpan(
 	if cfg.MessageQueueName.Enabled {
 		s.setMessageQueue(span)
 	}
+	if cfg.SpanLinks.Enabled {
+		s.setSpanLinks(span)
+	}
 	if cfg.RemoveMessaging.Enabled {
 		s.removeMessagingAttrs(span)
 	}
@@ -478,7 +495,9 @@ func (s *spanEnrichmentContext) getTxnType() string {
 	switch {
 	case s.typeValue != "":
 		txnType = s.typeValue
-	case s.isMessaging:
+	case s.isMessaging, s.isConsumer:
+		// Consumer spans without messaging attributes, such as batch
+		// consumers linking to the producer spans, are messaging too.
 		txnType = "messaging"
 	case s.isRPC, s.isHTTP:
 		txnType = "request"
@@ -528,7 +547,7 @@ func (s *spanEnrichmentContext) setEventOutcome(span ptrace.Span) {
 
 	// default to success outcome
 	outcome := outcomeSuccess
-	successCount := getRepresentativeCount(span.TraceState().AsRaw())
+	successCount := s.representativeCount
 	switch {
 	case s.spanStatusCode == ptrace.StatusCodeError:
 		outcome = outcomeFailure
@@ -563,6 +582,59 @@ func (s *spanEnrichmentContext) setMessageQueue(span ptrace.Span) {
 	}
 }
 
+// setSpanLinks sets span.links from the span links, as apm-data does. Links
+// to child spans are excluded, they are mapped to child.id by
+// setInferredSpans when enabled.
+func (s *spanEnrichmentContext) setSpanLinks(span ptrace.Span) {
+	if _, exists := span.Attributes().Get(elasticattr.SpanLinks); exists {
+		return
+	}
+	var spanLinks pcommon.Slice
+	links := span.Links()
+	for i := 0; i < links.Len(); i++ {
+		link := links.At(i)
+		if isChildLink(link) {
+			continue
+		}
+		if spanLinks == (pcommon.Slice{}) {
+			spanLinks = span.Attributes().PutEmptySlice(elasticattr.SpanLinks)
+		}
+		m := spanLinks.AppendEmpty().SetEmptyMap()
+		attribute.PutNonEmptyStr(m, elasticattr.SpanLinkTraceID, link.TraceID().String())
+		attribute.PutNonEmptyStr(m, elasticattr.SpanLinkSpanID, link.SpanID().String())
+	}
+}
+
+// setMessageAge sets message.age.ms of messaging consumer transactions to the
+// age of the oldest linked message when it was received. The message
+// timestamps are read from the timestampAttr link attribute.
+func (s *spanEnrichmentContext) setMessageAge(span ptrace.Span, timestampAttr string) {
+	if !s.isMessaging && !s.isConsumer {
+		return
+	}
+	if _, exists := span.Attributes().Get(elasticattr.MessageAgeMs); exists {
+		return
+	}
+	var oldest float64
+	links := span.Links()
+	for i := 0; i < links.Len(); i++ {
+		v, ok := links.At(i).Attributes().Get(timestampAttr)
+		if !ok {
+			continue
+		}
+		if ts, ok := getFloat(v); ok && ts > 0 && (oldest == 0 || ts < oldest) {
+			oldest = ts
+		}
+	}
+	if oldest == 0 {
+		return
+	}
+	received := float64(span.StartTimestamp().AsTime().UnixMilli())
+	if age := received - oldest; age >= 0 {
+		span.Attributes().PutInt(elasticattr.MessageAgeMs, int64(age))
+	}
+}
+
 // removeMessagingAttrs removes messaging semconv attributes from the span here during processing
 // to avoid having to either map or remove during export time.
 //
@@ -1032,6 +1104,69 @@ func getRepresentativeCount(tracestate string) float64 {
 	return math.Pow(2, float64(p))
 }
 
+// getSamplingAdjustment returns the factor by which a sampling stage after
+// head sampling, such as tail-based sampling, adjusts the representative
+// count, as recorded in the span attributes configured by cfg. It returns 1
+// if no valid adjusted count or probability is recorded.
+func getSamplingAdjustment(attrs pcommon.Map, cfg config.SamplingConfig) float64 {
+	if cfg.AdjustedCountAttribute != "" {
+		if v, ok := attrs.Get(cfg.AdjustedCountAttribute); ok {
+			if count, ok := getFloat(v); ok && count >= 0 && !math.IsInf(count, 0) {
+				return count
+			}
+		}
+	}
+	if cfg.ProbabilityAttribute != "" {
+		if v, ok := attrs.Get(cfg.ProbabilityAttribute); ok {
+			if p, ok := getFloat(v); ok && p > 0 && p <= 1 {
+				return 1 / p
+			}
+		}
+	}
+	return 1
+}
+
+// getFloat returns the numeric value of a double, int or numeric string
+// attribute.
+func getFloat(v pcommon.Value) (float64, bool) {
+	switch v.Type() {
+	case pcommon.ValueTypeDouble:
+		return v.Double(), !math.IsNaN(v.Double())
+	case pcommon.ValueTypeInt:
+		return float64(v.Int()), true
+	case pcommon.ValueTypeStr:
+		f, err := strconv.ParseFloat(v.Str(), 64)
+		return f, err == nil && !math.IsNaN(f)
+	}
+	return 0, false
+}
+
+// getLinksDestinationName returns the messaging destination name set on all
+// the links of a batch consumer span, or an empty string if the links have
+// no or different destination names.
+func getLinksDestinationName(links ptrace.SpanLinkSlice) string {
+	var name string
+	for i := 0; i < links.Len(); i++ {
+		v, ok := links.At(i).Attributes().Get(string(semconv25.MessagingDestinationNameKey))
+		if !ok || (name != "" && v.Str() != name) {
+			return ""
+		}
+		name = v.Str()
+	}
+	return name
+}
+
+// isChildLink returns true if the span link refers to a child span, as
+// marked by Elastic inferred spans.
+func isChildLink(link ptrace.SpanLink) bool {
+	for _, k := range []string{"is_child", "elastic.is_child"} {
+		if v, ok := link.Attributes().Get(k); ok {
+			return v.Bool()
+		}
+	}
+	return false
+}
+
 func getDurationUs(span ptrace.Span) int64 {
 	return int64(span.EndTimestamp()-span.StartTimestamp()) / 1000
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/ptracetest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/ua-parser/uap-go/uaparser"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -603,6 +604,7 @@ func TestElasticTransactionEnrich(t *testing.T) {
 				elasticattr.TransactionResult:              "Success",
 				elasticattr.TransactionType:                "unknown",
 				elasticattr.ChildIDs:                       []any{"0300000000000000", "0400000000000000"},
+				elasticattr.SpanLinks:                      []any{map[string]any{"span.id": "0200000000000000"}},
 			},
 			expectedSpanLinks: func() *ptrace.SpanLinkSlice {
 				spanLinks := ptrace.NewSpanLinkSlice()
@@ -649,6 +651,7 @@ func TestElasticTransactionEnrich(t *testing.T) {
 				elasticattr.TransactionResult:              "Success",
 				elasticattr.TransactionType:                "unknown",
 				elasticattr.ChildIDs:                       []any{"existing-child-id-1", "existing-child-id-2"},
+				elasticattr.SpanLinks:                      []any{map[string]any{"span.id": "0200000000000000"}},
 			},
 			expectedSpanLinks: func() *ptrace.SpanLinkSlice {
 				spanLinks := ptrace.NewSpanLinkSlice()
@@ -2240,6 +2243,7 @@ func TestElasticSpanEnrich(t *testing.T) {
 				elasticattr.EventOutcome:            outcomeSuccess,
 				elasticattr.SuccessCount:            int64(1),
 				elasticattr.ChildIDs:                []any{"0300000000000000", "0400000000000000"},
+				elasticattr.SpanLinks:               []any{map[string]any{"span.id": "0200000000000000"}},
 			},
 			expectedSpanLinks: func() *ptrace.SpanLinkSlice {
 				spanLinks := ptrace.NewSpanLinkSlice()
@@ -2281,6 +2285,7 @@ func TestElasticSpanEnrich(t *testing.T) {
 				elasticattr.EventOutcome:            outcomeSuccess,
 				elasticattr.SuccessCount:            int64(1),
 				elasticattr.ChildIDs:                []any{"existing-child-id-1", "existing-child-id-2"},
+				elasticattr.SpanLinks:               []any{map[string]any{"span.id": "0200000000000000"}},
 			},
 			expectedSpanLinks: func() *ptrace.SpanLinkSlice {
 				spanLinks := ptrace.NewSpanLinkSlice()
@@ -2968,3 +2973,187 @@ func TestIsElasticTransaction(t *testing.T) {
 		})
 	}
 }
+
+func TestRepresentativeCountWithSamplingAdjustment(t *testing.T) {
+	samplingCfg := config.SamplingConfig{
+		AdjustedCountAttribute: "sampling.adjusted_count",
+		ProbabilityAttribute:   "sampling.probability",
+	}
+	for _, tc := range []struct {
+		name       string
+		tracestate string
+		attrs      map[string]any
+		cfg        config.SamplingConfig
+		expected   float64
+	}{
+		{
+			name:     "no_attributes",
+			cfg:      samplingCfg,
+			expected: 1,
+		},
+		{
+			name:       "not_configured",
+			tracestate: "ot=th:8",
+			attrs:      map[string]any{"sampling.adjusted_count": 10},
+			expected:   2,
+		},
+		{
+			name:       "adjusted_count",
+			tracestate: "ot=th:8",
+			attrs:      map[string]any{"sampling.adjusted_count": 10},
+			cfg:        samplingCfg,
+			expected:   20,
+		},
+		{
+			name:       "adjusted_count_string",
+			tracestate: "ot=p:2",
+			attrs:      map[string]any{"sampling.adjusted_count": "2.5"},
+			cfg:        samplingCfg,
+			expected:   10,
+		},
+		{
+			name:     "probability",
+			attrs:    map[string]any{"sampling.probability": 0.25},
+			cfg:      samplingCfg,
+			expected: 4,
+		},
+		{
+			name:       "adjusted_count_takes_precedence",
+			tracestate: "ot=th:c",
+			attrs:      map[string]any{"sampling.adjusted_count": 3, "sampling.probability": 0.5},
+			cfg:        samplingCfg,
+			expected:   12,
+		},
+		{
+			name:     "invalid_values",
+			attrs:    map[string]any{"sampling.adjusted_count": -1, "sampling.probability": 2.0},
+			cfg:      samplingCfg,
+			expected: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			span := ptrace.NewSpan()
+			span.SetSpanID([8]byte{1})
+			span.TraceState().FromRaw(tc.tracestate)
+			require.NoError(t, span.Attributes().FromRaw(tc.attrs))
+
+			cfg := config.Enabled()
+			cfg.Sampling = tc.cfg
+			EnrichSpan(span, cfg, uaparser.NewFromSaved(), false)
+
+			repCount, ok := span.Attributes().Get(elasticattr.TransactionRepresentativeCount)
+			require.True(t, ok)
+			assert.Equal(t, tc.expected, repCount.Double())
+			successCount, ok := span.Attributes().Get(elasticattr.SuccessCount)
+			require.True(t, ok)
+			assert.Equal(t, int64(tc.expected), successCount.Int())
+		})
+	}
+}
+
+func TestMessagingBatchConsumerEnrich(t *testing.T) {
+	now := time.Unix(3600, 0)
+	type link struct {
+		queue string
+		sent  time.Time
+	}
+	for _, tc := range []struct {
+		name          string
+		links         []link
+		expectedQueue any
+		expectedAge   any
+	}{
+		{
+			name: "same_queue",
+			links: []link{
+				{queue: "orders", sent: now.Add(-250 * time.Millisecond)},
+				{queue: "orders", sent: now.Add(-time.Second)},
+			},
+			expectedQueue: "orders",
+			expectedAge:   int64(1000),
+		},
+		{
+			name: "different_queues",
+			links: []link{
+				{queue: "orders", sent: now.Add(-250 * time.Millisecond)},
+				{queue: "payments", sent: now.Add(-time.Second)},
+			},
+			expectedAge: int64(1000),
+		},
+		{
+			name: "without_timestamps",
+			links: []link{
+				{queue: "orders"},
+				{queue: "orders"},
+			},
+			expectedQueue: "orders",
+		},
+		{
+			name: "some_timestamps",
+			links: []link{
+				{queue: "orders"},
+				{queue: "orders", sent: now.Add(-250 * time.Millisecond)},
+			},
+			expectedQueue: "orders",
+			expectedAge:   int64(250),
+		},
+		{
+			name: "no_links",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			span := ptrace.NewSpan()
+			span.SetKind(ptrace.SpanKindConsumer)
+			span.SetSpanID([8]byte{1})
+			span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+			span.SetEndTimestamp(pcommon.NewTimestampFromTime(now.Add(time.Second)))
+			var expectedLinks []any
+			for i, l := range tc.links {
+				link := span.Links().AppendEmpty()
+				link.SetTraceID([16]byte{byte(i + 1)})
+				link.SetSpanID([8]byte{byte(i + 2)})
+				link.Attributes().PutStr(string(semconv25.MessagingDestinationNameKey), l.queue)
+				if !l.sent.IsZero() {
+					link.Attributes().PutInt(config.DefaultMessageTimestampAttribute, l.sent.UnixMilli())
+				}
+				expectedLinks = append(expectedLinks, map[string]any{
+					"trace.id": link.TraceID().String(),
+					"span.id":  link.SpanID().String(),
+				})
+			}
+
+			cfg := config.Enabled()
+			cfg.Transaction.MessageAge.Enabled = true
+			EnrichSpan(span, cfg, uaparser.NewFromSaved(), false)
+
+			attrs := span.Attributes().AsRaw()
+			assert.Equal(t, "messaging", attrs[elasticattr.TransactionType])
+			assert.Equal(t, tc.expectedQueue, attrs[elasticattr.TransactionMessageQueueName])
+			assert.Equal(t, tc.expectedAge, attrs[elasticattr.MessageAgeMs])
+			if expectedLinks == nil {
+				assert.NotContains(t, attrs, elasticattr.SpanLinks)
+			} else {
+				assert.Equal(t, expectedLinks, attrs[elasticattr.SpanLinks])
+			}
+		})
+	}
+}
+
+func TestMessageAgeTimestampAttribute(t *testing.T) {
+	now := time.Unix(3600, 0)
+	span := ptrace.NewSpan()
+	span.SetKind(ptrace.SpanKindConsumer)
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	link := span.Links().AppendEmpty()
+	link.Attributes().PutInt(config.DefaultMessageTimestampAttribute, now.Add(-time.Second).UnixMilli())
+	link.Attributes().PutInt("kafka.record.timestamp", now.Add(-2*time.Second).UnixMilli())
+
+	cfg := config.Enabled()
+	cfg.Transaction.MessageAge.Enabled = true
+	cfg.Transaction.MessageAge.TimestampAttribute = "kafka.record.timestamp"
+	EnrichSpan(span, cfg, uaparser.NewFromSaved(), false)
+
+	v, ok := span.Attributes().Get(elasticattr.MessageAgeMs)
+	require.True(t, ok)
+	assert.Equal(t, int64(2000), v.Int())
+}
@@ -38,11 +38,12 @@ type ecsTraceEnricher struct {
 	enricher               *Enricher
 	hostIPEnabled          bool
 	sanitizeExistingLabels bool
+	router                 *routing.Router
 }
 
 func (e *ecsTraceEnricher) enrichResourceSpans(ctx context.Context, rs ptrace.ResourceSpans) {
 	// Traces signal never need to be routed to service-specific datasets
-	resCtx := ecsPreProcessResource(ctx, rs.Resource(), routing.DataStreamTypeTraces, false, e.hostIPEnabled, e.sanitizeExistingLabels)
+	resCtx := ecsPreProcessResource(ctx, rs.Resource(), rs, routing.DataStreamTypeTraces, false, e.hostIPEnabled, e.sanitizeExistingLabels, e.router)
 	routeErrorSpanEvents(rs, resCtx.DataStreamNamespace)
 	e.enricher.EnrichResourceSpans(rs)
 }
@@ -85,7 +86,7 @@ func (e *APMTraceEnricher) EnrichResourceSpans(ctx context.Context, rs ptrace.Re
 }
 
 // NewAPMTraceEnricher creates a TraceEnricher for elastic APM intake events.
-func NewAPMTraceEnricher(baseCfg config.Config, hostIPEnabled bool) *APMTraceEnricher {
+func NewAPMTraceEnricher(baseCfg config.Config, hostIPEnabled bool, router *routing.Router) *APMTraceEnricher {
 	cfg := ecsAPMConfig(baseCfg)
 	// The intake receiver already sets transaction.result; skip re-deriving it
 	// to avoid overwriting the intake-supplied value or avoid deriving a value
@@ -96,6 +97,7 @@ func NewAPMTraceEnricher(baseCfg config.Config, hostIPEnabled bool) *APMTraceEnr
 			enricher:               NewEnricher(cfg, false /* remapToECSLabels */),
 			hostIPEnabled:          hostIPEnabled,
 			sanitizeExistingLabels: true,
+			router:                 router,
 		},
 	}
 }
@@ -113,13 +115,14 @@ func (e *OTelTraceEnricher) EnrichResourceSpans(ctx context.Context, rs ptrace.R
 }
 
 // NewOTelTraceEnricher creates a TraceEnricher for elastic OTel events.
-func NewOTelTraceEnricher(baseCfg config.Config, hostIPEnabled bool) *OTelTraceEnricher {
+func NewOTelTraceEnricher(baseCfg config.Config, hostIPEnabled bool, router *routing.Router) *OTelTraceEnricher {
 	cfg := ecsOTelConfig(baseCfg)
 	return &OTelTraceEnricher{
 		ecsTraceEnricher: ecsTraceEnricher{
 			enricher:               NewEnricher(cfg, true /* remapToECSLabels */),
 			hostIPEnabled:          hostIPEnabled,
 			sanitizeExistingLabels: false,
+			router:                 router,
 		},
 	}
 }
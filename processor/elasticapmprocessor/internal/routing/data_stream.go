@@ -88,19 +88,16 @@ func IsErrorEvent(attributes pcommon.Map) bool {
 func EncodeErrorDataStream(attributes pcommon.Map, dataStreamType, namespace string) {
 	attributes.PutStr(elasticattr.DataStreamType, dataStreamType)
 	attributes.PutStr(elasticattr.DataStreamDataset, "apm.error")
-	if isStrKeyMissingOrEmpty(attributes, elasticattr.DataStreamNamespace) {
-		if namespace == "" {
-			namespace = NamespaceDefault
-		}
-		attributes.PutStr(elasticattr.DataStreamNamespace, namespace)
-	}
+	putNamespaceIfMissing(attributes, namespace)
 }
 
 // EncodeDataStreamMetricDataPoint determines if the metric represents an internal metric
 // and sets the appropriate data stream for a metric data point.
 // This implements the routing logic from apm-data's metricsetDataset function.
+// namespace is used as the fallback when no namespace is already set on attributes;
+// if empty, NamespaceDefault is used.
 // Returns true if the metric is routed to an internal data stream, false otherwise.
-func EncodeDataStreamMetricDataPoint(attributes pcommon.Map, metricName string, hasServiceName bool) bool {
+func EncodeDataStreamMetricDataPoint(attributes pcommon.Map, metricName string, hasServiceName bool, namespace string) bool {
 	// Check for special cases: transaction/span context, no service name, or service_summary
 	if hasTransactionSpanContext(attributes) || !hasServiceName || isServiceSummary(attributes) {
 		// Check if there's a metricset interval for interval-based routing
@@ -111,16 +108,16 @@ func EncodeDataStreamMetricDataPoint(attributes pcommon.Map, metricName string,
 				// Fallback to a generic name if metricset.name is missing
 				metricsetName = "metrics"
 			}
-			internalIntervalMetricDataStream(attributes, DataStreamTypeMetrics, metricsetName, interval)
+			internalIntervalMetricDataStream(attributes, DataStreamTypeMetrics, metricsetName, interval, namespace)
 		} else {
-			internalMetricDataStream(attributes, DataStreamTypeMetrics)
+			internalMetricDataStream(attributes, DataStreamTypeMetrics, namespace)
 		}
 		return true
 	}
 
 	// Check if the metric name is recognized as an internal metric using apm-data conventions
 	if modelprocessor.IsInternalMetricName(metricName) && !isOTelRemapped(attributes) {
-		internalMetricDataStream(attributes, DataStreamTypeMetrics)
+		internalMetricDataStream(attributes, DataStreamTypeMetrics, namespace)
 		return true
 	}
 
@@ -176,21 +173,17 @@ func isServiceSummary(attributes pcommon.Map) bool {
 }
 
 // Using default "apm.internal" data stream
-func internalMetricDataStream(attributes pcommon.Map, dataStreamType string) {
+func internalMetricDataStream(attributes pcommon.Map, dataStreamType, namespace string) {
 	attributes.PutStr(elasticattr.DataStreamType, dataStreamType)
 	attributes.PutStr(elasticattr.DataStreamDataset, "apm.internal")
-	if isStrKeyMissingOrEmpty(attributes, elasticattr.DataStreamNamespace) {
-		attributes.PutStr(elasticattr.DataStreamNamespace, NamespaceDefault)
-	}
+	putNamespaceIfMissing(attributes, namespace)
 }
 
 // Data stream formatted as: apm.${metricset.name}.${metricset.interval}
-func internalIntervalMetricDataStream(attributes pcommon.Map, dataStreamType, metricsetName, interval string) {
+func internalIntervalMetricDataStream(attributes pcommon.Map, dataStreamType, metricsetName, interval, namespace string) {
 	attributes.PutStr(elasticattr.DataStreamType, dataStreamType)
 	attributes.PutStr(elasticattr.DataStreamDataset, fmt.Sprintf("apm.%s.%s", metricsetName, interval))
-	if isStrKeyMissingOrEmpty(attributes, elasticattr.DataStreamNamespace) {
-		attributes.PutStr(elasticattr.DataStreamNamespace, NamespaceDefault)
-	}
+	putNamespaceIfMissing(attributes, namespace)
 }
 
 func normalizeServiceName(s string) string {
@@ -222,6 +215,17 @@ func isOTelRemapped(attributes pcommon.Map) bool {
 	return false
 }
 
+// putNamespaceIfMissing sets the namespace on attributes unless already set,
+// using NamespaceDefault if namespace is empty.
+func putNamespaceIfMissing(attributes pcommon.Map, namespace string) {
+	if isStrKeyMissingOrEmpty(attributes, elasticattr.DataStreamNamespace) {
+		if namespace == "" {
+			namespace = NamespaceDefault
+		}
+		attributes.PutStr(elasticattr.DataStreamNamespace, namespace)
+	}
+}
+
 func isStrKeyMissingOrEmpty(attributes pcommon.Map, key string) bool {
 	value, exists := attributes.Get(key)
 	return !exists || (value.Type() == pcommon.ValueTypeStr && value.Str() == "")
@@ -587,7 +587,7 @@ func TestRouteMetricDataPoint(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			attrs := pcommon.NewMap()
 			tt.setupFn(attrs)
-			isInternal := routing.EncodeDataStreamMetricDataPoint(attrs, tt.metricName, tt.hasServiceName)
+			isInternal := routing.EncodeDataStreamMetricDataPoint(attrs, tt.metricName, tt.hasServiceName, "")
 
 			assert.Equal(t, tt.expectedInternal, isInternal, "unexpected internal metric detection")
 
@@ -0,0 +1,245 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package routing // import "github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/routing"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const (
+	resourceAttributePrefix = "resource.attributes."
+	clientMetadataPrefix    = "client.metadata."
+
+	// disallowedDataStreamChars holds the characters that are not allowed
+	// in data stream datasets and namespaces, besides uppercase letters.
+	// The hyphen separates the type, dataset and namespace.
+	disallowedDataStreamChars = "\\/*?\"<>| ,#:-"
+)
+
+// RuleConfig maps the resources matching an OTTL condition to a data stream
+// dataset and namespace. The dataset and namespace are templates in which
+// {resource.attributes.<name>} and {client.metadata.<key>} are replaced with
+// the resource attribute or client metadata value, normalized like service
+// names in "apm.app.<service.name>" datasets.
+type RuleConfig struct {
+	// Condition is an OTTL condition in the resource context. Rules without
+	// a condition match all resources.
+	Condition string `mapstructure:"condition"`
+
+	// Dataset is the template of the data_stream.dataset value.
+	// The default dataset is used if empty.
+	Dataset string `mapstructure:"dataset"`
+
+	// Namespace is the template of the data_stream.namespace value.
+	// The default namespace is used if empty.
+	Namespace string `mapstructure:"namespace"`
+}
+
+// Validate checks the routing rule configuration is valid.
+func (cfg *RuleConfig) Validate() error {
+	if cfg.Dataset == "" && cfg.Namespace == "" {
+		return errors.New("at least one of dataset and namespace must be set")
+	}
+	if _, err := parseTemplate(cfg.Dataset); err != nil {
+		return fmt.Errorf("invalid dataset: %w", err)
+	}
+	if _, err := parseTemplate(cfg.Namespace); err != nil {
+		return fmt.Errorf("invalid namespace: %w", err)
+	}
+	return nil
+}
+
+// SchemaURLItem is the resource container, such as ptrace.ResourceSpans,
+// providing the schema URL to OTTL conditions.
+type SchemaURLItem interface {
+	SchemaUrl() string
+	SetSchemaUrl(string)
+}
+
+// Router selects the data stream of resources using routing rules.
+type Router struct {
+	rules []rule
+}
+
+type rule struct {
+	// condition is nil if the rule matches all resources.
+	condition *ottl.ConditionSequence[*ottlresource.TransformContext]
+	dataset   template
+	namespace template
+}
+
+// NewRouter returns a Router evaluating rules in order, or nil if there are
+// no rules.
+func NewRouter(rules []RuleConfig, set component.TelemetrySettings) (*Router, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	parser, err := ottlresource.NewParser(ottlfuncs.StandardConverters[*ottlresource.TransformContext](), set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ottl parser for resources: %w", err)
+	}
+	router := &Router{rules: make([]rule, len(rules))}
+	for i, cfg := range rules {
+		r := &router.rules[i]
+		if cfg.Condition != "" {
+			parsed, err := parser.ParseCondition(cfg.Condition)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse routing rule %d condition: %w", i, err)
+			}
+			seq := ottlresource.NewConditionSequence(
+				[]*ottl.Condition[*ottlresource.TransformContext]{parsed}, set,
+				ottlresource.WithConditionSequenceErrorMode(ottl.IgnoreError),
+			)
+			r.condition = &seq
+		}
+		if r.dataset, err = parseTemplate(cfg.Dataset); err != nil {
+			return nil, fmt.Errorf("invalid routing rule %d dataset: %w", i, err)
+		}
+		if r.namespace, err = parseTemplate(cfg.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid routing rule %d namespace: %w", i, err)
+		}
+	}
+	return router, nil
+}
+
+// Route returns the dataset and namespace of the first rule matching
+// resource, either of which may be empty. Rules referencing a resource
+// attribute or client metadata value that is not set do not match. It must
+// be called before the resource attributes are translated to ECS.
+func (r *Router) Route(ctx context.Context, resource pcommon.Resource, schemaURLItem SchemaURLItem) (dataset, namespace string, ok bool) {
+	if r == nil {
+		return "", "", false
+	}
+	info := client.FromContext(ctx)
+	for _, rule := range r.rules {
+		if rule.condition != nil {
+			tCtx := ottlresource.NewTransformContextPtr(resource, schemaURLItem)
+			match, _ := rule.condition.Eval(ctx, tCtx)
+			tCtx.Close()
+			if !match {
+				continue
+			}
+		}
+		dataset, ok := rule.dataset.execute(resource.Attributes(), info)
+		if !ok {
+			continue
+		}
+		namespace, ok := rule.namespace.execute(resource.Attributes(), info)
+		if !ok {
+			continue
+		}
+		return dataset, namespace, true
+	}
+	return "", "", false
+}
+
+// template is a parsed dataset or namespace template: literal text
+// alternating with placeholders.
+type template []templatePart
+
+type templatePart struct {
+	literal string
+	// attribute or metadata is set for placeholders.
+	attribute string
+	metadata  string
+}
+
+func parseTemplate(s string) (template, error) {
+	var t template
+	for s != "" {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			start = len(s)
+		}
+		if start > 0 {
+			if err := validateLiteral(s[:start]); err != nil {
+				return nil, err
+			}
+			t = append(t, templatePart{literal: s[:start]})
+		}
+		if start == len(s) {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder in %q", s)
+		}
+		name := s[start+1 : start+end]
+		switch {
+		case strings.HasPrefix(name, resourceAttributePrefix) && len(name) > len(resourceAttributePrefix):
+			t = append(t, templatePart{attribute: strings.TrimPrefix(name, resourceAttributePrefix)})
+		case strings.HasPrefix(name, clientMetadataPrefix) && len(name) > len(clientMetadataPrefix):
+			t = append(t, templatePart{metadata: strings.TrimPrefix(name, clientMetadataPrefix)})
+		default:
+			return nil, fmt.Errorf("unsupported placeholder {%s}, must be {%s<name>} or {%s<key>}",
+				name, resourceAttributePrefix, clientMetadataPrefix)
+		}
+		s = s[start+end+1:]
+	}
+	return t, nil
+}
+
+// validateLiteral checks that the literal text of a template only holds
+// characters allowed in data stream names. Placeholder values are
+// normalized instead, see execute.
+func validateLiteral(s string) error {
+	for _, r := range s {
+		if unicode.IsUpper(r) || strings.ContainsRune(disallowedDataStreamChars, r) {
+			return fmt.Errorf("%q contains %q, which is not allowed in data stream names", s, r)
+		}
+	}
+	return nil
+}
+
+// execute returns the template with its placeholders replaced, or false if
+// a referenced value is not set.
+func (t template) execute(attributes pcommon.Map, info client.Info) (string, bool) {
+	var sb strings.Builder
+	for _, part := range t {
+		var value string
+		switch {
+		case part.attribute != "":
+			if v, ok := attributes.Get(part.attribute); ok {
+				value = v.AsString()
+			}
+		case part.metadata != "":
+			if values := info.Metadata.Get(part.metadata); len(values) > 0 {
+				value = values[0]
+			}
+		default:
+			sb.WriteString(part.literal)
+			continue
+		}
+		if value == "" {
+			return "", false
+		}
+		sb.WriteString(normalizeServiceName(value))
+	}
+	return sb.String(), true
+}
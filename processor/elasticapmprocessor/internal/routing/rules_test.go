@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package routing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/routing"
+)
+
+func TestRouter(t *testing.T) {
+	rules := []routing.RuleConfig{
+		{
+			Condition: `attributes["deployment.environment"] == "production"`,
+			Namespace: "prod",
+		},
+		{
+			Condition: `attributes["team"] != nil`,
+			Dataset:   "apm.team.{resource.attributes.team}",
+			Namespace: "{client.metadata.x-tenant}",
+		},
+		{
+			Dataset: "apm.app.{resource.attributes.service.name}",
+		},
+	}
+	router, err := routing.NewRouter(rules, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		attributes        map[string]any
+		metadata          map[string][]string
+		expectedDataset   string
+		expectedNamespace string
+		expectedOK        bool
+	}{
+		{
+			name:              "condition",
+			attributes:        map[string]any{"deployment.environment": "production", "team": "a"},
+			expectedNamespace: "prod",
+			expectedOK:        true,
+		},
+		{
+			name:              "resource attribute and client metadata",
+			attributes:        map[string]any{"team": "Team-A", "service.name": "svc"},
+			metadata:          map[string][]string{"x-tenant": {"tenant1"}},
+			expectedDataset:   "apm.team.team_a",
+			expectedNamespace: "tenant1",
+			expectedOK:        true,
+		},
+		{
+			name:            "missing client metadata falls through",
+			attributes:      map[string]any{"team": "a", "service.name": "My Service"},
+			expectedDataset: "apm.app.my_service",
+			expectedOK:      true,
+		},
+		{
+			name:       "no match",
+			attributes: map[string]any{"host.name": "h"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := ptrace.NewResourceSpans()
+			require.NoError(t, rs.Resource().Attributes().FromRaw(tt.attributes))
+			ctx := client.NewContext(context.Background(), client.Info{
+				Metadata: client.NewMetadata(tt.metadata),
+			})
+
+			dataset, namespace, ok := router.Route(ctx, rs.Resource(), rs)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedDataset, dataset)
+			assert.Equal(t, tt.expectedNamespace, namespace)
+		})
+	}
+}
+
+func TestRouterNil(t *testing.T) {
+	router, err := routing.NewRouter(nil, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	require.Nil(t, router)
+
+	rs := ptrace.NewResourceSpans()
+	_, _, ok := router.Route(context.Background(), rs.Resource(), rs)
+	assert.False(t, ok)
+}
+
+func TestNewRouterInvalidCondition(t *testing.T) {
+	_, err := routing.NewRouter([]routing.RuleConfig{{
+		Condition: `attributes["a"] ==`,
+		Namespace: "ns",
+	}}, componenttest.NewNopTelemetrySettings())
+	assert.ErrorContains(t, err, "failed to parse routing rule 0 condition")
+}
+
+func TestRuleConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         routing.RuleConfig
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			cfg:  routing.RuleConfig{Dataset: "apm.{resource.attributes.team}", Namespace: "{client.metadata.tenant}"},
+		},
+		{
+			name:        "empty",
+			cfg:         routing.RuleConfig{Condition: "true"},
+			expectedErr: "at least one of dataset and namespace must be set",
+		},
+		{
+			name:        "unclosed placeholder",
+			cfg:         routing.RuleConfig{Dataset: "apm.{resource.attributes.team"},
+			expectedErr: "invalid dataset: unclosed placeholder",
+		},
+		{
+			name:        "unsupported placeholder",
+			cfg:         routing.RuleConfig{Namespace: "{span.name}"},
+			expectedErr: "invalid namespace: unsupported placeholder {span.name}",
+		},
+		{
+			name:        "uppercase namespace",
+			cfg:         routing.RuleConfig{Namespace: "Foo-Bar"},
+			expectedErr: `invalid namespace: "Foo-Bar" contains 'F', which is not allowed in data stream names`,
+		},
+		{
+			name:        "hyphen in dataset literal",
+			cfg:         routing.RuleConfig{Dataset: "apm-{resource.attributes.team}"},
+			expectedErr: `invalid dataset: "apm-" contains '-', which is not allowed in data stream names`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments"
+	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/routing"
 )
 
 var _ processor.Traces = (*TraceProcessor)(nil)
@@ -39,8 +40,7 @@ var _ processor.Metrics = (*MetricProcessor)(nil)
 var _ processor.Logs = (*LogProcessor)(nil)
 
 type TraceProcessor struct {
-	component.StartFunc
-	component.ShutdownFunc
+	sourcemapComponent
 
 	next            consumer.Traces
 	defaultEnricher enrichments.TraceEnricher
@@ -50,15 +50,20 @@ type TraceProcessor struct {
 	cfg             *Config
 }
 
-func NewTraceProcessor(cfg *Config, next consumer.Traces, logger *zap.Logger) *TraceProcessor {
-	return &TraceProcessor{
-		next:            next,
-		logger:          logger,
-		defaultEnricher: enrichments.NewDefaultTraceEnricher(cfg.Config),
-		apmEnricher:     enrichments.NewAPMTraceEnricher(cfg.Config, cfg.HostIPEnabled),
-		otelEnricher:    enrichments.NewOTelTraceEnricher(cfg.Config, cfg.HostIPEnabled),
-		cfg:             cfg,
+func NewTraceProcessor(cfg *Config, next consumer.Traces, set processor.Settings) (*TraceProcessor, error) {
+	router, err := routing.NewRouter(cfg.DataStreamRouting, set.TelemetrySettings)
+	if err != nil {
+		return nil, err
 	}
+	return &TraceProcessor{
+		sourcemapComponent: sourcemapComponent{cfg: cfg.SourceMap, logger: set.Logger},
+		next:               next,
+		logger:             set.Logger,
+		defaultEnricher:    enrichments.NewDefaultTraceEnricher(cfg.Config),
+		apmEnricher:        enrichments.NewAPMTraceEnricher(cfg.Config, cfg.HostIPEnabled, router),
+		otelEnricher:       enrichments.NewOTelTraceEnricher(cfg.Config, cfg.HostIPEnabled, router),
+		cfg:                cfg,
+	}, nil
 }
 
 func (p *TraceProcessor) Capabilities() consumer.Capabilities {
@@ -70,6 +75,9 @@ func (p *TraceProcessor) ConsumeTraces(ctx context.Context, td ptrace.Traces) er
 	resourceSpans := td.ResourceSpans()
 	for i := 0; i < resourceSpans.Len(); i++ {
 		rs := resourceSpans.At(i)
+		if p.sourcemapComponent.enricher != nil {
+			p.sourcemapComponent.enricher.EnrichResourceSpans(ctx, rs)
+		}
 		enricher := p.defaultEnricher
 		if ecsMode {
 			if isElasticAPMAgent(rs.Resource()) {
@@ -108,8 +116,7 @@ func getMetadataValue(info client.Info) string {
 }
 
 type LogProcessor struct {
-	component.StartFunc
-	component.ShutdownFunc
+	sourcemapComponent
 
 	next            consumer.Logs
 	defaultEnricher enrichments.LogEnricher
@@ -119,15 +126,20 @@ type LogProcessor struct {
 	cfg             *Config
 }
 
-func newLogProcessor(cfg *Config, next consumer.Logs, logger *zap.Logger) *LogProcessor {
-	return &LogProcessor{
-		next:            next,
-		logger:          logger,
-		defaultEnricher: enrichments.NewDefaultLogEnricher(cfg.Config),
-		apmEnricher:     enrichments.NewAPMLogEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset),
-		otelEnricher:    enrichments.NewOTelLogEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset),
-		cfg:             cfg,
+func newLogProcessor(cfg *Config, next consumer.Logs, set processor.Settings) (*LogProcessor, error) {
+	router, err := routing.NewRouter(cfg.DataStreamRouting, set.TelemetrySettings)
+	if err != nil {
+		return nil, err
 	}
+	return &LogProcessor{
+		sourcemapComponent: sourcemapComponent{cfg: cfg.SourceMap, logger: set.Logger},
+		next:               next,
+		logger:             set.Logger,
+		defaultEnricher:    enrichments.NewDefaultLogEnricher(cfg.Config),
+		apmEnricher:        enrichments.NewAPMLogEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset, router),
+		otelEnricher:       enrichments.NewOTelLogEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset, router),
+		cfg:                cfg,
+	}, nil
 }
 
 func (p *LogProcessor) Capabilities() consumer.Capabilities {
@@ -146,15 +158,19 @@ type MetricProcessor struct {
 	cfg             *Config
 }
 
-func newMetricProcessor(cfg *Config, next consumer.Metrics, logger *zap.Logger) *MetricProcessor {
+func newMetricProcessor(cfg *Config, next consumer.Metrics, set processor.Settings) (*MetricProcessor, error) {
+	router, err := routing.NewRouter(cfg.DataStreamRouting, set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
 	return &MetricProcessor{
 		next:            next,
-		logger:          logger,
+		logger:          set.Logger,
 		defaultEnricher: enrichments.NewDefaultMetricEnricher(cfg.Config),
-		apmEnricher:     enrichments.NewAPMMetricEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset),
-		otelEnricher:    enrichments.NewOTelMetricEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset),
+		apmEnricher:     enrichments.NewAPMMetricEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset, router),
+		otelEnricher:    enrichments.NewOTelMetricEnricher(cfg.Config, cfg.HostIPEnabled, cfg.ServiceNameInDataStreamDataset, router),
 		cfg:             cfg,
-	}
+	}, nil
 }
 
 func (p *MetricProcessor) Capabilities() consumer.Capabilities {
@@ -190,6 +206,9 @@ func (p *LogProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
 	resourceLogs := ld.ResourceLogs()
 	for i := 0; i < resourceLogs.Len(); i++ {
 		rl := resourceLogs.At(i)
+		if p.sourcemapComponent.enricher != nil {
+			p.sourcemapComponent.enricher.EnrichResourceLogs(ctx, rl)
+		}
 		enricher := p.defaultEnricher
 		if ecsMode {
 			if isElasticAPMAgent(rl.Resource()) {
@@ -42,6 +42,7 @@ import (
 
 	"github.com/elastic/opentelemetry-collector-components/internal/elasticattr"
 	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/metadata"
+	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/routing"
 )
 
 var update = flag.Bool("update", false, "Flag to generate/updated the expected yaml files")
@@ -1573,3 +1574,47 @@ func consumeMetricResourceAttrsWithDataset(t *testing.T, ctx context.Context, fa
 	require.NoError(t, mp.ConsumeMetrics(ctx, metrics))
 	return next.AllMetrics()[0].ResourceMetrics().At(0).Resource().Attributes()
 }
+
+func TestECSDataStreamRoutingRules(t *testing.T) {
+	ctx := context.Background()
+	ctx = client.NewContext(ctx, client.Info{
+		Metadata: client.NewMetadata(map[string][]string{
+			"x-elastic-mapping-mode": {"ecs"},
+			"x-tenant":               {"tenant1"},
+		}),
+	})
+
+	factory := NewFactory()
+	settings := processortest.NewNopSettings(metadata.Type)
+	next := &consumertest.TracesSink{}
+
+	cfg := NewDefaultConfig().(*Config)
+	cfg.DataStreamRouting = []routing.RuleConfig{{
+		Condition: `attributes["service.name"] != nil`,
+		Namespace: "{client.metadata.x-tenant}",
+	}}
+	tp, err := factory.CreateTraces(ctx, settings, cfg, next)
+	require.NoError(t, err)
+
+	inputTraces, err := golden.ReadTraces("testdata/ecs/elastic_error/span_otlp_exception_input.yaml")
+	require.NoError(t, err)
+
+	require.NoError(t, tp.ConsumeTraces(ctx, inputTraces))
+	actual := next.AllTraces()[0]
+
+	require.Equal(t, 1, actual.ResourceSpans().Len())
+	resourceSpan := actual.ResourceSpans().At(0)
+	resourceAttrs := resourceSpan.Resource().Attributes()
+
+	dataStreamDataset, _ := resourceAttrs.Get("data_stream.dataset")
+	assert.Equal(t, "apm", dataStreamDataset.Str())
+	dataStreamNamespace, _ := resourceAttrs.Get("data_stream.namespace")
+	assert.Equal(t, "tenant1", dataStreamNamespace.Str())
+
+	// The namespace also applies to errors routed from span events.
+	exceptionAttrs := resourceSpan.ScopeSpans().At(0).Spans().At(0).Events().At(0).Attributes()
+	eventDataStreamDataset, _ := exceptionAttrs.Get("data_stream.dataset")
+	assert.Equal(t, "apm.error", eventDataStreamDataset.Str())
+	eventDataStreamNamespace, _ := exceptionAttrs.Get("data_stream.namespace")
+	assert.Equal(t, "tenant1", eventDataStreamNamespace.Str())
+}
@@ -0,0 +1,62 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor"
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+	"github.com/elastic/opentelemetry-collector-components/processor/elasticapmprocessor/internal/enrichments"
+)
+
+// sourcemapComponent opens the source map store on start. The enricher is
+// nil if source maps are not configured.
+type sourcemapComponent struct {
+	cfg    SourceMapConfig
+	logger *zap.Logger
+
+	store    sourcemap.Store
+	enricher *enrichments.SourcemapEnricher
+}
+
+func (c *sourcemapComponent) Start(ctx context.Context, host component.Host) error {
+	if !c.cfg.Enabled() {
+		return nil
+	}
+	store, err := sourcemap.NewStore(ctx, host, c.cfg.Config)
+	if err != nil {
+		return fmt.Errorf("failed to create source map store: %w", err)
+	}
+	c.store = store
+	c.enricher = enrichments.NewSourcemapEnricher(
+		sourcemap.NewFetcher(store, c.cfg.CacheSize, c.cfg.CacheExpiration),
+		c.logger,
+	)
+	return nil
+}
+
+func (c *sourcemapComponent) Shutdown(ctx context.Context) error {
+	if c.store == nil {
+		return nil
+	}
+	return c.store.Close(ctx)
+}
@@ -47,5 +47,5 @@ func createTraces(
 	if !ok {
 		return nil, fmt.Errorf("configuration parsing error")
 	}
-	return elasticapmprocessor.NewTraceProcessor(processorCfg, next, set.Logger), nil
+	return elasticapmprocessor.NewTraceProcessor(processorCfg, next, set)
 }
@@ -19,6 +19,9 @@ package integrationprocessor // import "github.com/elastic/opentelemetry-collect
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
 
 	"go.opentelemetry.io/collector/pipeline"
 )
@@ -31,6 +34,10 @@ type Config struct {
 	// Pipeline is the pipeline to instantiate, from the referenced integration.
 	Pipeline pipeline.ID `mapstructure:"pipeline"`
 
+	// Version constrains the versions of the integration that can be used, such as "^1.2.0" or
+	// ">= 1.2.0, < 2.0.0". The newest version available is used if empty.
+	Version string `mapstructure:"version"`
+
 	// Parameters are used to resolve the placeholders in parameterized integrations.
 	Parameters map[string]any `mapstructure:"parameters"`
 }
@@ -41,6 +48,12 @@ func (cfg *Config) Validate() error {
 		return errors.New("name is required")
 	}
 
+	if cfg.Version != "" {
+		if _, err := semver.NewConstraint(cfg.Version); err != nil {
+			return fmt.Errorf("invalid version %q: %w", cfg.Version, err)
+		}
+	}
+
 	if cfg.Pipeline.String() == "" {
 		return errors.New("pipeline is required")
 	}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"sync"
 
 	"go.uber.org/zap"
 
@@ -39,11 +40,24 @@ import (
 type integrationProcessor struct {
 	params              processor.Settings
 	config              *Config
-	components          []component.Component
-	capabilities        consumer.Capabilities
 	nextMetricsConsumer consumer.Metrics
 	nextLogsConsumer    consumer.Logs
 	nextTracesConsumer  consumer.Traces
+	unsubscribe         func()
+
+	// reloadMu serializes reloads of the chain of processors.
+	reloadMu sync.Mutex
+
+	// mu protects the current chain of processors, that is replaced when the integration changes.
+	// Consumers hold it for reading while data is being processed.
+	mu       sync.RWMutex
+	chain    processorChain
+	shutdown bool
+}
+
+// processorChain is a chain of processors created from a pipeline of an integration.
+type processorChain struct {
+	components []component.Component
 
 	metrics consumer.Metrics
 	logs    consumer.Logs
@@ -81,111 +95,187 @@ type factoryGetter interface {
 	GetFactory(component.Kind, component.Type) component.Factory
 }
 
-// Start creates and starts a processor, composed by a chain of other processors.
+// Start creates and starts a processor, composed by a chain of other processors. The chain is replaced when
+// the finder extensions notify that the integration has changed.
 func (r *integrationProcessor) Start(ctx context.Context, ch component.Host) error {
 	host, ok := ch.(factoryGetter)
 	if !ok {
 		return fmt.Errorf("integrationprocessor is not compatible with the provided component.Host")
 	}
 
-	integration, err := integrations.Find(ctx, r.params.Logger, host, r.config.Name)
+	chain, err := r.startChain(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.chain = chain
+	r.mu.Unlock()
+
+	r.unsubscribe = integrations.SubscribeChanges(host, r.config.Name, func() {
+		r.reload(host)
+	})
+	return nil
+}
+
+// startChain finds and resolves the integration, and creates and starts the chain of processors
+// of the configured pipeline.
+func (r *integrationProcessor) startChain(ctx context.Context, host factoryGetter) (processorChain, error) {
+	integration, err := integrations.FindVersion(ctx, r.params.Logger, host, r.config.Name, r.config.Version)
 	if err != nil {
-		return fmt.Errorf("failed to find integration %q: %w", r.config.Name, err)
+		return processorChain{}, fmt.Errorf("failed to find integration %q: %w", r.config.Name, err)
 	}
 
 	config, err := integration.Resolve(ctx, r.config.Parameters, []pipeline.ID{r.config.Pipeline})
 	if err != nil {
-		return fmt.Errorf("failed to build pipeline for integration %q: %w", r.config.Name, err)
+		return processorChain{}, fmt.Errorf("failed to build pipeline for integration %q: %w", r.config.Name, err)
 	}
 
 	// Confidence checks, this config should only have the requested pipeline.
 	if n := len(config.Pipelines); n != 1 {
-		return fmt.Errorf("exactly one pipeline expected, found %d", n)
+		return processorChain{}, fmt.Errorf("exactly one pipeline expected, found %d", n)
 	}
 	pipeline, found := config.Pipelines[r.config.Pipeline]
 	if !found {
-		return fmt.Errorf("expected pipeline %q not found", r.config.Pipeline)
+		return processorChain{}, fmt.Errorf("expected pipeline %q not found", r.config.Pipeline)
+	}
+	if len(pipeline.Exporters) > 0 {
+		return processorChain{}, fmt.Errorf("pipeline %q cannot be used as processor because it has exporters", r.config.Pipeline)
 	}
 
-	err = r.startPipeline(ctx, host, *config, r.config.Pipeline, pipeline)
+	chain, err := r.startPipeline(ctx, host, *config, r.config.Pipeline, pipeline)
 	if err != nil {
 		// Shutdown components that had been already started for cleanup.
-		if err := r.Shutdown(ctx); err != nil {
+		if err := shutdownComponents(ctx, chain.components); err != nil {
 			r.params.Logger.Warn("Failed to shutdown all components on error while starting",
 				zap.String("error", err.Error()))
 		}
-		return fmt.Errorf("failed to start pipeline %q: %w", r.config.Pipeline, err)
+		return processorChain{}, fmt.Errorf("failed to start pipeline %q: %w", r.config.Pipeline, err)
 	}
 
-	return nil
+	return chain, nil
 }
 
-func (r *integrationProcessor) startPipeline(ctx context.Context, host factoryGetter, config integrations.Config, pipelineID pipeline.ID, pipeline integrations.PipelineConfig) error {
-	r.logs = r.nextLogsConsumer
-	r.metrics = r.nextMetricsConsumer
-	r.traces = r.nextTracesConsumer
+func (r *integrationProcessor) startPipeline(ctx context.Context, host factoryGetter, config integrations.Config, pipelineID pipeline.ID, pipeline integrations.PipelineConfig) (processorChain, error) {
+	chain := processorChain{
+		logs:    r.nextLogsConsumer,
+		metrics: r.nextMetricsConsumer,
+		traces:  r.nextTracesConsumer,
+	}
 
 	processors := slices.Clone(pipeline.Processors)
 	slices.Reverse(processors)
 	for i, id := range processors {
 		processorConfig, found := config.Processors[id]
 		if !found {
-			return fmt.Errorf("processor %q not found", id)
+			return chain, fmt.Errorf("processor %q not found", id)
 		}
 
 		factory, ok := host.GetFactory(component.KindProcessor, id.Type()).(processor.Factory)
 		if !ok {
-			return fmt.Errorf("could not find processor factory for %q", id.Type())
+			return chain, fmt.Errorf("could not find processor factory for %q", id.Type())
 		}
 
 		config, err := convertComponentConfig(factory.CreateDefaultConfig, processorConfig)
 		if err != nil {
-			return fmt.Errorf("could not compose processor config for %s: %w", id.String(), err)
+			return chain, fmt.Errorf("could not compose processor config for %s: %w", id.String(), err)
 		}
 
 		params := processor.Settings(r.params)
 		params.ID = component.NewIDWithName(factory.Type(), fmt.Sprintf("%s-%s-%d", r.params.ID, pipelineID, i))
 		params.Logger = params.Logger.With(zap.String("name", params.ID.String()))
-		if r.logs != nil {
-			logs, err := factory.CreateLogs(ctx, params, config, r.logs)
+		if chain.logs != nil {
+			logs, err := factory.CreateLogs(ctx, params, config, chain.logs)
 			if err != nil {
-				return fmt.Errorf("failed to create logs processor %s: %w", params.ID, err)
+				return chain, fmt.Errorf("failed to create logs processor %s: %w", params.ID, err)
 			}
-			r.logs = logs
-			r.components = append(r.components, logs)
+			chain.logs = logs
+			chain.components = append(chain.components, logs)
 		}
-		if r.metrics != nil {
-			metrics, err := factory.CreateMetrics(ctx, params, config, r.metrics)
+		if chain.metrics != nil {
+			metrics, err := factory.CreateMetrics(ctx, params, config, chain.metrics)
 			if err != nil {
-				return fmt.Errorf("failed to create metrics processor %s: %w", params.ID, err)
+				return chain, fmt.Errorf("failed to create metrics processor %s: %w", params.ID, err)
 			}
-			r.metrics = metrics
-			r.components = append(r.components, metrics)
+			chain.metrics = metrics
+			chain.components = append(chain.components, metrics)
 		}
-		if r.traces != nil {
-			traces, err := factory.CreateTraces(ctx, params, config, r.traces)
+		if chain.traces != nil {
+			traces, err := factory.CreateTraces(ctx, params, config, chain.traces)
 			if err != nil {
-				return fmt.Errorf("failed to create traces processor %s: %w", params.ID, err)
+				return chain, fmt.Errorf("failed to create traces processor %s: %w", params.ID, err)
 			}
-			r.traces = traces
-			r.components = append(r.components, traces)
+			chain.traces = traces
+			chain.components = append(chain.components, traces)
 		}
 	}
 
-	for _, component := range r.components {
+	for _, component := range chain.components {
 		err := component.Start(ctx, host)
 		if err != nil {
-			return fmt.Errorf("failed to start component %q: %w", component, err)
+			return chain, fmt.Errorf("failed to start component %q: %w", component, err)
 		}
 	}
 
-	return nil
+	return chain, nil
+}
+
+// reload replaces the chain of processors with a new one, created from the current definition of
+// the integration. The new chain is started before replacing the previous one, and the previous one
+// is shut down once the data being processed by it is consumed, so no data is lost. The previous
+// chain is kept if the new one cannot be started.
+func (r *integrationProcessor) reload(host factoryGetter) {
+	ctx := context.Background()
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	chain, err := r.startChain(ctx, host)
+	if err != nil {
+		r.params.Logger.Error("failed to reload integration, keeping previous processors",
+			zap.String("integration", r.config.Name),
+			zap.Error(err))
+		return
+	}
+
+	r.mu.Lock()
+	if r.shutdown {
+		r.mu.Unlock()
+		if err := shutdownComponents(ctx, chain.components); err != nil {
+			r.params.Logger.Warn("failed to shutdown processors reloaded during shutdown", zap.Error(err))
+		}
+		return
+	}
+	previous := r.chain
+	r.chain = chain
+	r.mu.Unlock()
+
+	if err := shutdownComponents(ctx, previous.components); err != nil {
+		r.params.Logger.Warn("failed to shutdown previous processors after reloading integration",
+			zap.String("integration", r.config.Name),
+			zap.Error(err))
+	}
+	r.params.Logger.Info("integration reloaded", zap.String("integration", r.config.Name))
 }
 
 func (r *integrationProcessor) Shutdown(ctx context.Context) error {
+	if r.unsubscribe != nil {
+		r.unsubscribe()
+		r.unsubscribe = nil
+	}
+
+	r.mu.Lock()
+	r.shutdown = true
+	components := r.chain.components
+	r.chain.components = nil
+	r.mu.Unlock()
+
+	return shutdownComponents(ctx, components)
+}
+
+func shutdownComponents(ctx context.Context, components []component.Component) error {
 	// Shutdown them in reverse order as they were created.
-	components := slices.Clone(r.components)
-	slices.Reverse(r.components)
+	components = slices.Clone(components)
+	slices.Reverse(components)
 	for _, c := range components {
 		err := c.Shutdown(ctx)
 		if err != nil {
@@ -196,26 +286,38 @@ func (r *integrationProcessor) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities reports that the processor mutates data, as the processors of the integration are
+// only known once it is resolved on start, and may change on reload.
 func (r *integrationProcessor) Capabilities() consumer.Capabilities {
-	return r.capabilities
+	return consumer.Capabilities{MutatesData: true}
 }
 
 func (r *integrationProcessor) ConsumeLogs(ctx context.Context, logs plog.Logs) error {
-	return r.logs.ConsumeLogs(ctx, logs)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.chain.logs.ConsumeLogs(ctx, logs)
 }
 
 func (r *integrationProcessor) ConsumeMetrics(ctx context.Context, metrics pmetric.Metrics) error {
-	return r.metrics.ConsumeMetrics(ctx, metrics)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.chain.metrics.ConsumeMetrics(ctx, metrics)
 }
 
 func (r *integrationProcessor) ConsumeTraces(ctx context.Context, traces ptrace.Traces) error {
-	return r.traces.ConsumeTraces(ctx, traces)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.chain.traces.ConsumeTraces(ctx, traces)
 }
 
 // convertComponentConfig merges the raw configuration received from the integration
 // with the configuration object returned by `create`. `create` is expected to be the
 // `CreateDefaultConfig` of a component factory.
 func convertComponentConfig(create func() component.Config, config component.Config) (component.Config, error) {
+	if config == nil {
+		// Component defined without settings.
+		return create(), nil
+	}
 	received := confmap.New()
 	err := received.Marshal(config)
 	if err != nil {
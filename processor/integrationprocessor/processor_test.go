@@ -64,6 +64,15 @@ func TestConsumeLogs(t *testing.T) {
 			},
 			startErr: `failed to find integration "undefined": not found`,
 		},
+		{
+			title: "version not available",
+			setup: func(config *Config) {
+				config.Name = "empty"
+				config.Version = "^1.0.0"
+				config.Pipeline = pipeline.NewID(pipeline.SignalLogs)
+			},
+			startErr: `failed to find integration "empty": not found`,
+		},
 		{
 			title: "undefined pipeline",
 			setup: func(config *Config) {
@@ -72,6 +81,14 @@ func TestConsumeLogs(t *testing.T) {
 			},
 			startErr: `component "traces" not found`,
 		},
+		{
+			title: "pipeline with exporters",
+			setup: func(config *Config) {
+				config.Name = "exporter"
+				config.Pipeline = pipeline.NewID(pipeline.SignalLogs)
+			},
+			startErr: `pipeline "logs" cannot be used as processor because it has exporters`,
+		},
 
 		{
 			title: "use variable",
@@ -115,6 +132,60 @@ func TestConsumeLogs(t *testing.T) {
 	}
 }
 
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate := func(t *testing.T, value string) {
+		template := `
+processors:
+  transform:
+    log_statements:
+      - set(log.attributes["version"], "` + value + `")
+
+pipelines:
+  logs:
+    processors: [transform]
+`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "reload.yaml"), []byte(template), 0o600))
+	}
+	writeTemplate(t, "original")
+
+	factory := NewFactory()
+	config := factory.CreateDefaultConfig().(*Config)
+	config.Name = "reload"
+	config.Pipeline = pipeline.NewID(pipeline.SignalLogs)
+
+	sink := new(consumertest.LogsSink)
+	p, err := factory.CreateLogs(context.Background(), processortest.NewNopSettings(metadata.Type), config, sink)
+	require.NoError(t, err)
+
+	host := newMockHost(dir)
+	require.NoError(t, p.Start(context.Background(), host))
+	t.Cleanup(func() { require.NoError(t, p.Shutdown(context.Background())) })
+
+	input, err := golden.ReadLogs(filepath.Join("testdata", "logs.yaml"))
+	require.NoError(t, err)
+	assertVersion := func(t *testing.T, expected string) {
+		t.Helper()
+		sink.Reset()
+		require.NoError(t, p.ConsumeLogs(context.Background(), input))
+		require.Len(t, sink.AllLogs(), 1)
+		record := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+		version, found := record.Attributes().Get("version")
+		require.True(t, found)
+		assert.Equal(t, expected, version.Str())
+	}
+	assertVersion(t, "original")
+
+	// Invalid definitions are ignored, and previous processors are kept.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reload.yaml"), []byte("invalid"), 0o600))
+	host.notifyChange("reload")
+	assertVersion(t, "original")
+
+	writeTemplate(t, "reloaded")
+	host.notifyChange("reload")
+	assertVersion(t, "reloaded")
+}
+
 func TestConvertComponentConfig(t *testing.T) {
 	type componentConfig struct {
 		SomeSetting  string `mapstructure:"some_setting"`
@@ -149,6 +220,8 @@ func testConsumeLogs(t *testing.T, setup func(*Config), inputFile, expectedFile,
 	sink := new(consumertest.LogsSink)
 	p, err := factory.CreateLogs(context.Background(), processortest.NewNopSettings(metadata.Type), config, sink)
 	require.NoError(t, err)
+	// The processors of the integration may modify the data.
+	assert.True(t, p.Capabilities().MutatesData)
 
 	c := p.(component.Component)
 	err = c.Start(context.Background(), newMockHost("testdata/templates"))
@@ -184,7 +257,19 @@ func newMockHost(path string) *mockedHost {
 }
 
 type mockedHost struct {
-	path string
+	path        string
+	subscribers []func(string)
+}
+
+func (m *mockedHost) SubscribeChanges(fn func(string)) func() {
+	m.subscribers = append(m.subscribers, fn)
+	return func() {}
+}
+
+func (m *mockedHost) notifyChange(name string) {
+	for _, fn := range m.subscribers {
+		fn(name)
+	}
 }
 
 func (m *mockedHost) GetExtensions() map[component.ID]component.Component {
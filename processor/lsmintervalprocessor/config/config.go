@@ -18,11 +18,13 @@
 package config // import "github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/config"
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
 var _ component.Config = (*Config)(nil)
@@ -42,12 +44,18 @@ type Config struct {
 	// is because they lead to lossy aggregations.
 	PassThrough PassThrough `mapstructure:"pass_through"`
 
+	// SummaryAggregation configures how summary metrics are aggregated
+	// when they are not passed through.
+	SummaryAggregation SummaryAggregationConfig `mapstructure:"summary_aggregation"`
+
+	// EventTime configures bucketing of data points into aggregation
+	// windows by their own timestamps instead of their arrival time.
+	EventTime EventTimeConfig `mapstructure:"event_time"`
+
 	// Intervals is a list of interval configuration that the processor
-	// will aggregate over. The interval duration must be in increasing
-	// order and must be a factor of the smallest interval duration.
-	// TODO (lahsivjar): Make specifying interval easier. We can just
-	// optimize the timer to run on differnt times and remove any
-	// restriction on different interval configuration.
+	// will aggregate over. Each interval is harvested independently at
+	// its wall-clock aligned boundaries, so durations don't need to be
+	// multiples of each other, but they must be unique.
 	Intervals []IntervalConfig `mapstructure:"intervals"`
 
 	// MetadataKeys is a list of client.Metadata keys that will be
@@ -84,12 +92,91 @@ type PassThrough struct {
 	Summary bool `mapstructure:"summary"`
 }
 
+// SummaryAggregationMode defines how summary data points are aggregated.
+type SummaryAggregationMode string
+
+const (
+	// SummaryAggregationModeLast keeps the latest data point of each
+	// summary stream.
+	SummaryAggregationModeLast SummaryAggregationMode = "last"
+	// SummaryAggregationModeQuantiles converts summaries to exponential
+	// histograms which are merged and reported as summaries again, with
+	// the quantiles of each data point estimated from the merged histogram.
+	SummaryAggregationModeQuantiles SummaryAggregationMode = "quantiles"
+	// SummaryAggregationModeExponentialHistogram converts summaries to
+	// exponential histograms which are merged and reported as such.
+	SummaryAggregationModeExponentialHistogram SummaryAggregationMode = "exponential_histogram"
+)
+
+// SummaryAggregationConfig defines the configuration for aggregating
+// summary metrics. In the quantiles and exponential_histogram modes the
+// observations of each summary data point are approximated from its
+// quantiles with an exponential histogram, bounded by the
+// ExponentialHistogramMaxBuckets, while count and sum are kept exact.
+type SummaryAggregationConfig struct {
+	// Mode is one of `last`, `quantiles` or `exponential_histogram`.
+	// Defaults to `last`.
+	Mode SummaryAggregationMode `mapstructure:"mode"`
+	// Temporality is the aggregation temporality of the summaries in the
+	// quantiles and exponential_histogram modes. Data points of delta
+	// summaries are added together, while the latest data point of
+	// cumulative summaries is kept. Defaults to `cumulative`.
+	Temporality string `mapstructure:"temporality"`
+}
+
+// EventTimeConfig defines the configuration for event-time aggregation.
+// When enabled, each data point is aggregated into the window its own
+// timestamp falls into. Windows are kept open until the watermark, i.e.
+// the current time minus the allowed lateness, passes their end. Data
+// points falling into a window already passed by the watermark are late.
+type EventTimeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AllowedLateness is the duration for which windows are kept open
+	// after their end to accept delayed data points. Defaults to 0.
+	AllowedLateness time.Duration `mapstructure:"allowed_lateness"`
+	// LateData configures how data points arriving after the watermark
+	// has passed their window are handled.
+	LateData LateDataConfig `mapstructure:"late_data"`
+}
+
+// LateDataAction defines the handling of late data points.
+type LateDataAction string
+
+const (
+	// LateDataActionDrop drops late data points.
+	LateDataActionDrop LateDataAction = "drop"
+	// LateDataActionForward forwards data points that are late for every
+	// interval to the next consumer without aggregating them.
+	LateDataActionForward LateDataAction = "forward"
+)
+
+// LateDataConfig defines the configuration for handling late data points.
+type LateDataConfig struct {
+	// Action is either `drop` or `forward`. Defaults to `drop`.
+	Action LateDataAction `mapstructure:"action"`
+	// Attributes are added to the forwarded late data points so that they
+	// can be told apart, e.g. to route them to a separate pipeline.
+	Attributes []Attribute `mapstructure:"attributes"`
+}
+
 // IntervalConfig defines the configuration for the intervals that the
 // component will aggregate over. OTTL statements are also defined to
 // be applied to the metric harvested for each interval after they are
 // mature for the interval duration.
 type IntervalConfig struct {
+	// Duration is the length of the aggregation windows. Windows are
+	// aligned to wall-clock boundaries, i.e. to multiples of the duration
+	// since the Unix epoch, so that the output of several replicas lines
+	// up. Must be a whole number of seconds.
 	Duration time.Duration `mapstructure:"duration"`
+	// Offset shifts the window boundaries, e.g. an offset of 6h for a 24h
+	// duration produces windows starting at 06:00. Must be a whole number
+	// of seconds smaller than the duration. Defaults to 0.
+	Offset time.Duration `mapstructure:"offset"`
+	// Timezone is the IANA time zone name in whose wall clock the window
+	// boundaries are aligned, e.g. for daily rollups starting at local
+	// midnight. Defaults to UTC.
+	Timezone string `mapstructure:"timezone"`
 	// Statements are a list of OTTL statements to be executed on the
 	// metrics produced for a given interval. The list of available
 	// OTTL paths for datapoints can be checked at:
@@ -122,7 +209,20 @@ type Attribute struct {
 }
 
 func (cfg *Config) Validate() error {
-	// TODO (lahsivjar): Add validation for interval duration
+	if len(cfg.Intervals) == 0 {
+		return errors.New("at least one interval must be specified")
+	}
+	durations := make(map[time.Duration]bool, len(cfg.Intervals))
+	for _, ivl := range cfg.Intervals {
+		if err := ivl.Validate(); err != nil {
+			return fmt.Errorf("invalid interval %s: %w", ivl.Duration, err)
+		}
+		if durations[ivl.Duration] {
+			return fmt.Errorf("duplicate interval duration: %s", ivl.Duration)
+		}
+		durations[ivl.Duration] = true
+	}
+
 	uniq := map[string]bool{}
 	for _, k := range cfg.MetadataKeys {
 		l := strings.ToLower(k)
@@ -141,11 +241,85 @@ func (cfg *Config) Validate() error {
 	return nil
 }
 
+func (cfg *SummaryAggregationConfig) Validate() error {
+	switch cfg.Mode {
+	case SummaryAggregationModeLast, SummaryAggregationModeQuantiles, SummaryAggregationModeExponentialHistogram:
+	default:
+		return fmt.Errorf("invalid summary_aggregation mode %q, must be one of %q, %q or %q",
+			cfg.Mode,
+			SummaryAggregationModeLast,
+			SummaryAggregationModeQuantiles,
+			SummaryAggregationModeExponentialHistogram,
+		)
+	}
+	if _, err := cfg.AggregationTemporality(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AggregationTemporality returns the configured temporality of summaries.
+func (cfg *SummaryAggregationConfig) AggregationTemporality() (pmetric.AggregationTemporality, error) {
+	switch cfg.Temporality {
+	case "", "cumulative":
+		return pmetric.AggregationTemporalityCumulative, nil
+	case "delta":
+		return pmetric.AggregationTemporalityDelta, nil
+	}
+	return pmetric.AggregationTemporalityUnspecified, fmt.Errorf(
+		"invalid summary_aggregation temporality %q, must be either %q or %q",
+		cfg.Temporality, "cumulative", "delta",
+	)
+}
+
+func (cfg *EventTimeConfig) Validate() error {
+	if cfg.AllowedLateness < 0 {
+		return errors.New("allowed_lateness must not be negative")
+	}
+	switch cfg.LateData.Action {
+	case LateDataActionDrop, LateDataActionForward:
+	default:
+		return fmt.Errorf("invalid late_data action %q, must be one of %q or %q",
+			cfg.LateData.Action, LateDataActionDrop, LateDataActionForward,
+		)
+	}
+	return nil
+}
+
+func (ivl *IntervalConfig) Validate() error {
+	if ivl.Duration < time.Second || ivl.Duration%time.Second != 0 {
+		return errors.New("duration must be a positive whole number of seconds")
+	}
+	if ivl.Offset < 0 || ivl.Offset >= ivl.Duration || ivl.Offset%time.Second != 0 {
+		return errors.New("offset must be a whole number of seconds smaller than the duration")
+	}
+	if _, err := ivl.Location(); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", ivl.Timezone, err)
+	}
+	return nil
+}
+
+// Location returns the time zone in whose wall clock the interval windows
+// are aligned.
+func (ivl *IntervalConfig) Location() (*time.Location, error) {
+	if ivl.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(ivl.Timezone)
+}
+
 func CreateDefaultConfig() component.Config {
 	return &Config{
 		Intervals: []IntervalConfig{
 			{Duration: 60 * time.Second},
 		},
+		SummaryAggregation: SummaryAggregationConfig{
+			Mode:        SummaryAggregationModeLast,
+			Temporality: "cumulative",
+		},
+		EventTime: EventTimeConfig{
+			LateData: LateDataConfig{Action: LateDataActionDrop},
+		},
 		ExponentialHistogramMaxBuckets: defaultMaxExponentialHistogramBuckets,
 	}
 }
@@ -19,6 +19,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/metadata"
 	"github.com/stretchr/testify/assert"
@@ -56,6 +57,138 @@ func TestConfig(t *testing.T) {
 			},
 			expectedErrMsg: "invalid value for exponential_histogram_max_buckets",
 		},
+		{
+			name: "no_intervals",
+			input: map[string]any{
+				"intervals": []any{},
+			},
+			expectedErrMsg: "at least one interval must be specified",
+		},
+		{
+			name: "fractional_duration",
+			input: map[string]any{
+				"intervals": []any{map[string]any{"duration": "1500ms"}},
+			},
+			expectedErrMsg: "duration must be a positive whole number of seconds",
+		},
+		{
+			name: "offset_exceeds_duration",
+			input: map[string]any{
+				"intervals": []any{map[string]any{"duration": "1h", "offset": "1h"}},
+			},
+			expectedErrMsg: "offset must be a whole number of seconds smaller than the duration",
+		},
+		{
+			name: "invalid_timezone",
+			input: map[string]any{
+				"intervals": []any{map[string]any{"duration": "24h", "timezone": "Mars/Olympus_Mons"}},
+			},
+			expectedErrMsg: "invalid timezone",
+		},
+		{
+			name: "duplicate_interval",
+			input: map[string]any{
+				"intervals": []any{
+					map[string]any{"duration": "1m"},
+					map[string]any{"duration": "1m", "offset": "30s"},
+				},
+			},
+			expectedErrMsg: "duplicate interval duration: 1m0s",
+		},
+		{
+			name: "unaligned_intervals",
+			input: map[string]any{
+				"intervals": []any{
+					map[string]any{"duration": "15m"},
+					map[string]any{"duration": "1m"},
+					map[string]any{"duration": "24h", "offset": "6h", "timezone": "Europe/Berlin"},
+				},
+			},
+			expected: func() *Config {
+				cfg := CreateDefaultConfig().(*Config)
+				cfg.Intervals = []IntervalConfig{
+					{Duration: 15 * time.Minute},
+					{Duration: time.Minute},
+					{Duration: 24 * time.Hour, Offset: 6 * time.Hour, Timezone: "Europe/Berlin"},
+				}
+				return cfg
+			}(),
+		},
+		{
+			name: "invalid_summary_aggregation_mode",
+			input: map[string]any{
+				"summary_aggregation": map[string]any{"mode": "average"},
+			},
+			expectedErrMsg: `invalid summary_aggregation mode "average"`,
+		},
+		{
+			name: "invalid_summary_aggregation_temporality",
+			input: map[string]any{
+				"summary_aggregation": map[string]any{"mode": "quantiles", "temporality": "unspecified"},
+			},
+			expectedErrMsg: `invalid summary_aggregation temporality "unspecified"`,
+		},
+		{
+			name: "summary_aggregation",
+			input: map[string]any{
+				"summary_aggregation": map[string]any{"mode": "exponential_histogram", "temporality": "delta"},
+			},
+			expected: func() *Config {
+				cfg := CreateDefaultConfig().(*Config)
+				cfg.SummaryAggregation = SummaryAggregationConfig{
+					Mode:        SummaryAggregationModeExponentialHistogram,
+					Temporality: "delta",
+				}
+				return cfg
+			}(),
+		},
+		{
+			name: "invalid_late_data_action",
+			input: map[string]any{
+				"event_time": map[string]any{
+					"enabled":   true,
+					"late_data": map[string]any{"action": "ignore"},
+				},
+			},
+			expectedErrMsg: `invalid late_data action "ignore"`,
+		},
+		{
+			name: "negative_allowed_lateness",
+			input: map[string]any{
+				"event_time": map[string]any{
+					"enabled":          true,
+					"allowed_lateness": "-1m",
+				},
+			},
+			expectedErrMsg: "allowed_lateness must not be negative",
+		},
+		{
+			name: "event_time",
+			input: map[string]any{
+				"event_time": map[string]any{
+					"enabled":          true,
+					"allowed_lateness": "10m",
+					"late_data": map[string]any{
+						"action": "forward",
+						"attributes": []any{
+							map[string]any{"key": "late", "value": true},
+						},
+					},
+				},
+			},
+			expected: func() *Config {
+				cfg := CreateDefaultConfig().(*Config)
+				cfg.EventTime = EventTimeConfig{
+					Enabled:         true,
+					AllowedLateness: 10 * time.Minute,
+					LateData: LateDataConfig{
+						Action:     LateDataActionForward,
+						Attributes: []Attribute{{Key: "late", Value: true}},
+					},
+				}
+				return cfg
+			}(),
+		},
 		{
 			name: "valid_full",
 			input: map[string]any{
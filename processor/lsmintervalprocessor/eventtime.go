@@ -0,0 +1,256 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lsmintervalprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor"
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/config"
+	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/merger"
+)
+
+// eventTimeBuckets aggregates data points into the windows their own
+// timestamps fall into, separately for each interval. Data points whose
+// window has already been harvested are late and are not aggregated.
+type eventTimeBuckets struct {
+	p         *Processor
+	now       time.Time
+	watermark time.Time
+
+	// values holds the aggregated value of each window per interval, in
+	// the same order as the processor intervals, keyed by the window start
+	// in seconds since the Unix epoch.
+	values []map[int64]*merger.Value
+	// late holds the number of late data points per interval, in the same
+	// order as the processor intervals.
+	late []int64
+}
+
+func (p *Processor) newEventTimeBuckets(now time.Time) *eventTimeBuckets {
+	return &eventTimeBuckets{
+		p:         p,
+		now:       now,
+		watermark: now.Add(-p.cfg.EventTime.AllowedLateness),
+		values:    make([]map[int64]*merger.Value, len(p.intervals)),
+		late:      make([]int64, len(p.intervals)),
+	}
+}
+
+// timeOf returns the event time of a data point. Data points without a
+// timestamp are attributed to their arrival time.
+func (b *eventTimeBuckets) timeOf(ts pcommon.Timestamp) time.Time {
+	if ts == 0 {
+		return b.now
+	}
+	return ts.AsTime()
+}
+
+// isLate reports whether the window of the interval that the data point
+// falls into has been passed by the watermark, i.e. it has already been
+// harvested. This is the same condition the harvest uses, so data points
+// of the current window are never late, regardless of the allowed lateness.
+func (b *eventTimeBuckets) isLate(ivl intervalDef, ts pcommon.Timestamp) bool {
+	return !ivl.windowAt(b.timeOf(ts)).end.After(b.watermark)
+}
+
+// isLateForAll reports whether the data point is late for every interval.
+func (b *eventTimeBuckets) isLateForAll(ts pcommon.Timestamp) bool {
+	for _, ivl := range b.p.intervals {
+		if !b.isLate(ivl, ts) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeMetric merges the data points of the metric into their windows.
+// Lateness is decided per interval, so a data point may still be merged
+// into the open window of a longer interval while being late for a shorter
+// one. Late data points are counted per interval and, if configured, those
+// late for every interval are copied once to the metric returned by
+// forward. Data points merged into any window are not forwarded, as they
+// would otherwise be counted twice downstream.
+func (b *eventTimeBuckets) mergeMetric(
+	rm pmetric.ResourceMetrics,
+	sm pmetric.ScopeMetrics,
+	m pmetric.Metric,
+	forward func() pmetric.Metric,
+) error {
+	var errs []error
+	var anyLate bool
+	for i, ivl := range b.p.intervals {
+		var total, late int
+		visitDataPoints(m, func(ts pcommon.Timestamp, _ pcommon.Map) {
+			total++
+			if b.isLate(ivl, ts) {
+				late++
+			}
+		})
+		src := m
+		if late > 0 {
+			anyLate = true
+			b.late[i] += int64(late)
+			if late == total {
+				continue
+			}
+			src = pmetric.NewMetric()
+			m.CopyTo(src)
+			removeDataPoints(src, func(ts pcommon.Timestamp) bool { return b.isLate(ivl, ts) })
+		}
+
+		windowStart := func(ts pcommon.Timestamp) int64 {
+			return ivl.windowAt(b.timeOf(ts)).start.Unix()
+		}
+		starts := make(map[int64]struct{}, 1)
+		visitDataPoints(src, func(ts pcommon.Timestamp, _ pcommon.Map) {
+			starts[windowStart(ts)] = struct{}{}
+		})
+		for start := range starts {
+			part := src
+			if len(starts) > 1 {
+				part = pmetric.NewMetric()
+				src.CopyTo(part)
+				removeDataPoints(part, func(ts pcommon.Timestamp) bool {
+					return windowStart(ts) != start
+				})
+			}
+			if err := b.p.mergeMetric(b.value(i, start), rm, sm, part); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if anyLate && b.p.cfg.EventTime.LateData.Action == config.LateDataActionForward {
+		if err := b.forwardLate(m, forward); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// forwardLate copies the data points of the metric that are late for every
+// interval to the metric returned by forward, if there are any.
+func (b *eventTimeBuckets) forwardLate(m pmetric.Metric, forward func() pmetric.Metric) error {
+	var lateForAll bool
+	visitDataPoints(m, func(ts pcommon.Timestamp, _ pcommon.Map) {
+		lateForAll = lateForAll || b.isLateForAll(ts)
+	})
+	if !lateForAll {
+		return nil
+	}
+
+	var errs []error
+	lm := forward()
+	m.CopyTo(lm)
+	removeDataPoints(lm, func(ts pcommon.Timestamp) bool { return !b.isLateForAll(ts) })
+	visitDataPoints(lm, func(_ pcommon.Timestamp, attrs pcommon.Map) {
+		if err := decorate(attrs, b.p.cfg.EventTime.LateData.Attributes); err != nil {
+			errs = append(errs, err)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+func (b *eventTimeBuckets) value(ivlIdx int, start int64) *merger.Value {
+	if b.values[ivlIdx] == nil {
+		b.values[ivlIdx] = make(map[int64]*merger.Value, 1)
+	}
+	v, ok := b.values[ivlIdx][start]
+	if !ok {
+		v = b.p.newValue()
+		b.values[ivlIdx][start] = v
+	}
+	return v
+}
+
+// visitDataPoints calls fn with the timestamp and attributes of every data
+// point of the metric.
+func visitDataPoints(m pmetric.Metric, fn func(pcommon.Timestamp, pcommon.Map)) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			fn(dps.At(i).Timestamp(), dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			fn(dps.At(i).Timestamp(), dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			fn(dps.At(i).Timestamp(), dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			fn(dps.At(i).Timestamp(), dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			fn(dps.At(i).Timestamp(), dps.At(i).Attributes())
+		}
+	}
+}
+
+// removeDataPoints removes the data points of the metric for whose
+// timestamp fn returns true.
+func removeDataPoints(m pmetric.Metric, fn func(pcommon.Timestamp) bool) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		m.Gauge().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+			return fn(dp.Timestamp())
+		})
+	case pmetric.MetricTypeSum:
+		m.Sum().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+			return fn(dp.Timestamp())
+		})
+	case pmetric.MetricTypeSummary:
+		m.Summary().DataPoints().RemoveIf(func(dp pmetric.SummaryDataPoint) bool {
+			return fn(dp.Timestamp())
+		})
+	case pmetric.MetricTypeHistogram:
+		m.Histogram().DataPoints().RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+			return fn(dp.Timestamp())
+		})
+	case pmetric.MetricTypeExponentialHistogram:
+		m.ExponentialHistogram().DataPoints().RemoveIf(func(dp pmetric.ExponentialHistogramDataPoint) bool {
+			return fn(dp.Timestamp())
+		})
+	}
+}
+
+func decorate(target pcommon.Map, src []config.Attribute) error {
+	var errs []error
+	for _, attr := range src {
+		if err := target.PutEmpty(attr.Key).FromRaw(attr.Value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to decorate late data point: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -54,7 +54,18 @@ func createMetricsProcessor(
 
 	intervalDefs := make([]intervalDef, 0, len(processorConfig.Intervals))
 	for _, ivl := range processorConfig.Intervals {
-		ivlDef := intervalDef{Duration: ivl.Duration}
+		loc, err := ivl.Location()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to load timezone for interval %s: %w",
+				ivl.Duration, err,
+			)
+		}
+		ivlDef := intervalDef{
+			Duration: ivl.Duration,
+			Offset:   ivl.Offset,
+			Location: loc,
+		}
 		if len(ivl.Statements) > 0 {
 			parser, err := ottldatapoint.NewParser(
 				ottlfuncs.StandardFuncs[*ottldatapoint.TransformContext](),
@@ -88,5 +99,7 @@ func createMetricsProcessor(
 
 type intervalDef struct {
 	Duration   time.Duration
+	Offset     time.Duration
+	Location   *time.Location
 	Statements *ottl.StatementSequence[*ottldatapoint.TransformContext]
 }
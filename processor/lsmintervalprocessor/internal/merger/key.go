@@ -25,6 +25,10 @@ import (
 	"time"
 )
 
+// KeyFormatVersion is the version of the binary representation of Key.
+// Version 0 encoded the interval in 16 bits, limiting it to ~18 hours.
+const KeyFormatVersion = 1
+
 type Key struct {
 	Interval       time.Duration
 	ProcessingTime time.Time
@@ -42,8 +46,8 @@ type KeyValues struct {
 // AppendBinary marshals the key into its binary representation,
 // appending it to b.
 func (k *Key) AppendBinary(b []byte) ([]byte, error) {
-	b = slices.Grow(b, 10)
-	b = binary.BigEndian.AppendUint16(b, uint16(k.Interval.Seconds()))
+	b = slices.Grow(b, 12)
+	b = binary.BigEndian.AppendUint32(b, uint32(k.Interval.Seconds()))
 	b = binary.BigEndian.AppendUint64(b, uint64(k.ProcessingTime.Unix()))
 	if len(k.Metadata) != 0 {
 		b = binary.AppendUvarint(b, uint64(len(k.Metadata)))
@@ -65,13 +69,26 @@ func (k *Key) AppendBinary(b []byte) ([]byte, error) {
 
 // Unmarshal unmarshals the binary representation of the Key.
 func (k *Key) Unmarshal(d []byte) error {
+	if len(d) < 12 {
+		return errors.New("failed to unmarshal key, invalid sized buffer provided")
+	}
+	k.Interval = time.Duration(binary.BigEndian.Uint32(d[:4])) * time.Second
+	k.ProcessingTime = time.Unix(int64(binary.BigEndian.Uint64(d[4:12])), 0)
+	return k.unmarshalMetadata(d[12:])
+}
+
+// UnmarshalV0 unmarshals the binary representation of the Key in format
+// version 0, used to migrate keys persisted by older versions.
+func (k *Key) UnmarshalV0(d []byte) error {
 	if len(d) < 10 {
 		return errors.New("failed to unmarshal key, invalid sized buffer provided")
 	}
 	k.Interval = time.Duration(binary.BigEndian.Uint16(d[:2])) * time.Second
 	k.ProcessingTime = time.Unix(int64(binary.BigEndian.Uint64(d[2:10])), 0)
+	return k.unmarshalMetadata(d[10:])
+}
 
-	d = d[10:]
+func (k *Key) unmarshalMetadata(d []byte) error {
 	if len(d) > 0 {
 		numKeys, n := binary.Uvarint(d)
 		if n <= 0 {
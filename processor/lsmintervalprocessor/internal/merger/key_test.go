@@ -45,6 +45,13 @@ func TestKey(t *testing.T) {
 				ProcessingTime: time.Unix(time.Now().Unix(), 0),
 			},
 		},
+		{
+			name: "daily",
+			key: Key{
+				Interval:       24 * time.Hour,
+				ProcessingTime: time.Unix(time.Now().Unix(), 0),
+			},
+		},
 		{
 			name: "with_metadata_keys",
 			key: Key{
@@ -95,3 +102,19 @@ func TestKeyOrdered(t *testing.T) {
 		before = after
 	}
 }
+
+func TestKeyUnmarshalV0(t *testing.T) {
+	key := Key{
+		Interval:       time.Hour,
+		ProcessingTime: time.Unix(time.Now().Unix(), 0),
+		Metadata:       []KeyValues{{Key: "k", Values: []string{"v"}}},
+	}
+	b, err := key.AppendBinary(nil)
+	require.NoError(t, err)
+	// Format version 0 encoded the interval in 16 bits instead of 32.
+	v0 := b[2:]
+
+	var newKey Key
+	require.NoError(t, newKey.UnmarshalV0(v0))
+	assert.Equal(t, key, newKey)
+}
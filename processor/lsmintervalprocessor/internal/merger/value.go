@@ -594,6 +594,7 @@ func (s *Value) addMetric(
 	mOrig.SetName(otherM.Name())
 	mOrig.SetDescription(otherM.Description())
 	mOrig.SetUnit(otherM.Unit())
+	otherM.Metadata().CopyTo(mOrig.Metadata())
 	switch otherM.Type() {
 	case pmetric.MetricTypeGauge:
 		mOrig.SetEmptyGauge()
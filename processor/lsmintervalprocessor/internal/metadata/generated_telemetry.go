@@ -47,6 +47,7 @@ type TelemetryBuilder struct {
 	registrations                             []metric.Registration
 	LsmintervalExportedBytes                  metric.Int64Counter
 	LsmintervalExportedDataPoints             metric.Int64Counter
+	LsmintervalLateDataPoints                 metric.Int64Counter
 	LsmintervalOverflow                       metric.Int64Counter
 	LsmintervalPebbleCompactedBytesRead       metric.Int64ObservableCounter
 	LsmintervalPebbleCompactedBytesWritten    metric.Int64ObservableCounter
@@ -327,6 +328,12 @@ func NewTelemetryBuilder(settings component.TelemetrySettings, options ...Teleme
 		metric.WithUnit("1"),
 	)
 	errs = errors.Join(errs, err)
+	builder.LsmintervalLateDataPoints, err = builder.meter.Int64Counter(
+		"otelcol_lsminterval.late_data_points",
+		metric.WithDescription("The count of metric data points that arrived after the aggregation window they fall into was harvested. [Development]"),
+		metric.WithUnit("1"),
+	)
+	errs = errors.Join(errs, err)
 	builder.LsmintervalOverflow, err = builder.meter.Int64Counter(
 		"otelcol_lsminterval.overflow",
 		metric.WithDescription("The estimated count of unique items that overflowed due to cardinality limits. [Development]"),
@@ -95,6 +95,7 @@ func TestSetupTelemetry(t *testing.T) {
 	}))
 	tb.LsmintervalExportedBytes.Add(context.Background(), 1)
 	tb.LsmintervalExportedDataPoints.Add(context.Background(), 1)
+	tb.LsmintervalLateDataPoints.Add(context.Background(), 1)
 	tb.LsmintervalOverflow.Add(context.Background(), 1)
 	tb.LsmintervalProcessedBytes.Add(context.Background(), 1)
 	tb.LsmintervalProcessedDataPoints.Add(context.Background(), 1)
@@ -104,6 +105,9 @@ func TestSetupTelemetry(t *testing.T) {
 	AssertEqualLsmintervalExportedDataPoints(t, testTel,
 		[]metricdata.DataPoint[int64]{{Value: 1}},
 		metricdatatest.IgnoreTimestamp())
+	AssertEqualLsmintervalLateDataPoints(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
 	AssertEqualLsmintervalOverflow(t, testTel,
 		[]metricdata.DataPoint[int64]{{Value: 1}},
 		metricdatatest.IgnoreTimestamp())
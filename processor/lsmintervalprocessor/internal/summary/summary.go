@@ -0,0 +1,313 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package summary approximates summary data points with exponential
+// histograms so that they can be merged like histograms, and estimates
+// quantiles from the merged exponential histograms.
+package summary // import "github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/summary"
+
+import (
+	"math"
+	"slices"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/data/expo"
+)
+
+// QuantilesAttributeKey is the data point attribute recording the quantiles
+// of a summary data point converted to an exponential histogram, marking it
+// to be converted back to a summary with the same quantiles by
+// [RestoreMetrics]. As an attribute it is part of the stream identity, so
+// only data points with the same quantiles are merged together.
+const QuantilesAttributeKey = "lsminterval.summary.quantiles"
+
+const (
+	// maxScale is the scale at which bucket indices are first computed
+	// before downscaling to respect the maximum number of buckets.
+	maxScale = 20
+	// minScale is the smallest scale defined by the data model.
+	minScale = -10
+)
+
+// ToExponentialHistogramMetric converts the summary metric from into an
+// exponential histogram metric with the given temporality. If
+// keepQuantiles is true the quantiles of each data point are recorded in
+// its attributes so that the metric can be restored.
+func ToExponentialHistogramMetric(
+	from, to pmetric.Metric,
+	temporality pmetric.AggregationTemporality,
+	maxBuckets int,
+	keepQuantiles bool,
+) {
+	to.SetName(from.Name())
+	to.SetDescription(from.Description())
+	to.SetUnit(from.Unit())
+	from.Metadata().CopyTo(to.Metadata())
+
+	fromDPs := from.Summary().DataPoints()
+	exp := to.SetEmptyExponentialHistogram()
+	exp.SetAggregationTemporality(temporality)
+	exp.DataPoints().EnsureCapacity(fromDPs.Len())
+	for i := 0; i < fromDPs.Len(); i++ {
+		dp := exp.DataPoints().AppendEmpty()
+		ToExponentialHistogram(fromDPs.At(i), dp, maxBuckets)
+		if keepQuantiles {
+			qvs := sortedQuantileValues(fromDPs.At(i))
+			quantiles := dp.Attributes().PutEmptySlice(QuantilesAttributeKey)
+			quantiles.EnsureCapacity(len(qvs))
+			for _, qv := range qvs {
+				quantiles.AppendEmpty().SetDouble(qv.Quantile())
+			}
+		}
+	}
+}
+
+// ToExponentialHistogram approximates the summary data point from with an
+// exponential histogram of at most maxBuckets buckets per range. Count and
+// sum are kept as is. The observations between two consecutive quantiles
+// are attributed to the value of the upper quantile, and those above the
+// highest quantile to its value. Without quantiles all observations are
+// attributed to the mean.
+func ToExponentialHistogram(from pmetric.SummaryDataPoint, to pmetric.ExponentialHistogramDataPoint, maxBuckets int) {
+	from.Attributes().CopyTo(to.Attributes())
+	to.SetStartTimestamp(from.StartTimestamp())
+	to.SetTimestamp(from.Timestamp())
+	to.SetFlags(from.Flags())
+	to.SetCount(from.Count())
+	to.SetSum(from.Sum())
+
+	count := from.Count()
+	if count == 0 {
+		return
+	}
+
+	type mass struct {
+		value float64
+		count uint64
+	}
+	qvs := sortedQuantileValues(from)
+	masses := make([]mass, 0, len(qvs)+1)
+	var rank uint64
+	for _, qv := range qvs {
+		next := min(uint64(math.Round(qv.Quantile()*float64(count))), count)
+		if next > rank {
+			masses = append(masses, mass{value: qv.Value(), count: next - rank})
+			rank = next
+		}
+	}
+	if rank < count {
+		v := from.Sum() / float64(count)
+		if len(qvs) > 0 {
+			v = qvs[len(qvs)-1].Value()
+		}
+		masses = append(masses, mass{value: v, count: count - rank})
+	}
+	if len(qvs) > 0 {
+		if first := qvs[0]; first.Quantile() == 0 {
+			to.SetMin(first.Value())
+		}
+		if last := qvs[len(qvs)-1]; last.Quantile() == 1 {
+			to.SetMax(last.Value())
+		}
+	}
+
+	// Compute the bucket indices at the max scale and downscale until
+	// both the positive and the negative range fit the max buckets.
+	posMin, posMax := math.MaxInt, math.MinInt
+	negMin, negMax := math.MaxInt, math.MinInt
+	for _, m := range masses {
+		switch {
+		case m.value > 0:
+			idx := expo.Scale(maxScale).Idx(m.value)
+			posMin, posMax = min(posMin, idx), max(posMax, idx)
+		case m.value < 0:
+			idx := expo.Scale(maxScale).Idx(-m.value)
+			negMin, negMax = min(negMin, idx), max(negMax, idx)
+		}
+	}
+	width := func(lo, hi, shift int) int {
+		if lo > hi {
+			return 0
+		}
+		return (hi >> shift) - (lo >> shift) + 1
+	}
+	var shift int
+	for shift < maxScale-minScale &&
+		(width(posMin, posMax, shift) > maxBuckets || width(negMin, negMax, shift) > maxBuckets) {
+		shift++
+	}
+	to.SetScale(int32(maxScale - shift))
+
+	fill := func(buckets pmetric.ExponentialHistogramDataPointBuckets, lo, hi int) {
+		if n := width(lo, hi, shift); n > 0 {
+			buckets.SetOffset(int32(lo >> shift))
+			buckets.BucketCounts().FromRaw(make([]uint64, n))
+		}
+	}
+	fill(to.Positive(), posMin, posMax)
+	fill(to.Negative(), negMin, negMax)
+	add := func(buckets pmetric.ExponentialHistogramDataPointBuckets, v float64, n uint64) {
+		i := (expo.Scale(maxScale).Idx(v) >> shift) - int(buckets.Offset())
+		buckets.BucketCounts().SetAt(i, buckets.BucketCounts().At(i)+n)
+	}
+	for _, m := range masses {
+		switch {
+		case m.value > 0:
+			add(to.Positive(), m.value, m.count)
+		case m.value < 0:
+			add(to.Negative(), -m.value, m.count)
+		default:
+			to.SetZeroCount(to.ZeroCount() + m.count)
+		}
+	}
+}
+
+// RestoreMetrics converts the exponential histogram metrics converted by
+// [ToExponentialHistogramMetric] with kept quantiles back to summaries.
+func RestoreMetrics(md pmetric.Metrics) {
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				restoreMetric(ms.At(k))
+			}
+		}
+	}
+}
+
+func restoreMetric(m pmetric.Metric) {
+	if m.Type() != pmetric.MetricTypeExponentialHistogram {
+		return
+	}
+	expDPs := m.ExponentialHistogram().DataPoints()
+	if expDPs.Len() == 0 {
+		return
+	}
+	if _, ok := expDPs.At(0).Attributes().Get(QuantilesAttributeKey); !ok {
+		return
+	}
+
+	tmp := pmetric.NewExponentialHistogramDataPointSlice()
+	expDPs.MoveAndAppendTo(tmp)
+	dps := m.SetEmptySummary().DataPoints()
+	dps.EnsureCapacity(tmp.Len())
+	for i := 0; i < tmp.Len(); i++ {
+		expDP := tmp.At(i)
+		var quantiles []float64
+		if v, ok := expDP.Attributes().Get(QuantilesAttributeKey); ok && v.Type() == pcommon.ValueTypeSlice {
+			quantiles = make([]float64, v.Slice().Len())
+			for j := range quantiles {
+				quantiles[j] = v.Slice().At(j).Double()
+			}
+		}
+		expDP.Attributes().Remove(QuantilesAttributeKey)
+		ToSummary(expDP, quantiles, dps.AppendEmpty())
+	}
+}
+
+// ToSummary converts the exponential histogram data point from to a summary
+// data point with the given quantiles estimated from the histogram buckets.
+func ToSummary(from pmetric.ExponentialHistogramDataPoint, quantiles []float64, to pmetric.SummaryDataPoint) {
+	from.Attributes().CopyTo(to.Attributes())
+	to.SetStartTimestamp(from.StartTimestamp())
+	to.SetTimestamp(from.Timestamp())
+	to.SetFlags(from.Flags())
+	to.SetCount(from.Count())
+	to.SetSum(from.Sum())
+	if from.Count() == 0 {
+		return
+	}
+	qvs := to.QuantileValues()
+	qvs.EnsureCapacity(len(quantiles))
+	for _, q := range quantiles {
+		qv := qvs.AppendEmpty()
+		qv.SetQuantile(q)
+		qv.SetValue(Quantile(from, q))
+	}
+}
+
+// Quantile estimates the q-quantile of the exponential histogram data point
+// as the midpoint of the bucket containing it, bounded by min and max.
+func Quantile(dp pmetric.ExponentialHistogramDataPoint, q float64) float64 {
+	switch {
+	case q <= 0 && dp.HasMin():
+		return dp.Min()
+	case q >= 1 && dp.HasMax():
+		return dp.Max()
+	}
+
+	v := quantile(dp, max(q*float64(dp.Count()), 1))
+	if dp.HasMin() {
+		v = max(v, dp.Min())
+	}
+	if dp.HasMax() {
+		v = min(v, dp.Max())
+	}
+	return v
+}
+
+func quantile(dp pmetric.ExponentialHistogramDataPoint, rank float64) float64 {
+	scale := expo.Scale(dp.Scale())
+	midpoint := func(idx int) float64 {
+		lo, hi := scale.Bounds(idx)
+		return (lo + hi) / 2
+	}
+
+	var seen uint64
+	neg := dp.Negative()
+	for i := neg.BucketCounts().Len() - 1; i >= 0; i-- {
+		if seen += neg.BucketCounts().At(i); float64(seen) >= rank {
+			return -midpoint(int(neg.Offset()) + i)
+		}
+	}
+	if seen += dp.ZeroCount(); float64(seen) >= rank {
+		return 0
+	}
+	pos := dp.Positive()
+	for i := 0; i < pos.BucketCounts().Len(); i++ {
+		if seen += pos.BucketCounts().At(i); float64(seen) >= rank {
+			return midpoint(int(pos.Offset()) + i)
+		}
+	}
+	// The bucket counts don't add up to the count, fall back to the
+	// highest bucket.
+	if n := pos.BucketCounts().Len(); n > 0 {
+		return midpoint(int(pos.Offset()) + n - 1)
+	}
+	return 0
+}
+
+func sortedQuantileValues(dp pmetric.SummaryDataPoint) []pmetric.SummaryDataPointValueAtQuantile {
+	qvs := make([]pmetric.SummaryDataPointValueAtQuantile, dp.QuantileValues().Len())
+	for i := range qvs {
+		qvs[i] = dp.QuantileValues().At(i)
+	}
+	slices.SortFunc(qvs, func(a, b pmetric.SummaryDataPointValueAtQuantile) int {
+		switch {
+		case a.Quantile() < b.Quantile():
+			return -1
+		case a.Quantile() > b.Quantile():
+			return 1
+		}
+		return 0
+	})
+	return qvs
+}
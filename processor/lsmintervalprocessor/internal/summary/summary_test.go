@@ -0,0 +1,159 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package summary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestToExponentialHistogram(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		count      uint64
+		sum        float64
+		quantiles  map[float64]float64
+		maxBuckets int
+	}{
+		{
+			name:       "no_quantiles",
+			count:      10,
+			sum:        50,
+			maxBuckets: 160,
+		},
+		{
+			name:       "positive",
+			count:      100,
+			sum:        2500,
+			quantiles:  map[float64]float64{0: 1, 0.5: 20, 0.9: 60, 0.99: 90, 1: 100},
+			maxBuckets: 160,
+		},
+		{
+			name:       "negative_and_zero",
+			count:      100,
+			sum:        -500,
+			quantiles:  map[float64]float64{0: -80, 0.25: -10, 0.5: 0, 0.75: 10, 1: 30},
+			maxBuckets: 160,
+		},
+		{
+			name:       "few_buckets",
+			count:      1000,
+			sum:        1e6,
+			quantiles:  map[float64]float64{0.5: 0.001, 0.99: 1e6},
+			maxBuckets: 4,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			from := pmetric.NewSummaryDataPoint()
+			from.SetCount(tc.count)
+			from.SetSum(tc.sum)
+			from.Attributes().PutStr("k", "v")
+			for q, v := range tc.quantiles {
+				qv := from.QuantileValues().AppendEmpty()
+				qv.SetQuantile(q)
+				qv.SetValue(v)
+			}
+
+			to := pmetric.NewExponentialHistogramDataPoint()
+			ToExponentialHistogram(from, to, tc.maxBuckets)
+			assert.Equal(t, tc.count, to.Count())
+			assert.Equal(t, tc.sum, to.Sum())
+			assert.Equal(t, from.Attributes().AsRaw(), to.Attributes().AsRaw())
+			assert.LessOrEqual(t, to.Positive().BucketCounts().Len(), tc.maxBuckets)
+			assert.LessOrEqual(t, to.Negative().BucketCounts().Len(), tc.maxBuckets)
+
+			var total uint64
+			for _, bcs := range []pmetric.ExponentialHistogramDataPointBuckets{to.Positive(), to.Negative()} {
+				for i := 0; i < bcs.BucketCounts().Len(); i++ {
+					total += bcs.BucketCounts().At(i)
+				}
+			}
+			assert.Equal(t, tc.count, total+to.ZeroCount())
+
+			if tc.maxBuckets < 160 {
+				return
+			}
+			// Quantiles estimated from the histogram are within the
+			// relative error of the buckets.
+			for q, v := range tc.quantiles {
+				assert.InEpsilon(t, v+1, Quantile(to, q)+1, 0.01, "quantile %v", q)
+			}
+		})
+	}
+}
+
+func TestRestoreMetrics(t *testing.T) {
+	// Each data point keeps its own quantiles.
+	quantiles := [][]float64{{0.5, 0.9}, {0.99}}
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("summary")
+	m.SetUnit("ms")
+	summary := m.SetEmptySummary()
+	for i, v := range []float64{10, 100} {
+		dp := summary.DataPoints().AppendEmpty()
+		dp.Attributes().PutInt("i", int64(i))
+		dp.SetCount(10)
+		dp.SetSum(10 * v)
+		for _, q := range quantiles[i] {
+			qv := dp.QuantileValues().AppendEmpty()
+			qv.SetQuantile(q)
+			qv.SetValue(v)
+		}
+	}
+
+	exp := pmetric.NewMetric()
+	ToExponentialHistogramMetric(m, exp, pmetric.AggregationTemporalityDelta, 160, true)
+	require.Equal(t, pmetric.MetricTypeExponentialHistogram, exp.Type())
+	assert.Equal(t, pmetric.AggregationTemporalityDelta, exp.ExponentialHistogram().AggregationTemporality())
+	assert.Equal(t, 2, exp.ExponentialHistogram().DataPoints().Len())
+
+	restored := pmetric.NewMetrics()
+	exp.CopyTo(restored.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty())
+	RestoreMetrics(restored)
+	actual := restored.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	require.Equal(t, pmetric.MetricTypeSummary, actual.Type())
+	assert.Equal(t, "summary", actual.Name())
+	assert.Equal(t, "ms", actual.Unit())
+
+	dps := actual.Summary().DataPoints()
+	require.Equal(t, 2, dps.Len())
+	for i, v := range []float64{10, 100} {
+		dp := dps.At(i)
+		assert.Equal(t, map[string]any{"i": int64(i)}, dp.Attributes().AsRaw())
+		assert.Equal(t, uint64(10), dp.Count())
+		assert.Equal(t, 10*v, dp.Sum())
+		require.Equal(t, len(quantiles[i]), dp.QuantileValues().Len())
+		for j, q := range quantiles[i] {
+			assert.Equal(t, q, dp.QuantileValues().At(j).Quantile())
+			assert.InEpsilon(t, v, dp.QuantileValues().At(j).Value(), 0.01)
+		}
+	}
+}
+
+func TestRestoreMetrics_NotConverted(t *testing.T) {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetEmptyExponentialHistogram().DataPoints().AppendEmpty().SetCount(1)
+
+	RestoreMetrics(md)
+	assert.Equal(t, pmetric.MetricTypeExponentialHistogram, m.Type())
+}
@@ -0,0 +1,123 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lsmintervalprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor"
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/merger"
+)
+
+// keyFormatVersionKey is the database key holding the format version of the
+// keys in the database. Keys encoded by merger.Key are at least 12 bytes
+// long, so it never collides with them and sorts before all of them.
+var keyFormatVersionKey = []byte{0}
+
+// migrateKeys upgrades the keys in db to merger.KeyFormatVersion. A database
+// without a format version was written by a version of the processor using
+// format version 0, and its keys are re-encoded in place.
+func migrateKeys(db *pebble.DB, wOpts *pebble.WriteOptions, logger *zap.Logger) error {
+	version, err := keyFormatVersion(db)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version == merger.KeyFormatVersion:
+		return nil
+	case version > merger.KeyFormatVersion:
+		return fmt.Errorf("unsupported key format version %d, expected at most %d", version, merger.KeyFormatVersion)
+	}
+
+	batch := db.NewBatch()
+	defer batch.Close()
+	iter, err := db.NewIter(&pebble.IterOptions{KeyTypes: pebble.IterKeyTypePointsOnly})
+	if err != nil {
+		return fmt.Errorf("failed to create iterator: %w", err)
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+
+	// All the old keys are deleted before any new key is written, so that
+	// a re-encoded key never gets deleted as an old one.
+	var migrated int
+	for iter.First(); iter.Valid(); iter.Next() {
+		if err := batch.Delete(iter.Key(), nil); err != nil {
+			return fmt.Errorf("failed to delete key: %w", err)
+		}
+		migrated++
+	}
+	var buf []byte
+	for iter.First(); iter.Valid(); iter.Next() {
+		var key merger.Key
+		if err := key.UnmarshalV0(iter.Key()); err != nil {
+			return fmt.Errorf("failed to decode key in format version 0: %w", err)
+		}
+		buf, err = key.AppendBinary(buf[:0])
+		if err != nil {
+			return fmt.Errorf("failed to encode key: %w", err)
+		}
+		value, err := iter.ValueAndErr()
+		if err != nil {
+			return fmt.Errorf("failed to read value: %w", err)
+		}
+		if err := batch.Set(buf, value, nil); err != nil {
+			return fmt.Errorf("failed to set key: %w", err)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("failed to iterate keys: %w", err)
+	}
+	if err := batch.Set(keyFormatVersionKey, []byte{merger.KeyFormatVersion}, nil); err != nil {
+		return fmt.Errorf("failed to set key format version: %w", err)
+	}
+	if err := batch.Commit(wOpts); err != nil {
+		return fmt.Errorf("failed to commit migrated keys: %w", err)
+	}
+	if migrated > 0 {
+		logger.Info(
+			"migrated database keys",
+			zap.Int("keys", migrated),
+			zap.Int("from_version", version),
+			zap.Int("to_version", merger.KeyFormatVersion),
+		)
+	}
+	return nil
+}
+
+// keyFormatVersion returns the format version of the keys in db.
+func keyFormatVersion(db *pebble.DB) (int, error) {
+	v, closer, err := db.Get(keyFormatVersionKey)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read key format version: %w", err)
+	}
+	defer func() {
+		_ = closer.Close()
+	}()
+	if len(v) != 1 {
+		return 0, fmt.Errorf("invalid key format version of %d bytes", len(v))
+	}
+	return int(v[0]), nil
+}
@@ -0,0 +1,81 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lsmintervalprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/merger"
+)
+
+func TestMigrateKeys(t *testing.T) {
+	db, err := pebble.Open("/data", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	keys := []merger.Key{
+		{Interval: time.Minute, ProcessingTime: time.Unix(60, 0)},
+		{
+			Interval:       time.Hour,
+			ProcessingTime: time.Unix(3600, 0),
+			Metadata:       []merger.KeyValues{{Key: "k", Values: []string{"v"}}},
+		},
+	}
+	for i, key := range keys {
+		b, err := key.AppendBinary(nil)
+		require.NoError(t, err)
+		// Format version 0 encoded the interval in 16 bits instead of 32.
+		require.NoError(t, db.Set(b[2:], []byte{byte(i)}, pebble.Sync))
+	}
+
+	require.NoError(t, migrateKeys(db, pebble.Sync, zap.NewNop()))
+	version, err := keyFormatVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, merger.KeyFormatVersion, version)
+
+	var migrated []merger.Key
+	iter, err := db.NewIter(&pebble.IterOptions{LowerBound: []byte{0, 0}})
+	require.NoError(t, err)
+	for iter.First(); iter.Valid(); iter.Next() {
+		var key merger.Key
+		require.NoError(t, key.Unmarshal(iter.Key()))
+		assert.Equal(t, []byte{byte(len(migrated))}, iter.Value())
+		migrated = append(migrated, key)
+	}
+	require.NoError(t, iter.Close())
+	assert.Equal(t, keys, migrated)
+
+	// Migrating again is a no-op.
+	require.NoError(t, migrateKeys(db, pebble.Sync, zap.NewNop()))
+}
+
+func TestMigrateKeys_UnsupportedVersion(t *testing.T) {
+	db, err := pebble.Open("/data", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Set(keyFormatVersionKey, []byte{merger.KeyFormatVersion + 1}, pebble.Sync))
+	assert.ErrorContains(t, migrateKeys(db, pebble.Sync, zap.NewNop()), "unsupported key format version")
+}
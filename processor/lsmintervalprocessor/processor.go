@@ -40,6 +40,7 @@ import (
 	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/config"
 	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/merger"
 	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/metadata"
+	"github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor/internal/summary"
 )
 
 var _ processor.Metrics = (*Processor)(nil)
@@ -77,6 +78,13 @@ const (
 	dbCommitThresholdBytes = 8 << 20 // 8MB
 )
 
+var (
+	// minProcessingTime and maxProcessingTime bound the processing times
+	// of all keys in the database.
+	minProcessingTime = time.Unix(0, 0)
+	maxProcessingTime = time.Unix(1<<62, 0)
+)
+
 type Processor struct {
 	telemetryBuilder   *metadata.TelemetryBuilder
 	cfg                *config.Config
@@ -90,10 +98,14 @@ type Processor struct {
 	intervals  []intervalDef
 	next       consumer.Metrics
 	bufferPool sync.Pool
+	// lateness delays the harvest of windows in event-time mode.
+	lateness time.Duration
 
-	mu             sync.Mutex
-	batch          *pebble.Batch
-	processingTime time.Time
+	mu    sync.Mutex
+	batch *pebble.Batch
+	// windows holds the current aggregation window for each interval,
+	// in the same order as intervals.
+	windows []window
 
 	ctx           context.Context
 	cancel        context.CancelFunc
@@ -146,6 +158,16 @@ func newProcessor(
 	sortedMetadataKeys := append([]string{}, cfg.MetadataKeys...)
 	sort.Strings(sortedMetadataKeys)
 
+	var lateness time.Duration
+	if cfg.EventTime.Enabled {
+		lateness = cfg.EventTime.AllowedLateness
+	}
+	watermark := time.Now().Add(-lateness)
+	windows := make([]window, len(ivlDefs))
+	for i, ivl := range ivlDefs {
+		windows[i] = ivl.windowAt(watermark)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Processor{
 		telemetryBuilder:   telemetryBuilder,
@@ -156,7 +178,8 @@ func newProcessor(
 		wOpts:              writeOpts,
 		intervals:          ivlDefs,
 		next:               next,
-		processingTime:     time.Now().UTC().Truncate(ivlDefs[0].Duration),
+		lateness:           lateness,
+		windows:            windows,
 		ctx:                ctx,
 		cancel:             cancel,
 		logger:             log,
@@ -168,8 +191,14 @@ func (p *Processor) Start(ctx context.Context, host component.Host) error {
 	if p.db == nil {
 		db, err := pebble.Open(p.dataDir, p.dbOpts)
 		if err != nil {
+			p.mu.Unlock()
 			return fmt.Errorf("failed to open database: %w", err)
 		}
+		if err := migrateKeys(db, p.wOpts, p.logger); err != nil {
+			p.mu.Unlock()
+			_ = db.Close()
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
 		p.db = db
 	}
 
@@ -180,8 +209,7 @@ func (p *Processor) Start(ctx context.Context, host component.Host) error {
 
 	go func() {
 		defer close(p.exportStopped)
-		to := p.processingTime.Add(p.intervals[0].Duration)
-		timer := time.NewTimer(time.Until(to))
+		timer := time.NewTimer(time.Until(p.nextHarvest()))
 		defer timer.Stop()
 
 		for {
@@ -191,24 +219,58 @@ func (p *Processor) Start(ctx context.Context, host component.Host) error {
 			case <-timer.C:
 			}
 
+			// Swap out the batch and advance every interval whose window
+			// has been passed by the watermark, collecting the passed
+			// windows for export.
+			now := time.Now()
+			watermark := now.Add(-p.lateness)
+			var due []intervalWindow
 			p.mu.Lock()
 			batch := p.batch
 			p.batch = nil
-			p.processingTime = to
+			for i, ivl := range p.intervals {
+				if w := p.windows[i]; !w.end.After(watermark) {
+					if p.cfg.EventTime.Enabled {
+						// Data points are merged into the windows of their
+						// own timestamps, so any earlier windows are due too.
+						w.start = minProcessingTime
+					}
+					due = append(due, intervalWindow{ivl: ivl, window: w})
+					p.windows[i] = ivl.windowAt(watermark)
+				}
+			}
+			next := p.nextHarvestLocked()
 			p.mu.Unlock()
 
 			// Export the batch
-			if err := p.commitAndExport(p.ctx, batch, to); err != nil {
-				p.logger.Warn("failed to export", zap.Error(err), zap.Time("end_time", to))
+			if err := p.commitAndExport(p.ctx, batch, due); err != nil {
+				p.logger.Warn("failed to export", zap.Error(err), zap.Time("harvest_time", now))
 			}
 
-			to = to.Add(p.intervals[0].Duration)
-			timer.Reset(time.Until(to))
+			timer.Reset(time.Until(next))
 		}
 	}()
 	return p.registerPebbleMetrics()
 }
 
+// nextHarvest returns the time at which the watermark passes the earliest
+// end of the current interval windows.
+func (p *Processor) nextHarvest() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nextHarvestLocked()
+}
+
+func (p *Processor) nextHarvestLocked() time.Time {
+	next := p.windows[0].end
+	for _, w := range p.windows[1:] {
+		if w.end.Before(next) {
+			next = w.end
+		}
+	}
+	return next.Add(p.lateness)
+}
+
 func (p *Processor) Shutdown(ctx context.Context) error {
 	defer p.logger.Info("shutdown finished")
 	// Signal stop for the exporting goroutine
@@ -241,20 +303,19 @@ func (p *Processor) Shutdown(ctx context.Context) error {
 			p.batch = nil
 		}
 
-		var errs []error
-		for _, ivl := range p.intervals {
-			// At any particular time there will be 1 export candidate for
-			// each aggregation interval. We will align the end time and
-			// process each of these.
-			to := p.processingTime.Truncate(ivl.Duration).Add(ivl.Duration)
-			if err := p.export(ctx, to); err != nil {
-				errs = append(errs, fmt.Errorf(
-					"failed to export metrics for interval %s: %w", ivl.Duration, err),
-				)
+		// In processing-time mode there will be 1 export candidate for
+		// each aggregation interval, i.e. its current window. In event-time
+		// mode all the windows still kept open are exported.
+		due := make([]intervalWindow, len(p.intervals))
+		for i, ivl := range p.intervals {
+			w := p.windows[i]
+			if p.cfg.EventTime.Enabled {
+				w = window{start: minProcessingTime, end: maxProcessingTime}
 			}
+			due[i] = intervalWindow{ivl: ivl, window: w}
 		}
-		if len(errs) > 0 {
-			return fmt.Errorf("failed while running final export: %w", errors.Join(errs...))
+		if err := p.export(ctx, due); err != nil {
+			return fmt.Errorf("failed while running final export: %w", err)
 		}
 		if err := p.db.Close(); err != nil {
 			return fmt.Errorf("failed to close database: %w", err)
@@ -273,13 +334,11 @@ func (p *Processor) Capabilities() consumer.Capabilities {
 }
 
 func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
-	v := merger.NewValue(
-		p.cfg.ResourceLimit,
-		p.cfg.ScopeLimit,
-		p.cfg.MetricLimit,
-		p.cfg.DatapointLimit,
-		p.cfg.ExponentialHistogramMaxBuckets,
-	)
+	v := p.newValue()
+	var buckets *eventTimeBuckets
+	if p.cfg.EventTime.Enabled {
+		buckets = p.newEventTimeBuckets(time.Now())
+	}
 
 	var errs []error
 	nextMD := pmetric.NewMetrics()
@@ -291,6 +350,25 @@ func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) erro
 		for i := 0; i < sms.Len(); i++ {
 			var nextMDScopeMetrics pmetric.ScopeMetrics
 			sm := sms.At(i)
+			appendNext := func() pmetric.Metric {
+				if nextMDScopeMetrics == (pmetric.ScopeMetrics{}) {
+					if nextMDResourceMetrics == (pmetric.ResourceMetrics{}) {
+						nextMDResourceMetrics = nextMD.ResourceMetrics().AppendEmpty()
+						rm.Resource().CopyTo(nextMDResourceMetrics.Resource())
+						nextMDResourceMetrics.SetSchemaUrl(rm.SchemaUrl())
+					}
+					nextMDScopeMetrics = nextMDResourceMetrics.ScopeMetrics().AppendEmpty()
+					sm.Scope().CopyTo(nextMDScopeMetrics.Scope())
+					nextMDScopeMetrics.SetSchemaUrl(sm.SchemaUrl())
+				}
+				return nextMDScopeMetrics.Metrics().AppendEmpty()
+			}
+			merge := func(m pmetric.Metric) error {
+				if buckets != nil {
+					return buckets.mergeMetric(rm, sm, m, appendNext)
+				}
+				return p.mergeMetric(v, rm, sm, m)
+			}
 			ms := sm.Metrics()
 			for i := 0; i < ms.Len(); i++ {
 				m := ms.At(i)
@@ -305,12 +383,12 @@ func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) erro
 						// Copy across to nextMD below.
 						break
 					}
-					if err := v.MergeMetric(rm, sm, m); err != nil {
+					if err := merge(m); err != nil {
 						errs = append(errs, err)
 					}
 					continue
 				case pmetric.MetricTypeSum, pmetric.MetricTypeHistogram, pmetric.MetricTypeExponentialHistogram:
-					if err := v.MergeMetric(rm, sm, m); err != nil {
+					if err := merge(m); err != nil {
 						errs = append(errs, err)
 					}
 					continue
@@ -319,18 +397,7 @@ func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) erro
 					errs = append(errs, fmt.Errorf("unexpected metric type, dropping: %d", t))
 					continue
 				}
-
-				if nextMDScopeMetrics == (pmetric.ScopeMetrics{}) {
-					if nextMDResourceMetrics == (pmetric.ResourceMetrics{}) {
-						nextMDResourceMetrics = nextMD.ResourceMetrics().AppendEmpty()
-						rm.Resource().CopyTo(nextMDResourceMetrics.Resource())
-						nextMDResourceMetrics.SetSchemaUrl(rm.SchemaUrl())
-					}
-					nextMDScopeMetrics = nextMDResourceMetrics.ScopeMetrics().AppendEmpty()
-					sm.Scope().CopyTo(nextMDScopeMetrics.Scope())
-					nextMDScopeMetrics.SetSchemaUrl(sm.SchemaUrl())
-				}
-				m.CopyTo(nextMDScopeMetrics.Metrics().AppendEmpty())
+				m.CopyTo(appendNext())
 			}
 		}
 	}
@@ -341,12 +408,6 @@ func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) erro
 	}
 	defer p.bufferPool.Put(mb)
 
-	var err error
-	mb.value, err = v.AppendBinary(mb.value[:0])
-	if err != nil {
-		return errors.Join(append(errs, fmt.Errorf("failed to marshal value to proto binary: %w", err))...)
-	}
-
 	clientInfo := client.FromContext(ctx)
 	clientMetadata := make([]merger.KeyValues, 0, len(p.sortedMetadataKeys))
 	attributes := make([]attribute.KeyValue, 0, len(p.sortedMetadataKeys))
@@ -360,8 +421,36 @@ func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) erro
 		}
 	}
 
-	if err := p.mergeToBatch(mb, clientMetadata); err != nil {
-		return fmt.Errorf("failed to merge the value to batch: %w", err)
+	var mergedBytes int
+	if buckets != nil {
+		n, err := p.mergeBucketsToBatch(mb, buckets, clientMetadata)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		mergedBytes = n
+		for i, late := range buckets.late {
+			if late == 0 {
+				continue
+			}
+			p.telemetryBuilder.LsmintervalLateDataPoints.Add(
+				ctx,
+				late,
+				metric.WithAttributes(
+					attribute.String("action", string(p.cfg.EventTime.LateData.Action)),
+					attribute.String("interval", p.intervals[i].Duration.String()),
+				),
+			)
+		}
+	} else {
+		var err error
+		mb.value, err = v.AppendBinary(mb.value[:0])
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("failed to marshal value to proto binary: %w", err))...)
+		}
+		if err := p.mergeToBatch(mb, clientMetadata); err != nil {
+			return fmt.Errorf("failed to merge the value to batch: %w", err)
+		}
+		mergedBytes = len(mb.value) + len(mb.key)
 	}
 
 	p.telemetryBuilder.LsmintervalProcessedDataPoints.Add(
@@ -371,7 +460,7 @@ func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) erro
 	)
 	p.telemetryBuilder.LsmintervalProcessedBytes.Add(
 		ctx,
-		int64(len(mb.value)+len(mb.key)),
+		int64(mergedBytes),
 		metric.WithAttributes(attributes...),
 	)
 
@@ -388,6 +477,33 @@ func (p *Processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) erro
 	return nil
 }
 
+// mergeMetric merges the metric into the value, converting summaries to
+// exponential histograms first if configured.
+func (p *Processor) mergeMetric(
+	v *merger.Value,
+	rm pmetric.ResourceMetrics,
+	sm pmetric.ScopeMetrics,
+	m pmetric.Metric,
+) error {
+	if m.Type() == pmetric.MetricTypeSummary {
+		switch mode := p.cfg.SummaryAggregation.Mode; mode {
+		case config.SummaryAggregationModeQuantiles, config.SummaryAggregationModeExponentialHistogram:
+			temporality, err := p.cfg.SummaryAggregation.AggregationTemporality()
+			if err != nil {
+				return err
+			}
+			exp := pmetric.NewMetric()
+			summary.ToExponentialHistogramMetric(
+				m, exp, temporality,
+				p.cfg.ExponentialHistogramMaxBuckets,
+				mode == config.SummaryAggregationModeQuantiles,
+			)
+			m = exp
+		}
+	}
+	return v.MergeMetric(rm, sm, m)
+}
+
 func (p *Processor) mergeToBatch(mb *mergeBuffer, clientMetadata []merger.KeyValues) (err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -396,10 +512,10 @@ func (p *Processor) mergeToBatch(mb *mergeBuffer, clientMetadata []merger.KeyVal
 		p.batch = newBatch(p.db)
 	}
 
-	for _, ivl := range p.intervals {
+	for i, ivl := range p.intervals {
 		key := merger.Key{
 			Interval:       ivl.Duration,
-			ProcessingTime: p.processingTime,
+			ProcessingTime: p.windows[i].start,
 			Metadata:       clientMetadata,
 		}
 		var err error
@@ -412,6 +528,52 @@ func (p *Processor) mergeToBatch(mb *mergeBuffer, clientMetadata []merger.KeyVal
 		}
 	}
 
+	return p.commitFullBatchLocked()
+}
+
+// mergeBucketsToBatch merges the event-time windows aggregated in the buckets
+// to the batch and returns the number of bytes merged.
+func (p *Processor) mergeBucketsToBatch(
+	mb *mergeBuffer,
+	buckets *eventTimeBuckets,
+	clientMetadata []merger.KeyValues,
+) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.batch == nil {
+		p.batch = newBatch(p.db)
+	}
+
+	var n int
+	for i, ivl := range p.intervals {
+		for start, v := range buckets.values[i] {
+			var err error
+			mb.value, err = v.AppendBinary(mb.value[:0])
+			if err != nil {
+				return n, fmt.Errorf("failed to marshal value to proto binary: %w", err)
+			}
+			key := merger.Key{
+				Interval:       ivl.Duration,
+				ProcessingTime: time.Unix(start, 0),
+				Metadata:       clientMetadata,
+			}
+			mb.key, err = key.AppendBinary(mb.key[:0])
+			if err != nil {
+				return n, fmt.Errorf("failed to marshal key to binary for ivl %s: %w", ivl.Duration, err)
+			}
+			if err := p.batch.Merge(mb.key, mb.value, nil); err != nil {
+				return n, fmt.Errorf("failed to merge to db: %w", err)
+			}
+			n += len(mb.key) + len(mb.value)
+		}
+	}
+	return n, p.commitFullBatchLocked()
+}
+
+// commitFullBatchLocked commits the batch once it crosses the commit
+// threshold. The caller must hold the mutex.
+func (p *Processor) commitFullBatchLocked() error {
 	if p.batch.Len() >= dbCommitThresholdBytes {
 		if err := p.batch.Commit(p.wOpts); err != nil {
 			return fmt.Errorf("failed to commit a batch to db: %w", err)
@@ -429,10 +591,16 @@ type mergeBuffer struct {
 	value []byte
 }
 
-// commitAndExport commits the batch to DB and exports all aggregated metrics in the provided range
-// bounded by `to. If the batch is not committed then a corresponding error would be returned however
+// intervalWindow is an aggregation window of a specific interval.
+type intervalWindow struct {
+	ivl    intervalDef
+	window window
+}
+
+// commitAndExport commits the batch to DB and exports all aggregated metrics in the provided
+// windows. If the batch is not committed then a corresponding error would be returned however
 // exports will still proceed.
-func (p *Processor) commitAndExport(ctx context.Context, batch *pebble.Batch, to time.Time) error {
+func (p *Processor) commitAndExport(ctx context.Context, batch *pebble.Batch, due []intervalWindow) error {
 	var errs []error
 	if batch != nil {
 		if err := batch.Commit(p.wOpts); err != nil {
@@ -442,7 +610,7 @@ func (p *Processor) commitAndExport(ctx context.Context, batch *pebble.Batch, to
 			errs = append(errs, fmt.Errorf("failed to close batch before export: %w", err))
 		}
 	}
-	if err := p.export(ctx, to); err != nil {
+	if err := p.export(ctx, due); err != nil {
 		errs = append(errs, fmt.Errorf("failed to export: %w", err))
 	}
 	if len(errs) > 0 {
@@ -451,29 +619,28 @@ func (p *Processor) commitAndExport(ctx context.Context, batch *pebble.Batch, to
 	return nil
 }
 
-func (p *Processor) export(ctx context.Context, end time.Time) error {
+func (p *Processor) export(ctx context.Context, due []intervalWindow) error {
+	if len(due) == 0 {
+		return nil
+	}
 	snap := p.db.NewSnapshot()
 	defer func() {
 		_ = snap.Close()
 	}()
 
 	var errs []error
-	for _, ivl := range p.intervals {
-		// Check if the given aggregation interval needs to be exported now
-		if end.Truncate(ivl.Duration).Equal(end) {
-			start := end.Add(-ivl.Duration)
-			exportedCount, err := p.exportForInterval(ctx, snap, start, end, ivl)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("failed to export interval %s for end time %d: %w", ivl.Duration, end.Unix(), err))
-			}
-			p.logger.Debug(
-				"Finished exporting metrics",
-				zap.Int("exported_datapoints", exportedCount),
-				zap.Duration("interval", ivl.Duration),
-				zap.Time("exported_till(exclusive)", end),
-				zap.Error(err),
-			)
+	for _, d := range due {
+		exportedCount, err := p.exportForInterval(ctx, snap, d.window.start, d.window.end, d.ivl)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to export interval %s for end time %d: %w", d.ivl.Duration, d.window.end.Unix(), err))
 		}
+		p.logger.Debug(
+			"Finished exporting metrics",
+			zap.Int("exported_datapoints", exportedCount),
+			zap.Duration("interval", d.ivl.Duration),
+			zap.Time("exported_till(exclusive)", d.window.end),
+			zap.Error(err),
+		)
 	}
 	return errors.Join(errs...)
 }
@@ -515,13 +682,7 @@ func (p *Processor) exportForInterval(
 	var exportedDPCount int
 	rangeHasData := iter.First()
 	for ; iter.Valid(); iter.Next() {
-		v := merger.NewValue(
-			p.cfg.ResourceLimit,
-			p.cfg.ScopeLimit,
-			p.cfg.MetricLimit,
-			p.cfg.DatapointLimit,
-			p.cfg.ExponentialHistogramMaxBuckets,
-		)
+		v := p.newValue()
 		var key merger.Key
 		if err := key.Unmarshal(iter.Key()); err != nil {
 			errs = append(errs, fmt.Errorf("failed to decode key from database: %w", err))
@@ -536,6 +697,9 @@ func (p *Processor) exportForInterval(
 			errs = append(errs, fmt.Errorf("failed to finalize merged metric: %w", err))
 			continue
 		}
+		if p.cfg.SummaryAggregation.Mode == config.SummaryAggregationModeQuantiles {
+			summary.RestoreMetrics(finalMetrics)
+		}
 
 		// Restore client metadata from the storage key into the context so that
 		// OTTL statements can access it via otelcol.client.metadata["key"].
@@ -651,6 +815,16 @@ func (p *Processor) exportForInterval(
 	return exportedDPCount, nil
 }
 
+func (p *Processor) newValue() *merger.Value {
+	return merger.NewValue(
+		p.cfg.ResourceLimit,
+		p.cfg.ScopeLimit,
+		p.cfg.MetricLimit,
+		p.cfg.DatapointLimit,
+		p.cfg.ExponentialHistogramMaxBuckets,
+	)
+}
+
 func (p *Processor) registerPebbleMetrics() error {
 	// Because pebble.DB.Metrics call locks the database
 	// cache the result for 1 second to avoid superfluous locks
@@ -33,6 +33,7 @@ import (
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/processor"
 	"go.opentelemetry.io/collector/processor/processortest"
@@ -55,8 +56,9 @@ func TestAggregation(t *testing.T) {
 	t.Parallel()
 
 	testCases := []struct {
-		name        string
-		passThrough bool
+		name               string
+		passThrough        bool
+		summaryAggregation config.SummaryAggregationConfig
 	}{
 		{name: "sum_cumulative"},
 		{name: "sum_delta"},
@@ -66,6 +68,20 @@ func TestAggregation(t *testing.T) {
 		{name: "exphistogram_delta"},
 		{name: "summary_enabled"},
 		{name: "summary_passthrough", passThrough: true},
+		{
+			name: "summary_quantiles",
+			summaryAggregation: config.SummaryAggregationConfig{
+				Mode:        config.SummaryAggregationModeQuantiles,
+				Temporality: "delta",
+			},
+		},
+		{
+			name: "summary_exponential_histogram",
+			summaryAggregation: config.SummaryAggregationConfig{
+				Mode:        config.SummaryAggregationModeExponentialHistogram,
+				Temporality: "delta",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -84,6 +100,7 @@ func TestAggregation(t *testing.T) {
 			PassThrough: config.PassThrough{
 				Summary: tc.passThrough,
 			},
+			SummaryAggregation:             tc.summaryAggregation,
 			ExponentialHistogramMaxBuckets: 160,
 		}
 		t.Run(tc.name, func(t *testing.T) {
@@ -361,6 +378,217 @@ func TestClientMetadata(t *testing.T) {
 	assert.Equal(t, expected, received)
 }
 
+func TestEventTime(t *testing.T) {
+	t.Parallel()
+
+	for _, action := range []config.LateDataAction{config.LateDataActionDrop, config.LateDataActionForward} {
+		t.Run(string(action), func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &config.Config{
+				Intervals: []config.IntervalConfig{{
+					Duration: time.Hour,
+				}},
+				EventTime: config.EventTimeConfig{
+					Enabled:         true,
+					AllowedLateness: 2 * time.Hour,
+					LateData: config.LateDataConfig{
+						Action:     action,
+						Attributes: []config.Attribute{{Key: "late", Value: true}},
+					},
+				},
+			}
+
+			testTel := componenttest.NewTelemetry()
+			next := &consumertest.MetricsSink{}
+			p := newTestProcessor(t, cfg, testTel.NewTelemetrySettings(), next)
+			require.NoError(t, p.Start(context.Background(), componenttest.NewNopHost()))
+
+			now := time.Now()
+			md := pmetric.NewMetrics()
+			m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+			m.SetName("test_metric")
+			sum := m.SetEmptySum()
+			sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+			for _, dp := range []struct {
+				ts    time.Time
+				value int64
+			}{
+				{ts: now, value: 1},
+				{ts: now.Add(-90 * time.Minute), value: 2},
+				{value: 4}, // attributed to the arrival time
+				{ts: now.Add(-3 * time.Hour), value: 8},
+			} {
+				ndp := sum.DataPoints().AppendEmpty()
+				if !dp.ts.IsZero() {
+					ndp.SetTimestamp(pcommon.NewTimestampFromTime(dp.ts))
+				}
+				ndp.SetIntValue(dp.value)
+			}
+			require.NoError(t, p.ConsumeMetrics(context.Background(), md))
+
+			// Late data points are handled on consumption.
+			var expectedLate []int64
+			if action == config.LateDataActionForward {
+				expectedLate = []int64{8}
+			}
+			assert.Equal(t, expectedLate, sumValues(t, next.AllMetrics()))
+			if action == config.LateDataActionForward {
+				late, ok := next.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).
+					Metrics().At(0).Sum().DataPoints().At(0).Attributes().Get("late")
+				require.True(t, ok)
+				assert.True(t, late.Bool())
+			}
+			metadatatest.AssertEqualLsmintervalLateDataPoints(t, testTel, []metricdata.DataPoint[int64]{
+				{
+					Value: 1,
+					Attributes: attribute.NewSet(
+						attribute.String("action", string(action)),
+						attribute.String("interval", "1h0m0s"),
+					),
+				},
+			}, metricdatatest.IgnoreTimestamp())
+
+			// The windows kept open are exported on shutdown, one per window.
+			next.Reset()
+			require.NoError(t, p.Shutdown(context.Background()))
+			assert.ElementsMatch(t, []int64{5, 2}, sumValues(t, next.AllMetrics()))
+		})
+	}
+
+	t.Run("no_allowed_lateness", func(t *testing.T) {
+		t.Parallel()
+
+		// Without allowed lateness only the data points of windows that
+		// have already been harvested are late, not those of the current
+		// window that are older than the current time.
+		cfg := &config.Config{
+			Intervals: []config.IntervalConfig{{
+				Duration: time.Hour,
+			}},
+			EventTime: config.EventTimeConfig{
+				Enabled: true,
+				LateData: config.LateDataConfig{
+					Action: config.LateDataActionForward,
+				},
+			},
+		}
+
+		testTel := componenttest.NewTelemetry()
+		next := &consumertest.MetricsSink{}
+		p := newTestProcessor(t, cfg, testTel.NewTelemetrySettings(), next)
+		require.NoError(t, p.Start(context.Background(), componenttest.NewNopHost()))
+
+		now := time.Now()
+		md := pmetric.NewMetrics()
+		m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("test_metric")
+		sum := m.SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		for _, dp := range []struct {
+			ts    time.Time
+			value int64
+		}{
+			{ts: now.Truncate(time.Hour), value: 1},
+			{ts: now.Add(-time.Hour), value: 2},
+		} {
+			ndp := sum.DataPoints().AppendEmpty()
+			ndp.SetTimestamp(pcommon.NewTimestampFromTime(dp.ts))
+			ndp.SetIntValue(dp.value)
+		}
+		require.NoError(t, p.ConsumeMetrics(context.Background(), md))
+		assert.Equal(t, []int64{2}, sumValues(t, next.AllMetrics()))
+		metadatatest.AssertEqualLsmintervalLateDataPoints(t, testTel, []metricdata.DataPoint[int64]{
+			{
+				Value: 1,
+				Attributes: attribute.NewSet(
+					attribute.String("action", string(config.LateDataActionForward)),
+					attribute.String("interval", "1h0m0s"),
+				),
+			},
+		}, metricdatatest.IgnoreTimestamp())
+
+		next.Reset()
+		require.NoError(t, p.Shutdown(context.Background()))
+		assert.Equal(t, []int64{1}, sumValues(t, next.AllMetrics()))
+	})
+
+	t.Run("multiple_intervals", func(t *testing.T) {
+		t.Parallel()
+
+		// Data points late for the short interval but aggregated into the
+		// open window of the long one are not forwarded.
+		cfg := &config.Config{
+			Intervals: []config.IntervalConfig{
+				{Duration: time.Second},
+				{Duration: time.Hour},
+			},
+			EventTime: config.EventTimeConfig{
+				Enabled: true,
+				LateData: config.LateDataConfig{
+					Action: config.LateDataActionForward,
+				},
+			},
+		}
+
+		testTel := componenttest.NewTelemetry()
+		next := &consumertest.MetricsSink{}
+		p := newTestProcessor(t, cfg, testTel.NewTelemetrySettings(), next)
+		require.NoError(t, p.Start(context.Background(), componenttest.NewNopHost()))
+
+		now := time.Now()
+		md := pmetric.NewMetrics()
+		m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("test_metric")
+		sum := m.SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		for _, dp := range []struct {
+			ts    time.Time
+			value int64
+		}{
+			{ts: now.Truncate(time.Hour), value: 1},
+			{ts: now.Add(-2 * time.Hour), value: 2},
+		} {
+			ndp := sum.DataPoints().AppendEmpty()
+			ndp.SetTimestamp(pcommon.NewTimestampFromTime(dp.ts))
+			ndp.SetIntValue(dp.value)
+		}
+		require.NoError(t, p.ConsumeMetrics(context.Background(), md))
+		assert.Equal(t, []int64{2}, sumValues(t, next.AllMetrics()))
+		metadatatest.AssertEqualLsmintervalLateDataPoints(t, testTel, []metricdata.DataPoint[int64]{
+			{
+				Value: 2,
+				Attributes: attribute.NewSet(
+					attribute.String("action", string(config.LateDataActionForward)),
+					attribute.String("interval", "1s"),
+				),
+			},
+			{
+				Value: 1,
+				Attributes: attribute.NewSet(
+					attribute.String("action", string(config.LateDataActionForward)),
+					attribute.String("interval", "1h0m0s"),
+				),
+			},
+		}, metricdatatest.IgnoreTimestamp())
+
+		next.Reset()
+		require.NoError(t, p.Shutdown(context.Background()))
+		assert.Equal(t, []int64{1}, sumValues(t, next.AllMetrics()))
+	})
+}
+
+func sumValues(t *testing.T, mds []pmetric.Metrics) []int64 {
+	t.Helper()
+	var values []int64
+	for _, md := range mds {
+		require.Equal(t, 1, md.DataPointCount())
+		m := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+		values = append(values, m.Sum().DataPoints().At(0).IntValue())
+	}
+	return values
+}
+
 func TestConcurrentShutdownConsumeMetrics(t *testing.T) {
 	t.Parallel()
 
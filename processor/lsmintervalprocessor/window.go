@@ -0,0 +1,73 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lsmintervalprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/lsmintervalprocessor"
+
+import "time"
+
+// window is a half-open aggregation window [start, end) for an interval.
+type window struct {
+	start time.Time
+	end   time.Time
+}
+
+// windowAt returns the window of the interval containing t. Windows are
+// aligned to multiples of the interval duration, shifted by the offset,
+// on the wall clock of the interval's location. For locations observing
+// daylight saving time the window spanning a transition is correspondingly
+// shorter or longer than the duration.
+func (ivl intervalDef) windowAt(t time.Time) window {
+	loc := ivl.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	_, zoneOffset := t.Zone()
+
+	duration := int64(ivl.Duration / time.Second)
+	offset := int64(ivl.Offset / time.Second)
+	wall := t.Unix() + int64(zoneOffset)
+	start := wall - mod(wall-offset, duration)
+
+	// Converting wall clock boundaries back to instants is ambiguous for
+	// wall times repeated or skipped by a zone transition, in which case
+	// the boundaries are derived from the zone offset in effect at t.
+	w := window{
+		start: wallToTime(start, loc),
+		end:   wallToTime(start+duration, loc),
+	}
+	if w.start.After(t) || !w.end.After(t) {
+		w.start = time.Unix(t.Unix()-(wall-start), 0).In(loc)
+		w.end = w.start.Add(ivl.Duration)
+	}
+	return w
+}
+
+func mod(a, b int64) int64 {
+	r := a % b
+	if r < 0 {
+		r += b
+	}
+	return r
+}
+
+// wallToTime converts seconds since the Unix epoch on the wall clock of
+// loc to the corresponding instant.
+func wallToTime(wall int64, loc *time.Location) time.Time {
+	u := time.Unix(wall, 0).UTC()
+	return time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc)
+}
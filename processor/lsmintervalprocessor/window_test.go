@@ -0,0 +1,95 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lsmintervalprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowAt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name          string
+		ivl           intervalDef
+		at            time.Time
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{
+			name:          "minute",
+			ivl:           intervalDef{Duration: time.Minute},
+			at:            time.Date(2024, 3, 5, 10, 7, 42, 500, time.UTC),
+			expectedStart: time.Date(2024, 3, 5, 10, 7, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 3, 5, 10, 8, 0, 0, time.UTC),
+		},
+		{
+			name:          "not_a_factor_of_an_hour_aligned_to_epoch",
+			ivl:           intervalDef{Duration: 7 * time.Minute},
+			at:            time.Date(2024, 3, 5, 10, 7, 42, 0, time.UTC),
+			expectedStart: time.Date(2024, 3, 5, 10, 5, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 3, 5, 10, 12, 0, 0, time.UTC),
+		},
+		{
+			name:          "boundary",
+			ivl:           intervalDef{Duration: 15 * time.Minute},
+			at:            time.Date(2024, 3, 5, 10, 15, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, 3, 5, 10, 15, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:          "daily_with_offset",
+			ivl:           intervalDef{Duration: 24 * time.Hour, Offset: 6 * time.Hour},
+			at:            time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, 3, 4, 6, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "daily_in_timezone",
+			ivl:           intervalDef{Duration: 24 * time.Hour, Location: berlin},
+			at:            time.Date(2024, 3, 5, 23, 30, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "daily_across_dst_transition",
+			ivl:           intervalDef{Duration: 24 * time.Hour, Location: berlin},
+			at:            time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, 3, 30, 23, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 3, 31, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "repeated_wall_clock_hour",
+			ivl:           intervalDef{Duration: time.Hour, Location: berlin},
+			at:            time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 10, 27, 2, 0, 0, 0, time.UTC),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := tc.ivl.windowAt(tc.at)
+			assert.True(t, tc.expectedStart.Equal(w.start), "expected start %s, got %s", tc.expectedStart, w.start)
+			assert.True(t, tc.expectedEnd.Equal(w.end), "expected end %s, got %s", tc.expectedEnd, w.end)
+		})
+	}
+}
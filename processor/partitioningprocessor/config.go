@@ -17,10 +17,94 @@
 
 package partitioningprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/partitioningprocessor"
 
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// ScopeNameMetadataKey is the client metadata key used to expose the
+	// instrumentation scope name of a partition when partitioning by
+	// scope name is enabled.
+	ScopeNameMetadataKey = "otel.scope.name"
+
+	// DefaultMaxParallelism is the default value for the maximum number
+	// of partitions that are forwarded concurrently.
+	DefaultMaxParallelism = 8
+)
+
 // Config is the configuration for the partitioning processor.
-type Config struct{}
+type Config struct {
+	// MetadataKeys holds a list of client metadata keys that are part
+	// of the partition key. All data within a single request share the
+	// same client metadata, so these keys do not split a batch on their
+	// own. All client metadata of the incoming context, not only these
+	// keys, is kept in the outgoing context of every partition.
+	MetadataKeys []string `mapstructure:"metadata_keys"`
+
+	// ResourceAttributes holds a list of resource attribute keys that
+	// are part of the partition key. The values of these attributes are
+	// added to the client metadata of each partition under the same key,
+	// replacing incoming client metadata of that key. They must not
+	// overlap with MetadataKeys.
+	ResourceAttributes []string `mapstructure:"resource_attributes"`
+
+	// ScopeName enables partitioning by instrumentation scope name. The
+	// scope name is added to the client metadata of each partition
+	// under ScopeNameMetadataKey.
+	ScopeName bool `mapstructure:"scope_name"`
+
+	// MaxParallelism holds the maximum number of partitions of a single
+	// batch that are forwarded to the next consumer concurrently.
+	//
+	// Defaults to 8.
+	MaxParallelism int `mapstructure:"max_parallelism"`
+}
 
 // Validate validates the configuration.
 func (cfg *Config) Validate() error {
+	var errs []error
+	if cfg.MaxParallelism <= 0 {
+		errs = append(errs, errors.New("max_parallelism must be greater than zero"))
+	}
+	if err := validateKeys("metadata_keys", cfg.MetadataKeys); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateKeys("resource_attributes", cfg.ResourceAttributes); err != nil {
+		errs = append(errs, err)
+	}
+	for _, k := range cfg.ResourceAttributes {
+		// Client metadata keys are case-insensitive.
+		if slices.ContainsFunc(cfg.MetadataKeys, func(mk string) bool { return strings.EqualFold(mk, k) }) {
+			errs = append(errs, fmt.Errorf("resource_attributes and metadata_keys must not both contain %q", k))
+		}
+	}
+	if cfg.ScopeName && slices.Contains(cfg.ResourceAttributes, ScopeNameMetadataKey) {
+		errs = append(errs, fmt.Errorf(
+			"resource_attributes must not contain %q when scope_name is enabled",
+			ScopeNameMetadataKey,
+		))
+	}
+	return errors.Join(errs...)
+}
+
+// enabled returns true if at least one partitioning key is configured.
+func (cfg *Config) enabled() bool {
+	return len(cfg.MetadataKeys) > 0 || len(cfg.ResourceAttributes) > 0 || cfg.ScopeName
+}
+
+func validateKeys(name string, keys []string) error {
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if k == "" {
+			return fmt.Errorf("%s must not contain empty keys", name)
+		}
+		if _, ok := seen[k]; ok {
+			return fmt.Errorf("%s contains duplicate key %q", name, k)
+		}
+		seen[k] = struct{}{}
+	}
 	return nil
 }
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package partitioningprocessor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+	"go.opentelemetry.io/collector/confmap/xconfmap"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/partitioningprocessor/internal/metadata"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    component.Config
+		expectedErr string
+	}{
+		{
+			name: "default",
+			expected: &Config{
+				MaxParallelism: DefaultMaxParallelism,
+			},
+		},
+		{
+			name: "all",
+			expected: &Config{
+				MetadataKeys:       []string{"x-tenant-id"},
+				ResourceAttributes: []string{"service.name", "deployment.environment"},
+				ScopeName:          true,
+				MaxParallelism:     4,
+			},
+		},
+		{
+			name:        "invalid_max_parallelism",
+			expectedErr: "max_parallelism must be greater than zero",
+		},
+		{
+			name:        "empty_key",
+			expectedErr: "resource_attributes must not contain empty keys",
+		},
+		{
+			name:        "duplicate_key",
+			expectedErr: `metadata_keys contains duplicate key "x-tenant-id"`,
+		},
+		{
+			name:        "scope_name_conflict",
+			expectedErr: `resource_attributes must not contain "otel.scope.name" when scope_name is enabled`,
+		},
+		{
+			name:        "metadata_keys_conflict",
+			expectedErr: `resource_attributes and metadata_keys must not both contain "x-tenant-id"`,
+		},
+	}
+
+	factory := NewFactory()
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := factory.CreateDefaultConfig()
+			id := component.NewIDWithName(metadata.Type, tt.name)
+			sub, err := cm.Sub(id.String())
+			require.NoError(t, err)
+			require.NoError(t, sub.Unmarshal(cfg))
+
+			err = xconfmap.Validate(cfg)
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cfg)
+		})
+	}
+}
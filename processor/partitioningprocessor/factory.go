@@ -42,21 +42,23 @@ func NewFactory() xprocessor.Factory {
 }
 
 func createDefaultConfig() component.Config {
-	return &Config{}
+	return &Config{
+		MaxParallelism: DefaultMaxParallelism,
+	}
 }
 
 func createLogsProcessor(_ context.Context, _ processor.Settings, cfg component.Config, next consumer.Logs) (processor.Logs, error) {
-	return &partitioningProcessor{nextLogs: next}, nil
+	return &partitioningProcessor{cfg: cfg.(*Config), nextLogs: next}, nil
 }
 
 func createMetricsProcessor(_ context.Context, _ processor.Settings, cfg component.Config, next consumer.Metrics) (processor.Metrics, error) {
-	return &partitioningProcessor{nextMetrics: next}, nil
+	return &partitioningProcessor{cfg: cfg.(*Config), nextMetrics: next}, nil
 }
 
 func createTracesProcessor(_ context.Context, _ processor.Settings, cfg component.Config, next consumer.Traces) (processor.Traces, error) {
-	return &partitioningProcessor{nextTraces: next}, nil
+	return &partitioningProcessor{cfg: cfg.(*Config), nextTraces: next}, nil
 }
 
 func createProfilesProcessor(_ context.Context, _ processor.Settings, cfg component.Config, next xconsumer.Profiles) (xprocessor.Profiles, error) {
-	return &partitioningProcessor{nextProfiles: next}, nil
+	return &partitioningProcessor{cfg: cfg.(*Config), nextProfiles: next}, nil
 }
@@ -0,0 +1,240 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package partitioningprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/partitioningprocessor"
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// partition holds the data and client metadata of a single partition
+// of an incoming batch.
+type partition[T any] struct {
+	// key holds the length-prefixed encoding of the partition's ordered
+	// key/values pairs, see appendKey.
+	key      string
+	metadata map[string][]string
+	data     T
+	// resources maps the index of a resource in the incoming batch to
+	// the index of its copy in data, so that scopes of the same resource
+	// that end up in the same partition share a single resource.
+	resources map[int]int
+}
+
+// partitioner splits a batch of type T into partitions, preserving the
+// order in which partitions are first seen.
+type partitioner[T any] struct {
+	cfg     *Config
+	newData func() T
+	// base holds the client metadata shared by all partitions: all the
+	// client metadata of the incoming context.
+	base map[string][]string
+	// baseKey holds the partition key prefix derived from the
+	// configured metadata keys.
+	baseKey    string
+	partitions []*partition[T]
+	byKey      map[string]*partition[T]
+}
+
+func newPartitioner[T any](ctx context.Context, cfg *Config, newData func() T) *partitioner[T] {
+	md := client.FromContext(ctx).Metadata
+	base := make(map[string][]string)
+	for k := range md.Keys() {
+		base[k] = md.Get(k)
+	}
+	var key []byte
+	for _, k := range cfg.MetadataKeys {
+		vs := md.Get(k)
+		if len(vs) == 0 {
+			continue
+		}
+		key = appendKey(key, k, vs...)
+	}
+	return &partitioner[T]{
+		cfg:     cfg,
+		newData: newData,
+		base:    base,
+		baseKey: string(key),
+		byKey:   make(map[string]*partition[T]),
+	}
+}
+
+// resourceKey returns the partition key and metadata for a resource. The
+// returned metadata must not be modified.
+func (p *partitioner[T]) resourceKey(res pcommon.Resource) (string, map[string][]string) {
+	if len(p.cfg.ResourceAttributes) == 0 {
+		return p.baseKey, p.base
+	}
+	md := make(map[string][]string, len(p.base)+len(p.cfg.ResourceAttributes)+1)
+	for k, vs := range p.base {
+		md[k] = vs
+	}
+	key := []byte(p.baseKey)
+	attrs := res.Attributes()
+	for _, k := range p.cfg.ResourceAttributes {
+		v, ok := attrs.Get(k)
+		if !ok {
+			continue
+		}
+		s := v.AsString()
+		// Client metadata keys are case-insensitive, so the value must
+		// replace incoming metadata of any case.
+		md[strings.ToLower(k)] = []string{s}
+		key = appendKey(key, k, s)
+	}
+	return string(key), md
+}
+
+// scopeKey extends a resource partition key and metadata with the scope
+// name, if partitioning by scope name is enabled.
+func (p *partitioner[T]) scopeKey(resKey string, resMetadata map[string][]string, scope pcommon.InstrumentationScope) (string, map[string][]string) {
+	if !p.cfg.ScopeName {
+		return resKey, resMetadata
+	}
+	md := make(map[string][]string, len(resMetadata)+1)
+	for k, vs := range resMetadata {
+		md[k] = vs
+	}
+	md[ScopeNameMetadataKey] = []string{scope.Name()}
+	return string(appendKey([]byte(resKey), ScopeNameMetadataKey, scope.Name())), md
+}
+
+// get returns the partition for the given key, creating it if needed.
+func (p *partitioner[T]) get(key string, metadata map[string][]string) *partition[T] {
+	if part, ok := p.byKey[key]; ok {
+		return part
+	}
+	part := &partition[T]{
+		key:       key,
+		metadata:  metadata,
+		data:      p.newData(),
+		resources: make(map[int]int),
+	}
+	p.byKey[key] = part
+	p.partitions = append(p.partitions, part)
+	return part
+}
+
+// consumePartitions forwards every partition to next with its metadata
+// set in the client info of the context. At most maxParallelism
+// partitions are forwarded concurrently, and the errors of all
+// partitions are joined. Partitions that have not been forwarded yet
+// when ctx is done are dropped.
+func consumePartitions[T any](
+	ctx context.Context,
+	partitions []*partition[T],
+	maxParallelism int,
+	next func(context.Context, T) error,
+) error {
+	info := client.FromContext(ctx)
+	consume := func(part *partition[T]) error {
+		partInfo := info
+		partInfo.Metadata = client.NewMetadata(part.metadata)
+		if err := next(client.NewContext(ctx, partInfo), part.data); err != nil {
+			return fmt.Errorf("failed to consume partition %q: %w", formatKey(part.key), err)
+		}
+		return nil
+	}
+	if len(partitions) == 1 {
+		return consume(partitions[0])
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(partitions))
+	sem := make(chan struct{}, maxParallelism)
+	for i, part := range partitions {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		// A free slot may be picked even though ctx is already done.
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = consume(part)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// appendKey appends a key and its values to a partition key. Every
+// string is prefixed with its length and the values with their count,
+// so that distinct key/values pairs never encode to the same key.
+func appendKey(b []byte, key string, values ...string) []byte {
+	b = appendString(b, key)
+	b = binary.AppendUvarint(b, uint64(len(values)))
+	for _, v := range values {
+		b = appendString(b, v)
+	}
+	return b
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// formatKey returns a human readable form of a partition key, using the
+// same format as the unique key of the ratelimit processor. Unlike the
+// client metadata of a partition, the key only holds the configured keys,
+// so it is safe to include in errors.
+func formatKey(key string) string {
+	var sb strings.Builder
+	b := []byte(key)
+	for len(b) > 0 {
+		var k string
+		k, b = readString(b)
+		n, l := binary.Uvarint(b)
+		b = b[l:]
+		if sb.Len() > 0 {
+			sb.WriteByte(';')
+		}
+		sb.WriteString(k)
+		sb.WriteByte(':')
+		for i := uint64(0); i < n; i++ {
+			var v string
+			v, b = readString(b)
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(v)
+		}
+	}
+	return sb.String()
+}
+
+func readString(b []byte) (string, []byte) {
+	n, l := binary.Uvarint(b)
+	b = b[l:]
+	return string(b[:n]), b[n:]
+}
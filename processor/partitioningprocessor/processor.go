@@ -23,6 +23,7 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/xconsumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pprofile"
@@ -33,6 +34,8 @@ type partitioningProcessor struct {
 	component.StartFunc
 	component.ShutdownFunc
 
+	cfg *Config
+
 	nextLogs     consumer.Logs
 	nextMetrics  consumer.Metrics
 	nextTraces   consumer.Traces
@@ -40,21 +43,104 @@ type partitioningProcessor struct {
 }
 
 func (p *partitioningProcessor) Capabilities() consumer.Capabilities {
-	return consumer.Capabilities{MutatesData: false}
+	// Resources and scopes are moved out of the incoming batch into
+	// the partitions.
+	return consumer.Capabilities{MutatesData: true}
 }
 
 func (p *partitioningProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
-	return p.nextLogs.ConsumeLogs(ctx, ld)
+	return consumeBatch(ctx, p.cfg, ld, plog.NewLogs,
+		plog.Logs.ResourceLogs, plog.ResourceLogs.ScopeLogs, p.nextLogs.ConsumeLogs,
+	)
 }
 
 func (p *partitioningProcessor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
-	return p.nextMetrics.ConsumeMetrics(ctx, md)
+	return consumeBatch(ctx, p.cfg, md, pmetric.NewMetrics,
+		pmetric.Metrics.ResourceMetrics, pmetric.ResourceMetrics.ScopeMetrics, p.nextMetrics.ConsumeMetrics,
+	)
 }
 
 func (p *partitioningProcessor) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
-	return p.nextTraces.ConsumeTraces(ctx, td)
+	return consumeBatch(ctx, p.cfg, td, ptrace.NewTraces,
+		ptrace.Traces.ResourceSpans, ptrace.ResourceSpans.ScopeSpans, p.nextTraces.ConsumeTraces,
+	)
 }
 
 func (p *partitioningProcessor) ConsumeProfiles(ctx context.Context, pd pprofile.Profiles) error {
-	return p.nextProfiles.ConsumeProfiles(ctx, pd)
+	newProfiles := func() pprofile.Profiles {
+		// Profiles reference the shared dictionary by index, so every
+		// partition carries its own copy of it.
+		part := pprofile.NewProfiles()
+		pd.Dictionary().CopyTo(part.Dictionary())
+		return part
+	}
+	return consumeBatch(ctx, p.cfg, pd, newProfiles,
+		pprofile.Profiles.ResourceProfiles, pprofile.ResourceProfiles.ScopeProfiles, p.nextProfiles.ConsumeProfiles,
+	)
+}
+
+// slice is implemented by the pdata slices of resources and scopes.
+type slice[E any] interface {
+	Len() int
+	At(int) E
+	AppendEmpty() E
+}
+
+// resourceData is implemented by the resource types of all signals.
+type resourceData[R any] interface {
+	Resource() pcommon.Resource
+	SchemaUrl() string
+	SetSchemaUrl(string)
+	MoveTo(R)
+}
+
+// scopeData is implemented by the scope types of all signals.
+type scopeData[S any] interface {
+	Scope() pcommon.InstrumentationScope
+	MoveTo(S)
+}
+
+// consumeBatch splits a batch of type T into partitions and forwards
+// them to next. The resources and scopes functions return the resources
+// of a batch and the scopes of a resource.
+func consumeBatch[T any, RS slice[R], R resourceData[R], SS slice[S], S scopeData[S]](
+	ctx context.Context,
+	cfg *Config,
+	data T,
+	newData func() T,
+	resources func(T) RS,
+	scopes func(R) SS,
+	next func(context.Context, T) error,
+) error {
+	rs := resources(data)
+	if !cfg.enabled() || rs.Len() == 0 {
+		return next(ctx, data)
+	}
+
+	pt := newPartitioner(ctx, cfg, newData)
+	for i := 0; i < rs.Len(); i++ {
+		r := rs.At(i)
+		resKey, resMetadata := pt.resourceKey(r.Resource())
+		if !cfg.ScopeName {
+			r.MoveTo(resources(pt.get(resKey, resMetadata).data).AppendEmpty())
+			continue
+		}
+		// Resources without any scope carry no data and are dropped.
+		ss := scopes(r)
+		for j := 0; j < ss.Len(); j++ {
+			s := ss.At(j)
+			part := pt.get(pt.scopeKey(resKey, resMetadata, s.Scope()))
+			partResources := resources(part.data)
+			dest, ok := part.resources[i]
+			if !ok {
+				dest = partResources.Len()
+				part.resources[i] = dest
+				dr := partResources.AppendEmpty()
+				r.Resource().CopyTo(dr.Resource())
+				dr.SetSchemaUrl(r.SchemaUrl())
+			}
+			s.MoveTo(scopes(partResources.At(dest)).AppendEmpty())
+		}
+	}
+	return consumePartitions(ctx, pt.partitions, cfg.MaxParallelism, next)
 }
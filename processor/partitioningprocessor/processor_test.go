@@ -0,0 +1,349 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package partitioningprocessor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// recordingConsumer records the data and client metadata of every call.
+type recordingConsumer struct {
+	mu       sync.Mutex
+	logs     map[string]plog.Logs
+	metadata map[string]client.Metadata
+}
+
+func newRecordingConsumer() *recordingConsumer {
+	return &recordingConsumer{
+		logs:     make(map[string]plog.Logs),
+		metadata: make(map[string]client.Metadata),
+	}
+}
+
+func (c *recordingConsumer) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	md := client.FromContext(ctx).Metadata
+	key := ""
+	for _, k := range []string{"x-tenant-id", "service.name", ScopeNameMetadataKey} {
+		for _, v := range md.Get(k) {
+			key += k + "=" + v + ";"
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logs[key] = ld
+	c.metadata[key] = md
+	return nil
+}
+
+func (c *recordingConsumer) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{}
+}
+
+func newTestLogs() plog.Logs {
+	ld := plog.NewLogs()
+	for _, svc := range []string{"a", "b", "a"} {
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("service.name", svc)
+		for _, scope := range []string{"s1", "s2"} {
+			sl := rl.ScopeLogs().AppendEmpty()
+			sl.Scope().SetName(scope)
+			sl.LogRecords().AppendEmpty().Body().SetStr(svc + "/" + scope)
+		}
+	}
+	// Resource without the partitioning attribute.
+	rl := ld.ResourceLogs().AppendEmpty()
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("s1")
+	sl.LogRecords().AppendEmpty().Body().SetStr("none/s1")
+	return ld
+}
+
+func newTestContext() context.Context {
+	return client.NewContext(context.Background(), client.Info{
+		Metadata: client.NewMetadata(map[string][]string{
+			"x-tenant-id": {"tenant"},
+			"x-other":     {"other"},
+		}),
+	})
+}
+
+func TestConsumeLogs(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		next := new(consumertest.LogsSink)
+		p := &partitioningProcessor{cfg: createDefaultConfig().(*Config), nextLogs: next}
+		ctx := newTestContext()
+		require.NoError(t, p.ConsumeLogs(ctx, newTestLogs()))
+		require.Len(t, next.AllLogs(), 1)
+		assert.Equal(t, 7, next.AllLogs()[0].LogRecordCount())
+		assert.Equal(t, []string{"other"}, client.FromContext(next.Contexts()[0]).Metadata.Get("x-other"))
+	})
+
+	t.Run("metadata_keys", func(t *testing.T) {
+		next := newRecordingConsumer()
+		cfg := createDefaultConfig().(*Config)
+		cfg.MetadataKeys = []string{"x-tenant-id"}
+		p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+		require.NoError(t, p.ConsumeLogs(newTestContext(), newTestLogs()))
+		require.Len(t, next.logs, 1)
+		md := next.metadata["x-tenant-id=tenant;"]
+		assert.Equal(t, []string{"tenant"}, md.Get("x-tenant-id"))
+		assert.Equal(t, []string{"other"}, md.Get("x-other"))
+		assert.Equal(t, 7, next.logs["x-tenant-id=tenant;"].LogRecordCount())
+	})
+
+	t.Run("incoming_metadata", func(t *testing.T) {
+		next := newRecordingConsumer()
+		cfg := createDefaultConfig().(*Config)
+		cfg.ResourceAttributes = []string{"Service.Name"}
+		p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+		ctx := client.NewContext(context.Background(), client.Info{
+			Metadata: client.NewMetadata(map[string][]string{
+				"authorization": {"secret"},
+				"service.name":  {"incoming"},
+			}),
+		})
+		ld := plog.NewLogs()
+		ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("Service.Name", "a")
+		require.NoError(t, p.ConsumeLogs(ctx, ld))
+		require.Len(t, next.metadata, 1)
+		md := next.metadata["service.name=a;"]
+		assert.Equal(t, []string{"secret"}, md.Get("authorization"))
+		assert.Equal(t, []string{"a"}, md.Get("service.name"))
+	})
+
+	t.Run("key_separators", func(t *testing.T) {
+		next := new(consumertest.LogsSink)
+		cfg := createDefaultConfig().(*Config)
+		cfg.ResourceAttributes = []string{"a", "b"}
+		p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+		ld := plog.NewLogs()
+		// Values containing separators must not merge distinct partitions.
+		attrs := ld.ResourceLogs().AppendEmpty().Resource().Attributes()
+		attrs.PutStr("a", "x;b:y")
+		attrs = ld.ResourceLogs().AppendEmpty().Resource().Attributes()
+		attrs.PutStr("a", "x")
+		attrs.PutStr("b", "y")
+		require.NoError(t, p.ConsumeLogs(context.Background(), ld))
+		assert.Len(t, next.AllLogs(), 2)
+	})
+
+	t.Run("resource_attributes", func(t *testing.T) {
+		next := newRecordingConsumer()
+		cfg := createDefaultConfig().(*Config)
+		cfg.MetadataKeys = []string{"x-tenant-id"}
+		cfg.ResourceAttributes = []string{"service.name"}
+		p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+		require.NoError(t, p.ConsumeLogs(newTestContext(), newTestLogs()))
+		require.Len(t, next.logs, 3)
+
+		a := next.logs["x-tenant-id=tenant;service.name=a;"]
+		assert.Equal(t, 2, a.ResourceLogs().Len())
+		assert.Equal(t, 4, a.LogRecordCount())
+		assert.Equal(t, []string{"a"}, next.metadata["x-tenant-id=tenant;service.name=a;"].Get("service.name"))
+
+		b := next.logs["x-tenant-id=tenant;service.name=b;"]
+		assert.Equal(t, 1, b.ResourceLogs().Len())
+		assert.Equal(t, 2, b.LogRecordCount())
+
+		none := next.logs["x-tenant-id=tenant;"]
+		assert.Equal(t, 1, none.LogRecordCount())
+		assert.Empty(t, next.metadata["x-tenant-id=tenant;"].Get("service.name"))
+	})
+
+	t.Run("scope_name", func(t *testing.T) {
+		next := newRecordingConsumer()
+		cfg := createDefaultConfig().(*Config)
+		cfg.ResourceAttributes = []string{"service.name"}
+		cfg.ScopeName = true
+		p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+		require.NoError(t, p.ConsumeLogs(context.Background(), newTestLogs()))
+		require.Len(t, next.logs, 5)
+
+		as1 := next.logs["service.name=a;otel.scope.name=s1;"]
+		require.Equal(t, 2, as1.ResourceLogs().Len())
+		for i := 0; i < as1.ResourceLogs().Len(); i++ {
+			rl := as1.ResourceLogs().At(i)
+			v, ok := rl.Resource().Attributes().Get("service.name")
+			require.True(t, ok)
+			assert.Equal(t, "a", v.Str())
+			require.Equal(t, 1, rl.ScopeLogs().Len())
+			assert.Equal(t, "s1", rl.ScopeLogs().At(0).Scope().Name())
+			assert.Equal(t, "a/s1", rl.ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+		}
+		assert.Equal(t, 1, next.logs["service.name=b;otel.scope.name=s2;"].LogRecordCount())
+		assert.Equal(t, 1, next.logs["otel.scope.name=s1;"].LogRecordCount())
+	})
+}
+
+func TestConsumePartitionsErrors(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.ResourceAttributes = []string{"service.name"}
+	errFailed := errors.New("failed")
+	next, err := consumer.NewLogs(func(ctx context.Context, _ plog.Logs) error {
+		if client.FromContext(ctx).Metadata.Get("service.name")[0] == "b" {
+			return errFailed
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+
+	ld := plog.NewLogs()
+	for _, svc := range []string{"a", "b", "c"} {
+		ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("service.name", svc)
+	}
+	err = p.ConsumeLogs(context.Background(), ld)
+	require.ErrorIs(t, err, errFailed)
+	assert.EqualError(t, err, `failed to consume partition "service.name:b": failed`)
+}
+
+func TestConsumePartitionsContextDone(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.ResourceAttributes = []string{"service.name"}
+	cfg.MaxParallelism = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int64
+	next, err := consumer.NewLogs(func(context.Context, plog.Logs) error {
+		calls.Add(1)
+		cancel()
+		return nil
+	})
+	require.NoError(t, err)
+	p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+
+	ld := plog.NewLogs()
+	for _, svc := range []string{"a", "b", "c"} {
+		ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("service.name", svc)
+	}
+	err = p.ConsumeLogs(ctx, ld)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestConsumePartitionsMaxParallelism(t *testing.T) {
+	const maxParallelism = 2
+	cfg := createDefaultConfig().(*Config)
+	cfg.ResourceAttributes = []string{"service.name"}
+	cfg.MaxParallelism = maxParallelism
+
+	var (
+		inflight atomic.Int64
+		peak     atomic.Int64
+		release  = make(chan struct{})
+	)
+	next, err := consumer.NewLogs(func(context.Context, plog.Logs) error {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		return nil
+	})
+	require.NoError(t, err)
+	p := &partitioningProcessor{cfg: cfg, nextLogs: next}
+
+	ld := plog.NewLogs()
+	for _, svc := range []string{"a", "b", "c", "d", "e"} {
+		ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("service.name", svc)
+	}
+	done := make(chan error)
+	go func() { done <- p.ConsumeLogs(context.Background(), ld) }()
+	require.Eventually(t, func() bool { return inflight.Load() == maxParallelism }, time.Second, time.Millisecond)
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, int64(maxParallelism), peak.Load())
+}
+
+func TestConsumeOtherSignals(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.ResourceAttributes = []string{"service.name"}
+	cfg.ScopeName = true
+
+	t.Run("metrics", func(t *testing.T) {
+		next := new(consumertest.MetricsSink)
+		p := &partitioningProcessor{cfg: cfg, nextMetrics: next}
+		md := pmetric.NewMetrics()
+		for _, svc := range []string{"a", "b", "a"} {
+			rm := md.ResourceMetrics().AppendEmpty()
+			rm.Resource().Attributes().PutStr("service.name", svc)
+			sm := rm.ScopeMetrics().AppendEmpty()
+			sm.Scope().SetName("s")
+			sm.Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty()
+		}
+		require.NoError(t, p.ConsumeMetrics(context.Background(), md))
+		require.Len(t, next.AllMetrics(), 2)
+		assert.Equal(t, 3, next.DataPointCount())
+	})
+
+	t.Run("traces", func(t *testing.T) {
+		next := new(consumertest.TracesSink)
+		p := &partitioningProcessor{cfg: cfg, nextTraces: next}
+		td := ptrace.NewTraces()
+		for _, svc := range []string{"a", "b", "a"} {
+			rs := td.ResourceSpans().AppendEmpty()
+			rs.Resource().Attributes().PutStr("service.name", svc)
+			ss := rs.ScopeSpans().AppendEmpty()
+			ss.Scope().SetName("s")
+			ss.Spans().AppendEmpty()
+		}
+		require.NoError(t, p.ConsumeTraces(context.Background(), td))
+		require.Len(t, next.AllTraces(), 2)
+		assert.Equal(t, 3, next.SpanCount())
+	})
+
+	t.Run("profiles", func(t *testing.T) {
+		next := new(consumertest.ProfilesSink)
+		p := &partitioningProcessor{cfg: cfg, nextProfiles: next}
+		pd := pprofile.NewProfiles()
+		pd.Dictionary().StringTable().Append("", "cpu")
+		for _, svc := range []string{"a", "b", "a"} {
+			rp := pd.ResourceProfiles().AppendEmpty()
+			rp.Resource().Attributes().PutStr("service.name", svc)
+			sp := rp.ScopeProfiles().AppendEmpty()
+			sp.Scope().SetName("s")
+			sp.Profiles().AppendEmpty().Samples().AppendEmpty()
+		}
+		require.NoError(t, p.ConsumeProfiles(context.Background(), pd))
+		require.Len(t, next.AllProfiles(), 2)
+		assert.Equal(t, 3, next.SampleCount())
+		for _, part := range next.AllProfiles() {
+			assert.Equal(t, []string{"", "cpu"}, part.Dictionary().StringTable().AsRaw())
+		}
+	})
+}
@@ -25,6 +25,8 @@ import (
 
 	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/config/configoptional"
 )
 
 // Config holds configuration for the ratelimit processor.
@@ -37,11 +39,101 @@ type Config struct {
 	// Embed the rate limit settings
 	RateLimitSettings `mapstructure:",squash"`
 
+	// Weights holds per-signal multipliers applied to the number of
+	// tokens taken from the bucket of the main limit. Additional limits
+	// are not weighted.
+	//
+	// Defaults to 1 for every signal.
+	Weights SignalWeights `mapstructure:"weights"`
+
+	// Shedding holds the OTTL conditions selecting the low-priority
+	// records dropped by the "shed" throttle behavior.
+	Shedding SheddingConfig `mapstructure:"shedding"`
+
 	// Overrides holds a list of overrides for the rate limiter.
 	//
 	// Defaults to empty
 	// Overrides map[string]RateLimitOverrides `mapstructure:"overrides"`
 	Overrides []RateLimitOverrides `mapstructure:"overrides"`
+
+	// DynamicOverrides holds the configuration for loading overrides at
+	// runtime, without restarting the collector. Dynamic overrides take
+	// precedence over Overrides.
+	DynamicOverrides configoptional.Optional[DynamicOverridesConfig] `mapstructure:"dynamic_overrides"`
+
+	// Distributed holds the configuration for sharing rate limit buckets
+	// across collector replicas. When not set, buckets are local to each
+	// collector.
+	Distributed configoptional.Optional[DistributedConfig] `mapstructure:"distributed"`
+}
+
+// SheddingConfig holds the OTTL conditions selecting low-priority records
+// for each signal. A record is low-priority if any of the conditions of its
+// signal matches.
+type SheddingConfig struct {
+	// Logs holds conditions evaluated in the OTTL log context.
+	Logs []string `mapstructure:"logs"`
+
+	// DataPoints holds conditions evaluated in the OTTL datapoint context.
+	DataPoints []string `mapstructure:"datapoints"`
+
+	// Spans holds conditions evaluated in the OTTL span context.
+	Spans []string `mapstructure:"spans"`
+
+	// ProfileSamples holds conditions evaluated in the OTTL profilesample
+	// context.
+	ProfileSamples []string `mapstructure:"profile_samples"`
+}
+
+// DistributedConfig holds the configuration for the distributed rate
+// limiter. Each unique key is owned by a single peer, chosen through
+// consistent hashing, and rate limit requests for that key are forwarded
+// to its owner.
+type DistributedConfig struct {
+	// Server holds the gRPC server configuration used to serve rate
+	// limit requests forwarded by peers.
+	Server configgrpc.ServerConfig `mapstructure:"server"`
+
+	// Client holds the gRPC client configuration used to forward rate
+	// limit requests to peers. The endpoint is ignored and replaced by
+	// the address of each peer.
+	Client configgrpc.ClientConfig `mapstructure:"client"`
+
+	// AdvertiseAddress holds the address under which peers reach this
+	// collector. It must match the address of this collector in the
+	// discovered peers, e.g. "${env:POD_IP}:4320".
+	AdvertiseAddress string `mapstructure:"advertise_address"`
+
+	// Peers holds the static list of peer addresses. Mutually exclusive
+	// with DNS.
+	Peers []string `mapstructure:"peers"`
+
+	// DNS holds the configuration for discovering peers by resolving a
+	// hostname, e.g. a Kubernetes headless service. Mutually exclusive
+	// with Peers.
+	DNS configoptional.Optional[DNSDiscoveryConfig] `mapstructure:"dns"`
+
+	// Timeout holds the timeout of requests forwarded to peers when
+	// throttle_behavior is error. If a peer does not respond in time or
+	// cannot be reached, the rate limit is applied locally.
+	//
+	// Defaults to 500ms
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// DNSDiscoveryConfig holds the configuration for DNS based peer discovery.
+type DNSDiscoveryConfig struct {
+	// Hostname holds the hostname to resolve to the peer addresses.
+	Hostname string `mapstructure:"hostname"`
+
+	// Port holds the port of the peer gRPC servers.
+	Port int `mapstructure:"port"`
+
+	// RefreshInterval holds the interval at which the hostname is
+	// resolved again.
+	//
+	// Defaults to 30s
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
 }
 
 // RateLimitSettings holds the core rate limiting configuration.
@@ -73,6 +165,38 @@ type RateLimitSettings struct {
 	//
 	// Defaults to 1s
 	RetryDelay time.Duration `mapstructure:"retry_delay"`
+
+	// AdditionalLimits holds limits on other strategies that must be
+	// satisfied together with the limit defined by Strategy, Rate and
+	// Burst, e.g. limiting both records and bytes. Tokens are only taken
+	// from the buckets if all limits pass.
+	//
+	// Defaults to empty
+	AdditionalLimits []AdditionalLimit `mapstructure:"additional_limits"`
+}
+
+// AdditionalLimit defines a limit on an additional strategy.
+type AdditionalLimit struct {
+	// Strategy holds the rate limiting strategy of the limit. It must
+	// differ from the main strategy and from other additional limits.
+	Strategy Strategy `mapstructure:"strategy"`
+
+	// Rate holds bucket refill rate, in tokens per second.
+	Rate int `mapstructure:"rate"`
+
+	// Burst holds the maximum capacity of rate limit buckets.
+	Burst int `mapstructure:"burst"`
+}
+
+// SignalWeights holds per-signal multipliers applied to the number of
+// tokens taken from the bucket of the main limit, e.g. to make a profile
+// sample cost more than a log record. The resulting number of tokens is
+// rounded up.
+type SignalWeights struct {
+	Logs     float64 `mapstructure:"logs"`
+	Metrics  float64 `mapstructure:"metrics"`
+	Traces   float64 `mapstructure:"traces"`
+	Profiles float64 `mapstructure:"profiles"`
 }
 
 // RateLimitOverrides defines per-unique-key override settings.
@@ -94,6 +218,19 @@ type RateLimitOverrides struct {
 	ThrottleInterval *time.Duration `mapstructure:"throttle_interval"`
 }
 
+// DynamicOverridesConfig holds the configuration for the source of
+// dynamic overrides. Exactly one of File or Extension must be set.
+type DynamicOverridesConfig struct {
+	// File holds the path to a JSON or YAML file holding a list of
+	// overrides under the "overrides" key. The file is watched and
+	// reloaded whenever it changes.
+	File string `mapstructure:"file"`
+
+	// Extension holds the ID of an extension implementing
+	// OverridesProvider.
+	Extension *component.ID `mapstructure:"extension"`
+}
+
 // Strategy identifies the rate-limiting strategy: requests, records, or bytes.
 type Strategy string
 
@@ -135,6 +272,14 @@ const (
 
 	// DefaultRetryDelay is the default value for the retry delay.
 	DefaultRetryDelay time.Duration = 1 * time.Second
+
+	// DefaultPeerTimeout is the default value for the timeout of
+	// requests forwarded to peers.
+	DefaultPeerTimeout time.Duration = 500 * time.Millisecond
+
+	// DefaultDNSRefreshInterval is the default value for the interval
+	// at which peers are discovered through DNS.
+	DefaultDNSRefreshInterval time.Duration = 30 * time.Second
 )
 
 // ThrottleBehavior identifies the behavior when rate limit is exceeded.
@@ -146,6 +291,11 @@ const (
 
 	// ThrottleBehaviorDelay is the behavior to delay the sending until it is no longer throttled.
 	ThrottleBehaviorDelay ThrottleBehavior = "delay"
+
+	// ThrottleBehaviorShed is the behavior to drop the low-priority records of a throttled
+	// request and to send the remaining records if they are within the limit, returning an
+	// error otherwise.
+	ThrottleBehaviorShed ThrottleBehavior = "shed"
 )
 
 func createDefaultConfig() component.Config {
@@ -156,31 +306,52 @@ func createDefaultConfig() component.Config {
 			ThrottleInterval: DefaultThrottleInterval,
 			RetryDelay:       DefaultRetryDelay,
 		},
+		Weights: SignalWeights{
+			Logs:     1,
+			Metrics:  1,
+			Traces:   1,
+			Profiles: 1,
+		},
+		Distributed: configoptional.Default(defaultDistributedConfig()),
+	}
+}
+
+func defaultDistributedConfig() DistributedConfig {
+	return DistributedConfig{
+		Server:  configgrpc.NewDefaultServerConfig(),
+		Client:  configgrpc.NewDefaultClientConfig(),
+		Timeout: DefaultPeerTimeout,
+		DNS: configoptional.Default(DNSDiscoveryConfig{
+			RefreshInterval: DefaultDNSRefreshInterval,
+		}),
 	}
 }
 
 // resolveRateLimit computes the effective RateLimitSettings for a given unique key.
-func resolveRateLimit(cfg *Config, metadata client.Metadata) RateLimitSettings {
+// Dynamic overrides are matched before the configured overrides.
+func resolveRateLimit(cfg *Config, dynamic []RateLimitOverrides, metadata client.Metadata) RateLimitSettings {
 	result := cfg.RateLimitSettings
-	for _, override := range cfg.Overrides {
-		match := true
-		for k, v := range override.Matches {
-			if slices.Compare(metadata.Get(k), v) != 0 {
-				match = false
-				break
-			}
-		}
-		if match {
-			if override.Rate != nil {
-				result.Rate = *override.Rate
-			}
-			if override.Burst != nil {
-				result.Burst = *override.Burst
+	for _, overrides := range [2][]RateLimitOverrides{dynamic, cfg.Overrides} {
+		for _, override := range overrides {
+			match := true
+			for k, v := range override.Matches {
+				if slices.Compare(metadata.Get(k), v) != 0 {
+					match = false
+					break
+				}
 			}
-			if override.ThrottleInterval != nil {
-				result.ThrottleInterval = *override.ThrottleInterval
+			if match {
+				if override.Rate != nil {
+					result.Rate = *override.Rate
+				}
+				if override.Burst != nil {
+					result.Burst = *override.Burst
+				}
+				if override.ThrottleInterval != nil {
+					result.ThrottleInterval = *override.ThrottleInterval
+				}
+				return result
 			}
-			return result
 		}
 	}
 	return result
@@ -204,6 +375,51 @@ func (r *RateLimitSettings) Validate() error {
 	if r.ThrottleInterval <= 0 {
 		errs = append(errs, fmt.Errorf("throttle_interval must be greater than zero"))
 	}
+	strategies := []Strategy{r.Strategy}
+	for i, limit := range r.AdditionalLimits {
+		if err := limit.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("additional_limits %d: %w", i, err))
+			continue
+		}
+		if slices.Contains(strategies, limit.Strategy) {
+			errs = append(errs, fmt.Errorf("additional_limits %d: strategy %q is already limited", i, string(limit.Strategy)))
+		}
+		strategies = append(strategies, limit.Strategy)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate performs semantic validation of an AdditionalLimit instance.
+func (l *AdditionalLimit) Validate() error {
+	var errs []error
+	if l.Rate <= 0 {
+		errs = append(errs, errors.New("rate must be greater than zero"))
+	}
+	if l.Burst <= 0 {
+		errs = append(errs, errors.New("burst must be greater than zero"))
+	}
+	if err := l.Strategy.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate performs semantic validation of a SignalWeights instance.
+func (w *SignalWeights) Validate() error {
+	var errs []error
+	for _, weight := range []struct {
+		name  string
+		value float64
+	}{
+		{"logs", w.Logs},
+		{"metrics", w.Metrics},
+		{"traces", w.Traces},
+		{"profiles", w.Profiles},
+	} {
+		if weight.value <= 0 {
+			errs = append(errs, fmt.Errorf("weights::%s must be greater than zero", weight.name))
+		}
+	}
 	return errors.Join(errs...)
 }
 
@@ -226,6 +442,50 @@ func (r *RateLimitOverrides) Validate() error {
 	return errors.Join(errs...)
 }
 
+// Validate performs semantic validation of a DistributedConfig instance.
+func (d *DistributedConfig) Validate() error {
+	var errs []error
+	if d.Server.NetAddr.Endpoint == "" {
+		errs = append(errs, errors.New("server endpoint must be specified"))
+	}
+	if d.AdvertiseAddress == "" {
+		errs = append(errs, errors.New("advertise_address must be specified"))
+	}
+	switch {
+	case len(d.Peers) == 0 && !d.DNS.HasValue():
+		errs = append(errs, errors.New("one of peers or dns must be specified"))
+	case len(d.Peers) > 0 && d.DNS.HasValue():
+		errs = append(errs, errors.New("peers and dns are mutually exclusive"))
+	}
+	if d.Timeout <= 0 {
+		errs = append(errs, errors.New("timeout must be greater than zero"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate performs semantic validation of a DNSDiscoveryConfig instance.
+func (d *DNSDiscoveryConfig) Validate() error {
+	var errs []error
+	if d.Hostname == "" {
+		errs = append(errs, errors.New("hostname must be specified"))
+	}
+	if d.Port <= 0 || d.Port > 65535 {
+		errs = append(errs, errors.New("port must be between 1 and 65535"))
+	}
+	if d.RefreshInterval <= 0 {
+		errs = append(errs, errors.New("refresh_interval must be greater than zero"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate performs semantic validation of a DynamicOverridesConfig instance.
+func (d *DynamicOverridesConfig) Validate() error {
+	if (d.File == "") == (d.Extension == nil) {
+		return errors.New("exactly one of file or extension must be specified")
+	}
+	return nil
+}
+
 func (config *Config) Validate() error {
 	var errs []error
 	if err := config.RateLimitSettings.Validate(); err != nil {
@@ -236,6 +496,9 @@ func (config *Config) Validate() error {
 			errs = append(errs, fmt.Errorf("override %q: %w", key, err))
 		}
 	}
+	if config.ThrottleBehavior == ThrottleBehaviorShed && config.Shedding.empty() {
+		errs = append(errs, errors.New("shedding conditions must be specified when throttle_behavior is shed"))
+	}
 	return errors.Join(errs...)
 }
 
@@ -258,7 +521,7 @@ func (s Strategy) Validate() error {
 // Validate checks if throttle behavior matches the possible options
 func (s ThrottleBehavior) Validate() error {
 	switch s {
-	case ThrottleBehaviorError, ThrottleBehaviorDelay:
+	case ThrottleBehaviorError, ThrottleBehaviorDelay, ThrottleBehaviorShed:
 		return nil
 	}
 	return fmt.Errorf(
@@ -266,6 +529,11 @@ func (s ThrottleBehavior) Validate() error {
 		s, []string{
 			string(ThrottleBehaviorError),
 			string(ThrottleBehaviorDelay),
+			string(ThrottleBehaviorShed),
 		},
 	)
 }
+
+func (s *SheddingConfig) empty() bool {
+	return len(s.Logs) == 0 && len(s.DataPoints) == 0 && len(s.Spans) == 0 && len(s.ProfileSamples) == 0
+}
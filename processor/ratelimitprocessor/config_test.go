@@ -26,6 +26,7 @@ import (
 	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/config/configoptional"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
 	"go.opentelemetry.io/collector/confmap/xconfmap"
 
@@ -36,6 +37,7 @@ func TestLoadConfig(t *testing.T) {
 	grpcClientConfig := configgrpc.NewDefaultClientConfig()
 	grpcClientConfig.Endpoint = "localhost:1081"
 
+	defaultWeights := SignalWeights{Logs: 1, Metrics: 1, Traces: 1, Profiles: 1}
 	tests := []struct {
 		name        string
 		expected    component.Config
@@ -44,6 +46,8 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "local",
 			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
 				RateLimitSettings: RateLimitSettings{
 					Rate:             100,
 					Burst:            200,
@@ -57,6 +61,8 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "strategy",
 			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
 				RateLimitSettings: RateLimitSettings{
 					Rate:             100,
 					Burst:            200,
@@ -70,6 +76,8 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "metadata_keys",
 			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
 				RateLimitSettings: RateLimitSettings{
 					Rate:             100,
 					Burst:            200,
@@ -84,6 +92,8 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "overrides_all",
 			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
 				RateLimitSettings: RateLimitSettings{
 					Rate:             100,
 					Burst:            200,
@@ -106,6 +116,8 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "overrides_rate",
 			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
 				RateLimitSettings: RateLimitSettings{
 					Rate:             100,
 					Burst:            200,
@@ -127,6 +139,8 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "overrides_burst",
 			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
 				RateLimitSettings: RateLimitSettings{
 					Rate:             100,
 					Burst:            200,
@@ -148,6 +162,8 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "overrides_throttle_interval",
 			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
 				RateLimitSettings: RateLimitSettings{
 					Rate:             100,
 					Burst:            200,
@@ -167,6 +183,143 @@ func TestLoadConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "distributed_static",
+			expected: &Config{
+				Weights: defaultWeights,
+				RateLimitSettings: RateLimitSettings{
+					Rate:             100,
+					Burst:            200,
+					Strategy:         StrategyRateLimitRequests,
+					ThrottleBehavior: ThrottleBehaviorError,
+					ThrottleInterval: 1 * time.Second,
+					RetryDelay:       1 * time.Second,
+				},
+				Distributed: func() configoptional.Optional[DistributedConfig] {
+					d := defaultDistributedConfig()
+					d.Server.NetAddr.Endpoint = "0.0.0.0:4320"
+					d.AdvertiseAddress = "10.0.0.1:4320"
+					d.Peers = []string{"10.0.0.1:4320", "10.0.0.2:4320"}
+					return configoptional.Some(d)
+				}(),
+			},
+		},
+		{
+			name: "distributed_dns",
+			expected: &Config{
+				Weights: defaultWeights,
+				RateLimitSettings: RateLimitSettings{
+					Rate:             100,
+					Burst:            200,
+					Strategy:         StrategyRateLimitRequests,
+					ThrottleBehavior: ThrottleBehaviorError,
+					ThrottleInterval: 1 * time.Second,
+					RetryDelay:       1 * time.Second,
+				},
+				Distributed: func() configoptional.Optional[DistributedConfig] {
+					d := defaultDistributedConfig()
+					d.Server.NetAddr.Endpoint = "0.0.0.0:4320"
+					d.AdvertiseAddress = "10.0.0.1:4320"
+					d.DNS = configoptional.Some(DNSDiscoveryConfig{
+						Hostname:        "collector-headless.default.svc.cluster.local",
+						Port:            4320,
+						RefreshInterval: DefaultDNSRefreshInterval,
+					})
+					d.Timeout = time.Second
+					return configoptional.Some(d)
+				}(),
+			},
+		},
+		{
+			name:        "distributed_no_peers",
+			expectedErr: "one of peers or dns must be specified",
+		},
+		{
+			name:        "distributed_invalid_dns",
+			expectedErr: "port must be between 1 and 65535",
+		},
+		{
+			name: "dynamic_overrides_file",
+			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
+				RateLimitSettings: RateLimitSettings{
+					Rate:             100,
+					Burst:            200,
+					Strategy:         StrategyRateLimitRequests,
+					ThrottleBehavior: ThrottleBehaviorError,
+					ThrottleInterval: 1 * time.Second,
+					RetryDelay:       1 * time.Second,
+				},
+				DynamicOverrides: configoptional.Some(DynamicOverridesConfig{
+					File: "/etc/otelcol/ratelimit-overrides.yaml",
+				}),
+			},
+		},
+		{
+			name:        "dynamic_overrides_invalid",
+			expectedErr: "exactly one of file or extension must be specified",
+		},
+		{
+			name: "additional_limits",
+			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights: SignalWeights{
+					Logs:     1,
+					Metrics:  1,
+					Traces:   2.5,
+					Profiles: 10,
+				},
+				RateLimitSettings: RateLimitSettings{
+					Rate:             100,
+					Burst:            200,
+					Strategy:         StrategyRateLimitRecords,
+					ThrottleBehavior: ThrottleBehaviorError,
+					ThrottleInterval: 1 * time.Second,
+					RetryDelay:       1 * time.Second,
+					AdditionalLimits: []AdditionalLimit{{
+						Strategy: StrategyRateLimitBytes,
+						Rate:     1000,
+						Burst:    2000,
+					}},
+				},
+			},
+		},
+		{
+			name:        "additional_limits_duplicate_strategy",
+			expectedErr: `additional_limits 0: strategy "records" is already limited`,
+		},
+		{
+			name:        "additional_limits_invalid",
+			expectedErr: "additional_limits 0: rate must be greater than zero",
+		},
+		{
+			name:        "invalid_weights",
+			expectedErr: "weights::profiles must be greater than zero",
+		},
+		{
+			name: "shed",
+			expected: &Config{
+				Distributed: configoptional.Default(defaultDistributedConfig()),
+				Weights:     defaultWeights,
+				RateLimitSettings: RateLimitSettings{
+					Rate:             100,
+					Burst:            200,
+					Strategy:         StrategyRateLimitRecords,
+					ThrottleBehavior: ThrottleBehaviorShed,
+					ThrottleInterval: 1 * time.Second,
+					RetryDelay:       1 * time.Second,
+				},
+				Shedding: SheddingConfig{
+					Logs:  []string{"severity_number < SEVERITY_NUMBER_WARN"},
+					Spans: []string{"status.code != STATUS_CODE_ERROR"},
+				},
+			},
+		},
+		{
+			name:        "shed_no_conditions",
+			expectedErr: "shedding conditions must be specified when throttle_behavior is shed",
+		},
 		{
 			name:        "invalid_rate",
 			expectedErr: "rate must be greater than zero",
@@ -181,7 +334,7 @@ func TestLoadConfig(t *testing.T) {
 		},
 		{
 			name:        "invalid_throttle_behavior",
-			expectedErr: `invalid throttle behavior "foo", expected one of ["error" "delay"]`,
+			expectedErr: `invalid throttle behavior "foo", expected one of ["error" "delay" "shed"]`,
 		},
 	}
 
@@ -235,7 +388,7 @@ func TestResolveEffectiveRateLimit(t *testing.T) {
 			"project-id":   {"e678ebd7-3a15-43dd-a95c-1cf0639a6292"},
 			"project-type": {"test"},
 		})
-		res := resolveRateLimit(cfg, metadata)
+		res := resolveRateLimit(cfg, nil, metadata)
 		require.Equal(t, 300, res.Rate)
 		require.Equal(t, 400, res.Burst)
 		require.Equal(t, 10*time.Second, res.ThrottleInterval)
@@ -246,7 +399,7 @@ func TestResolveEffectiveRateLimit(t *testing.T) {
 			"project-id":   {"e678ebd7-3a15-43dd-a95c-1cf0639a6292"},
 			"project-type": {"404"},
 		})
-		res := resolveRateLimit(cfg, metadata)
+		res := resolveRateLimit(cfg, nil, metadata)
 		require.Equal(t, cfg.RateLimitSettings, res)
 	})
 }
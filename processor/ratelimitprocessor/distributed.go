@@ -0,0 +1,388 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/metadata"
+	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/peerrpc"
+	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/telemetry"
+)
+
+var _ RateLimiter = (*distributedRateLimiter)(nil)
+
+// distributedRateLimiter shares rate limit buckets across collector
+// replicas. Every unique key is owned by a single peer, chosen through
+// consistent hashing of the key, which holds the bucket in its local rate
+// limiter. Requests for keys owned by other peers are forwarded to them
+// over gRPC. If the owner cannot be reached, the rate limit is applied by
+// the local rate limiter instead.
+type distributedRateLimiter struct {
+	cfg   *Config
+	dcfg  *DistributedConfig
+	set   processor.Settings
+	local *localRateLimiter
+	tb    *metadata.TelemetryBuilder
+
+	// lookupHost resolves the DNS discovery hostname, overridden in tests.
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+
+	server *grpc.Server
+	host   component.Host
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu    sync.RWMutex
+	ring  *hashRing
+	peers []string
+	conns map[string]*grpc.ClientConn
+	// failedPeers holds the peers whose connection could not be set up, so
+	// the failure is only logged when their state changes.
+	failedPeers map[string]struct{}
+}
+
+func newDistributedRateLimiter(cfg *Config, set processor.Settings) (*distributedRateLimiter, error) {
+	local, err := newLocalRateLimiter(cfg, set)
+	if err != nil {
+		return nil, err
+	}
+	tb, err := metadata.NewTelemetryBuilder(set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	return &distributedRateLimiter{
+		cfg:         cfg,
+		dcfg:        cfg.Distributed.Get(),
+		set:         set,
+		local:       local,
+		tb:          tb,
+		lookupHost:  net.DefaultResolver.LookupHost,
+		ring:        newHashRing(nil),
+		conns:       make(map[string]*grpc.ClientConn),
+		failedPeers: make(map[string]struct{}),
+	}, nil
+}
+
+func (r *distributedRateLimiter) Start(ctx context.Context, host component.Host) error {
+	if err := r.local.Start(ctx, host); err != nil {
+		return err
+	}
+	r.host = host
+
+	var err error
+	r.server, err = r.dcfg.Server.ToServer(ctx, host.GetExtensions(), r.set.TelemetrySettings)
+	if err != nil {
+		return fmt.Errorf("failed to create peer server: %w", err)
+	}
+	peerrpc.RegisterServer(r.server, r.handlePeerRequest)
+
+	ln, err := r.dcfg.Server.NetAddr.Listen(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", r.dcfg.Server.NetAddr.Endpoint, err)
+	}
+	r.set.Logger.Info("Starting peer rate limiter server",
+		zap.String("endpoint", ln.Addr().String()),
+		zap.String("advertise_address", r.dcfg.AdvertiseAddress),
+	)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if errGRPC := r.server.Serve(ln); errGRPC != nil && !errors.Is(errGRPC, grpc.ErrServerStopped) {
+			componentstatus.ReportStatus(host, componentstatus.NewFatalErrorEvent(errGRPC))
+		}
+	}()
+
+	if len(r.dcfg.Peers) > 0 {
+		r.setPeers(r.dcfg.Peers)
+		return nil
+	}
+
+	// The discovery context must outlive Start, so it is not derived from ctx.
+	discoveryCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	dns := r.dcfg.DNS.Get()
+	r.discoverDNS(discoveryCtx, dns)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(dns.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-discoveryCtx.Done():
+				return
+			case <-ticker.C:
+				r.discoverDNS(discoveryCtx, dns)
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *distributedRateLimiter) Shutdown(ctx context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	if r.server != nil {
+		// Requests forwarded in delay mode may wait for a long time,
+		// so the server is stopped without waiting for them.
+		r.server.Stop()
+	}
+	r.wg.Wait()
+
+	r.mu.Lock()
+	var errs []error
+	for peer, conn := range r.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close connection to peer %s: %w", peer, err))
+		}
+	}
+	r.conns = make(map[string]*grpc.ClientConn)
+	r.failedPeers = make(map[string]struct{})
+	r.ring = newHashRing(nil)
+	r.peers = nil
+	r.mu.Unlock()
+
+	errs = append(errs, r.local.Shutdown(ctx))
+	r.tb.Shutdown()
+	return errors.Join(errs...)
+}
+
+func (r *distributedRateLimiter) RateLimit(ctx context.Context, hits int, additional ...int) (RateLimitResult, error) {
+	clientMetadata := client.FromContext(ctx).Metadata
+	key := getUniqueKey(clientMetadata, r.cfg.MetadataKeys)
+	cfg := resolveRateLimit(r.cfg, r.local.overrides.get(), clientMetadata)
+
+	peer, conn, err := r.owner(key)
+	if err != nil {
+		// The failure is logged by owner when the peer starts failing.
+		r.tb.RatelimitPeerFallbacks.Add(ctx, 1, metric.WithAttributes(telemetry.WithPeer(peer)))
+		return r.local.rateLimitKey(ctx, key, cfg, hits, additional)
+	}
+	if conn == nil {
+		// This collector owns the key.
+		return r.local.rateLimitKey(ctx, key, cfg, hits, additional)
+	}
+
+	result, err := r.forward(ctx, conn, key, cfg, hits, additional)
+	switch {
+	case err == nil, result.Decision == DecisionThrottled:
+		return result, err
+	case ctx.Err() != nil:
+		return RateLimitResult{Decision: DecisionCancelled, ConfigRate: float64(cfg.Rate)}, ctx.Err()
+	}
+	if ce := r.set.Logger.Check(zap.DebugLevel, "peer is unreachable, rate limiting locally"); ce != nil {
+		ce.Write(zap.String("peer", peer), zap.Error(err))
+	}
+	r.tb.RatelimitPeerFallbacks.Add(ctx, 1, metric.WithAttributes(telemetry.WithPeer(peer)))
+	return r.local.rateLimitKey(ctx, key, cfg, hits, additional)
+}
+
+// owner returns the peer owning key and a connection to it. The returned
+// connection is nil if this collector owns the key.
+func (r *distributedRateLimiter) owner(key string) (string, *grpc.ClientConn, error) {
+	r.mu.RLock()
+	peer := r.ring.get(key)
+	conn, ok := r.conns[peer]
+	r.mu.RUnlock()
+	if peer == "" || peer == r.dcfg.AdvertiseAddress || ok {
+		return peer, conn, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if conn, ok := r.conns[peer]; ok {
+		return peer, conn, nil
+	}
+	if !slices.Contains(r.peers, peer) {
+		// Peers changed concurrently, the key is retried locally.
+		return peer, nil, nil
+	}
+	clientCfg := r.dcfg.Client
+	clientCfg.Endpoint = peer
+	conn, err := clientCfg.ToClientConn(context.Background(), r.host.GetExtensions(), r.set.TelemetrySettings)
+	if err != nil {
+		if _, failed := r.failedPeers[peer]; !failed {
+			r.failedPeers[peer] = struct{}{}
+			r.set.Logger.Warn("failed to connect to peer, rate limiting its keys locally until the connection can be set up",
+				zap.String("peer", peer), zap.Error(err),
+			)
+		}
+		return peer, nil, err
+	}
+	if _, failed := r.failedPeers[peer]; failed {
+		delete(r.failedPeers, peer)
+		r.set.Logger.Info("connected to peer", zap.String("peer", peer))
+	}
+	r.conns[peer] = conn
+	return peer, conn, nil
+}
+
+// forward forwards a rate limit request to the peer behind conn.
+func (r *distributedRateLimiter) forward(
+	ctx context.Context,
+	conn *grpc.ClientConn,
+	key string,
+	cfg RateLimitSettings,
+	hits int,
+	additional []int,
+) (RateLimitResult, error) {
+	// In delay mode the owner holds the request until tokens are
+	// available, so the request is only bounded by the caller.
+	if cfg.ThrottleBehavior != ThrottleBehaviorDelay {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.dcfg.Timeout)
+		defer cancel()
+	}
+	req := &peerrpc.Request{
+		Key:              key,
+		Hits:             hits,
+		Rate:             cfg.Rate,
+		Burst:            cfg.Burst,
+		ThrottleBehavior: string(cfg.ThrottleBehavior),
+	}
+	for i, limit := range cfg.AdditionalLimits {
+		var n int
+		if i < len(additional) {
+			n = additional[i]
+		}
+		req.AdditionalLimits = append(req.AdditionalLimits, peerrpc.Limit{
+			Strategy: string(limit.Strategy),
+			Hits:     n,
+			Rate:     limit.Rate,
+			Burst:    limit.Burst,
+		})
+	}
+	resp, err := peerrpc.Invoke(ctx, conn, req)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	result := RateLimitResult{
+		Decision:         Decision(resp.Decision),
+		Delay:            time.Duration(resp.DelayNanos),
+		TokensBefore:     resp.TokensBefore,
+		TokensAfter:      resp.TokensAfter,
+		ConfigRate:       resp.ConfigRate,
+		EmitTokenMetrics: resp.EmitTokenMetrics,
+		Limit:            Strategy(resp.Limit),
+	}
+	if result.Decision == DecisionThrottled {
+		return result, errorWithDetails(errTooManyRequests, cfg)
+	}
+	return result, nil
+}
+
+// handlePeerRequest applies a rate limit request forwarded by a peer to
+// the local bucket of the key. Throttling is part of the response rather
+// than an error, so that peers can tell it apart from transport errors.
+func (r *distributedRateLimiter) handlePeerRequest(ctx context.Context, req *peerrpc.Request) (*peerrpc.Response, error) {
+	cfg := r.cfg.RateLimitSettings
+	cfg.Rate = req.Rate
+	cfg.Burst = req.Burst
+	cfg.ThrottleBehavior = ThrottleBehavior(req.ThrottleBehavior)
+	cfg.AdditionalLimits = make([]AdditionalLimit, len(req.AdditionalLimits))
+	additional := make([]int, len(req.AdditionalLimits))
+	for i, limit := range req.AdditionalLimits {
+		cfg.AdditionalLimits[i] = AdditionalLimit{
+			Strategy: Strategy(limit.Strategy),
+			Rate:     limit.Rate,
+			Burst:    limit.Burst,
+		}
+		additional[i] = limit.Hits
+	}
+
+	result, err := r.local.rateLimitKey(ctx, req.Key, cfg, req.Hits, additional)
+	if err != nil && result.Decision != DecisionThrottled {
+		return nil, err
+	}
+	return &peerrpc.Response{
+		Decision:         string(result.Decision),
+		DelayNanos:       int64(result.Delay),
+		TokensBefore:     result.TokensBefore,
+		TokensAfter:      result.TokensAfter,
+		ConfigRate:       result.ConfigRate,
+		EmitTokenMetrics: result.EmitTokenMetrics,
+		Limit:            string(result.Limit),
+	}, nil
+}
+
+// discoverDNS resolves the configured hostname and updates the peers.
+// The previous peers are kept if the hostname cannot be resolved.
+func (r *distributedRateLimiter) discoverDNS(ctx context.Context, dns *DNSDiscoveryConfig) {
+	addrs, err := r.lookupHost(ctx, dns.Hostname)
+	if err != nil {
+		r.set.Logger.Warn("failed to discover peers", zap.String("hostname", dns.Hostname), zap.Error(err))
+		return
+	}
+	peers := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		peers = append(peers, net.JoinHostPort(addr, strconv.Itoa(dns.Port)))
+	}
+	r.setPeers(peers)
+}
+
+// setPeers updates the hash ring with the given peers, always including
+// this collector, and closes the connections to removed peers.
+func (r *distributedRateLimiter) setPeers(peers []string) {
+	peers = slices.Clone(peers)
+	if !slices.Contains(peers, r.dcfg.AdvertiseAddress) {
+		peers = append(peers, r.dcfg.AdvertiseAddress)
+	}
+	slices.Sort(peers)
+	peers = slices.Compact(peers)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Equal(peers, r.peers) {
+		return
+	}
+	for peer, conn := range r.conns {
+		if slices.Contains(peers, peer) {
+			continue
+		}
+		if err := conn.Close(); err != nil {
+			r.set.Logger.Warn("failed to close connection to peer", zap.String("peer", peer), zap.Error(err))
+		}
+		delete(r.conns, peer)
+	}
+	for peer := range r.failedPeers {
+		if !slices.Contains(peers, peer) {
+			delete(r.failedPeers, peer)
+		}
+	}
+	r.peers = peers
+	r.ring = newHashRing(peers)
+	r.set.Logger.Info("updated rate limiter peers", zap.Strings("peers", peers))
+}
@@ -0,0 +1,307 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configauth"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.opentelemetry.io/collector/confmap/xconfmap"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/metadata"
+	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/metadatatest"
+)
+
+// newTestDistributedRateLimiters starts n distributed rate limiters that
+// know each other as peers.
+func newTestDistributedRateLimiters(t *testing.T, n int, settings RateLimitSettings, extraPeers ...string) []*distributedRateLimiter {
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = getAvailableLocalAddress(t)
+	}
+	rls := make([]*distributedRateLimiter, n)
+	for i, addr := range addrs {
+		d := defaultDistributedConfig()
+		d.Server.NetAddr.Endpoint = addr
+		d.AdvertiseAddress = addr
+		d.Client.TLS.Insecure = true
+		d.Peers = append(addrs[:n:n], extraPeers...)
+		cfg := createDefaultConfig().(*Config)
+		cfg.MetadataKeys = []string{"x-tenant-id"}
+		cfg.RateLimitSettings = settings
+		cfg.Distributed = configoptional.Some(d)
+		require.NoError(t, xconfmap.Validate(cfg))
+		rl, err := newDistributedRateLimiter(cfg, processortest.NewNopSettings(metadata.Type))
+		require.NoError(t, err)
+		require.NoError(t, rl.Start(context.Background(), componenttest.NewNopHost()))
+		t.Cleanup(func() {
+			assert.NoError(t, rl.Shutdown(context.Background()))
+		})
+		rls[i] = rl
+	}
+	return rls
+}
+
+func getAvailableLocalAddress(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func tenantContext(tenant string) context.Context {
+	return client.NewContext(context.Background(), client.Info{
+		Metadata: client.NewMetadata(map[string][]string{"x-tenant-id": {tenant}}),
+	})
+}
+
+// tenantOwnedBy returns a tenant whose unique key is owned by peer.
+func tenantOwnedBy(t *testing.T, rl *distributedRateLimiter, peer string) string {
+	for _, tenant := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		rl.mu.RLock()
+		owner := rl.ring.get(getUniqueKey(client.FromContext(tenantContext(tenant)).Metadata, rl.cfg.MetadataKeys))
+		rl.mu.RUnlock()
+		if owner == peer {
+			return tenant
+		}
+	}
+	t.Fatalf("no tenant owned by %s", peer)
+	return ""
+}
+
+func TestDistributedRateLimiter_SharedBucket(t *testing.T) {
+	const burst = 10
+	rls := newTestDistributedRateLimiters(t, 3, RateLimitSettings{
+		Rate:             1,
+		Burst:            burst,
+		Strategy:         StrategyRateLimitRequests,
+		ThrottleBehavior: ThrottleBehaviorError,
+		ThrottleInterval: time.Second,
+	})
+
+	ctx := tenantContext("tenant")
+	var accepted, throttled int
+	for i := 0; i < 3*burst; i++ {
+		result, err := rls[i%len(rls)].RateLimit(ctx, 1)
+		switch result.Decision {
+		case DecisionAccepted:
+			require.NoError(t, err)
+			accepted++
+		case DecisionThrottled:
+			require.Error(t, err)
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+			throttled++
+		default:
+			t.Fatalf("unexpected decision %q: %v", result.Decision, err)
+		}
+	}
+	// All replicas share a single bucket, so the burst is not multiplied
+	// by the number of replicas. The bucket may refill by one token while
+	// the test runs.
+	assert.InDelta(t, burst, accepted, 1)
+	assert.Equal(t, 3*burst, accepted+throttled)
+}
+
+func TestDistributedRateLimiter_AdditionalLimits(t *testing.T) {
+	rls := newTestDistributedRateLimiters(t, 2, RateLimitSettings{
+		Rate:             1,
+		Burst:            10,
+		Strategy:         StrategyRateLimitRecords,
+		ThrottleBehavior: ThrottleBehaviorError,
+		ThrottleInterval: time.Second,
+		AdditionalLimits: []AdditionalLimit{{
+			Strategy: StrategyRateLimitBytes,
+			Rate:     1,
+			Burst:    100,
+		}},
+	})
+	// Use a tenant owned by the other replica so that the additional
+	// hits are forwarded.
+	ctx := tenantContext(tenantOwnedBy(t, rls[0], rls[1].dcfg.AdvertiseAddress))
+
+	result, err := rls[0].RateLimit(ctx, 1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionAccepted, result.Decision)
+
+	result, err = rls[0].RateLimit(ctx, 1, 1)
+	require.Error(t, err)
+	assert.Equal(t, DecisionThrottled, result.Decision)
+}
+
+func TestDistributedRateLimiter_Delay(t *testing.T) {
+	rls := newTestDistributedRateLimiters(t, 2, RateLimitSettings{
+		Rate:             10,
+		Burst:            1,
+		Strategy:         StrategyRateLimitRequests,
+		ThrottleBehavior: ThrottleBehaviorDelay,
+		ThrottleInterval: time.Second,
+	})
+	// Send requests through the replica that does not own the key.
+	tenant := tenantOwnedBy(t, rls[0], rls[1].dcfg.AdvertiseAddress)
+	ctx := tenantContext(tenant)
+
+	result, err := rls[0].RateLimit(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionAccepted, result.Decision)
+	assert.True(t, result.EmitTokenMetrics)
+
+	start := time.Now()
+	result, err = rls[0].RateLimit(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionDelayed, result.Decision)
+	assert.Positive(t, result.Delay)
+	assert.GreaterOrEqual(t, time.Since(start), result.Delay)
+	assert.Equal(t, float64(10), result.ConfigRate)
+}
+
+func TestDistributedRateLimiter_FallbackToLocal(t *testing.T) {
+	unreachable := getAvailableLocalAddress(t)
+	rls := newTestDistributedRateLimiters(t, 1, RateLimitSettings{
+		Rate:             1,
+		Burst:            2,
+		Strategy:         StrategyRateLimitRequests,
+		ThrottleBehavior: ThrottleBehaviorError,
+		ThrottleInterval: time.Second,
+	}, unreachable)
+	rl := rls[0]
+	tenant := tenantOwnedBy(t, rl, unreachable)
+	ctx := tenantContext(tenant)
+
+	for i := 0; i < 2; i++ {
+		result, err := rl.RateLimit(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, DecisionAccepted, result.Decision)
+	}
+	result, err := rl.RateLimit(ctx, 1)
+	require.Error(t, err)
+	assert.Equal(t, DecisionThrottled, result.Decision)
+}
+
+func TestDistributedRateLimiter_PeerConnectionFailure(t *testing.T) {
+	addr := getAvailableLocalAddress(t)
+	failing := getAvailableLocalAddress(t)
+	d := defaultDistributedConfig()
+	d.Server.NetAddr.Endpoint = addr
+	d.AdvertiseAddress = addr
+	// Connections cannot be set up because the authenticator doesn't exist.
+	d.Client.Auth = configoptional.Some(configauth.Config{AuthenticatorID: component.MustNewID("missing")})
+	d.Peers = []string{addr, failing}
+	cfg := createDefaultConfig().(*Config)
+	cfg.MetadataKeys = []string{"x-tenant-id"}
+	cfg.Rate = 100
+	cfg.Burst = 100
+	cfg.Distributed = configoptional.Some(d)
+	require.NoError(t, xconfmap.Validate(cfg))
+
+	observedZapCore, observedLogs := observer.New(zapcore.WarnLevel)
+	tt := componenttest.NewTelemetry()
+	set := processortest.NewNopSettings(metadata.Type)
+	set.TelemetrySettings = tt.NewTelemetrySettings()
+	set.Logger = zap.New(observedZapCore)
+	rl, err := newDistributedRateLimiter(cfg, set)
+	require.NoError(t, err)
+	require.NoError(t, rl.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, rl.Shutdown(context.Background()))
+	})
+
+	ctx := tenantContext(tenantOwnedBy(t, rl, failing))
+	for i := 0; i < 3; i++ {
+		result, err := rl.RateLimit(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, DecisionAccepted, result.Decision)
+	}
+
+	// The failure is logged once, and every fallback is counted.
+	assert.Equal(t, 1, observedLogs.FilterMessageSnippet("failed to connect to peer").Len())
+	metadatatest.AssertEqualRatelimitPeerFallbacks(t, tt, []metricdata.DataPoint[int64]{
+		{
+			Value:      3,
+			Attributes: attribute.NewSet(attribute.String("peer", failing)),
+		},
+	}, metricdatatest.IgnoreTimestamp())
+}
+
+func TestDistributedRateLimiter_DNSDiscovery(t *testing.T) {
+	d := defaultDistributedConfig()
+	d.Server.NetAddr.Endpoint = getAvailableLocalAddress(t)
+	d.AdvertiseAddress = "10.0.0.1:4320"
+	d.DNS = configoptional.Some(DNSDiscoveryConfig{
+		Hostname:        "collector",
+		Port:            4320,
+		RefreshInterval: 10 * time.Millisecond,
+	})
+	cfg := createDefaultConfig().(*Config)
+	cfg.Rate = 1
+	cfg.Burst = 1
+	cfg.Distributed = configoptional.Some(d)
+	require.NoError(t, xconfmap.Validate(cfg))
+
+	rl, err := newDistributedRateLimiter(cfg, processortest.NewNopSettings(metadata.Type))
+	require.NoError(t, err)
+	addrs := make(chan []string, 1)
+	addrs <- []string{"10.0.0.2"}
+	var last []string
+	rl.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		assert.Equal(t, "collector", host)
+		select {
+		case last = <-addrs:
+		default:
+		}
+		if last == nil {
+			return nil, errors.New("no such host")
+		}
+		return last, nil
+	}
+	require.NoError(t, rl.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, rl.Shutdown(context.Background()))
+	})
+
+	peers := func() []string {
+		rl.mu.RLock()
+		defer rl.mu.RUnlock()
+		return rl.peers
+	}
+	// This collector is always part of the peers.
+	assert.Equal(t, []string{"10.0.0.1:4320", "10.0.0.2:4320"}, peers())
+
+	addrs <- []string{"10.0.0.1", "10.0.0.3"}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"10.0.0.1:4320", "10.0.0.3:4320"}, peers())
+	}, time.Second, 10*time.Millisecond)
+}
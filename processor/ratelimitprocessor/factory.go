@@ -57,6 +57,9 @@ func getRateLimiter(
 	set processor.Settings,
 ) (*sharedcomponent.Component[rateLimiterComponent], error) {
 	return rateLimiters.LoadOrStore(config, func() (rateLimiterComponent, error) {
+		if config.Distributed.HasValue() {
+			return newDistributedRateLimiter(config, set)
+		}
 		return newLocalRateLimiter(config, set)
 	})
 }
@@ -76,6 +79,12 @@ func createLogsProcessor(
 	if err != nil {
 		return nil, err
 	}
+	var shed shedFunc[plog.Logs]
+	if config.ThrottleBehavior == ThrottleBehaviorShed {
+		if shed, err = newLogsShedFunc(config.Shedding.Logs, set.TelemetrySettings); err != nil {
+			return nil, err
+		}
+	}
 	return NewLogsRateLimiterProcessor(
 		rateLimiter,
 		set.Logger,
@@ -86,6 +95,9 @@ func createLogsProcessor(
 			return nextConsumer.ConsumeLogs(ctx, ld)
 		},
 		config.MetadataKeys,
+		config.Weights.Logs,
+		config.AdditionalLimits,
+		shed,
 	)
 }
 
@@ -104,6 +116,12 @@ func createMetricsProcessor(
 	if err != nil {
 		return nil, err
 	}
+	var shed shedFunc[pmetric.Metrics]
+	if config.ThrottleBehavior == ThrottleBehaviorShed {
+		if shed, err = newMetricsShedFunc(config.Shedding.DataPoints, set.TelemetrySettings); err != nil {
+			return nil, err
+		}
+	}
 	return NewMetricsRateLimiterProcessor(
 		rateLimiter,
 		set.Logger,
@@ -114,6 +132,9 @@ func createMetricsProcessor(
 			return nextConsumer.ConsumeMetrics(ctx, md)
 		},
 		config.MetadataKeys,
+		config.Weights.Metrics,
+		config.AdditionalLimits,
+		shed,
 	)
 }
 
@@ -132,6 +153,12 @@ func createTracesProcessor(
 	if err != nil {
 		return nil, err
 	}
+	var shed shedFunc[ptrace.Traces]
+	if config.ThrottleBehavior == ThrottleBehaviorShed {
+		if shed, err = newTracesShedFunc(config.Shedding.Spans, set.TelemetrySettings); err != nil {
+			return nil, err
+		}
+	}
 	return NewTracesRateLimiterProcessor(
 		rateLimiter,
 		set.Logger,
@@ -142,6 +169,9 @@ func createTracesProcessor(
 			return nextConsumer.ConsumeTraces(ctx, td)
 		},
 		config.MetadataKeys,
+		config.Weights.Traces,
+		config.AdditionalLimits,
+		shed,
 	)
 }
 
@@ -160,6 +190,12 @@ func createProfilesProcessor(
 	if err != nil {
 		return nil, err
 	}
+	var shed shedFunc[pprofile.Profiles]
+	if config.ThrottleBehavior == ThrottleBehaviorShed {
+		if shed, err = newProfilesShedFunc(config.Shedding.ProfileSamples, set.TelemetrySettings); err != nil {
+			return nil, err
+		}
+	}
 	return NewProfilesRateLimiterProcessor(
 		rateLimiter,
 		set.Logger,
@@ -170,5 +206,8 @@ func createProfilesProcessor(
 			return nextConsumer.ConsumeProfiles(ctx, td)
 		},
 		config.MetadataKeys,
+		config.Weights.Profiles,
+		config.AdditionalLimits,
+		shed,
 	)
 }
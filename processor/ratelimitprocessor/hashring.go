@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor"
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// hashRingReplicas is the number of virtual nodes of each peer in the
+// hash ring. A higher number spreads keys more evenly across peers.
+const hashRingReplicas = 128
+
+// hashRing assigns unique keys to peers using consistent hashing, so
+// that adding or removing a peer only moves the keys of that peer.
+type hashRing struct {
+	hashes []uint64
+	peers  map[uint64]string
+}
+
+func newHashRing(peers []string) *hashRing {
+	r := &hashRing{
+		hashes: make([]uint64, 0, len(peers)*hashRingReplicas),
+		peers:  make(map[uint64]string, len(peers)*hashRingReplicas),
+	}
+	for _, peer := range peers {
+		for i := 0; i < hashRingReplicas; i++ {
+			h := xxhash.Sum64String(strconv.Itoa(i) + peer)
+			if _, ok := r.peers[h]; ok {
+				continue
+			}
+			r.peers[h] = peer
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	slices.Sort(r.hashes)
+	return r
+}
+
+// get returns the peer owning key, or an empty string if the ring has
+// no peers.
+func (r *hashRing) get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := xxhash.Sum64String(key)
+	i, _ := slices.BinarySearch(r.hashes, h)
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.peers[r.hashes[i]]
+}
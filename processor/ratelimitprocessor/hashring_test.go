@@ -0,0 +1,54 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashRing(t *testing.T) {
+	assert.Empty(t, newHashRing(nil).get("key"))
+
+	peers := []string{"10.0.0.1:4320", "10.0.0.2:4320", "10.0.0.3:4320"}
+	ring := newHashRing(peers)
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := "x-tenant-id:" + strconv.Itoa(i)
+		owner := ring.get(key)
+		assert.Contains(t, peers, owner)
+		assert.Equal(t, owner, ring.get(key), "ownership must be deterministic")
+		owners[key] = owner
+		counts[owner]++
+	}
+	for _, peer := range peers {
+		// Every peer should own a reasonable share of the keys.
+		assert.Greater(t, counts[peer], 600, peer)
+	}
+
+	// Removing a peer only moves the keys owned by that peer.
+	ring = newHashRing(peers[:2])
+	for key, owner := range owners {
+		if owner != peers[2] {
+			assert.Equal(t, owner, ring.get(key), key)
+		}
+	}
+}
@@ -45,9 +45,11 @@ type TelemetryBuilder struct {
 	registrations               []metric.Registration
 	RatelimitConcurrentRequests metric.Int64UpDownCounter
 	RatelimitDelayDuration      metric.Float64Histogram
+	RatelimitPeerFallbacks      metric.Int64Counter
 	RatelimitRequestDuration    metric.Float64Histogram
 	RatelimitRequestSize        metric.Int64Histogram
 	RatelimitRequests           metric.Int64Counter
+	RatelimitShedRecords        metric.Int64Counter
 	RatelimitTokensAfter        metric.Float64Gauge
 	RatelimitTokensBefore       metric.Float64Gauge
 }
@@ -94,6 +96,12 @@ func NewTelemetryBuilder(settings component.TelemetrySettings, options ...Teleme
 		metric.WithExplicitBucketBoundaries([]float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.5, 1, 5, 10, 30}...),
 	)
 	errs = errors.Join(errs, err)
+	builder.RatelimitPeerFallbacks, err = builder.meter.Int64Counter(
+		"otelcol_ratelimit.peer_fallbacks",
+		metric.WithDescription("Number of rate limit requests applied locally because the peer owning their key could not be reached. Only emitted for distributed rate limiting. [Development]"),
+		metric.WithUnit("{requests}"),
+	)
+	errs = errors.Join(errs, err)
 	builder.RatelimitRequestDuration, err = builder.meter.Float64Histogram(
 		"otelcol_ratelimit.request_duration",
 		metric.WithDescription("Time(in seconds) taken to process a rate limit request [Development]"),
@@ -114,6 +122,12 @@ func NewTelemetryBuilder(settings component.TelemetrySettings, options ...Teleme
 		metric.WithUnit("{requests}"),
 	)
 	errs = errors.Join(errs, err)
+	builder.RatelimitShedRecords, err = builder.meter.Int64Counter(
+		"otelcol_ratelimit.shed_records",
+		metric.WithDescription("Number of low-priority records dropped from requests over the limit before rate limiting them again. Only emitted for throttle_behavior=shed. [Development]"),
+		metric.WithUnit("{records}"),
+	)
+	errs = errors.Join(errs, err)
 	builder.RatelimitTokensAfter, err = builder.meter.Float64Gauge(
 		"otelcol_ratelimit.tokens_after",
 		metric.WithDescription("Token bucket level after this request was served. Negative values indicate the bucket is in debt. Only emitted for throttle_behavior=delay. [Development]"),
@@ -38,9 +38,11 @@ func TestSetupTelemetry(t *testing.T) {
 	defer tb.Shutdown()
 	tb.RatelimitConcurrentRequests.Add(context.Background(), 1)
 	tb.RatelimitDelayDuration.Record(context.Background(), 1)
+	tb.RatelimitPeerFallbacks.Add(context.Background(), 1)
 	tb.RatelimitRequestDuration.Record(context.Background(), 1)
 	tb.RatelimitRequestSize.Record(context.Background(), 1)
 	tb.RatelimitRequests.Add(context.Background(), 1)
+	tb.RatelimitShedRecords.Add(context.Background(), 1)
 	tb.RatelimitTokensAfter.Record(context.Background(), 1)
 	tb.RatelimitTokensBefore.Record(context.Background(), 1)
 	AssertEqualRatelimitConcurrentRequests(t, testTel,
@@ -49,6 +51,9 @@ func TestSetupTelemetry(t *testing.T) {
 	AssertEqualRatelimitDelayDuration(t, testTel,
 		[]metricdata.HistogramDataPoint[float64]{{}}, metricdatatest.IgnoreValue(),
 		metricdatatest.IgnoreTimestamp())
+	AssertEqualRatelimitPeerFallbacks(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
 	AssertEqualRatelimitRequestDuration(t, testTel,
 		[]metricdata.HistogramDataPoint[float64]{{}}, metricdatatest.IgnoreValue(),
 		metricdatatest.IgnoreTimestamp())
@@ -58,6 +63,9 @@ func TestSetupTelemetry(t *testing.T) {
 	AssertEqualRatelimitRequests(t, testTel,
 		[]metricdata.DataPoint[int64]{{Value: 1}},
 		metricdatatest.IgnoreTimestamp())
+	AssertEqualRatelimitShedRecords(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
 	AssertEqualRatelimitTokensAfter(t, testTel,
 		[]metricdata.DataPoint[float64]{{Value: 1}},
 		metricdatatest.IgnoreTimestamp())
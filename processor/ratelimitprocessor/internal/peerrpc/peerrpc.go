@@ -0,0 +1,133 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package peerrpc implements the gRPC protocol used by ratelimit processor
+// replicas to forward rate limit requests to the replica that owns the
+// bucket of a unique key.
+//
+// Messages are encoded as JSON using a dedicated gRPC codec, which is
+// selected through the content-subtype of each call.
+package peerrpc // import "github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/peerrpc"
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+const (
+	serviceName = "elastic.ratelimitprocessor.v1.PeerRateLimiter"
+	methodName  = "RateLimit"
+	codecName   = "ratelimitjson"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// Request is a rate limit request forwarded to the owner of a bucket.
+type Request struct {
+	// Key is the unique key identifying the bucket.
+	Key string `json:"key"`
+	// Hits is the number of tokens to take from the bucket.
+	Hits int `json:"hits"`
+	// Rate is the effective bucket refill rate for the key.
+	Rate int `json:"rate"`
+	// Burst is the effective bucket capacity for the key.
+	Burst int `json:"burst"`
+	// ThrottleBehavior is the behavior when the rate limit is exceeded.
+	ThrottleBehavior string `json:"throttle_behavior"`
+	// AdditionalLimits holds the additional limits for the key, which
+	// must be satisfied together with the main limit.
+	AdditionalLimits []Limit `json:"additional_limits,omitempty"`
+}
+
+// Limit is an additional limit of a forwarded rate limit request.
+type Limit struct {
+	// Strategy is the rate limiting strategy of the limit.
+	Strategy string `json:"strategy"`
+	// Hits is the number of tokens to take from the bucket of the limit.
+	Hits int `json:"hits"`
+	// Rate is the bucket refill rate of the limit.
+	Rate int `json:"rate"`
+	// Burst is the bucket capacity of the limit.
+	Burst int `json:"burst"`
+}
+
+// Response is the outcome of a forwarded rate limit request.
+type Response struct {
+	Decision         string  `json:"decision"`
+	DelayNanos       int64   `json:"delay_nanos,omitempty"`
+	TokensBefore     float64 `json:"tokens_before,omitempty"`
+	TokensAfter      float64 `json:"tokens_after,omitempty"`
+	ConfigRate       float64 `json:"config_rate"`
+	EmitTokenMetrics bool    `json:"emit_token_metrics,omitempty"`
+	// Limit is the strategy of the limit that throttled or delayed the request, if any.
+	Limit string `json:"limit,omitempty"`
+}
+
+// Handler handles rate limit requests forwarded by peers.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// RegisterServer registers h as the peer rate limiter service on s.
+func RegisterServer(s *grpc.Server, h Handler) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: methodName,
+			Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := new(Request)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return h(ctx, req)
+				}
+				info := &grpc.UnaryServerInfo{FullMethod: "/" + serviceName + "/" + methodName}
+				return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+					return h(ctx, req.(*Request))
+				})
+			},
+		}},
+	}, nil)
+}
+
+// Invoke forwards req to the peer behind conn.
+func Invoke(ctx context.Context, conn grpc.ClientConnInterface, req *Request) (*Response, error) {
+	resp := new(Response)
+	if err := conn.Invoke(ctx, "/"+serviceName+"/"+methodName, req, resp, grpc.CallContentSubtype(codecName)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
@@ -29,6 +29,8 @@ const (
 	reasonKey         = "reason"
 	decisionKey       = "ratelimit_decision"
 	limitThresholdKey = "limit_threshold"
+	peerKey           = "peer"
+	limitKey          = "limit"
 
 	StatusUnderLimit Reason = "under_limit"
 	StatusOverLimit  Reason = "over_limit"
@@ -50,3 +52,14 @@ func WithReason(reason Reason) attribute.KeyValue {
 func WithLimitThreshold(limitThreshold float64) attribute.KeyValue {
 	return attribute.Float64(limitThresholdKey, limitThreshold)
 }
+
+// WithPeer returns a peer attribute with key.
+func WithPeer(peer string) attribute.KeyValue {
+	return attribute.String(peerKey, peer)
+}
+
+// WithLimit returns a limit attribute with key, naming the strategy of the
+// limit that throttled or delayed a request.
+func WithLimit(limit string) attribute.KeyValue {
+	return attribute.String(limitKey, limit)
+}
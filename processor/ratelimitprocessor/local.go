@@ -47,38 +47,76 @@ type keyState struct {
 type localRateLimiter struct {
 	cfg *Config
 	set processor.Settings
+	// overrides holds the dynamic overrides, nil if not configured.
+	overrides *dynamicOverrides
 	// TODO use an LRU to keep a cap on the number of limiters.
 	// When the LRU capacity is exceeded, reuse the evicted limiter.
 	limiters sync.Map // map[string]*keyState
 }
 
 func newLocalRateLimiter(cfg *Config, set processor.Settings) (*localRateLimiter, error) {
-	return &localRateLimiter{cfg: cfg, set: set}, nil
+	r := &localRateLimiter{cfg: cfg, set: set}
+	if cfg.DynamicOverrides.HasValue() {
+		r.overrides = newDynamicOverrides(cfg.DynamicOverrides.Get(), set.Logger)
+	}
+	return r, nil
 }
 
-func (r *localRateLimiter) Start(_ context.Context, _ component.Host) error {
+func (r *localRateLimiter) Start(ctx context.Context, host component.Host) error {
+	if r.overrides != nil {
+		return r.overrides.Start(ctx, host)
+	}
 	return nil
 }
 
-func (r *localRateLimiter) Shutdown(_ context.Context) error {
+func (r *localRateLimiter) Shutdown(ctx context.Context) error {
 	r.limiters = sync.Map{}
+	if r.overrides != nil {
+		return r.overrides.Shutdown(ctx)
+	}
 	return nil
 }
 
-func (r *localRateLimiter) RateLimit(ctx context.Context, hits int) (RateLimitResult, error) {
+func (r *localRateLimiter) RateLimit(ctx context.Context, hits int, additional ...int) (RateLimitResult, error) {
 	clientMetadata := client.FromContext(ctx).Metadata
 	// Each (shared) processor gets its own rate limiter,
 	// so it's enough to use client metadata-based unique key.
 	key := getUniqueKey(clientMetadata, r.cfg.MetadataKeys)
-	cfg := resolveRateLimit(r.cfg, clientMetadata)
+	cfg := resolveRateLimit(r.cfg, r.overrides.get(), clientMetadata)
+	return r.rateLimitKey(ctx, key, cfg, hits, additional)
+}
 
-	v, _ := r.limiters.LoadOrStore(key, &keyState{
-		limiter: rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst),
-	})
-	state := v.(*keyState)
+// rateLimitKey applies the rate limit settings cfg to the bucket identified
+// by key. It is used both for local requests and for requests forwarded by
+// peers that resolved the key and its settings on their side.
+//
+// additional holds the hits for each of cfg.AdditionalLimits, which are
+// applied to their own buckets. Missing hits count as zero.
+func (r *localRateLimiter) rateLimitKey(
+	ctx context.Context,
+	key string,
+	cfg RateLimitSettings,
+	hits int,
+	additional []int,
+) (RateLimitResult, error) {
+	states := make([]*keyState, 1, 1+len(cfg.AdditionalLimits))
+	allHits := make([]int, 1, 1+len(cfg.AdditionalLimits))
+	strategies := make([]Strategy, 1, 1+len(cfg.AdditionalLimits))
+	states[0], allHits[0], strategies[0] = r.keyState(key, cfg.Rate, cfg.Burst), hits, cfg.Strategy
+	for i, limit := range cfg.AdditionalLimits {
+		var n int
+		if i < len(additional) {
+			n = additional[i]
+		}
+		states = append(states, r.keyState(key+"|"+string(limit.Strategy), limit.Rate, limit.Burst))
+		allHits = append(allHits, n)
+		strategies = append(strategies, limit.Strategy)
+	}
 
-	makeResult := func(decision Decision, delay time.Duration, tokensBefore, tokensAfter float64, emitTokenMetrics bool) RateLimitResult {
-		return RateLimitResult{
+	// limit is the index of the limit that throttled or delayed the
+	// request, or -1 if none did.
+	makeResult := func(decision Decision, delay time.Duration, tokensBefore, tokensAfter float64, emitTokenMetrics bool, limit int) RateLimitResult {
+		result := RateLimitResult{
 			Decision:         decision,
 			Delay:            delay,
 			TokensBefore:     tokensBefore,
@@ -86,22 +124,41 @@ func (r *localRateLimiter) RateLimit(ctx context.Context, hits int) (RateLimitRe
 			ConfigRate:       float64(cfg.Rate),
 			EmitTokenMetrics: emitTokenMetrics,
 		}
+		if limit >= 0 {
+			result.Limit = strategies[limit]
+		}
+		return result
 	}
 
 	switch cfg.ThrottleBehavior {
-	case ThrottleBehaviorError:
-		if ok := state.limiter.AllowN(time.Now(), hits); !ok {
-			return makeResult(DecisionThrottled, 0, 0, 0, false), errorWithDetails(errTooManyRequests, cfg)
+	case ThrottleBehaviorError, ThrottleBehaviorShed:
+		if rejected := allowAll(states, allHits); rejected >= 0 {
+			return makeResult(DecisionThrottled, 0, 0, 0, false, rejected), errorWithDetails(errTooManyRequests, cfg)
 		}
-		return makeResult(DecisionAccepted, 0, 0, 0, false), nil
+		return makeResult(DecisionAccepted, 0, 0, 0, false, -1), nil
 
 	case ThrottleBehaviorDelay:
-		reservations, delay, tokensBefore, tokensAfter, err := reserveAll(state, hits)
+		// Token metrics are reported for the main limit only.
+		reservations, delay, tokensBefore, tokensAfter, err := reserveAll(states[0], allHits[0])
 		if err != nil {
-			return makeResult(DecisionThrottled, 0, tokensBefore, tokensAfter, true), errorWithDetails(err, cfg)
+			return makeResult(DecisionThrottled, 0, tokensBefore, tokensAfter, true, 0), errorWithDetails(err, cfg)
+		}
+		delayed := 0
+		for i := 1; i < len(states); i++ {
+			more, moreDelay, _, _, err := reserveAll(states[i], allHits[i])
+			if err != nil {
+				cancelReservations(reservations)
+				return makeResult(DecisionThrottled, 0, tokensBefore, tokensAfter, true, i), errorWithDetails(err, cfg)
+			}
+			// Reservations of different buckets are independent, so they
+			// can be cancelled in any order relative to each other.
+			reservations = append(reservations, more...)
+			if moreDelay > delay {
+				delay, delayed = moreDelay, i
+			}
 		}
 		if delay <= 0 {
-			return makeResult(DecisionAccepted, 0, tokensBefore, tokensAfter, true), nil
+			return makeResult(DecisionAccepted, 0, tokensBefore, tokensAfter, true, -1), nil
 		}
 		timer := time.NewTimer(delay)
 		defer timer.Stop()
@@ -109,13 +166,60 @@ func (r *localRateLimiter) RateLimit(ctx context.Context, hits int) (RateLimitRe
 		case <-ctx.Done():
 			cancelReservations(reservations)
 			// tokensAfter reflects peak debt at reservation time, before cancellation.
-			return makeResult(DecisionCancelled, 0, tokensBefore, tokensAfter, true), ctx.Err()
+			return makeResult(DecisionCancelled, 0, tokensBefore, tokensAfter, true, delayed), ctx.Err()
 		case <-timer.C:
-			return makeResult(DecisionDelayed, delay, tokensBefore, tokensAfter, true), nil
+			return makeResult(DecisionDelayed, delay, tokensBefore, tokensAfter, true, delayed), nil
 		}
 	}
 
-	return makeResult(DecisionAccepted, 0, 0, 0, false), nil
+	return makeResult(DecisionAccepted, 0, 0, 0, false, -1), nil
+}
+
+// keyState returns the state of the bucket identified by key, creating it
+// if needed.
+func (r *localRateLimiter) keyState(key string, limit, burst int) *keyState {
+	v, _ := r.limiters.LoadOrStore(key, &keyState{
+		limiter: rate.NewLimiter(rate.Limit(limit), burst),
+	})
+	state := v.(*keyState)
+	// Overrides may change at runtime, in which case the existing bucket
+	// is updated in place so that its tokens are preserved.
+	if state.limiter.Limit() != rate.Limit(limit) {
+		state.limiter.SetLimit(rate.Limit(limit))
+	}
+	if state.limiter.Burst() != burst {
+		state.limiter.SetBurst(burst)
+	}
+	return state
+}
+
+// allowAll checks whether hits[i] tokens are available in the bucket of
+// states[i] for every i, taking the tokens only if all buckets allow it.
+// This way a request rejected by one limit does not consume the tokens of
+// the other limits. It returns the index of the first limit rejecting the
+// request, or -1 if all of them allow it.
+func allowAll(states []*keyState, hits []int) int {
+	now := time.Now()
+	if len(states) == 1 {
+		if !states[0].limiter.AllowN(now, hits[0]) {
+			return 0
+		}
+		return -1
+	}
+	reservations := make([]*rate.Reservation, 0, len(states))
+	for i, state := range states {
+		lr := state.limiter.ReserveN(now, hits[i])
+		if !lr.OK() {
+			cancelReservationsAt(reservations, now)
+			return i
+		}
+		reservations = append(reservations, lr)
+		if lr.DelayFrom(now) > 0 {
+			cancelReservationsAt(reservations, now)
+			return i
+		}
+	}
+	return -1
 }
 
 // reserveAll books all chunks for one caller atomically under
@@ -172,7 +276,14 @@ func reserveAll(state *keyState, hits int) ([]*rate.Reservation, time.Duration,
 // making the next-newest reservation the new "most recent" and therefore
 // fully restorable in turn.
 func cancelReservations(reservations []*rate.Reservation) {
-	now := time.Now()
+	cancelReservationsAt(reservations, time.Now())
+}
+
+// cancelReservationsAt is like cancelReservations, cancelling at the given
+// time. Reservations that are already due at that time are not restored,
+// so reservations made without delay must be cancelled at the time they
+// were made.
+func cancelReservationsAt(reservations []*rate.Reservation, now time.Time) {
 	for i := len(reservations) - 1; i >= 0; i-- {
 		reservations[i].CancelAt(now)
 	}
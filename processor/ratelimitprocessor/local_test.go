@@ -473,3 +473,70 @@ func TestLocalRateLimiter_MultipleRequests_Delay(t *testing.T) {
 		}
 	}
 }
+
+func TestLocalRateLimiter_AdditionalLimits(t *testing.T) {
+	rateLimiter := newTestLocalRateLimiter(t, &Config{
+		RateLimitSettings: RateLimitSettings{
+			Rate:             1,
+			Burst:            10,
+			Strategy:         StrategyRateLimitRecords,
+			ThrottleBehavior: ThrottleBehaviorError,
+			AdditionalLimits: []AdditionalLimit{{
+				Strategy: StrategyRateLimitBytes,
+				Rate:     1,
+				Burst:    100,
+			}},
+		},
+	})
+	require.NoError(t, rateLimiter.Start(context.Background(), componenttest.NewNopHost()))
+
+	// Both limits pass.
+	result, err := rateLimiter.RateLimit(context.Background(), 5, 50)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionAccepted, result.Decision)
+
+	// The bytes limit fails, so no records must be taken.
+	result, err = rateLimiter.RateLimit(context.Background(), 5, 60)
+	require.Error(t, err)
+	assert.Equal(t, DecisionThrottled, result.Decision)
+	assert.Equal(t, StrategyRateLimitBytes, result.Limit)
+
+	// The records limit fails, so no bytes must be taken.
+	result, err = rateLimiter.RateLimit(context.Background(), 6, 10)
+	require.Error(t, err)
+	assert.Equal(t, DecisionThrottled, result.Decision)
+	assert.Equal(t, StrategyRateLimitRecords, result.Limit)
+
+	// The remaining 5 records and 50 bytes are still available.
+	result, err = rateLimiter.RateLimit(context.Background(), 5, 50)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionAccepted, result.Decision)
+}
+
+func TestLocalRateLimiter_AdditionalLimits_Delay(t *testing.T) {
+	rateLimiter := newTestLocalRateLimiter(t, &Config{
+		RateLimitSettings: RateLimitSettings{
+			Rate:             10,
+			Burst:            10,
+			Strategy:         StrategyRateLimitRecords,
+			ThrottleBehavior: ThrottleBehaviorDelay,
+			AdditionalLimits: []AdditionalLimit{{
+				Strategy: StrategyRateLimitBytes,
+				Rate:     100,
+				Burst:    100,
+			}},
+		},
+	})
+	require.NoError(t, rateLimiter.Start(context.Background(), componenttest.NewNopHost()))
+
+	result, err := rateLimiter.RateLimit(context.Background(), 1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionAccepted, result.Decision)
+
+	// The records bucket has tokens left but the bytes bucket is empty,
+	// so the request is delayed by the bytes limit.
+	result, err = rateLimiter.RateLimit(context.Background(), 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionDelayed, result.Decision)
+	assert.Greater(t, result.Delay, 100*time.Millisecond)
+}
@@ -0,0 +1,207 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+// OverridesProvider is implemented by extensions providing rate limit
+// overrides at runtime. It allows other components to raise or lower the
+// rate limits of specific tenants without restarting the collector.
+type OverridesProvider interface {
+	// SubscribeOverrides registers fn to be called with the complete
+	// list of overrides every time it changes. Implementations must call
+	// fn with the current overrides before returning. The returned
+	// function unregisters fn.
+	SubscribeOverrides(fn func([]RateLimitOverrides)) (unsubscribe func())
+}
+
+// dynamicOverrides holds the overrides loaded from the configured dynamic
+// overrides source.
+type dynamicOverrides struct {
+	cfg    *DynamicOverridesConfig
+	logger *zap.Logger
+
+	current atomic.Pointer[[]RateLimitOverrides]
+
+	unsubscribe func()
+	watcher     *fsnotify.Watcher
+	wg          sync.WaitGroup
+	// content holds the last loaded file content, so that unrelated
+	// events in the watched directory do not reload the overrides.
+	content []byte
+}
+
+func newDynamicOverrides(cfg *DynamicOverridesConfig, logger *zap.Logger) *dynamicOverrides {
+	return &dynamicOverrides{cfg: cfg, logger: logger}
+}
+
+// get returns the current dynamic overrides. It is safe to call on a nil
+// receiver, in which case there are no dynamic overrides.
+func (d *dynamicOverrides) get() []RateLimitOverrides {
+	if d == nil {
+		return nil
+	}
+	if overrides := d.current.Load(); overrides != nil {
+		return *overrides
+	}
+	return nil
+}
+
+func (d *dynamicOverrides) set(overrides []RateLimitOverrides) {
+	d.current.Store(&overrides)
+	d.logger.Info("loaded dynamic rate limit overrides", zap.Int("overrides", len(overrides)))
+}
+
+func (d *dynamicOverrides) Start(_ context.Context, host component.Host) error {
+	if d.cfg.Extension != nil {
+		ext, ok := host.GetExtensions()[*d.cfg.Extension]
+		if !ok {
+			return fmt.Errorf("dynamic overrides extension %q not found", d.cfg.Extension)
+		}
+		provider, ok := ext.(OverridesProvider)
+		if !ok {
+			return fmt.Errorf("extension %q does not implement OverridesProvider", d.cfg.Extension)
+		}
+		d.unsubscribe = provider.SubscribeOverrides(func(overrides []RateLimitOverrides) {
+			if err := validateOverrides(overrides); err != nil {
+				d.logger.Error("ignoring invalid dynamic rate limit overrides", zap.Error(err))
+				return
+			}
+			d.set(overrides)
+		})
+		return nil
+	}
+
+	if err := d.reload(); err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic overrides file watcher: %w", err)
+	}
+	// The directory is watched instead of the file, so that atomic
+	// replacements (e.g. Kubernetes ConfigMap updates) are detected.
+	if err := watcher.Add(filepath.Dir(d.cfg.File)); err != nil {
+		return errors.Join(
+			fmt.Errorf("failed to watch dynamic overrides file: %w", err),
+			watcher.Close(),
+		)
+	}
+	d.watcher = watcher
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if err := d.reload(); err != nil {
+					d.logger.Error("failed to reload dynamic rate limit overrides, keeping previous overrides", zap.Error(err))
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				d.logger.Warn("dynamic overrides file watcher error", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+func (d *dynamicOverrides) Shutdown(_ context.Context) error {
+	if d.unsubscribe != nil {
+		d.unsubscribe()
+		d.unsubscribe = nil
+	}
+	var err error
+	if d.watcher != nil {
+		err = d.watcher.Close()
+		d.wg.Wait()
+		d.watcher = nil
+	}
+	return err
+}
+
+// reload loads the overrides file if its content changed since the last
+// load.
+func (d *dynamicOverrides) reload() error {
+	content, err := os.ReadFile(d.cfg.File)
+	if err != nil {
+		return fmt.Errorf("failed to read dynamic overrides file: %w", err)
+	}
+	if d.content != nil && bytes.Equal(content, d.content) {
+		return nil
+	}
+	overrides, err := parseOverrides(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse dynamic overrides file %q: %w", d.cfg.File, err)
+	}
+	d.content = content
+	d.set(overrides)
+	return nil
+}
+
+// parseOverrides parses a JSON or YAML document holding a list of overrides
+// under the "overrides" key, using the same format as the processor config.
+func parseOverrides(content []byte) ([]RateLimitOverrides, error) {
+	retrieved, err := confmap.NewRetrievedFromYAML(content)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := retrieved.AsConf()
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Overrides []RateLimitOverrides `mapstructure:"overrides"`
+	}
+	if err := conf.Unmarshal(&doc); err != nil {
+		return nil, err
+	}
+	if err := validateOverrides(doc.Overrides); err != nil {
+		return nil, err
+	}
+	return doc.Overrides, nil
+}
+
+func validateOverrides(overrides []RateLimitOverrides) error {
+	var errs []error
+	for i, override := range overrides {
+		if err := override.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("override %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,228 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configoptional"
+	"go.uber.org/zap"
+)
+
+type overridesProviderExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+
+	mu          sync.Mutex
+	overrides   []RateLimitOverrides
+	subscribers map[int]func([]RateLimitOverrides)
+	nextID      int
+}
+
+func (e *overridesProviderExtension) SubscribeOverrides(fn func([]RateLimitOverrides)) func() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.subscribers == nil {
+		e.subscribers = make(map[int]func([]RateLimitOverrides))
+	}
+	id := e.nextID
+	e.nextID++
+	e.subscribers[id] = fn
+	fn(e.overrides)
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subscribers, id)
+	}
+}
+
+func (e *overridesProviderExtension) update(overrides []RateLimitOverrides) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.overrides = overrides
+	for _, fn := range e.subscribers {
+		fn(overrides)
+	}
+}
+
+type extensionsHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func (h *extensionsHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+func TestParseOverrides(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		overrides, err := parseOverrides([]byte(`
+overrides:
+  - matches:
+      x-tenant-id: [noisy]
+    rate: 10
+    throttle_interval: 5s
+`))
+		require.NoError(t, err)
+		assert.Equal(t, []RateLimitOverrides{{
+			Matches:          map[string][]string{"x-tenant-id": {"noisy"}},
+			Rate:             ptr(10),
+			ThrottleInterval: ptr(5 * time.Second),
+		}}, overrides)
+	})
+	t.Run("json", func(t *testing.T) {
+		overrides, err := parseOverrides([]byte(`{"overrides": [{"matches": {"x-tenant-id": ["big"]}, "burst": 1000}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, []RateLimitOverrides{{
+			Matches: map[string][]string{"x-tenant-id": {"big"}},
+			Burst:   ptr(1000),
+		}}, overrides)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseOverrides([]byte(`{"overrides": [{"rate": -1}]}`))
+		require.EqualError(t, err, "override 0: rate must be greater than zero")
+	})
+}
+
+func TestDynamicOverrides_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	writeOverrides := func(content string) {
+		// Replace the file atomically, like Kubernetes ConfigMap updates.
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o600))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	writeOverrides("overrides: [{matches: {x-tenant-id: [a]}, rate: 10}]")
+
+	d := newDynamicOverrides(&DynamicOverridesConfig{File: path}, zap.NewNop())
+	require.NoError(t, d.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		require.NoError(t, d.Shutdown(context.Background()))
+	})
+	require.Len(t, d.get(), 1)
+	assert.Equal(t, ptr(10), d.get()[0].Rate)
+
+	writeOverrides("overrides: [{matches: {x-tenant-id: [a]}, rate: 20}]")
+	require.Eventually(t, func() bool {
+		return *d.get()[0].Rate == 20
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Invalid content keeps the previous overrides.
+	writeOverrides("overrides: [{matches: {x-tenant-id: [a]}, rate: -1}]")
+	writeOverrides("overrides: [{matches: {x-tenant-id: [a]}, rate: 30}, {rate: -1}]")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, ptr(20), d.get()[0].Rate)
+}
+
+func TestDynamicOverrides_FileNotFound(t *testing.T) {
+	d := newDynamicOverrides(&DynamicOverridesConfig{
+		File: filepath.Join(t.TempDir(), "missing.yaml"),
+	}, zap.NewNop())
+	require.ErrorContains(t, d.Start(context.Background(), componenttest.NewNopHost()), "failed to read dynamic overrides file")
+	require.NoError(t, d.Shutdown(context.Background()))
+}
+
+func TestDynamicOverrides_Extension(t *testing.T) {
+	id := component.MustNewID("overrides")
+	ext := &overridesProviderExtension{}
+	host := &extensionsHost{
+		Host:       componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{id: ext},
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		missing := component.MustNewID("missing")
+		d := newDynamicOverrides(&DynamicOverridesConfig{Extension: &missing}, zap.NewNop())
+		require.EqualError(t, d.Start(context.Background(), host), `dynamic overrides extension "missing" not found`)
+	})
+
+	d := newDynamicOverrides(&DynamicOverridesConfig{Extension: &id}, zap.NewNop())
+	require.NoError(t, d.Start(context.Background(), host))
+	assert.Empty(t, d.get())
+
+	ext.update([]RateLimitOverrides{{Rate: ptr(5)}})
+	assert.Equal(t, []RateLimitOverrides{{Rate: ptr(5)}}, d.get())
+
+	// Invalid overrides are ignored.
+	ext.update([]RateLimitOverrides{{Rate: ptr(-5)}})
+	assert.Equal(t, []RateLimitOverrides{{Rate: ptr(5)}}, d.get())
+
+	require.NoError(t, d.Shutdown(context.Background()))
+	assert.Empty(t, ext.subscribers)
+}
+
+func TestLocalRateLimiter_DynamicOverrides(t *testing.T) {
+	id := component.MustNewID("overrides")
+	ext := &overridesProviderExtension{}
+	host := &extensionsHost{
+		Host:       componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{id: ext},
+	}
+	rateLimiter := newTestLocalRateLimiter(t, &Config{
+		MetadataKeys: []string{"x-tenant-id"},
+		RateLimitSettings: RateLimitSettings{
+			Rate:             1,
+			Burst:            2,
+			ThrottleBehavior: ThrottleBehaviorError,
+		},
+		Overrides: []RateLimitOverrides{{
+			Matches: map[string][]string{"x-tenant-id": {"noisy"}},
+			Burst:   ptr(3),
+		}},
+		DynamicOverrides: configoptional.Some(DynamicOverridesConfig{Extension: &id}),
+	})
+	require.NoError(t, rateLimiter.Start(context.Background(), host))
+
+	ctx := client.NewContext(context.Background(), client.Info{
+		Metadata: client.NewMetadata(map[string][]string{"x-tenant-id": {"noisy"}}),
+	})
+	// The static override applies until a dynamic override matches.
+	for i := 0; i < 3; i++ {
+		_, err := rateLimiter.RateLimit(ctx, 1)
+		require.NoError(t, err)
+	}
+	result, err := rateLimiter.RateLimit(ctx, 1)
+	require.Error(t, err)
+	assert.Equal(t, DecisionThrottled, result.Decision)
+
+	// Raising the burst updates the existing bucket without refilling it.
+	ext.update([]RateLimitOverrides{{
+		Matches: map[string][]string{"x-tenant-id": {"noisy"}},
+		Rate:    ptr(1000),
+		Burst:   ptr(1000),
+	}})
+	v, ok := rateLimiter.limiters.Load("x-tenant-id:noisy")
+	require.True(t, ok)
+	require.Eventually(t, func() bool {
+		_, err := rateLimiter.RateLimit(ctx, 1)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	state := v.(*keyState)
+	assert.Equal(t, 1000, state.limiter.Burst())
+	assert.Less(t, state.limiter.Tokens(), float64(100))
+}
@@ -19,6 +19,7 @@ package ratelimitprocessor // import "github.com/elastic/opentelemetry-collector
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
@@ -50,25 +51,49 @@ type rateLimiterProcessor struct {
 type LogsRateLimiterProcessor struct {
 	rateLimiterProcessor
 	count func(logs plog.Logs) int
-	next  func(ctx context.Context, logs plog.Logs) error
+	// additionalCounts holds the count functions of the additional
+	// limits, in the same order as RateLimitSettings.AdditionalLimits.
+	additionalCounts []func(logs plog.Logs) int
+	// shed removes the low-priority records of throttled requests, and
+	// is nil unless the throttle behavior is "shed".
+	shed shedFunc[plog.Logs]
+	next func(ctx context.Context, logs plog.Logs) error
 }
 
 type MetricsRateLimiterProcessor struct {
 	rateLimiterProcessor
 	count func(metrics pmetric.Metrics) int
-	next  func(ctx context.Context, metrics pmetric.Metrics) error
+	// additionalCounts holds the count functions of the additional
+	// limits, in the same order as RateLimitSettings.AdditionalLimits.
+	additionalCounts []func(metrics pmetric.Metrics) int
+	// shed removes the low-priority records of throttled requests, and
+	// is nil unless the throttle behavior is "shed".
+	shed shedFunc[pmetric.Metrics]
+	next func(ctx context.Context, metrics pmetric.Metrics) error
 }
 
 type TracesRateLimiterProcessor struct {
 	rateLimiterProcessor
 	count func(traces ptrace.Traces) int
-	next  func(ctx context.Context, traces ptrace.Traces) error
+	// additionalCounts holds the count functions of the additional
+	// limits, in the same order as RateLimitSettings.AdditionalLimits.
+	additionalCounts []func(traces ptrace.Traces) int
+	// shed removes the low-priority records of throttled requests, and
+	// is nil unless the throttle behavior is "shed".
+	shed shedFunc[ptrace.Traces]
+	next func(ctx context.Context, traces ptrace.Traces) error
 }
 
 type ProfilesRateLimiterProcessor struct {
 	rateLimiterProcessor
 	count func(profiles pprofile.Profiles) int
-	next  func(ctx context.Context, profiles pprofile.Profiles) error
+	// additionalCounts holds the count functions of the additional
+	// limits, in the same order as RateLimitSettings.AdditionalLimits.
+	additionalCounts []func(profiles pprofile.Profiles) int
+	// shed removes the low-priority records of throttled requests, and
+	// is nil unless the throttle behavior is "shed".
+	shed shedFunc[pprofile.Profiles]
+	next func(ctx context.Context, profiles pprofile.Profiles) error
 }
 
 func NewLogsRateLimiterProcessor(
@@ -79,7 +104,14 @@ func NewLogsRateLimiterProcessor(
 	strategy Strategy,
 	next func(ctx context.Context, logs plog.Logs) error,
 	metadataKeys []string,
+	weight float64,
+	additionalLimits []AdditionalLimit,
+	shed func(ctx context.Context, logs plog.Logs) (trimmed plog.Logs, dropped, remaining int),
 ) (*LogsRateLimiterProcessor, error) {
+	additionalCounts := make([]func(plog.Logs) int, len(additionalLimits))
+	for i, limit := range additionalLimits {
+		additionalCounts[i] = getLogsCountFunc(limit.Strategy)
+	}
 	return &LogsRateLimiterProcessor{
 		rateLimiterProcessor: rateLimiterProcessor{
 			Component:        rateLimiter,
@@ -90,8 +122,10 @@ func NewLogsRateLimiterProcessor(
 			metadataKeys:     metadataKeys,
 			strategy:         strategy,
 		},
-		count: getLogsCountFunc(strategy),
-		next:  next,
+		count:            withWeight(getLogsCountFunc(strategy), weight),
+		additionalCounts: additionalCounts,
+		shed:             shed,
+		next:             next,
 	}, nil
 }
 
@@ -103,7 +137,14 @@ func NewMetricsRateLimiterProcessor(
 	strategy Strategy,
 	next func(ctx context.Context, metrics pmetric.Metrics) error,
 	metadataKeys []string,
+	weight float64,
+	additionalLimits []AdditionalLimit,
+	shed func(ctx context.Context, metrics pmetric.Metrics) (trimmed pmetric.Metrics, dropped, remaining int),
 ) (*MetricsRateLimiterProcessor, error) {
+	additionalCounts := make([]func(pmetric.Metrics) int, len(additionalLimits))
+	for i, limit := range additionalLimits {
+		additionalCounts[i] = getMetricsCountFunc(limit.Strategy)
+	}
 	return &MetricsRateLimiterProcessor{
 		rateLimiterProcessor: rateLimiterProcessor{
 			Component:        rateLimiter,
@@ -114,8 +155,10 @@ func NewMetricsRateLimiterProcessor(
 			metadataKeys:     metadataKeys,
 			strategy:         strategy,
 		},
-		count: getMetricsCountFunc(strategy),
-		next:  next,
+		count:            withWeight(getMetricsCountFunc(strategy), weight),
+		additionalCounts: additionalCounts,
+		shed:             shed,
+		next:             next,
 	}, nil
 }
 
@@ -127,7 +170,14 @@ func NewTracesRateLimiterProcessor(
 	strategy Strategy,
 	next func(ctx context.Context, traces ptrace.Traces) error,
 	metadataKeys []string,
+	weight float64,
+	additionalLimits []AdditionalLimit,
+	shed func(ctx context.Context, traces ptrace.Traces) (trimmed ptrace.Traces, dropped, remaining int),
 ) (*TracesRateLimiterProcessor, error) {
+	additionalCounts := make([]func(ptrace.Traces) int, len(additionalLimits))
+	for i, limit := range additionalLimits {
+		additionalCounts[i] = getTracesCountFunc(limit.Strategy)
+	}
 	return &TracesRateLimiterProcessor{
 		rateLimiterProcessor: rateLimiterProcessor{
 			Component:        rateLimiter,
@@ -138,8 +188,10 @@ func NewTracesRateLimiterProcessor(
 			metadataKeys:     metadataKeys,
 			strategy:         strategy,
 		},
-		count: getTracesCountFunc(strategy),
-		next:  next,
+		count:            withWeight(getTracesCountFunc(strategy), weight),
+		additionalCounts: additionalCounts,
+		shed:             shed,
+		next:             next,
 	}, nil
 }
 
@@ -151,7 +203,14 @@ func NewProfilesRateLimiterProcessor(
 	strategy Strategy,
 	next func(ctx context.Context, profiles pprofile.Profiles) error,
 	metadataKeys []string,
+	weight float64,
+	additionalLimits []AdditionalLimit,
+	shed func(ctx context.Context, profiles pprofile.Profiles) (trimmed pprofile.Profiles, dropped, remaining int),
 ) (*ProfilesRateLimiterProcessor, error) {
+	additionalCounts := make([]func(pprofile.Profiles) int, len(additionalLimits))
+	for i, limit := range additionalLimits {
+		additionalCounts[i] = getProfilesCountFunc(limit.Strategy)
+	}
 	return &ProfilesRateLimiterProcessor{
 		rateLimiterProcessor: rateLimiterProcessor{
 			Component:        rateLimiter,
@@ -162,8 +221,10 @@ func NewProfilesRateLimiterProcessor(
 			metadataKeys:     metadataKeys,
 			strategy:         strategy,
 		},
-		count: getProfilesCountFunc(strategy),
-		next:  next,
+		count:            withWeight(getProfilesCountFunc(strategy), weight),
+		additionalCounts: additionalCounts,
+		shed:             shed,
+		next:             next,
 	}, nil
 }
 
@@ -185,11 +246,15 @@ func (r *ProfilesRateLimiterProcessor) Capabilities() consumer.Capabilities {
 
 func getTelemetryAttrs(attrsCommon []attribute.KeyValue, result RateLimitResult, err error) []attribute.KeyValue {
 	switch result.Decision {
-	case DecisionDelayed, DecisionThrottled, DecisionCancelled:
-		return append(attrsCommon,
+	case DecisionDelayed, DecisionThrottled, DecisionCancelled, DecisionShed:
+		attrs := append(attrsCommon,
 			telemetry.WithDecision(string(result.Decision)),
 			telemetry.WithReason(telemetry.StatusOverLimit),
 		)
+		if result.Limit != "" {
+			attrs = append(attrs, telemetry.WithLimit(string(result.Limit)))
+		}
+		return attrs
 	default: // DecisionAccepted
 		if err != nil {
 			return append(attrsCommon,
@@ -205,8 +270,10 @@ func getTelemetryAttrs(attrsCommon []attribute.KeyValue, result RateLimitResult,
 }
 
 func withRateLimit[T any](ctx context.Context,
-	hits int,
-	rateLimit func(ctx context.Context, n int) (RateLimitResult, error),
+	count func(T) int,
+	additionalCounts []func(T) int,
+	shed shedFunc[T],
+	rateLimit func(ctx context.Context, n int, additional ...int) (RateLimitResult, error),
 	metadataKeys []string,
 	tb *metadata.TelemetryBuilder,
 	logger *zap.Logger,
@@ -219,7 +286,32 @@ func withRateLimit[T any](ctx context.Context,
 	defer tb.RatelimitConcurrentRequests.Add(ctx, -1, metric.WithAttributeSet(attrsSet))
 
 	start := time.Now()
-	result, err := rateLimit(ctx, hits)
+	hits := count(data)
+	result, err := rateLimit(ctx, hits, additionalHits(data, additionalCounts)...)
+	// In shed mode, the low-priority records of a throttled request are
+	// dropped, and the remaining records are rate limited again.
+	// The trimmed request replaces the original one only if it is accepted,
+	// so a throttled request is returned intact to be retried upstream.
+	var dropped, remaining int
+	if result.Decision == DecisionThrottled && shed != nil {
+		throttledBy := result.Limit
+		var trimmed T
+		trimmed, dropped, remaining = shed(ctx, data)
+		switch {
+		case dropped > 0 && remaining == 0:
+			// Nothing left to forward, so there is no need to take tokens.
+			hits = 0
+			result, err = RateLimitResult{Decision: DecisionShed, Limit: throttledBy}, nil
+		case dropped > 0:
+			hits = count(trimmed)
+			result, err = rateLimit(ctx, hits, additionalHits(trimmed, additionalCounts)...)
+			if err == nil {
+				result.Decision = DecisionShed
+				result.Limit = throttledBy
+				data = trimmed
+			}
+		}
+	}
 	tb.RatelimitRequestDuration.Record(ctx,
 		time.Since(start).Seconds(),
 		metric.WithAttributeSet(attrsSet),
@@ -229,6 +321,9 @@ func withRateLimit[T any](ctx context.Context,
 	attrRequestsSet := attribute.NewSet(attrRequests...)
 	tb.RatelimitRequestSize.Record(ctx, int64(hits), metric.WithAttributeSet(attrRequestsSet))
 	tb.RatelimitRequests.Add(ctx, 1, metric.WithAttributeSet(attrRequestsSet))
+	if dropped > 0 {
+		tb.RatelimitShedRecords.Add(ctx, int64(dropped), metric.WithAttributeSet(attrRequestsSet))
+	}
 	if result.Decision == DecisionDelayed {
 		tb.RatelimitDelayDuration.Record(ctx, result.Delay.Seconds(), metric.WithAttributeSet(attrRequestsSet))
 	}
@@ -258,13 +353,18 @@ func withRateLimit[T any](ctx context.Context,
 		}
 		return err
 	}
+	if dropped > 0 && remaining == 0 {
+		return nil
+	}
 	return next(ctx, data)
 }
 
 func (r *LogsRateLimiterProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
 	return withRateLimit(
 		ctx,
-		r.count(ld),
+		r.count,
+		r.additionalCounts,
+		r.shed,
 		r.rl.RateLimit,
 		r.metadataKeys,
 		r.telemetryBuilder,
@@ -276,7 +376,9 @@ func (r *LogsRateLimiterProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs
 func (r *MetricsRateLimiterProcessor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
 	return withRateLimit(
 		ctx,
-		r.count(md),
+		r.count,
+		r.additionalCounts,
+		r.shed,
 		r.rl.RateLimit,
 		r.metadataKeys,
 		r.telemetryBuilder,
@@ -288,7 +390,9 @@ func (r *MetricsRateLimiterProcessor) ConsumeMetrics(ctx context.Context, md pme
 func (r *TracesRateLimiterProcessor) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
 	return withRateLimit(
 		ctx,
-		r.count(td),
+		r.count,
+		r.additionalCounts,
+		r.shed,
 		r.rl.RateLimit,
 		r.metadataKeys,
 		r.telemetryBuilder,
@@ -300,7 +404,9 @@ func (r *TracesRateLimiterProcessor) ConsumeTraces(ctx context.Context, td ptrac
 func (r *ProfilesRateLimiterProcessor) ConsumeProfiles(ctx context.Context, pd pprofile.Profiles) error {
 	return withRateLimit(
 		ctx,
-		r.count(pd),
+		r.count,
+		r.additionalCounts,
+		r.shed,
 		r.rl.RateLimit,
 		r.metadataKeys,
 		r.telemetryBuilder,
@@ -309,6 +415,30 @@ func (r *ProfilesRateLimiterProcessor) ConsumeProfiles(ctx context.Context, pd p
 	)
 }
 
+// withWeight returns a count function multiplying the result of count by
+// weight, rounded up so that a non-empty payload always costs tokens.
+func withWeight[T any](count func(T) int, weight float64) func(T) int {
+	if weight == 1 || weight == 0 {
+		return count
+	}
+	return func(data T) int {
+		return int(math.Ceil(float64(count(data)) * weight))
+	}
+}
+
+// additionalHits returns the hits of data for each of the additional
+// count functions.
+func additionalHits[T any](data T, counts []func(T) int) []int {
+	if len(counts) == 0 {
+		return nil
+	}
+	hits := make([]int, len(counts))
+	for i, count := range counts {
+		hits[i] = count(data)
+	}
+	return hits
+}
+
 func getLogsCountFunc(strategy Strategy) func(ld plog.Logs) int {
 	switch strategy {
 	case StrategyRateLimitRequests:
@@ -27,24 +27,25 @@ import (
 	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/metadata"
 	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/metadatatest"
 	"github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor/internal/telemetry"
-	"google.golang.org/genproto/googleapis/rpc/errdetails"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pprofile"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processortest"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -182,6 +183,201 @@ func TestGetCountFunc_Profiles(t *testing.T) {
 	assert.Nil(t, getProfilesCountFunc(""))
 }
 
+func TestWithWeight(t *testing.T) {
+	logs := plog.NewLogs()
+	logRecords := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for range 3 {
+		logRecords.AppendEmpty()
+	}
+	count := getLogsCountFunc(StrategyRateLimitRecords)
+
+	assert.Equal(t, 3, withWeight(count, 1)(logs))
+	assert.Equal(t, 6, withWeight(count, 2)(logs))
+	assert.Equal(t, 2, withWeight(count, 0.5)(logs))         // 1.5 rounded up
+	assert.Equal(t, 1, withWeight(count, 0.1)(logs))         // 0.3 rounded up
+	assert.Equal(t, 0, withWeight(count, 2)(plog.NewLogs())) // empty payloads stay free
+}
+
+func TestConsume_AdditionalLimits(t *testing.T) {
+	rateLimiter := newTestLocalRateLimiter(t, &Config{
+		RateLimitSettings: RateLimitSettings{
+			Rate:             1,
+			Burst:            4,
+			Strategy:         StrategyRateLimitRecords,
+			ThrottleBehavior: ThrottleBehaviorError,
+			RetryDelay:       1 * time.Second,
+			ThrottleInterval: 1 * time.Second,
+			AdditionalLimits: []AdditionalLimit{{
+				Strategy: StrategyRateLimitRequests,
+				Rate:     1,
+				Burst:    1,
+			}},
+		},
+	})
+	require.NoError(t, rateLimiter.Start(context.Background(), componenttest.NewNopHost()))
+
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	var consumed int
+	processor := &ProfilesRateLimiterProcessor{
+		rateLimiterProcessor: rateLimiterProcessor{
+			rl:               rateLimiter,
+			telemetryBuilder: telemetryBuilder,
+			logger:           zap.NewNop(),
+			strategy:         StrategyRateLimitRecords,
+		},
+		// Each sample costs two tokens of the records limit.
+		count: withWeight(getProfilesCountFunc(StrategyRateLimitRecords), 2),
+		additionalCounts: []func(pprofile.Profiles) int{
+			getProfilesCountFunc(StrategyRateLimitRequests),
+		},
+		next: func(context.Context, pprofile.Profiles) error {
+			consumed++
+			return nil
+		},
+	}
+
+	profiles := pprofile.NewProfiles()
+	profile := profiles.ResourceProfiles().AppendEmpty().ScopeProfiles().AppendEmpty().Profiles().AppendEmpty()
+	profile.Samples().AppendEmpty()
+
+	// 2 record tokens and 1 request token are taken.
+	require.NoError(t, processor.ConsumeProfiles(clientContext, profiles))
+	assert.Equal(t, 1, consumed)
+
+	// The records limit has tokens left, but the requests limit does not.
+	err = processor.ConsumeProfiles(clientContext, profiles)
+	testError(t, err)
+	assert.Equal(t, 1, consumed)
+}
+
+func TestConsume_WeightsOnlyMainLimit(t *testing.T) {
+	cfg := NewFactory().CreateDefaultConfig().(*Config)
+	cfg.Rate = 1
+	cfg.Burst = 10
+	cfg.Strategy = StrategyRateLimitRequests
+	cfg.Weights.Profiles = 10
+	cfg.AdditionalLimits = []AdditionalLimit{{
+		Strategy: StrategyRateLimitRecords,
+		Rate:     1,
+		Burst:    1,
+	}}
+	require.NoError(t, cfg.Validate())
+
+	sink := new(consumertest.ProfilesSink)
+	processor, err := NewFactory().CreateProfiles(t.Context(), processortest.NewNopSettings(metadata.Type), cfg, sink)
+	require.NoError(t, err)
+	require.NoError(t, processor.Start(t.Context(), componenttest.NewNopHost()))
+	defer func() {
+		require.NoError(t, processor.Shutdown(t.Context()))
+	}()
+
+	profiles := pprofile.NewProfiles()
+	profile := profiles.ResourceProfiles().AppendEmpty().ScopeProfiles().AppendEmpty().Profiles().AppendEmpty()
+	profile.Samples().AppendEmpty()
+
+	// The request costs 10 tokens of the main limit, and a single token of
+	// the records limit, which would be throttled if it was weighted too.
+	require.NoError(t, processor.ConsumeProfiles(t.Context(), profiles))
+	assert.Len(t, sink.AllProfiles(), 1)
+}
+
+func TestConsume_Shed(t *testing.T) {
+	rateLimiter := newTestLocalRateLimiter(t, &Config{
+		RateLimitSettings: RateLimitSettings{
+			Rate:             1,
+			Burst:            3,
+			Strategy:         StrategyRateLimitRecords,
+			ThrottleBehavior: ThrottleBehaviorShed,
+			RetryDelay:       1 * time.Second,
+			ThrottleInterval: 1 * time.Second,
+		},
+	})
+	require.NoError(t, rateLimiter.Start(context.Background(), componenttest.NewNopHost()))
+
+	tt := componenttest.NewTelemetry()
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(tt.NewTelemetrySettings())
+	require.NoError(t, err)
+	shed, err := newLogsShedFunc(
+		[]string{"severity_number < SEVERITY_NUMBER_WARN"},
+		componenttest.NewNopTelemetrySettings(),
+	)
+	require.NoError(t, err)
+
+	var consumed []plog.Logs
+	processor := &LogsRateLimiterProcessor{
+		rateLimiterProcessor: rateLimiterProcessor{
+			rl:               rateLimiter,
+			telemetryBuilder: telemetryBuilder,
+			logger:           zap.NewNop(),
+			metadataKeys:     []string{"x-tenant-id"},
+			strategy:         StrategyRateLimitRecords,
+		},
+		count: getLogsCountFunc(StrategyRateLimitRecords),
+		shed:  shed,
+		next: func(_ context.Context, ld plog.Logs) error {
+			consumed = append(consumed, ld)
+			return nil
+		},
+	}
+	assert.False(t, processor.Capabilities().MutatesData)
+
+	newLogs := func(severities ...plog.SeverityNumber) plog.Logs {
+		logs := plog.NewLogs()
+		records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		for _, severity := range severities {
+			records.AppendEmpty().SetSeverityNumber(severity)
+		}
+		return logs
+	}
+
+	// 5 records are over the limit of 3, but the 2 error records are within it.
+	err = processor.ConsumeLogs(clientContext, newLogs(
+		plog.SeverityNumberDebug, plog.SeverityNumberError, plog.SeverityNumberInfo,
+		plog.SeverityNumberError, plog.SeverityNumberDebug,
+	))
+	require.NoError(t, err)
+	require.Len(t, consumed, 1)
+	assert.Equal(t, 2, consumed[0].LogRecordCount())
+
+	// Only low-priority records, everything is dropped and nothing is forwarded.
+	err = processor.ConsumeLogs(clientContext, newLogs(plog.SeverityNumberDebug, plog.SeverityNumberDebug))
+	require.NoError(t, err)
+	assert.Len(t, consumed, 1)
+
+	// The error records alone are still over the limit, and the request is
+	// left intact so it can be retried.
+	throttled := newLogs(
+		plog.SeverityNumberError, plog.SeverityNumberError, plog.SeverityNumberDebug,
+	)
+	err = processor.ConsumeLogs(clientContext, throttled)
+	testError(t, err)
+	assert.Len(t, consumed, 1)
+	assert.Equal(t, 3, throttled.LogRecordCount())
+
+	metadatatest.AssertEqualRatelimitShedRecords(t, tt, []metricdata.DataPoint[int64]{
+		{
+			Value: 5,
+			Attributes: attribute.NewSet(
+				telemetry.WithDecision("shed"),
+				telemetry.WithReason(telemetry.StatusOverLimit),
+				telemetry.WithLimit("records"),
+				attribute.String("x-tenant-id", "TestProjectID"),
+			),
+		},
+		{
+			Value: 1,
+			Attributes: attribute.NewSet(
+				telemetry.WithDecision("throttled"),
+				telemetry.WithReason(telemetry.StatusOverLimit),
+				telemetry.WithLimit("records"),
+				attribute.String("x-tenant-id", "TestProjectID"),
+			),
+		},
+	}, metricdatatest.IgnoreTimestamp())
+}
+
 func TestConsume_Logs(t *testing.T) {
 	rateLimiter := newTestLocalRateLimiter(t, &Config{
 		RateLimitSettings: RateLimitSettings{
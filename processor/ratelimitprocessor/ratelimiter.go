@@ -48,6 +48,9 @@ const (
 	DecisionThrottled Decision = "throttled"
 	// DecisionCancelled means the context was cancelled while waiting for a delayed request.
 	DecisionCancelled Decision = "cancelled"
+	// DecisionShed means low-priority records were dropped from a throttled request in shed
+	// mode, and the remaining records passed through.
+	DecisionShed Decision = "shed"
 )
 
 // RateLimitResult carries the outcome of a RateLimit call plus the data
@@ -60,12 +63,17 @@ type RateLimitResult struct {
 	TokensAfter      float64       // bucket level after the call; negative means in debt; delay mode only
 	ConfigRate       float64       // effective configured rate (tokens/sec) for the key
 	EmitTokenMetrics bool          // true only for delay mode, where token metrics are meaningful and accurate
+	Limit            Strategy      // strategy of the limit that throttled or delayed the request, if any
 }
 
 // RateLimiter provides an interface for rate limiting by some number
 // of things: requests, records, or bytes.
+//
+// n holds the number of tokens for the main limit, and additional holds
+// the number of tokens for each of the configured additional limits, in
+// the same order.
 type RateLimiter interface {
-	RateLimit(ctx context.Context, n int) (RateLimitResult, error)
+	RateLimit(ctx context.Context, n int, additional ...int) (RateLimitResult, error)
 }
 
 // getUniqueKey returns a unique key based on client metadata stored
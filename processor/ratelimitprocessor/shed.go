@@ -0,0 +1,219 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor // import "github.com/elastic/opentelemetry-collector-components/processor/ratelimitprocessor"
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlprofilesample"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// shedFunc returns a copy of data without its low-priority records, and
+// the number of records removed and remaining. Containers left without
+// records are removed as well. data itself is not modified, so it is left
+// intact if the trimmed copy is throttled too. Records for which the
+// conditions fail to evaluate are kept; the errors are logged by the
+// condition sequence.
+type shedFunc[T any] func(ctx context.Context, data T) (trimmed T, dropped, remaining int)
+
+// newLogsShedFunc returns a shedFunc removing the log records matching any
+// of conditions, or nil if there are no conditions.
+func newLogsShedFunc(conditions []string, set component.TelemetrySettings) (shedFunc[plog.Logs], error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	parser, err := ottllog.NewParser(ottlfuncs.StandardConverters[*ottllog.TransformContext](), set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ottl parser for logs: %w", err)
+	}
+	parsed, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ottl conditions for logs: %w", err)
+	}
+	seq := ottllog.NewConditionSequence(parsed, set, ottllog.WithConditionSequenceErrorMode(ottl.IgnoreError))
+	return func(ctx context.Context, ld plog.Logs) (trimmed plog.Logs, dropped, remaining int) {
+		trimmed = plog.NewLogs()
+		ld.CopyTo(trimmed)
+		trimmed.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+			rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+				sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+					tCtx := ottllog.NewTransformContextPtr(rl, sl, lr)
+					defer tCtx.Close()
+					match, _ := seq.Eval(ctx, tCtx)
+					return countMatch(match, &dropped, &remaining)
+				})
+				return sl.LogRecords().Len() == 0
+			})
+			return rl.ScopeLogs().Len() == 0
+		})
+		return trimmed, dropped, remaining
+	}, nil
+}
+
+// newMetricsShedFunc returns a shedFunc removing the data points matching
+// any of conditions, or nil if there are no conditions.
+func newMetricsShedFunc(conditions []string, set component.TelemetrySettings) (shedFunc[pmetric.Metrics], error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	parser, err := ottldatapoint.NewParser(ottlfuncs.StandardConverters[*ottldatapoint.TransformContext](), set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ottl parser for datapoints: %w", err)
+	}
+	parsed, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ottl conditions for datapoints: %w", err)
+	}
+	seq := ottldatapoint.NewConditionSequence(parsed, set, ottldatapoint.WithConditionSequenceErrorMode(ottl.IgnoreError))
+	return func(ctx context.Context, md pmetric.Metrics) (trimmed pmetric.Metrics, dropped, remaining int) {
+		trimmed = pmetric.NewMetrics()
+		md.CopyTo(trimmed)
+		trimmed.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+			rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+				sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+					match := func(dp any) bool {
+						tCtx := ottldatapoint.NewTransformContextPtr(rm, sm, m, dp)
+						defer tCtx.Close()
+						match, _ := seq.Eval(ctx, tCtx)
+						return countMatch(match, &dropped, &remaining)
+					}
+					switch m.Type() {
+					case pmetric.MetricTypeGauge:
+						dps := m.Gauge().DataPoints()
+						dps.RemoveIf(func(dp pmetric.NumberDataPoint) bool { return match(dp) })
+						return dps.Len() == 0
+					case pmetric.MetricTypeSum:
+						dps := m.Sum().DataPoints()
+						dps.RemoveIf(func(dp pmetric.NumberDataPoint) bool { return match(dp) })
+						return dps.Len() == 0
+					case pmetric.MetricTypeHistogram:
+						dps := m.Histogram().DataPoints()
+						dps.RemoveIf(func(dp pmetric.HistogramDataPoint) bool { return match(dp) })
+						return dps.Len() == 0
+					case pmetric.MetricTypeExponentialHistogram:
+						dps := m.ExponentialHistogram().DataPoints()
+						dps.RemoveIf(func(dp pmetric.ExponentialHistogramDataPoint) bool { return match(dp) })
+						return dps.Len() == 0
+					case pmetric.MetricTypeSummary:
+						dps := m.Summary().DataPoints()
+						dps.RemoveIf(func(dp pmetric.SummaryDataPoint) bool { return match(dp) })
+						return dps.Len() == 0
+					}
+					return false
+				})
+				return sm.Metrics().Len() == 0
+			})
+			return rm.ScopeMetrics().Len() == 0
+		})
+		return trimmed, dropped, remaining
+	}, nil
+}
+
+// newTracesShedFunc returns a shedFunc removing the spans matching any of
+// conditions, or nil if there are no conditions.
+func newTracesShedFunc(conditions []string, set component.TelemetrySettings) (shedFunc[ptrace.Traces], error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	parser, err := ottlspan.NewParser(ottlfuncs.StandardConverters[*ottlspan.TransformContext](), set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ottl parser for spans: %w", err)
+	}
+	parsed, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ottl conditions for spans: %w", err)
+	}
+	seq := ottlspan.NewConditionSequence(parsed, set, ottlspan.WithConditionSequenceErrorMode(ottl.IgnoreError))
+	return func(ctx context.Context, td ptrace.Traces) (trimmed ptrace.Traces, dropped, remaining int) {
+		trimmed = ptrace.NewTraces()
+		td.CopyTo(trimmed)
+		trimmed.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+			rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+				ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+					tCtx := ottlspan.NewTransformContextPtr(rs, ss, span)
+					defer tCtx.Close()
+					match, _ := seq.Eval(ctx, tCtx)
+					return countMatch(match, &dropped, &remaining)
+				})
+				return ss.Spans().Len() == 0
+			})
+			return rs.ScopeSpans().Len() == 0
+		})
+		return trimmed, dropped, remaining
+	}, nil
+}
+
+// newProfilesShedFunc returns a shedFunc removing the profile samples
+// matching any of conditions, or nil if there are no conditions.
+func newProfilesShedFunc(conditions []string, set component.TelemetrySettings) (shedFunc[pprofile.Profiles], error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	parser, err := ottlprofilesample.NewParser(ottlfuncs.StandardConverters[*ottlprofilesample.TransformContext](), set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ottl parser for profile samples: %w", err)
+	}
+	parsed, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ottl conditions for profile samples: %w", err)
+	}
+	seq := ottlprofilesample.NewConditionSequence(parsed, set, ottlprofilesample.WithConditionSequenceErrorMode(ottl.IgnoreError))
+	return func(ctx context.Context, pd pprofile.Profiles) (trimmed pprofile.Profiles, dropped, remaining int) {
+		trimmed = pprofile.NewProfiles()
+		pd.CopyTo(trimmed)
+		dictionary := trimmed.Dictionary()
+		trimmed.ResourceProfiles().RemoveIf(func(rp pprofile.ResourceProfiles) bool {
+			rp.ScopeProfiles().RemoveIf(func(sp pprofile.ScopeProfiles) bool {
+				sp.Profiles().RemoveIf(func(profile pprofile.Profile) bool {
+					profile.Samples().RemoveIf(func(sample pprofile.Sample) bool {
+						tCtx := ottlprofilesample.NewTransformContextPtr(rp, sp, profile, sample, dictionary)
+						defer tCtx.Close()
+						match, _ := seq.Eval(ctx, tCtx)
+						return countMatch(match, &dropped, &remaining)
+					})
+					return profile.Samples().Len() == 0
+				})
+				return sp.Profiles().Len() == 0
+			})
+			return rp.ScopeProfiles().Len() == 0
+		})
+		return trimmed, dropped, remaining
+	}, nil
+}
+
+// countMatch increments dropped or remaining depending on whether a record
+// matched, and returns whether the record must be removed.
+func countMatch(match bool, dropped, remaining *int) bool {
+	if match {
+		*dropped++
+	} else {
+		*remaining++
+	}
+	return match
+}
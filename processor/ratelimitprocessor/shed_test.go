@@ -0,0 +1,148 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ratelimitprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestLogsShedFunc(t *testing.T) {
+	shed, err := newLogsShedFunc(
+		[]string{`severity_number < SEVERITY_NUMBER_WARN`, `attributes["priority"] == "low"`},
+		componenttest.NewNopTelemetrySettings(),
+	)
+	require.NoError(t, err)
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty().SetSeverityNumber(plog.SeverityNumberDebug)
+	records.AppendEmpty().SetSeverityNumber(plog.SeverityNumberError)
+	lr := records.AppendEmpty()
+	lr.SetSeverityNumber(plog.SeverityNumberError)
+	lr.Attributes().PutStr("priority", "low")
+	// A scope holding only low-priority records is removed.
+	logs.ResourceLogs().At(0).ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().SetSeverityNumber(plog.SeverityNumberInfo)
+
+	trimmed, dropped, remaining := shed(context.Background(), logs)
+	assert.Equal(t, 3, dropped)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, 1, trimmed.LogRecordCount())
+	assert.Equal(t, 1, trimmed.ResourceLogs().At(0).ScopeLogs().Len())
+	assert.Equal(t, plog.SeverityNumberError, trimmed.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityNumber())
+	// The original logs are not modified.
+	assert.Equal(t, 4, logs.LogRecordCount())
+}
+
+func TestMetricsShedFunc(t *testing.T) {
+	shed, err := newMetricsShedFunc(
+		[]string{`attributes["priority"] == "low"`},
+		componenttest.NewNopTelemetrySettings(),
+	)
+	require.NoError(t, err)
+
+	metrics := pmetric.NewMetrics()
+	ms := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	sum := ms.AppendEmpty().SetEmptySum()
+	sum.DataPoints().AppendEmpty().Attributes().PutStr("priority", "low")
+	sum.DataPoints().AppendEmpty().Attributes().PutStr("priority", "high")
+	// A metric holding only low-priority data points is removed.
+	ms.AppendEmpty().SetEmptyHistogram().DataPoints().AppendEmpty().Attributes().PutStr("priority", "low")
+
+	trimmed, dropped, remaining := shed(context.Background(), metrics)
+	assert.Equal(t, 2, dropped)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, 1, trimmed.DataPointCount())
+	assert.Equal(t, 1, trimmed.MetricCount())
+	assert.Equal(t, 3, metrics.DataPointCount())
+}
+
+func TestTracesShedFunc(t *testing.T) {
+	shed, err := newTracesShedFunc(
+		[]string{`status.code != STATUS_CODE_ERROR`},
+		componenttest.NewNopTelemetrySettings(),
+	)
+	require.NoError(t, err)
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	spans.AppendEmpty().Status().SetCode(ptrace.StatusCodeOk)
+	spans.AppendEmpty().Status().SetCode(ptrace.StatusCodeError)
+	spans.AppendEmpty()
+	// A resource holding only low-priority spans is removed.
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+
+	trimmed, dropped, remaining := shed(context.Background(), traces)
+	assert.Equal(t, 3, dropped)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, 1, trimmed.SpanCount())
+	assert.Equal(t, 1, trimmed.ResourceSpans().Len())
+	assert.Equal(t, 4, traces.SpanCount())
+}
+
+func TestProfilesShedFunc(t *testing.T) {
+	shed, err := newProfilesShedFunc(
+		[]string{`link_index == 0`},
+		componenttest.NewNopTelemetrySettings(),
+	)
+	require.NoError(t, err)
+
+	profiles := pprofile.NewProfiles()
+	scopeProfiles := profiles.ResourceProfiles().AppendEmpty().ScopeProfiles().AppendEmpty()
+	samples := scopeProfiles.Profiles().AppendEmpty().Samples()
+	samples.AppendEmpty()
+	samples.AppendEmpty().SetLinkIndex(1)
+	// A profile holding only low-priority samples is removed.
+	scopeProfiles.Profiles().AppendEmpty().Samples().AppendEmpty()
+
+	trimmed, dropped, remaining := shed(context.Background(), profiles)
+	assert.Equal(t, 2, dropped)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, 1, trimmed.SampleCount())
+	assert.Equal(t, 1, trimmed.ResourceProfiles().At(0).ScopeProfiles().At(0).Profiles().Len())
+	assert.Equal(t, 3, profiles.SampleCount())
+}
+
+func TestShedFunc_NoConditions(t *testing.T) {
+	set := componenttest.NewNopTelemetrySettings()
+	logs, err := newLogsShedFunc(nil, set)
+	require.NoError(t, err)
+	assert.Nil(t, logs)
+	metrics, err := newMetricsShedFunc(nil, set)
+	require.NoError(t, err)
+	assert.Nil(t, metrics)
+	traces, err := newTracesShedFunc(nil, set)
+	require.NoError(t, err)
+	assert.Nil(t, traces)
+	profiles, err := newProfilesShedFunc(nil, set)
+	require.NoError(t, err)
+	assert.Nil(t, profiles)
+}
+
+func TestShedFunc_InvalidCondition(t *testing.T) {
+	_, err := newLogsShedFunc([]string{`invalid(`}, componenttest.NewNopTelemetrySettings())
+	assert.ErrorContains(t, err, "failed to parse ottl conditions for logs")
+}
@@ -0,0 +1,200 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/metadata"
+)
+
+var (
+	errAsyncQueueFull   = errors.New("async intake queue is full")
+	errAsyncQueueClosed = errors.New("async intake queue is shutting down")
+)
+
+// asyncBatch is a decoded batch waiting in the async queue.
+type asyncBatch struct {
+	ctx context.Context
+	ld  *plog.Logs
+	md  *pmetric.Metrics
+	td  *ptrace.Traces
+}
+
+// asyncQueue is a bounded queue of decoded batches consumed by a fixed
+// number of workers, decoupling intake requests from the next consumer.
+type asyncQueue struct {
+	policy    DropPolicy
+	workers   int
+	batches   chan asyncBatch
+	consume   func(context.Context, *plog.Logs, *pmetric.Metrics, *ptrace.Traces) []error
+	logger    *zap.Logger
+	telemetry *metadata.TelemetryBuilder
+
+	// mu guards closed and batches against sends after close. Producers
+	// hold a read lock while enqueueing; closing unblocks those waiting
+	// for room in the queue.
+	mu        sync.RWMutex
+	closed    bool
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// stopCtx is canceled when the queue cannot be drained before the
+	// shutdown deadline; remaining batches are then dropped.
+	stopCtx context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newAsyncQueue(
+	cfg AsyncConfig,
+	consume func(context.Context, *plog.Logs, *pmetric.Metrics, *ptrace.Traces) []error,
+	logger *zap.Logger,
+	telemetry *metadata.TelemetryBuilder,
+) *asyncQueue {
+	stopCtx, stop := context.WithCancel(context.Background())
+	return &asyncQueue{
+		policy:    cfg.DropPolicy,
+		workers:   cfg.Workers,
+		batches:   make(chan asyncBatch, cfg.QueueSize),
+		consume:   consume,
+		logger:    logger,
+		telemetry: telemetry,
+		closing:   make(chan struct{}),
+		stopCtx:   stopCtx,
+		stop:      stop,
+	}
+}
+
+// start starts the workers consuming queued batches.
+func (q *asyncQueue) start() {
+	q.wg.Add(q.workers)
+	for range q.workers {
+		go func() {
+			defer q.wg.Done()
+			for b := range q.batches {
+				q.telemetry.ElasticapmintakeAsyncQueueSize.Add(context.Background(), -1)
+				q.consumeBatch(b)
+			}
+		}()
+	}
+}
+
+func (q *asyncQueue) consumeBatch(b asyncBatch) {
+	if q.stopCtx.Err() != nil {
+		q.telemetry.ElasticapmintakeAsyncQueueDropped.Add(context.Background(), 1)
+		return
+	}
+	ctx, cancel := context.WithCancel(b.ctx)
+	defer cancel()
+	stop := context.AfterFunc(q.stopCtx, cancel)
+	defer stop()
+	if err := errors.Join(q.consume(ctx, b.ld, b.md, b.td)...); err != nil {
+		q.logger.Warn("failed to consume queued intake batch", zap.Error(err))
+	}
+}
+
+// enqueue queues a decoded batch, applying the drop policy if the queue is
+// full. It is an ndjsondecoder.BatchConsumer.
+func (q *asyncQueue) enqueue(ctx context.Context, ld *plog.Logs, md *pmetric.Metrics, td *ptrace.Traces) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return errAsyncQueueClosed
+	}
+
+	// The request context is canceled once the response is written, but
+	// its values, such as client metadata, must be kept.
+	b := asyncBatch{ctx: context.WithoutCancel(ctx), ld: ld, md: md, td: td}
+	for {
+		select {
+		case q.batches <- b:
+			q.telemetry.ElasticapmintakeAsyncQueueSize.Add(context.Background(), 1)
+			return nil
+		default:
+		}
+
+		switch q.policy {
+		case DropPolicyDropOldest:
+			select {
+			case <-q.batches:
+				q.telemetry.ElasticapmintakeAsyncQueueSize.Add(context.Background(), -1)
+				q.telemetry.ElasticapmintakeAsyncQueueDropped.Add(context.Background(), 1)
+			default:
+			}
+		case DropPolicyBlock:
+			select {
+			case q.batches <- b:
+				q.telemetry.ElasticapmintakeAsyncQueueSize.Add(context.Background(), 1)
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-q.closing:
+				return errAsyncQueueClosed
+			}
+		default:
+			q.telemetry.ElasticapmintakeAsyncQueueDropped.Add(context.Background(), 1)
+			return errAsyncQueueFull
+		}
+	}
+}
+
+// shutdown stops accepting batches and waits for the workers to consume the
+// queued ones. If ctx is done first, the remaining batches are dropped.
+func (q *asyncQueue) shutdown(ctx context.Context) error {
+	q.closeOnce.Do(func() {
+		close(q.closing)
+		q.mu.Lock()
+		q.closed = true
+		close(q.batches)
+		q.mu.Unlock()
+	})
+
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		q.stop()
+		if remaining := len(q.batches); remaining > 0 {
+			q.logger.Warn("dropping queued intake batches on shutdown", zap.Int("batches", remaining))
+		}
+		return fmt.Errorf("failed to drain async intake queue: %w", ctx.Err())
+	}
+}
+
+// isAsyncRequest reports whether the events of req should be queued, which
+// is only the case if the request has an "async" query parameter set to true.
+func isAsyncRequest(req *http.Request) bool {
+	async, err := strconv.ParseBool(req.URL.Query().Get("async"))
+	return err == nil && async
+}
@@ -0,0 +1,223 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/testutil"
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/metadata"
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/metadatatest"
+	"github.com/elastic/opentelemetry-lib/agentcfg"
+)
+
+// blockingConsumer records the logs it consumes, blocking until released.
+type blockingConsumer struct {
+	mu       sync.Mutex
+	started  chan struct{}
+	release  chan struct{}
+	consumed []*plog.Logs
+}
+
+func newBlockingConsumer() *blockingConsumer {
+	return &blockingConsumer{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (c *blockingConsumer) consume(ctx context.Context, ld *plog.Logs, _ *pmetric.Metrics, _ *ptrace.Traces) []error {
+	c.started <- struct{}{}
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return []error{ctx.Err()}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consumed = append(c.consumed, ld)
+	return nil
+}
+
+func (c *blockingConsumer) all() []*plog.Logs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.consumed
+}
+
+func newTestAsyncQueue(t *testing.T, policy DropPolicy, consume *blockingConsumer) (*asyncQueue, *componenttest.Telemetry) {
+	t.Helper()
+	tt := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+	telemetry, err := metadata.NewTelemetryBuilder(tt.NewTelemetrySettings())
+	require.NoError(t, err)
+	q := newAsyncQueue(AsyncConfig{QueueSize: 1, Workers: 1, DropPolicy: policy}, consume.consume, zap.NewNop(), telemetry)
+	q.start()
+	return q, tt
+}
+
+func TestAsyncQueueDropPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy          DropPolicy
+		expectedErr     error
+		expectedBatches []int
+	}{
+		{policy: DropPolicyReject, expectedErr: errAsyncQueueFull, expectedBatches: []int{0, 1}},
+		{policy: DropPolicyDropOldest, expectedBatches: []int{0, 2}},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			consumer := newBlockingConsumer()
+			q, tt := newTestAsyncQueue(t, tc.policy, consumer)
+
+			batches := make([]*plog.Logs, 3)
+			for i := range batches {
+				ld := plog.NewLogs()
+				batches[i] = &ld
+			}
+			require.NoError(t, q.enqueue(context.Background(), batches[0], nil, nil))
+			<-consumer.started // the worker holds the first batch
+			require.NoError(t, q.enqueue(context.Background(), batches[1], nil, nil))
+			assert.ErrorIs(t, q.enqueue(context.Background(), batches[2], nil, nil), tc.expectedErr)
+
+			metadatatest.AssertEqualElasticapmintakeAsyncQueueSize(t, tt, []metricdata.DataPoint[int64]{{Value: 1}}, metricdatatest.IgnoreTimestamp())
+			metadatatest.AssertEqualElasticapmintakeAsyncQueueDropped(t, tt, []metricdata.DataPoint[int64]{{Value: 1}}, metricdatatest.IgnoreTimestamp())
+
+			close(consumer.release)
+			require.NoError(t, q.shutdown(context.Background()))
+			var expected []*plog.Logs
+			for _, i := range tc.expectedBatches {
+				expected = append(expected, batches[i])
+			}
+			assert.Equal(t, expected, consumer.all())
+			metadatatest.AssertEqualElasticapmintakeAsyncQueueSize(t, tt, []metricdata.DataPoint[int64]{{Value: 0}}, metricdatatest.IgnoreTimestamp())
+		})
+	}
+}
+
+func TestAsyncQueueBlock(t *testing.T) {
+	consumer := newBlockingConsumer()
+	q, _ := newTestAsyncQueue(t, DropPolicyBlock, consumer)
+
+	ld := plog.NewLogs()
+	require.NoError(t, q.enqueue(context.Background(), &ld, nil, nil))
+	<-consumer.started
+	require.NoError(t, q.enqueue(context.Background(), &ld, nil, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.enqueue(ctx, &ld, nil, nil), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() { done <- q.enqueue(context.Background(), &ld, nil, nil) }()
+	close(consumer.release)
+	require.NoError(t, <-done)
+	require.NoError(t, q.shutdown(context.Background()))
+	assert.Len(t, consumer.all(), 3)
+}
+
+func TestAsyncQueueShutdown(t *testing.T) {
+	consumer := newBlockingConsumer()
+	q, tt := newTestAsyncQueue(t, DropPolicyReject, consumer)
+
+	ld := plog.NewLogs()
+	require.NoError(t, q.enqueue(context.Background(), &ld, nil, nil))
+	<-consumer.started
+	require.NoError(t, q.enqueue(context.Background(), &ld, nil, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.shutdown(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, q.enqueue(context.Background(), &ld, nil, nil), errAsyncQueueClosed)
+
+	// The in-flight batch is canceled and the queued one dropped.
+	q.wg.Wait()
+	assert.Empty(t, consumer.all())
+	metadatatest.AssertEqualElasticapmintakeAsyncQueueDropped(t, tt, []metricdata.DataPoint[int64]{{Value: 1}}, metricdatatest.IgnoreTimestamp())
+}
+
+func TestEventsHandlerAsync(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Async.Enabled = true
+	cfg.NetAddr.Endpoint = testutil.GetAvailableLocalAddress(t)
+
+	rcvr, err := newElasticAPMIntakeReceiver(
+		func(context.Context, component.Host) (agentcfg.Fetcher, error) { return nil, nil },
+		cfg,
+		receivertest.NewNopSettings(metadata.Type),
+	)
+	require.NoError(t, err)
+	nextTraces := new(consumertest.TracesSink)
+	rcvr.nextTraces = nextTraces
+	require.NoError(t, rcvr.Start(context.Background(), componenttest.NewNopHost()))
+
+	handler := rcvr.newElasticAPMEventsHandler(func(req *http.Request) context.Context {
+		return withECSMappingMode(req.Context(), false)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, intakeV2EventsPath+"?async=false", bytes.NewReader(generateTransactionPayload(1)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, nextTraces.AllTraces(), 1)
+
+	// Requests are synchronous unless they ask for async intake.
+	req = httptest.NewRequest(http.MethodPost, intakeV2EventsPath, bytes.NewReader(generateTransactionPayload(1)))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, nextTraces.AllTraces(), 2)
+
+	req = httptest.NewRequest(http.MethodPost, intakeV2EventsPath+"?async=true", bytes.NewReader(generateTransactionPayload(1)))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"accepted":1}`, rec.Body.String())
+
+	// Shutdown drains the queue.
+	require.NoError(t, rcvr.Shutdown(context.Background()))
+	assert.Len(t, nextTraces.AllTraces(), 3)
+}
+
+func TestIsAsyncRequest(t *testing.T) {
+	for query, expected := range map[string]bool{
+		"":             false,
+		"?async=true":  true,
+		"?async=1":     true,
+		"?async=false": false,
+		"?async=foo":   false,
+	} {
+		req := httptest.NewRequest(http.MethodPost, intakeV2EventsPath+query, http.NoBody)
+		assert.Equal(t, expected, isAsyncRequest(req), query)
+	}
+}
@@ -0,0 +1,175 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver"
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/elasticattr"
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/ndjsondecoder"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	bearerScheme        = "Bearer"
+	apiKeyScheme        = "ApiKey"
+)
+
+var (
+	errAuthMissing       = errors.New("missing or improperly formatted Authorization header")
+	errAuthInvalidToken  = errors.New("invalid secret token")
+	errAuthInvalidAPIKey = errors.New("invalid API key")
+
+	// errAnonymousNotAllowed is returned for events sent anonymously for
+	// agents or services not in the anonymous allow-lists.
+	errAnonymousNotAllowed = errors.New("unauthorized: anonymous access not permitted")
+)
+
+// agentAuthenticator authenticates APM agents with the configured secret
+// token and API keys.
+type agentAuthenticator struct {
+	cfg AgentAuthConfig
+}
+
+func newAgentAuthenticator(cfg AgentAuthConfig) *agentAuthenticator {
+	return &agentAuthenticator{cfg: cfg}
+}
+
+// authenticate checks the credentials in the Authorization header of req.
+// It returns errAuthMissing if there are none.
+func (a *agentAuthenticator) authenticate(req *http.Request) error {
+	if !a.cfg.Enabled() {
+		return nil
+	}
+	scheme, credentials, ok := strings.Cut(req.Header.Get(authorizationHeader), " ")
+	if !ok || credentials == "" {
+		return errAuthMissing
+	}
+	switch scheme {
+	case bearerScheme:
+		if a.cfg.SecretToken == "" || !secureEqual(credentials, string(a.cfg.SecretToken)) {
+			return errAuthInvalidToken
+		}
+		return nil
+	case apiKeyScheme:
+		for _, key := range a.cfg.APIKeys {
+			if secureEqual(credentials, string(key)) {
+				return nil
+			}
+		}
+		return errAuthInvalidAPIKey
+	}
+	return errAuthMissing
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// anonymousContextKey marks requests allowed in without credentials.
+type anonymousContextKey struct{}
+
+func isAnonymous(ctx context.Context) bool {
+	anonymous, _ := ctx.Value(anonymousContextKey{}).(bool)
+	return anonymous
+}
+
+// middleware rejects unauthenticated requests with 401. If allowAnonymous is
+// set and anonymous access is enabled, requests without an Authorization
+// header are let through and marked as anonymous instead.
+func (a *agentAuthenticator) middleware(next http.Handler, allowAnonymous bool) http.Handler {
+	if !a.cfg.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		err := a.authenticate(req)
+		if errors.Is(err, errAuthMissing) && allowAnonymous && a.cfg.Anonymous.Enabled && req.Header.Get(authorizationHeader) == "" {
+			req = req.WithContext(context.WithValue(req.Context(), anonymousContextKey{}, true))
+			err = nil
+		}
+		if err != nil {
+			w.Header().Set(ContentType, "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error": "authentication failed: " + err.Error(),
+			})
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// anonymousConsumer wraps consumer to reject events whose agent or service
+// are not allowed anonymous access.
+func (a *agentAuthenticator) anonymousConsumer(consumer ndjsondecoder.BatchConsumer) ndjsondecoder.BatchConsumer {
+	return func(ctx context.Context, ld *plog.Logs, md *pmetric.Metrics, td *ptrace.Traces) error {
+		if ld != nil {
+			for i := 0; i < ld.ResourceLogs().Len(); i++ {
+				if err := a.checkAnonymous(ld.ResourceLogs().At(i).Resource()); err != nil {
+					return err
+				}
+			}
+		}
+		if md != nil {
+			for i := 0; i < md.ResourceMetrics().Len(); i++ {
+				if err := a.checkAnonymous(md.ResourceMetrics().At(i).Resource()); err != nil {
+					return err
+				}
+			}
+		}
+		if td != nil {
+			for i := 0; i < td.ResourceSpans().Len(); i++ {
+				if err := a.checkAnonymous(td.ResourceSpans().At(i).Resource()); err != nil {
+					return err
+				}
+			}
+		}
+		return consumer(ctx, ld, md, td)
+	}
+}
+
+func (a *agentAuthenticator) checkAnonymous(resource pcommon.Resource) error {
+	var agentName, serviceName string
+	if v, ok := resource.Attributes().Get(elasticattr.AgentName); ok {
+		agentName = v.Str()
+	}
+	if v, ok := resource.Attributes().Get(string(semconv.ServiceNameKey)); ok {
+		serviceName = v.Str()
+	}
+	anonymous := a.cfg.Anonymous
+	if len(anonymous.AllowAgent) > 0 && !slices.Contains(anonymous.AllowAgent, agentName) {
+		return fmt.Errorf("%w for agent %q", errAnonymousNotAllowed, agentName)
+	}
+	if len(anonymous.AllowService) > 0 && !slices.Contains(anonymous.AllowService, serviceName) {
+		return fmt.Errorf("%w for service %q", errAnonymousNotAllowed, serviceName)
+	}
+	return nil
+}
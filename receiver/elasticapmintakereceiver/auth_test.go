@@ -0,0 +1,199 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/config/configopaque"
+)
+
+func rumPayload(agentName, serviceName string) []byte {
+	return []byte(`{"metadata":{"service":{"name":"` + serviceName + `","agent":{"name":"` + agentName + `","version":"5.0.0"}}}}
+{"transaction":{"id":"aaaaaaaaaaaaaaaa","trace_id":"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb","type":"page-load","duration":1,"span_count":{"started":0}}}
+`)
+}
+
+func authHeader(value string) http.Header {
+	header := rumHeader()
+	if value != "" {
+		header.Set(authorizationHeader, value)
+	}
+	return header
+}
+
+func TestAgentAuth(t *testing.T) {
+	testEndpoint, nextTraces, _ := startRUMReceiver(t, func(cfg *Config) {
+		cfg.AgentAuth.SecretToken = "abc123"
+		cfg.AgentAuth.APIKeys = []configopaque.String{"a2V5MTp2YWx1ZTE="}
+		cfg.AgentAuth.Anonymous.AllowService = []string{"frontend"}
+	})
+	intakeURL := "http://" + testEndpoint + intakeV2EventsPath
+	rumURL := "http://" + testEndpoint + intakeRUMV2EventsPath
+
+	for name, tc := range map[string]struct {
+		url           string
+		authorization string
+		payload       []byte
+		expectedCode  int
+		expectedError string
+	}{
+		"secret token": {
+			url:           intakeURL,
+			authorization: "Bearer abc123",
+			payload:       generateTransactionPayload(1),
+			expectedCode:  http.StatusAccepted,
+		},
+		"api key": {
+			url:           intakeURL,
+			authorization: "ApiKey a2V5MTp2YWx1ZTE=",
+			payload:       generateTransactionPayload(1),
+			expectedCode:  http.StatusAccepted,
+		},
+		"missing credentials": {
+			url:           intakeURL,
+			payload:       generateTransactionPayload(1),
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "authentication failed: missing or improperly formatted Authorization header",
+		},
+		"invalid secret token": {
+			url:           intakeURL,
+			authorization: "Bearer wrong",
+			payload:       generateTransactionPayload(1),
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "authentication failed: invalid secret token",
+		},
+		"invalid api key": {
+			url:           intakeURL,
+			authorization: "ApiKey d3Jvbmc=",
+			payload:       generateTransactionPayload(1),
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "authentication failed: invalid API key",
+		},
+		"unknown scheme": {
+			url:           intakeURL,
+			authorization: "Basic dXNlcjpwYXNz",
+			payload:       generateTransactionPayload(1),
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "authentication failed: missing or improperly formatted Authorization header",
+		},
+		"rum anonymous": {
+			url:          rumURL,
+			payload:      rumPayload("rum-js", "frontend"),
+			expectedCode: http.StatusAccepted,
+		},
+		"rum anonymous agent not allowed": {
+			url:           rumURL,
+			payload:       rumPayload("nodejs", "frontend"),
+			expectedCode:  http.StatusForbidden,
+			expectedError: `unauthorized: anonymous access not permitted for agent "nodejs"`,
+		},
+		"rum anonymous service not allowed": {
+			url:           rumURL,
+			payload:       rumPayload("rum-js", "backend"),
+			expectedCode:  http.StatusForbidden,
+			expectedError: `unauthorized: anonymous access not permitted for service "backend"`,
+		},
+		"rum authenticated bypasses allow-lists": {
+			url:           rumURL,
+			authorization: "Bearer abc123",
+			payload:       rumPayload("nodejs", "backend"),
+			expectedCode:  http.StatusAccepted,
+		},
+		"rum invalid credentials": {
+			url:           rumURL,
+			authorization: "Bearer wrong",
+			payload:       rumPayload("rum-js", "frontend"),
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "authentication failed: invalid secret token",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			nextTraces.Reset()
+			resp := postRUM(t, tc.url, bytes.NewReader(tc.payload), authHeader(tc.authorization))
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedCode, resp.StatusCode, string(body))
+			if tc.expectedError == "" {
+				assert.Equal(t, 1, nextTraces.SpanCount())
+				return
+			}
+			assert.Zero(t, nextTraces.SpanCount())
+			var result struct {
+				Error  string   `json:"error"`
+				Errors []string `json:"errors"`
+			}
+			require.NoError(t, json.Unmarshal(body, &result))
+			assert.Equal(t, tc.expectedError, result.Error+strings.Join(result.Errors, ""))
+		})
+	}
+}
+
+func TestAgentAuthAnonymousDisabled(t *testing.T) {
+	testEndpoint, _, _ := startRUMReceiver(t, func(cfg *Config) {
+		cfg.AgentAuth.SecretToken = "abc123"
+		cfg.AgentAuth.Anonymous.Enabled = false
+	})
+	resp := postRUM(t, "http://"+testEndpoint+intakeRUMV2EventsPath, bytes.NewReader(rumPayload("rum-js", "frontend")), rumHeader())
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// CORS preflight requests never carry credentials.
+	req, err := http.NewRequest(http.MethodOptions, "http://"+testEndpoint+intakeRUMV2EventsPath, nil)
+	require.NoError(t, err)
+	req.Header.Set(Origin, "http://localhost:8000")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestAgentAuthRootHandler(t *testing.T) {
+	testEndpoint, _, _ := startRUMReceiver(t, func(cfg *Config) {
+		cfg.AgentAuth.SecretToken = "abc123"
+	})
+	for name, tc := range map[string]struct {
+		authorization string
+		expectedBody  string
+	}{
+		"authorized":    {authorization: "Bearer abc123", expectedBody: `{"publish_ready":true,"version":"8.9.0"}` + "\n"},
+		"anonymous":     {expectedBody: ""},
+		"invalid token": {authorization: "Bearer wrong", expectedBody: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://"+testEndpoint+rootPath, nil)
+			require.NoError(t, err)
+			if tc.authorization != "" {
+				req.Header.Set(authorizationHeader, tc.authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tc.expectedBody, string(body))
+		})
+	}
+}
@@ -19,10 +19,14 @@ package elasticapmintakereceiver // import "github.com/elastic/opentelemetry-col
 
 import (
 	"fmt"
+	"net/netip"
 	"time"
 
 	"github.com/elastic/opentelemetry-lib/config/configelasticsearch"
 	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configopaque"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
 )
 
 // Config defines configuration for the Elastic APM receiver.
@@ -41,6 +45,23 @@ type Config struct {
 	// MaxEventSize is the maximum allowed event size, in bytes.
 	MaxEventSize int `mapstructure:"max_event_size"`
 
+	// Async configures asynchronous processing of intake requests.
+	Async AsyncConfig `mapstructure:"async"`
+
+	// RUM configures the intake endpoints used by Elastic RUM agents.
+	RUM RUMConfig `mapstructure:"rum"`
+
+	// AgentAuth configures the authentication of APM agents built into the
+	// receiver, in addition to any confighttp authenticator.
+	AgentAuth AgentAuthConfig `mapstructure:"agent_auth"`
+
+	// IntakeV1 configures the deprecated intake v1 endpoints.
+	IntakeV1 IntakeV1Config `mapstructure:"intake_v1"`
+
+	// SourceMap configures where source maps uploaded to /assets/v1/sourcemaps
+	// are stored. The endpoint is registered only if a store is configured.
+	SourceMap sourcemap.Config `mapstructure:"sourcemap"`
+
 	confighttp.ServerConfig `mapstructure:",squash"`
 }
 
@@ -58,6 +79,206 @@ type AgentConfig struct {
 	CacheDuration time.Duration `mapstructure:"cache_duration"`
 }
 
+// DropPolicy determines what happens to a batch when the asynchronous intake
+// queue is full.
+type DropPolicy string
+
+const (
+	// DropPolicyReject rejects the request with 503 Service Unavailable.
+	DropPolicyReject DropPolicy = "reject"
+	// DropPolicyDropOldest drops the oldest queued batch to make room.
+	DropPolicyDropOldest DropPolicy = "drop_oldest"
+	// DropPolicyBlock waits for room in the queue, holding the request open.
+	DropPolicyBlock DropPolicy = "block"
+)
+
+// AsyncConfig configures asynchronous intake. When enabled, decoded events
+// are queued and the request is acknowledged without waiting for the next
+// consumer, similar to the "async" query parameter of APM Server.
+type AsyncConfig struct {
+	// Enabled queues the events of intake requests sent with the
+	// "async=true" query parameter. Other requests are still processed
+	// synchronously. Async intake is disabled by default.
+	Enabled bool `mapstructure:"enabled"`
+
+	// QueueSize is the maximum number of decoded batches waiting to be
+	// consumed. Default is 1000.
+	QueueSize int `mapstructure:"queue_size"`
+
+	// Workers is the number of goroutines consuming queued batches.
+	// Default is 10.
+	Workers int `mapstructure:"workers"`
+
+	// DropPolicy determines what happens when the queue is full: "reject",
+	// "drop_oldest" or "block". Default is "reject".
+	DropPolicy DropPolicy `mapstructure:"drop_policy"`
+}
+
+// Validate checks the async intake configuration is valid.
+func (cfg *AsyncConfig) Validate() error {
+	if cfg.QueueSize <= 0 {
+		return fmt.Errorf("queue_size must be positive")
+	}
+	if cfg.Workers <= 0 {
+		return fmt.Errorf("workers must be positive")
+	}
+	switch cfg.DropPolicy {
+	case DropPolicyReject, DropPolicyDropOldest, DropPolicyBlock:
+	default:
+		return fmt.Errorf("unsupported drop_policy %q, must be one of %q, %q or %q",
+			cfg.DropPolicy, DropPolicyReject, DropPolicyDropOldest, DropPolicyBlock)
+	}
+	return nil
+}
+
+// RUMConfig configures the RUM intake endpoints, /intake/v2/rum/events and
+// /intake/v3/rum/events. They accept unauthenticated requests from browsers,
+// limited by AgentAuthConfig.Anonymous if agent authentication is enabled.
+type RUMConfig struct {
+	// Enabled registers the RUM intake endpoints. RUM is disabled by default.
+	Enabled bool `mapstructure:"enabled"`
+
+	// AllowOrigins lists the origins browsers may send RUM events from.
+	// Origins may contain "*" wildcards. Default is ["*"].
+	AllowOrigins []string `mapstructure:"allow_origins"`
+
+	// AllowHeaders lists the headers allowed in CORS requests, in addition
+	// to Content-Type, Content-Encoding and Accept.
+	AllowHeaders []string `mapstructure:"allow_headers"`
+
+	// RateLimit limits the number of events accepted per client IP.
+	RateLimit RUMRateLimitConfig `mapstructure:"rate_limit"`
+
+	// ClientGeoHeaders names the request headers carrying the client
+	// location, such as those set by a CDN or load balancer.
+	ClientGeoHeaders ClientGeoHeadersConfig `mapstructure:"client_geo_headers"`
+
+	// TrustedProxies lists the IP addresses or CIDR ranges of the proxies
+	// whose X-Real-IP and X-Forwarded-For headers are used to determine the
+	// client IP. The headers are ignored for requests from other peers, as
+	// any client can set them.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// Validate checks the RUM configuration is valid.
+func (cfg *RUMConfig) Validate() error {
+	if _, err := parseTrustedProxies(cfg.TrustedProxies); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseTrustedProxies parses the trusted proxy IP addresses and CIDR ranges
+// as prefixes, single addresses becoming prefixes of their full length.
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_proxies must contain IP addresses or CIDR ranges, got %q", proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// IntakeV1Config configures the deprecated intake v1 endpoints,
+// /v1/transactions and /v1/errors, used by APM agents predating APM Server
+// 6.5. Their JSON documents are translated to intake v2 events.
+type IntakeV1Config struct {
+	// Enabled registers the intake v1 endpoints. They are disabled by default.
+	Enabled bool `mapstructure:"enabled"`
+
+	// MaxRequestSize is the maximum allowed size of an uncompressed intake
+	// v1 document, in bytes. Default is 30MiB.
+	MaxRequestSize int `mapstructure:"max_request_size"`
+}
+
+// Validate checks the intake v1 configuration is valid.
+func (cfg *IntakeV1Config) Validate() error {
+	if cfg.MaxRequestSize <= 0 {
+		return fmt.Errorf("max_request_size must be positive")
+	}
+	return nil
+}
+
+// AgentAuthConfig configures secret token and API key authentication, as
+// supported by APM Server. Authentication is enabled when a secret token or
+// at least one API key is set.
+type AgentAuthConfig struct {
+	// SecretToken is the token agents send as "Authorization: Bearer <token>".
+	SecretToken configopaque.String `mapstructure:"secret_token"`
+
+	// APIKeys are the encoded API keys agents may send as
+	// "Authorization: ApiKey <key>".
+	APIKeys []configopaque.String `mapstructure:"api_keys"`
+
+	// Anonymous configures unauthenticated access to the RUM endpoints.
+	Anonymous AnonymousAuthConfig `mapstructure:"anonymous"`
+}
+
+type AnonymousAuthConfig struct {
+	// Enabled allows requests without an Authorization header to the RUM
+	// endpoints when authentication is enabled. Default is true.
+	Enabled bool `mapstructure:"enabled"`
+
+	// AllowAgent lists the agent names anonymous requests may send events
+	// for. Default is ["rum-js", "js-base"].
+	AllowAgent []string `mapstructure:"allow_agent"`
+
+	// AllowService lists the service names anonymous requests may send
+	// events for. All services are allowed if empty.
+	AllowService []string `mapstructure:"allow_service"`
+}
+
+// Enabled reports whether agents must authenticate.
+func (cfg *AgentAuthConfig) Enabled() bool {
+	return cfg.SecretToken != "" || len(cfg.APIKeys) > 0
+}
+
+// Validate checks the agent authentication configuration is valid.
+func (cfg *AgentAuthConfig) Validate() error {
+	for _, key := range cfg.APIKeys {
+		if key == "" {
+			return fmt.Errorf("api_keys must not contain empty keys")
+		}
+	}
+	return nil
+}
+
+type RUMRateLimitConfig struct {
+	// EventLimit is the number of events per second accepted from a single
+	// client IP, with bursts of up to three times as many. Zero disables
+	// rate limiting. Default is 300.
+	EventLimit int `mapstructure:"event_limit"`
+
+	// IPLimit is the number of client IPs whose rate limits are tracked.
+	// The least recently seen IPs are evicted first. Default is 1000.
+	IPLimit int `mapstructure:"ip_limit"`
+}
+
+type ClientGeoHeadersConfig struct {
+	CountryISOCode string `mapstructure:"country_iso_code"`
+	RegionISOCode  string `mapstructure:"region_iso_code"`
+	RegionName     string `mapstructure:"region_name"`
+	CityName       string `mapstructure:"city_name"`
+}
+
+// Validate checks the RUM rate limit configuration is valid.
+func (cfg *RUMRateLimitConfig) Validate() error {
+	if cfg.EventLimit < 0 {
+		return fmt.Errorf("event_limit must not be negative (0 disables rate limiting)")
+	}
+	if cfg.EventLimit > 0 && cfg.IPLimit <= 0 {
+		return fmt.Errorf("ip_limit must be positive")
+	}
+	return nil
+}
+
 // Validate checks the receiver configuration is valid.
 func (cfg *Config) Validate() error {
 	if cfg.BatchSize <= 0 {
@@ -29,6 +29,7 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
 	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/config/configopaque"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
 	"go.opentelemetry.io/collector/confmap/xconfmap"
 )
@@ -47,6 +48,27 @@ func TestLoadConfig(t *testing.T) {
 			BatchSize:             defaultBatchSize,
 			MaxConcurrentDecoders: int(defaultMaxConcurrentDecoders),
 			MaxEventSize:          defaultMaxEventSize,
+			RUM: RUMConfig{
+				AllowOrigins: []string{"*"},
+				RateLimit: RUMRateLimitConfig{
+					EventLimit: defaultRUMEventLimit,
+					IPLimit:    defaultRUMIPLimit,
+				},
+			},
+			AgentAuth: AgentAuthConfig{
+				Anonymous: AnonymousAuthConfig{
+					Enabled:    true,
+					AllowAgent: []string{"rum-js", "js-base"},
+				},
+			},
+			Async: AsyncConfig{
+				QueueSize:  defaultAsyncQueueSize,
+				Workers:    defaultAsyncWorkers,
+				DropPolicy: DropPolicyReject,
+			},
+			IntakeV1: IntakeV1Config{
+				MaxRequestSize: defaultIntakeV1MaxSize,
+			},
 			AgentConfig: AgentConfig{
 				Enabled:       false,
 				CacheDuration: 30 * time.Second,
@@ -101,6 +123,110 @@ func TestLoadConfig(t *testing.T) {
 				return cfg
 			}(),
 		},
+		{
+			id: component.NewIDWithName(metadata.Type, "rum"),
+			expected: func() *Config {
+				cfg := expectedDefaultConfig()
+				cfg.RUM = RUMConfig{
+					Enabled:      true,
+					AllowOrigins: []string{"https://*.example.com"},
+					AllowHeaders: []string{"X-Custom"},
+					RateLimit: RUMRateLimitConfig{
+						EventLimit: 100,
+						IPLimit:    50,
+					},
+					ClientGeoHeaders: ClientGeoHeadersConfig{
+						CountryISOCode: "CloudFront-Viewer-Country",
+						CityName:       "CloudFront-Viewer-City",
+					},
+					TrustedProxies: []string{"10.0.0.1", "172.16.0.0/12"},
+				}
+				return cfg
+			}(),
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "rum_disabled_rate_limit"),
+			expected: func() *Config {
+				cfg := expectedDefaultConfig()
+				cfg.RUM.Enabled = true
+				cfg.RUM.RateLimit.EventLimit = 0
+				return cfg
+			}(),
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_rum_event_limit"),
+			validateErrorMessage: "event_limit must not be negative",
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_rum_trusted_proxies"),
+			validateErrorMessage: `trusted_proxies must contain IP addresses or CIDR ranges, got "10.0.0.0/33"`,
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_rum_ip_limit"),
+			validateErrorMessage: "ip_limit must be positive",
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "agent_auth"),
+			expected: func() *Config {
+				cfg := expectedDefaultConfig()
+				cfg.AgentAuth.SecretToken = "abc123"
+				cfg.AgentAuth.APIKeys = []configopaque.String{"a2V5MTp2YWx1ZTE="}
+				cfg.AgentAuth.Anonymous.AllowAgent = []string{"rum-js"}
+				cfg.AgentAuth.Anonymous.AllowService = []string{"frontend"}
+				return cfg
+			}(),
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_agent_auth_api_key"),
+			validateErrorMessage: "api_keys must not contain empty keys",
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "async"),
+			expected: func() *Config {
+				cfg := expectedDefaultConfig()
+				cfg.Async = AsyncConfig{
+					Enabled:    true,
+					QueueSize:  500,
+					Workers:    4,
+					DropPolicy: DropPolicyDropOldest,
+				}
+				return cfg
+			}(),
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_async_queue_size"),
+			validateErrorMessage: "queue_size must be positive",
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_async_drop_policy"),
+			validateErrorMessage: `unsupported drop_policy "drop_newest"`,
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "intake_v1"),
+			expected: func() *Config {
+				cfg := expectedDefaultConfig()
+				cfg.IntakeV1.Enabled = true
+				cfg.IntakeV1.MaxRequestSize = 1048576
+				return cfg
+			}(),
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_intake_v1_max_request_size"),
+			validateErrorMessage: "max_request_size must be positive",
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "sourcemap_storage"),
+			expected: func() *Config {
+				cfg := expectedDefaultConfig()
+				storageID := component.MustNewID("file_storage")
+				cfg.SourceMap.StorageID = &storageID
+				return cfg
+			}(),
+		},
+		{
+			id:                   component.NewIDWithName(metadata.Type, "invalid_sourcemap"),
+			validateErrorMessage: "only one of directory and storage may be set",
+		},
 		{
 			id:                   component.NewIDWithName(metadata.Type, "invalid_batch_size"),
 			validateErrorMessage: "batch_size must be positive",
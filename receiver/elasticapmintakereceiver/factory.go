@@ -39,6 +39,11 @@ const (
 	defaultBatchSize             = 10
 	defaultMaxConcurrentDecoders = 100
 	defaultMaxEventSize          = 1024 * 1024 // 1Mib
+	defaultIntakeV1MaxSize       = 30 * 1024 * 1024
+	defaultAsyncQueueSize        = 1000
+	defaultAsyncWorkers          = 10
+	defaultRUMEventLimit         = 300
+	defaultRUMIPLimit            = 1000
 )
 
 // NewFactory creates a new factory for the elasticapm receiver.
@@ -69,6 +74,29 @@ func createDefaultConfig() component.Config {
 		BatchSize:             defaultBatchSize,
 		MaxConcurrentDecoders: defaultMaxConcurrentDecoders,
 		MaxEventSize:          defaultMaxEventSize,
+		// based on apm-server defaults https://github.com/elastic/apm-server/blob/main/internal/beater/config/auth.go
+		AgentAuth: AgentAuthConfig{
+			Anonymous: AnonymousAuthConfig{
+				Enabled:    true,
+				AllowAgent: []string{"rum-js", "js-base"},
+			},
+		},
+		Async: AsyncConfig{
+			QueueSize:  defaultAsyncQueueSize,
+			Workers:    defaultAsyncWorkers,
+			DropPolicy: DropPolicyReject,
+		},
+		IntakeV1: IntakeV1Config{
+			MaxRequestSize: defaultIntakeV1MaxSize,
+		},
+		// based on apm-server defaults https://github.com/elastic/apm-server/blob/main/internal/beater/config/rum.go
+		RUM: RUMConfig{
+			AllowOrigins: []string{"*"},
+			RateLimit: RUMRateLimitConfig{
+				EventLimit: defaultRUMEventLimit,
+				IPLimit:    defaultRUMIPLimit,
+			},
+		},
 		AgentConfig: AgentConfig{
 			Enabled:       false,
 			Elasticsearch: defaultESClientConfig,
@@ -0,0 +1,62 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver"
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/ndjsondecoder"
+)
+
+const (
+	intakeV1TransactionsPath = "/v1/transactions"
+	intakeV1ErrorsPath       = "/v1/errors"
+)
+
+// registerIntakeV1Handlers registers the deprecated intake v1 endpoints if
+// they are enabled.
+func (r *elasticAPMIntakeReceiver) registerIntakeV1Handlers(mux *http.ServeMux) {
+	if !r.cfg.IntakeV1.Enabled {
+		return
+	}
+	mux.Handle(intakeV1TransactionsPath, r.auth.middleware(r.newIntakeV1EventsHandler(ndjsondecoder.V1Transactions), false))
+	mux.Handle(intakeV1ErrorsPath, r.auth.middleware(r.newIntakeV1EventsHandler(ndjsondecoder.V1Errors), false))
+}
+
+// newIntakeV1EventsHandler returns a handler that translates intake v1
+// documents of the given kind to intake v2 events and decodes them as such.
+// The Content-Type header is ignored, since proxies in front of old agents
+// are known to rewrite it.
+func (r *elasticAPMIntakeReceiver) newIntakeV1EventsHandler(kind ndjsondecoder.V1Kind) http.HandlerFunc {
+	ctxFunc := func(req *http.Request) context.Context {
+		return withECSMappingMode(req.Context(), r.cfg.IncludeMetadata)
+	}
+	return r.newEventsHandler(ctxFunc, func(ctx context.Context, req *http.Request, consumer ndjsondecoder.BatchConsumer) (int, []error) {
+		body, err := sniffCompressedBody(req)
+		if err != nil {
+			return 0, []error{err}
+		}
+		events, err := ndjsondecoder.TranslateV1(body, kind, r.cfg.IntakeV1.MaxRequestSize, time.Now())
+		if err != nil {
+			return 0, []error{err}
+		}
+		return ndjsondecoder.HandleStream(ctx, events, r.cfg.BatchSize, r.cfg.MaxEventSize, r.settings.Logger, consumer)
+	})
+}
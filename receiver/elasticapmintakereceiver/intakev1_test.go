@@ -0,0 +1,98 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testV1Transactions = `{"service":{"name":"legacy","agent":{"name":"python","version":"3.0.0"}},"transactions":[` +
+		`{"id":"945254c5-67a5-417e-8a4e-aa29efb4c2e1","name":"GET /","type":"request","duration":10,"timestamp":"2017-05-30T18:53:27.154Z",` +
+		`"spans":[{"id":0,"name":"SELECT","type":"db.postgresql.query","start":1,"duration":2}]}]}`
+	testV1Errors = `{"service":{"name":"legacy","agent":{"name":"python","version":"3.0.0"}},"errors":[` +
+		`{"id":"5f0e9b3c-2a3f-4b8e-9a1d-6c7b8e9f0a1b","exception":{"message":"boom","type":"ValueError"},"transaction":{"id":"945254c5-67a5-417e-8a4e-aa29efb4c2e1"}}]}`
+)
+
+func TestIntakeV1(t *testing.T) {
+	testEndpoint, nextTraces, nextLogs := startRUMReceiver(t, func(cfg *Config) { cfg.IntakeV1.Enabled = true })
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err := gw.Write([]byte(testV1Transactions))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	// The proxy dropped Content-Encoding and rewrote Content-Type.
+	resp := postRUM(t, "http://"+testEndpoint+intakeV1TransactionsPath, &gzipped, http.Header{"Content-Type": {"text/plain"}})
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, string(body))
+	assert.JSONEq(t, `{"accepted":2}`, string(body))
+
+	require.Len(t, nextTraces.AllTraces(), 1)
+	spans := nextTraces.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 2, spans.Len())
+	assert.Equal(t, "945254c567a5417e8a4eaa29efb4c2e1", spans.At(0).TraceID().String())
+	assert.Equal(t, spans.At(0).SpanID(), spans.At(1).ParentSpanID())
+
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	_, err = zw.Write([]byte(testV1Errors))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	resp = postRUM(t, "http://"+testEndpoint+intakeV1ErrorsPath, &deflated, http.Header{
+		"Content-Type":     {"application/json"},
+		"Content-Encoding": {"deflate"},
+	})
+	body, _ = io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, string(body))
+
+	require.Len(t, nextLogs.AllLogs(), 1)
+	record := nextLogs.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	assert.Equal(t, "945254c567a5417e8a4eaa29efb4c2e1", record.TraceID().String())
+}
+
+func TestIntakeV1Errors(t *testing.T) {
+	testEndpoint, _, _ := startRUMReceiver(t, func(cfg *Config) {
+		cfg.IntakeV1.Enabled = true
+		cfg.IntakeV1.MaxRequestSize = 64
+	})
+
+	resp := postRUM(t, "http://"+testEndpoint+intakeV1TransactionsPath, strings.NewReader(testV1Transactions), http.Header{})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	resp = postRUM(t, "http://"+testEndpoint+intakeV1ErrorsPath, strings.NewReader(`{"errors":[`), http.Header{})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestIntakeV1Disabled(t *testing.T) {
+	testEndpoint, _, _ := startRUMReceiver(t, nil)
+
+	resp := postRUM(t, "http://"+testEndpoint+intakeV1TransactionsPath, strings.NewReader(testV1Transactions), http.Header{})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
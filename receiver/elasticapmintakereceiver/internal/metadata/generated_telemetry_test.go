@@ -0,0 +1,91 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	embeddedmetric "go.opentelemetry.io/otel/metric/embedded"
+	noopmetric "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	embeddedtrace "go.opentelemetry.io/otel/trace/embedded"
+	nooptrace "go.opentelemetry.io/otel/trace/noop"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+)
+
+type mockMeter struct {
+	noopmetric.Meter
+	name string
+}
+type mockMeterProvider struct {
+	embeddedmetric.MeterProvider
+}
+
+func (m mockMeterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return mockMeter{name: name}
+}
+
+type mockTracer struct {
+	nooptrace.Tracer
+	name string
+}
+
+type mockTracerProvider struct {
+	embeddedtrace.TracerProvider
+}
+
+func (m mockTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return mockTracer{name: name}
+}
+
+func TestProviders(t *testing.T) {
+	set := component.TelemetrySettings{
+		MeterProvider:  mockMeterProvider{},
+		TracerProvider: mockTracerProvider{},
+	}
+
+	meter := Meter(set)
+	if m, ok := meter.(mockMeter); ok {
+		require.Equal(t, "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver", m.name)
+	} else {
+		require.Fail(t, "returned Meter not mockMeter")
+	}
+
+	tracer := Tracer(set)
+	if m, ok := tracer.(mockTracer); ok {
+		require.Equal(t, "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver", m.name)
+	} else {
+		require.Fail(t, "returned Meter not mockTracer")
+	}
+}
+
+func TestNewTelemetryBuilder(t *testing.T) {
+	set := componenttest.NewNopTelemetrySettings()
+	applied := false
+	_, err := NewTelemetryBuilder(set, telemetryBuilderOptionFunc(func(b *TelemetryBuilder) {
+		applied = true
+	}))
+	require.NoError(t, err)
+	require.True(t, applied)
+}
@@ -0,0 +1,85 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ndjsondecoder // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/ndjsondecoder"
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/elasticattr"
+)
+
+// RUMRequest holds the information about the HTTP request carrying a RUM
+// event stream. Browsers do not report it in the events themselves.
+type RUMRequest struct {
+	// Time is when the request was received. It is used as the timestamp
+	// of events that do not have one.
+	Time time.Time
+	// ClientIP is the address of the browser that sent the request.
+	ClientIP string
+	// UserAgent is the User-Agent header of the request.
+	UserAgent string
+	// Geo holds the client location, if known.
+	Geo ClientGeo
+}
+
+// ClientGeo is the location of a RUM client.
+type ClientGeo struct {
+	CountryISOCode string
+	RegionISOCode  string
+	RegionName     string
+	CityName       string
+}
+
+func (r *RUMRequest) writeResourceAttributes(ld *plog.Logs, md *pmetric.Metrics, td *ptrace.Traces) {
+	if ld != nil {
+		for i := 0; i < ld.ResourceLogs().Len(); i++ {
+			r.writeAttrs(ld.ResourceLogs().At(i).Resource().Attributes())
+		}
+	}
+	if md != nil {
+		for i := 0; i < md.ResourceMetrics().Len(); i++ {
+			r.writeAttrs(md.ResourceMetrics().At(i).Resource().Attributes())
+		}
+	}
+	if td != nil {
+		for i := 0; i < td.ResourceSpans().Len(); i++ {
+			r.writeAttrs(td.ResourceSpans().At(i).Resource().Attributes())
+		}
+	}
+}
+
+// writeAttrs writes the request attributes, replacing any derived from the
+// events: the HTTP request is the authoritative source for RUM.
+func (r *RUMRequest) writeAttrs(attrs pcommon.Map) {
+	if ip := validateIP(r.ClientIP); ip != "" {
+		attrs.PutStr(string(semconv.ClientAddressKey), ip)
+		attrs.PutStr(string(semconv.SourceAddressKey), ip)
+	}
+	w := mapWriter{m: attrs}
+	putStrNonEmpty(w, string(semconv.UserAgentOriginalKey), r.UserAgent)
+	putStrNonEmpty(w, elasticattr.ClientGeoCountryISO, r.Geo.CountryISOCode)
+	putStrNonEmpty(w, elasticattr.ClientGeoRegionISO, r.Geo.RegionISOCode)
+	putStrNonEmpty(w, elasticattr.ClientGeoRegionName, r.Geo.RegionName)
+	putStrNonEmpty(w, elasticattr.ClientGeoCityName, r.Geo.CityName)
+}
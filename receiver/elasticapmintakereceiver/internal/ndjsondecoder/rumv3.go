@@ -0,0 +1,455 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Field names adapted from github.com/elastic/apm-data/input/elasticapm/internal/modeldecoder/rumv3.
+
+package ndjsondecoder // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/ndjsondecoder"
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// rumV3Field describes how a RUM v3 field is renamed to its intake v2 name.
+// A nil fields map copies the value as is; otherwise the value (or, for
+// arrays, every element) is an object whose fields are renamed recursively.
+type rumV3Field struct {
+	name   string
+	fields rumV3Fields
+	// convert, when set, replaces the value instead of fields.
+	convert func(any) any
+}
+
+type rumV3Fields map[string]rumV3Field
+
+func (f rumV3Fields) translate(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			field, ok := f[k]
+			if !ok {
+				continue
+			}
+			switch {
+			case field.convert != nil:
+				out[field.name] = field.convert(val)
+			case field.fields != nil:
+				out[field.name] = field.fields.translate(val)
+			default:
+				out[field.name] = val
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = f.translate(elem)
+		}
+		return out
+	}
+	return v
+}
+
+func rumV3Value(name string) rumV3Field { return rumV3Field{name: name} }
+
+func rumV3Object(name string, fields rumV3Fields) rumV3Field {
+	return rumV3Field{name: name, fields: fields}
+}
+
+var (
+	rumV3NameVersion = rumV3Fields{
+		"n":  rumV3Value("name"),
+		"ve": rumV3Value("version"),
+	}
+	rumV3Service = rumV3Fields{
+		"a":  rumV3Object("agent", rumV3NameVersion),
+		"en": rumV3Value("environment"),
+		"fw": rumV3Object("framework", rumV3NameVersion),
+		"la": rumV3Object("language", rumV3NameVersion),
+		"n":  rumV3Value("name"),
+		"ru": rumV3Object("runtime", rumV3NameVersion),
+		"ve": rumV3Value("version"),
+	}
+	rumV3User = rumV3Fields{
+		"ud": rumV3Value("domain"),
+		"id": rumV3Value("id"),
+		"em": rumV3Value("email"),
+		"un": rumV3Value("username"),
+	}
+	rumV3Metadata = rumV3Fields{
+		"l":  rumV3Value("labels"),
+		"se": rumV3Object("service", rumV3Service),
+		"u":  rumV3Object("user", rumV3User),
+		"n": rumV3Object("network", rumV3Fields{
+			"c": rumV3Object("connection", rumV3Fields{"t": rumV3Value("type")}),
+		}),
+	}
+	rumV3Stacktrace = rumV3Fields{
+		"ap":  rumV3Value("abs_path"),
+		"cn":  rumV3Value("classname"),
+		"cli": rumV3Value("context_line"),
+		"f":   rumV3Value("filename"),
+		"fn":  rumV3Value("function"),
+		"mo":  rumV3Value("module"),
+		"poc": rumV3Value("post_context"),
+		"prc": rumV3Value("pre_context"),
+		"co":  rumV3Value("colno"),
+		"li":  rumV3Value("lineno"),
+	}
+	rumV3Context = rumV3Fields{
+		"cu": rumV3Value("custom"),
+		"g":  rumV3Value("tags"),
+		"se": rumV3Object("service", rumV3Service),
+		"u":  rumV3Object("user", rumV3User),
+		"q": rumV3Object("request", rumV3Fields{
+			"en":  rumV3Value("env"),
+			"he":  rumV3Value("headers"),
+			"hve": rumV3Value("http_version"),
+			"mt":  rumV3Value("method"),
+		}),
+		"p": rumV3Object("page", rumV3Fields{
+			"rf":  rumV3Value("referer"),
+			"url": rumV3Value("url"),
+		}),
+		"r": rumV3Object("response", rumV3Fields{
+			"he":  rumV3Value("headers"),
+			"dbs": rumV3Value("decoded_body_size"),
+			"ebs": rumV3Value("encoded_body_size"),
+			"sc":  rumV3Value("status_code"),
+			"ts":  rumV3Value("transfer_size"),
+		}),
+	}
+	rumV3Exception = rumV3Fields{
+		"at": rumV3Value("attributes"),
+		"cd": rumV3Value("code"),
+		"mg": rumV3Value("message"),
+		"mo": rumV3Value("module"),
+		"st": rumV3Object("stacktrace", rumV3Stacktrace),
+		"t":  rumV3Value("type"),
+		"hd": rumV3Value("handled"),
+	}
+	rumV3Error = rumV3Fields{
+		"timestamp": rumV3Value("timestamp"),
+		"log": rumV3Object("log", rumV3Fields{
+			"lv":  rumV3Value("level"),
+			"ln":  rumV3Value("logger_name"),
+			"mg":  rumV3Value("message"),
+			"pmg": rumV3Value("param_message"),
+			"st":  rumV3Object("stacktrace", rumV3Stacktrace),
+		}),
+		"cl":  rumV3Value("culprit"),
+		"id":  rumV3Value("id"),
+		"pid": rumV3Value("parent_id"),
+		"tid": rumV3Value("trace_id"),
+		"xid": rumV3Value("transaction_id"),
+		"ex":  rumV3Object("exception", rumV3Exception),
+		"x": rumV3Object("transaction", rumV3Fields{
+			"n":  rumV3Value("name"),
+			"t":  rumV3Value("type"),
+			"sm": rumV3Value("sampled"),
+		}),
+		"c": rumV3Object("context", rumV3Context),
+	}
+	rumV3Span = rumV3Fields{
+		"n":  rumV3Value("name"),
+		"st": rumV3Object("stacktrace", rumV3Stacktrace),
+		"t":  rumV3Value("type"),
+		"su": rumV3Value("subtype"),
+		"ac": rumV3Value("action"),
+		"id": rumV3Value("id"),
+		"o":  rumV3Value("outcome"),
+		"c": rumV3Object("context", rumV3Fields{
+			"g": rumV3Value("tags"),
+			"se": rumV3Object("service", rumV3Fields{
+				"a": rumV3Object("agent", rumV3NameVersion),
+				"n": rumV3Value("name"),
+			}),
+			"dt": rumV3Object("destination", rumV3Fields{
+				"se": rumV3Object("service", rumV3Fields{
+					"n":  rumV3Value("name"),
+					"rc": rumV3Value("resource"),
+					"t":  rumV3Value("type"),
+				}),
+				"ad": rumV3Value("address"),
+				"po": rumV3Value("port"),
+			}),
+			"h": rumV3Object("http", rumV3Fields{
+				"mt":  rumV3Value("method"),
+				"url": rumV3Value("url"),
+				"sc":  rumV3Value("status_code"),
+				"r": rumV3Object("response", rumV3Fields{
+					"dbs": rumV3Value("decoded_body_size"),
+					"ebs": rumV3Value("encoded_body_size"),
+					"ts":  rumV3Value("transfer_size"),
+				}),
+			}),
+		}),
+		"sr": rumV3Value("sample_rate"),
+		"s":  rumV3Value("start"),
+		"d":  rumV3Value("duration"),
+		"sy": rumV3Value("sync"),
+	}
+	rumV3MetricsetSpan = rumV3Fields{
+		"su": rumV3Value("subtype"),
+		"t":  rumV3Value("type"),
+	}
+	rumV3MetricsetSamples = map[string]string{
+		"ysc": "span.self_time.count",
+		"yss": "span.self_time.sum.us",
+	}
+	rumV3Transaction = rumV3Fields{
+		"k":   rumV3Field{name: "marks", convert: translateRUMV3Marks},
+		"tid": rumV3Value("trace_id"),
+		"t":   rumV3Value("type"),
+		"rt":  rumV3Value("result"),
+		"id":  rumV3Value("id"),
+		"n":   rumV3Value("name"),
+		"o":   rumV3Value("outcome"),
+		"pid": rumV3Value("parent_id"),
+		"ses": rumV3Object("session", rumV3Fields{
+			"id":  rumV3Value("id"),
+			"seq": rumV3Value("sequence"),
+		}),
+		"c": rumV3Object("context", rumV3Context),
+		"exp": rumV3Object("experience", rumV3Fields{
+			"cls": rumV3Value("cls"),
+			"fid": rumV3Value("fid"),
+			"tbt": rumV3Value("tbt"),
+			"lt": rumV3Object("longtask", rumV3Fields{
+				"count": rumV3Value("count"),
+				"max":   rumV3Value("max"),
+				"sum":   rumV3Value("sum"),
+			}),
+		}),
+		"yc": rumV3Object("span_count", rumV3Fields{
+			"dd": rumV3Value("dropped"),
+			"sd": rumV3Value("started"),
+		}),
+		"sr": rumV3Value("sample_rate"),
+		"d":  rumV3Value("duration"),
+		"sm": rumV3Value("sampled"),
+	}
+
+	rumV3MarkEventNames = map[string]string{
+		"a":  "agent",
+		"nt": "navigationTiming",
+	}
+	rumV3MarkMeasurementNames = map[string]string{
+		"ce": "connectEnd",
+		"cs": "connectStart",
+		"dc": "domComplete",
+		"de": "domContentLoadedEventEnd",
+		"di": "domInteractive",
+		"dl": "domLoading",
+		"ds": "domContentLoadedEventStart",
+		"ee": "loadEventEnd",
+		"es": "loadEventStart",
+		"fb": "timeToFirstByte",
+		"fp": "firstContentfulPaint",
+		"fs": "fetchStart",
+		"le": "domainLookupEnd",
+		"lp": "largestContentfulPaint",
+		"ls": "domainLookupStart",
+		"re": "responseEnd",
+		"rs": "responseStart",
+		"qs": "requestStart",
+	}
+)
+
+func init() {
+	// Exception causes are exceptions themselves.
+	rumV3Exception["ca"] = rumV3Object("cause", rumV3Exception)
+}
+
+// translateRUMV3Marks expands the abbreviated mark event and measurement
+// names. Unknown names are kept as is.
+func translateRUMV3Marks(v any) any {
+	return renameKeys(v, rumV3MarkEventNames, func(events any) any {
+		return renameKeys(events, rumV3MarkMeasurementNames, nil)
+	})
+}
+
+func renameKeys(v any, names map[string]string, convert func(any) any) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	out := make(map[string]any, len(m))
+	for k, val := range m {
+		if long, ok := names[k]; ok {
+			k = long
+		}
+		if convert != nil {
+			val = convert(val)
+		}
+		out[k] = val
+	}
+	return out
+}
+
+// NewRUMV3Reader returns a reader that rewrites the RUM v3 event stream read
+// from r into the equivalent intake v2 event stream, so it can be passed to
+// HandleRUMStream. Transactions are split into the transaction itself and
+// its nested spans and metricsets. Lines that cannot be translated, including
+// lines longer than maxLineLength, are passed through unchanged so that the
+// decoder reports them.
+func NewRUMV3Reader(r io.Reader, maxLineLength int) io.Reader {
+	return &rumV3Reader{br: bufio.NewReaderSize(r, maxLineLength)}
+}
+
+type rumV3Reader struct {
+	br  *bufio.Reader
+	out bytes.Buffer
+	err error
+	// passthrough is set while copying the remainder of a line that did
+	// not fit in the buffer.
+	passthrough bool
+}
+
+func (r *rumV3Reader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	return r.out.Read(p)
+}
+
+func (r *rumV3Reader) fill() {
+	line, err := r.br.ReadSlice('\n')
+	switch {
+	case err == bufio.ErrBufferFull:
+		r.out.Write(line)
+		r.passthrough = true
+		return
+	case r.passthrough:
+		r.out.Write(line)
+		r.passthrough = false
+	default:
+		r.translateLine(line)
+	}
+	if err != nil {
+		r.err = err
+	}
+}
+
+func (r *rumV3Reader) translateLine(line []byte) {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 {
+		r.out.Write(line)
+		return
+	}
+	var root map[string]any
+	if err := jsonConfig.Unmarshal(trimmed, &root); err != nil || len(root) != 1 {
+		r.out.Write(line)
+		return
+	}
+	var events []map[string]any
+	for k, v := range root {
+		switch k {
+		case "m":
+			events = append(events, map[string]any{"metadata": rumV3Metadata.translate(v)})
+		case "e":
+			events = append(events, map[string]any{"error": rumV3Error.translate(v)})
+		case "x":
+			events = translateRUMV3Transaction(v)
+		}
+	}
+	if len(events) == 0 {
+		r.out.Write(line)
+		return
+	}
+	for _, event := range events {
+		b, err := jsonConfig.Marshal(event)
+		if err != nil {
+			r.out.Write(line)
+			return
+		}
+		r.out.Write(b)
+		r.out.WriteByte('\n')
+	}
+}
+
+// translateRUMV3Transaction returns the intake v2 transaction, span and
+// metricset events for a RUM v3 transaction. Spans are linked to the
+// transaction, or to the span at their parent index.
+func translateRUMV3Transaction(v any) []map[string]any {
+	raw, ok := v.(map[string]any)
+	if !ok {
+		return []map[string]any{{"transaction": v}}
+	}
+	tx := rumV3Transaction.translate(raw).(map[string]any)
+	events := []map[string]any{{"transaction": tx}}
+
+	spans, _ := raw["y"].([]any)
+	spanIDs := make([]any, len(spans))
+	for i, s := range spans {
+		if m, ok := s.(map[string]any); ok {
+			spanIDs[i] = m["id"]
+		}
+	}
+	for _, s := range spans {
+		span, ok := rumV3Span.translate(s).(map[string]any)
+		if !ok {
+			continue
+		}
+		span["trace_id"] = tx["trace_id"]
+		span["transaction_id"] = tx["id"]
+		span["parent_id"] = tx["id"]
+		if m, ok := s.(map[string]any); ok {
+			if idx, ok := m["pi"].(json.Number); ok {
+				if i, err := idx.Int64(); err == nil && i >= 0 && i < int64(len(spanIDs)) {
+					span["parent_id"] = spanIDs[i]
+				}
+			}
+		}
+		events = append(events, map[string]any{"span": span})
+	}
+
+	metricsets, _ := raw["me"].([]any)
+	for _, ms := range metricsets {
+		m, ok := ms.(map[string]any)
+		if !ok {
+			continue
+		}
+		metricset := map[string]any{
+			"transaction": map[string]any{"name": tx["name"], "type": tx["type"]},
+		}
+		if span, ok := m["y"]; ok {
+			metricset["span"] = rumV3MetricsetSpan.translate(span)
+		}
+		if samples, ok := m["sa"].(map[string]any); ok {
+			out := make(map[string]any, len(samples))
+			for k, sample := range samples {
+				name, ok := rumV3MetricsetSamples[k]
+				if !ok {
+					continue
+				}
+				if sample, ok := sample.(map[string]any); ok {
+					out[name] = map[string]any{"value": sample["v"]}
+				}
+			}
+			metricset["samples"] = out
+		}
+		events = append(events, map[string]any{"metricset": metricset})
+	}
+	return events
+}
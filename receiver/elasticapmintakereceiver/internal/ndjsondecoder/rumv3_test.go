@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ndjsondecoder
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+func TestRUMV3Reader(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input    string
+		expected string
+	}{
+		"metadata": {
+			input:    `{"m":{"se":{"n":"frontend","a":{"n":"rum-js","ve":"5.0.0"},"la":{"n":"javascript"}},"u":{"id":1,"un":"jane"},"l":{"k":"v"},"n":{"c":{"t":"4g"}}}}`,
+			expected: `{"metadata":{"labels":{"k":"v"},"network":{"connection":{"type":"4g"}},"service":{"agent":{"name":"rum-js","version":"5.0.0"},"language":{"name":"javascript"},"name":"frontend"},"user":{"id":1,"username":"jane"}}}`,
+		},
+		"transaction": {
+			input: `{"x":{"id":"tx1","tid":"trace1","n":"page-load","t":"page-load","d":10,"yc":{"sd":2},"k":{"a":{"fb":5,"custom":1}},` +
+				`"y":[{"id":"s1","n":"first","t":"rc","s":1,"d":2},{"id":"s2","pi":0,"n":"second","t":"external","s":2,"d":1,"c":{"h":{"mt":"GET","sc":200}}}],` +
+				`"me":[{"y":{"t":"rc"},"sa":{"ysc":{"v":1},"yss":{"v":2}}}]}}`,
+			expected: `{"transaction":{"duration":10,"id":"tx1","marks":{"agent":{"custom":1,"timeToFirstByte":5}},"name":"page-load","span_count":{"started":2},"trace_id":"trace1","type":"page-load"}}
+{"span":{"duration":2,"id":"s1","name":"first","parent_id":"tx1","start":1,"trace_id":"trace1","transaction_id":"tx1","type":"rc"}}
+{"span":{"context":{"http":{"method":"GET","status_code":200}},"duration":1,"id":"s2","name":"second","parent_id":"s1","start":2,"trace_id":"trace1","transaction_id":"tx1","type":"external"}}
+{"metricset":{"samples":{"span.self_time.count":{"value":1},"span.self_time.sum.us":{"value":2}},"span":{"type":"rc"},"transaction":{"name":"page-load","type":"page-load"}}}`,
+		},
+		"error": {
+			input:    `{"e":{"id":"e1","ex":{"mg":"boom","ca":[{"t":"Cause","st":[{"f":"app.js","li":3}]}]},"c":{"p":{"url":"http://x"}}}}`,
+			expected: `{"error":{"context":{"page":{"url":"http://x"}},"exception":{"cause":[{"stacktrace":[{"filename":"app.js","lineno":3}],"type":"Cause"}],"message":"boom"},"id":"e1"}}`,
+		},
+		"invalid json is passed through": {
+			input:    `{"x":`,
+			expected: `{"x":`,
+		},
+		"unknown event type is passed through": {
+			input:    `{"unknown":{}}`,
+			expected: `{"unknown":{}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := io.ReadAll(NewRUMV3Reader(strings.NewReader(tc.input+"\n"), 1024))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected+"\n", string(out))
+		})
+	}
+}
+
+func TestRUMV3ReaderLongLine(t *testing.T) {
+	long := `{"e":{"id":"` + strings.Repeat("a", 100) + `"}}`
+	input := long + "\n" + `{"e":{"id":"e2"}}` + "\n"
+	out, err := io.ReadAll(NewRUMV3Reader(strings.NewReader(input), 32))
+	require.NoError(t, err)
+	assert.Equal(t, long+"\n"+`{"error":{"id":"e2"}}`+"\n", string(out))
+}
+
+func TestHandleRUMStream(t *testing.T) {
+	input := `{"m":{"se":{"n":"frontend","a":{"n":"rum-js","ve":"5.0.0"}}}}
+{"x":{"id":"aaaaaaaaaaaaaaaa","tid":"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb","t":"page-load","d":10,"yc":{"sd":1},"y":[{"id":"cccccccccccccccc","n":"span","t":"rc","s":1,"d":2}]}}
+{"e":{"id":"dddddddddddddddd","ex":{"mg":"boom"}}}
+`
+	now := time.Unix(1700000000, 0)
+	rum := RUMRequest{
+		Time:      now,
+		ClientIP:  "192.0.2.1",
+		UserAgent: "Mozilla/5.0",
+		Geo:       ClientGeo{CountryISOCode: "SE", CityName: "Stockholm"},
+	}
+
+	var traces []ptrace.Traces
+	var logs []plog.Logs
+	consumer := func(_ context.Context, ld *plog.Logs, _ *pmetric.Metrics, td *ptrace.Traces) error {
+		if td != nil {
+			traces = append(traces, *td)
+		}
+		if ld != nil {
+			logs = append(logs, *ld)
+		}
+		return nil
+	}
+	accepted, errs := HandleRUMStream(context.Background(), NewRUMV3Reader(strings.NewReader(input), 1024), rum, 10, 1024, zap.NewNop(), consumer)
+	require.Empty(t, errs)
+	assert.Equal(t, 3, accepted)
+	require.Len(t, traces, 1)
+	require.Len(t, logs, 1)
+
+	expectedAttrs := map[string]any{
+		"client.address":              "192.0.2.1",
+		"source.address":              "192.0.2.1",
+		"user_agent.original":         "Mozilla/5.0",
+		"client.geo.country_iso_code": "SE",
+		"client.geo.city_name":        "Stockholm",
+	}
+	assertAttrs := func(attrs pcommon.Map) {
+		for k, v := range expectedAttrs {
+			got, ok := attrs.Get(k)
+			if assert.True(t, ok, k) {
+				assert.Equal(t, v, got.AsRaw(), k)
+			}
+		}
+	}
+
+	rs := traces[0].ResourceSpans().At(0)
+	assertAttrs(rs.Resource().Attributes())
+	spans := rs.ScopeSpans().At(0).Spans()
+	require.Equal(t, 2, spans.Len())
+	assert.Equal(t, pcommon.NewTimestampFromTime(now), spans.At(0).StartTimestamp())
+	assert.Equal(t, pcommon.NewTimestampFromTime(now.Add(time.Millisecond)), spans.At(1).StartTimestamp())
+	assert.Equal(t, spans.At(0).SpanID(), spans.At(1).ParentSpanID())
+
+	rl := logs[0].ResourceLogs().At(0)
+	assertAttrs(rl.Resource().Attributes())
+	assert.Equal(t, pcommon.NewTimestampFromTime(now), rl.ScopeLogs().At(0).LogRecords().At(0).Timestamp())
+}
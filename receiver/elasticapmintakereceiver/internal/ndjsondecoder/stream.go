@@ -60,6 +60,36 @@ func HandleStream(
 	batchSize, maxLineLength int,
 	logger *zap.Logger,
 	consumer BatchConsumer,
+) (int, []error) {
+	return handleStream(ctx, body, nil, batchSize, maxLineLength, logger, consumer)
+}
+
+// HandleRUMStream is like HandleStream, but for intake v2 event streams sent
+// by RUM agents. Events without a timestamp are assigned the request time, and
+// the client information in rum is added to every resource.
+func HandleRUMStream(
+	ctx context.Context,
+	body io.Reader,
+	rum RUMRequest,
+	batchSize, maxLineLength int,
+	logger *zap.Logger,
+	consumer BatchConsumer,
+) (int, []error) {
+	next := consumer
+	consumer = func(ctx context.Context, ld *plog.Logs, md *pmetric.Metrics, td *ptrace.Traces) error {
+		rum.writeResourceAttributes(ld, md, td)
+		return next(ctx, ld, md, td)
+	}
+	return handleStream(ctx, body, &rum, batchSize, maxLineLength, logger, consumer)
+}
+
+func handleStream(
+	ctx context.Context,
+	body io.Reader,
+	rum *RUMRequest,
+	batchSize, maxLineLength int,
+	logger *zap.Logger,
+	consumer BatchConsumer,
 ) (int, []error) {
 	dec, ok := decoderPool.Get().(*NDJSONStreamDecoder)
 	if ok && dec.lineReader.maxLineLength == maxLineLength {
@@ -78,6 +108,11 @@ func HandleStream(
 		return 0, []error{err}
 	}
 
+	var spanBase pcommon.Timestamp
+	if rum != nil {
+		spanBase = pcommon.NewTimestampFromTime(rum.Time)
+	}
+
 	keyIndex, allGlobalKeys := buildKeyIndex(meta)
 	useBig := len(keyIndex) > 64
 	h := xxhash.New()
@@ -113,6 +148,9 @@ func HandleStream(
 				var tx *transaction
 				tx, appendErr = DecodeTransaction(dec)
 				if appendErr == nil {
+					if rum != nil && !tx.Timestamp.IsSet() {
+						tx.Timestamp.Set(rum.Time)
+					}
 					svc := TransactionContextService(tx)
 					// elastic.profiler_stack_trace_ids is extracted by AppendTransaction; exclude it from labels.
 					txOTelAttrs := tx.OTel.Attributes
@@ -149,12 +187,15 @@ func HandleStream(
 					}
 					fp := fingerprintEvent(meta, svc, tags, nil, extras, h)
 					ss := getOrCreateTraceScope(fp, target, meta, svc, tags, nil, extras)
-					AppendSpan(ss, sp, 0, logger)
+					AppendSpan(ss, sp, spanBase, logger)
 				}
 			case "error":
 				var e *errorEvent
 				e, appendErr = DecodeError(dec)
 				if appendErr == nil {
+					if rum != nil && !e.Timestamp.IsSet() {
+						e.Timestamp.Set(rum.Time)
+					}
 					svc := ErrorContextService(e)
 					tags := e.Context.Tags
 					target := routeTarget(&main, &shadows, tags, keyIndex, useBig)
@@ -167,6 +208,9 @@ func HandleStream(
 				var l *log
 				l, appendErr = DecodeLog(dec)
 				if appendErr == nil {
+					if rum != nil && !l.Timestamp.IsSet() {
+						l.Timestamp.Set(rum.Time)
+					}
 					svc := LogContextService(l)
 					target := routeTarget(&main, &shadows, l.Labels, keyIndex, useBig)
 					fp := fingerprintLog(meta, svc, &l.FAAS, l.Labels, h)
@@ -181,6 +225,9 @@ func HandleStream(
 				var ms *metricset
 				ms, appendErr = DecodeMetricset(dec)
 				if appendErr == nil {
+					if rum != nil && !ms.Timestamp.IsSet() {
+						ms.Timestamp.Set(rum.Time)
+					}
 					svc := MetricsetContextService(ms)
 					tags := MetricsetTags(ms)
 					target := routeTarget(&main, &shadows, tags, keyIndex, useBig)
@@ -0,0 +1,245 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// The intake v1 format is described in github.com/elastic/apm-server/docs/spec, up to 6.x.
+
+package ndjsondecoder // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/ndjsondecoder"
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// V1Kind identifies the deprecated intake v1 endpoint a document was sent to.
+type V1Kind int
+
+const (
+	// V1Transactions is a /v1/transactions document.
+	V1Transactions V1Kind = iota
+	// V1Errors is a /v1/errors document.
+	V1Errors
+)
+
+// ErrRequestTooLarge is returned when an intake v1 document exceeds the
+// maximum permitted size.
+var ErrRequestTooLarge = errors.New("request body exceeded permitted size")
+
+// v1MetadataFields are the top-level fields of an intake v1 document that
+// are shared by all of its events.
+var v1MetadataFields = []string{"service", "process", "system"}
+
+// TranslateV1 reads an intake v1 document of the given kind from r and
+// returns the equivalent intake v2 event stream, so it can be passed to
+// HandleStream. Intake v1 documents are single JSON objects holding the
+// metadata and an array of events, with UUID event IDs, RFC 3339 timestamps
+// and spans nested in their transaction. Transaction and error IDs are
+// converted to trace IDs, and spans are given IDs derived from their
+// transaction. Events without a timestamp are timestamped with now.
+func TranslateV1(r io.Reader, kind V1Kind, maxSize int, now time.Time) (io.Reader, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, ErrRequestTooLarge
+	}
+	var doc map[string]any
+	if err := jsonConfig.Unmarshal(data, &doc); err != nil {
+		return nil, JSONDecodeError{err: err}
+	}
+
+	metadata := make(map[string]any, len(v1MetadataFields))
+	for _, k := range v1MetadataFields {
+		if v, ok := doc[k]; ok {
+			metadata[k] = v
+		}
+	}
+	events := []map[string]any{{"metadata": metadata}}
+	switch kind {
+	case V1Transactions:
+		transactions, _ := doc["transactions"].([]any)
+		for _, tx := range transactions {
+			events = append(events, translateV1Transaction(tx, now)...)
+		}
+	case V1Errors:
+		errs, _ := doc["errors"].([]any)
+		for _, e := range errs {
+			events = append(events, map[string]any{"error": translateV1Error(e, now)})
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		b, err := jsonConfig.Marshal(event)
+		if err != nil {
+			return nil, JSONDecodeError{err: err}
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return &buf, nil
+}
+
+// translateV1Transaction returns the intake v2 transaction and span events
+// for an intake v1 transaction. Span timestamps are computed from the
+// transaction timestamp and their start offset.
+func translateV1Transaction(v any, now time.Time) []map[string]any {
+	raw, ok := v.(map[string]any)
+	if !ok {
+		return []map[string]any{{"transaction": v}}
+	}
+	tx := copyExcept(raw, "id", "spans", "span_count")
+	traceID := v1TraceID(raw["id"])
+	tx["id"] = traceID[:16]
+	tx["trace_id"] = traceID
+	ts, tsOK := translateV1Timestamp(tx, now)
+
+	spans, _ := raw["spans"].([]any)
+	spanCount := map[string]any{"started": len(spans)}
+	if sc, ok := raw["span_count"].(map[string]any); ok {
+		if dropped, ok := sc["dropped"].(map[string]any); ok {
+			spanCount["dropped"] = dropped["total"]
+		}
+	}
+	tx["span_count"] = spanCount
+	events := []map[string]any{{"transaction": tx}}
+
+	// Intake v1 span IDs are optional integers, unique within the
+	// transaction, that nested spans refer to as their parent.
+	spanIDs := make(map[string]string, len(spans))
+	for i, s := range spans {
+		if m, ok := s.(map[string]any); ok {
+			if id, ok := m["id"].(json.Number); ok {
+				spanIDs[id.String()] = v1SpanID(traceID, i)
+			}
+		}
+	}
+	for i, s := range spans {
+		m, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		span := copyExcept(m, "id", "parent")
+		span["id"] = v1SpanID(traceID, i)
+		span["trace_id"] = traceID
+		span["transaction_id"] = tx["id"]
+		span["parent_id"] = tx["id"]
+		if parent, ok := m["parent"].(json.Number); ok {
+			if id, ok := spanIDs[parent.String()]; ok {
+				span["parent_id"] = id
+			}
+		}
+		if start, err := numberFloat(m["start"]); err == nil && tsOK {
+			delete(span, "start")
+			span["timestamp"] = ts + int64(start*1000)
+		}
+		events = append(events, map[string]any{"span": span})
+	}
+	return events
+}
+
+// translateV1Error returns the intake v2 error for an intake v1 error,
+// linked to the trace of the transaction it occurred in.
+func translateV1Error(v any, now time.Time) any {
+	raw, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	e := copyExcept(raw, "id", "transaction")
+	e["id"] = v1TraceID(raw["id"])
+	translateV1Timestamp(e, now)
+	if tx, ok := raw["transaction"].(map[string]any); ok && tx["id"] != nil {
+		traceID := v1TraceID(tx["id"])
+		e["trace_id"] = traceID
+		e["transaction_id"] = traceID[:16]
+		e["parent_id"] = traceID[:16]
+	}
+	return e
+}
+
+// translateV1Timestamp replaces the RFC 3339 timestamp of event with the
+// number of microseconds since the epoch, and returns it. Timestamps that
+// cannot be parsed are left for the decoder to reject.
+func translateV1Timestamp(event map[string]any, now time.Time) (int64, bool) {
+	t := now
+	if v, ok := event["timestamp"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return 0, false
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return 0, false
+		}
+		t = parsed
+	}
+	us := t.UnixMicro()
+	event["timestamp"] = us
+	return us, true
+}
+
+// v1TraceID returns the 32 hex character trace ID for an intake v1 UUID.
+// Other IDs are hashed, and a random ID is returned if there is no ID, so
+// that events without IDs are not all given the same one.
+func v1TraceID(v any) string {
+	s, _ := v.(string)
+	if s == "" {
+		var id [16]byte
+		_, _ = rand.Read(id[:])
+		return hex.EncodeToString(id[:])
+	}
+	id := strings.ToLower(strings.ReplaceAll(s, "-", ""))
+	if _, err := hex.DecodeString(id); err == nil && len(id) == 32 {
+		return id
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
+
+// v1SpanID returns the 16 hex character ID of the span at index i of the
+// transaction with the given trace ID.
+func v1SpanID(traceID string, i int) string {
+	sum := sha256.Sum256([]byte(traceID + "/" + strconv.Itoa(i)))
+	return hex.EncodeToString(sum[:8])
+}
+
+func copyExcept(m map[string]any, keys ...string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	for _, k := range keys {
+		delete(out, k)
+	}
+	return out
+}
+
+func numberFloat(v any) (float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, errors.New("not a number")
+	}
+	return n.Float64()
+}
@@ -0,0 +1,113 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ndjsondecoder
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+func TestTranslateV1(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for name, tc := range map[string]struct {
+		kind     V1Kind
+		input    string
+		expected string
+	}{
+		"transactions": {
+			kind: V1Transactions,
+			input: `{"service":{"name":"legacy","agent":{"name":"python","version":"3.0.0"}},"system":{"hostname":"host1"},"transactions":[` +
+				`{"id":"945254C5-67A5-417E-8A4E-AA29EFB4C2E1","name":"GET /","type":"request","duration":32.5,"timestamp":"2017-05-30T18:53:27.154Z","result":"200","span_count":{"dropped":{"total":3}},` +
+				`"spans":[{"id":0,"name":"SELECT","type":"db.postgresql.query","start":2.5,"duration":3},{"id":1,"parent":0,"name":"fetch","type":"template","start":4,"duration":1}]}]}`,
+			expected: `{"metadata":{"service":{"agent":{"name":"python","version":"3.0.0"},"name":"legacy"},"system":{"hostname":"host1"}}}
+{"transaction":{"duration":32.5,"id":"945254c567a5417e","name":"GET /","result":"200","span_count":{"dropped":3,"started":2},"timestamp":1496170407154000,"trace_id":"945254c567a5417e8a4eaa29efb4c2e1","type":"request"}}
+{"span":{"duration":3,"id":"c0c5802418fa495f","name":"SELECT","parent_id":"945254c567a5417e","timestamp":1496170407156500,"trace_id":"945254c567a5417e8a4eaa29efb4c2e1","transaction_id":"945254c567a5417e","type":"db.postgresql.query"}}
+{"span":{"duration":1,"id":"9d3c0d3873d78fa9","name":"fetch","parent_id":"c0c5802418fa495f","timestamp":1496170407158000,"trace_id":"945254c567a5417e8a4eaa29efb4c2e1","transaction_id":"945254c567a5417e","type":"template"}}`,
+		},
+		"errors": {
+			kind: V1Errors,
+			input: `{"service":{"name":"legacy","agent":{"name":"python","version":"3.0.0"}},"errors":[` +
+				`{"id":"5f0e9b3c-2a3f-4b8e-9a1d-6c7b8e9f0a1b","culprit":"app.views","exception":{"message":"boom","type":"ValueError"},"transaction":{"id":"945254c5-67a5-417e-8a4e-aa29efb4c2e1"}},` +
+				`{"id":"not-a-uuid","timestamp":"2017-05-30T18:53:27Z","log":{"message":"oops"}}]}`,
+			expected: `{"metadata":{"service":{"agent":{"name":"python","version":"3.0.0"},"name":"legacy"}}}
+{"error":{"culprit":"app.views","exception":{"message":"boom","type":"ValueError"},"id":"5f0e9b3c2a3f4b8e9a1d6c7b8e9f0a1b","parent_id":"945254c567a5417e","timestamp":1700000000000000,"trace_id":"945254c567a5417e8a4eaa29efb4c2e1","transaction_id":"945254c567a5417e"}}
+{"error":{"id":"2184a32a3bae6ad756d745ec8678ad6a","log":{"message":"oops"},"timestamp":1496170407000000}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, err := TranslateV1(strings.NewReader(tc.input), tc.kind, 1024*1024, now)
+			require.NoError(t, err)
+			out, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected+"\n", string(out))
+		})
+	}
+}
+
+func TestV1TraceIDEmpty(t *testing.T) {
+	for _, id := range []any{"", nil} {
+		a, b := v1TraceID(id), v1TraceID(id)
+		assert.Len(t, a, 32)
+		assert.NotEqual(t, a, b)
+	}
+}
+
+func TestTranslateV1Errors(t *testing.T) {
+	_, err := TranslateV1(strings.NewReader(`{"transactions":[`), V1Transactions, 1024, time.Now())
+	assert.ErrorAs(t, err, &JSONDecodeError{})
+
+	_, err = TranslateV1(strings.NewReader(`{"transactions":[]}`), V1Transactions, 8, time.Now())
+	assert.ErrorIs(t, err, ErrRequestTooLarge)
+}
+
+func TestHandleStreamV1(t *testing.T) {
+	input := `{"service":{"name":"legacy","agent":{"name":"python","version":"3.0.0"}},"transactions":[` +
+		`{"id":"945254c5-67a5-417e-8a4e-aa29efb4c2e1","name":"GET /","type":"request","duration":10,"timestamp":"2017-05-30T18:53:27.154Z",` +
+		`"spans":[{"name":"SELECT","type":"db.postgresql.query","start":1,"duration":2}]}]}`
+	r, err := TranslateV1(strings.NewReader(input), V1Transactions, 1024*1024, time.Now())
+	require.NoError(t, err)
+
+	var traces []ptrace.Traces
+	consumer := func(_ context.Context, _ *plog.Logs, _ *pmetric.Metrics, td *ptrace.Traces) error {
+		if td != nil {
+			traces = append(traces, *td)
+		}
+		return nil
+	}
+	accepted, errs := HandleStream(context.Background(), r, 10, 1024, zap.NewNop(), consumer)
+	require.Empty(t, errs)
+	assert.Equal(t, 2, accepted)
+	require.Len(t, traces, 1)
+	spans := traces[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 2, spans.Len())
+	tx, span := spans.At(0), spans.At(1)
+	assert.Equal(t, "945254c567a5417e8a4eaa29efb4c2e1", tx.TraceID().String())
+	assert.Equal(t, tx.TraceID(), span.TraceID())
+	assert.Equal(t, tx.SpanID(), span.ParentSpanID())
+	assert.Equal(t, tx.StartTimestamp()+1e6, span.StartTimestamp())
+}
@@ -18,11 +18,16 @@
 package elasticapmintakereceiver // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver"
 
 import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net"
 	"net/http"
+	"runtime/debug"
 	"sync"
 
 	"go.opentelemetry.io/collector/client"
@@ -40,6 +45,8 @@ import (
 	"google.golang.org/grpc/codes"
 	grpcstatus "google.golang.org/grpc/status"
 
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/metadata"
 	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/ndjsondecoder"
 	"github.com/elastic/opentelemetry-lib/agentcfg"
 )
@@ -76,6 +83,12 @@ type elasticAPMIntakeReceiver struct {
 
 	fetcherFactory agentCfgFetcherFactory
 	cancelFn       context.CancelFunc
+
+	auth           *agentAuthenticator
+	sourcemapStore sourcemap.Store
+
+	telemetry  *metadata.TelemetryBuilder
+	asyncQueue *asyncQueue
 }
 
 // newElasticAPMIntakeReceiver just creates the OpenTelemetry receiver services. It is the caller's
@@ -91,11 +104,18 @@ func newElasticAPMIntakeReceiver(fetcher agentCfgFetcherFactory, cfg *Config, se
 		return nil, err
 	}
 
+	telemetry, err := metadata.NewTelemetryBuilder(set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+
 	return &elasticAPMIntakeReceiver{
 		cfg:            cfg,
 		settings:       set,
 		obsreport:      obsreport,
 		fetcherFactory: fetcher,
+		auth:           newAgentAuthenticator(cfg.AgentAuth),
+		telemetry:      telemetry,
 	}, nil
 }
 
@@ -109,14 +129,23 @@ func (r *elasticAPMIntakeReceiver) Start(ctx context.Context, host component.Hos
 }
 
 func (r *elasticAPMIntakeReceiver) startHTTPServer(ctx context.Context, host component.Host) error {
+	if r.cfg.Async.Enabled {
+		r.asyncQueue = newAsyncQueue(r.cfg.Async, r.consumeOTel, r.settings.Logger, r.telemetry)
+		r.asyncQueue.start()
+	}
+
 	httpMux := http.NewServeMux()
 
 	httpMux.HandleFunc("GET /{$}", r.newRootHandler())
-	httpMux.HandleFunc(intakeV2EventsPath, r.newElasticAPMEventsHandler(func(req *http.Request) context.Context {
+	httpMux.Handle(intakeV2EventsPath, r.auth.middleware(r.newElasticAPMEventsHandler(func(req *http.Request) context.Context {
 		return withECSMappingMode(req.Context(), r.cfg.IncludeMetadata)
-	}))
-	httpMux.HandleFunc(agentConfigPath, r.newElasticAPMConfigsHandler(ctx, host))
-	// TODO rum v2, v3
+	}), false))
+	httpMux.Handle(agentConfigPath, r.auth.middleware(r.newElasticAPMConfigsHandler(ctx, host), false))
+	r.registerRUMHandlers(httpMux)
+	r.registerIntakeV1Handlers(httpMux)
+	if err := r.registerSourcemapHandler(ctx, host, httpMux); err != nil {
+		return err
+	}
 
 	var err error
 	if r.httpServer, err = r.cfg.ToServer(
@@ -153,6 +182,15 @@ func (r *elasticAPMIntakeReceiver) Shutdown(ctx context.Context) error {
 		err = r.httpServer.Shutdown(ctx)
 	}
 	r.shutdownWG.Wait()
+	// Queued batches are drained only once the HTTP server stopped
+	// accepting requests.
+	if r.asyncQueue != nil {
+		err = errors.Join(err, r.asyncQueue.shutdown(ctx))
+	}
+	r.telemetry.Shutdown()
+	if r.sourcemapStore != nil {
+		err = errors.Join(err, r.sourcemapStore.Close(ctx))
+	}
 	return err
 }
 
@@ -160,18 +198,77 @@ func errorHandler(w http.ResponseWriter, r *http.Request, errMsg string, statusC
 	// TODO
 }
 
-// newRootHandler returns an unauthenticated handler for GET /. It mirrors the
-// MIS behaviour: return HTTP 200 with a JSON version payload so that APM
-// agents can confirm they are talking to a compatible server.
+// rootResponse is the body of the root endpoint, as returned by APM Server.
+type rootResponse struct {
+	BuildDate    string `json:"build_date,omitempty"`
+	BuildSHA     string `json:"build_sha,omitempty"`
+	PublishReady bool   `json:"publish_ready"`
+	Version      string `json:"version"`
+}
+
+// newRootHandler returns the handler for GET /. It mirrors APM Server: the
+// build and version information is only returned to authorized callers, so
+// that agents can confirm they are talking to a compatible server; others
+// get an empty 200 response. The version is fakeVersion and the build is
+// that of the collector binary.
 func (r *elasticAPMIntakeReceiver) newRootHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, _ *http.Request) {
+	resp := rootResponse{PublishReady: true, Version: fakeVersion}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				resp.BuildSHA = setting.Value
+			case "vcs.time":
+				resp.BuildDate = setting.Value
+			}
+		}
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		if r.auth.authenticate(req) != nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
 		w.Header().Set(ContentType, "application/json")
 		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(map[string]string{"version": fakeVersion})
+		_ = json.NewEncoder(w).Encode(resp)
 	}
 }
 
+// streamHandler decodes the event stream in the body of req, passing the
+// decoded batches to consumer.
+type streamHandler func(ctx context.Context, req *http.Request, consumer ndjsondecoder.BatchConsumer) (int, []error)
+
 func (r *elasticAPMIntakeReceiver) newElasticAPMEventsHandler(ctxFunc func(*http.Request) context.Context) http.HandlerFunc {
+	return r.newEventsHandler(ctxFunc, func(ctx context.Context, req *http.Request, consumer ndjsondecoder.BatchConsumer) (int, []error) {
+		body, err := sniffCompressedBody(req)
+		if err != nil {
+			return 0, []error{err}
+		}
+		return ndjsondecoder.HandleStream(ctx, body, r.cfg.BatchSize, r.cfg.MaxEventSize, r.settings.Logger, consumer)
+	})
+}
+
+// sniffCompressedBody returns the request body, decompressing it if it is
+// gzip or zlib compressed without a Content-Encoding header, as happens when
+// a proxy strips the header. Bodies with a Content-Encoding header have
+// already been decompressed by the HTTP server.
+func sniffCompressedBody(req *http.Request) (io.Reader, error) {
+	br := bufio.NewReader(req.Body)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 {
+		return br, nil
+	}
+	switch {
+	case magic[0] == 0x1f && magic[1] == 0x8b:
+		return gzip.NewReader(br)
+	case magic[0] == 0x78 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0:
+		// zlib header: deflate compression method and a valid checksum.
+		return zlib.NewReader(br)
+	}
+	return br, nil
+}
+
+func (r *elasticAPMIntakeReceiver) newEventsHandler(ctxFunc func(*http.Request) context.Context, handleStream streamHandler) http.HandlerFunc {
 	// A zero MaxConcurrentDecoders disables the limit: sem stays nil and the
 	// per-request acquire/release below is skipped entirely.
 	var sem *semaphore.Weighted
@@ -214,8 +311,11 @@ func (r *elasticAPMIntakeReceiver) newElasticAPMEventsHandler(ctxFunc func(*http
 		consumer := ndjsondecoder.BatchConsumer(func(ctx context.Context, ld *plog.Logs, md *pmetric.Metrics, td *ptrace.Traces) error {
 			return errors.Join(r.consumeOTel(ctx, ld, md, td)...)
 		})
+		if r.asyncQueue != nil && isAsyncRequest(req) {
+			consumer = r.asyncQueue.enqueue
+		}
 
-		accepted, streamErrs := ndjsondecoder.HandleStream(ctx, req.Body, r.cfg.BatchSize, r.cfg.MaxEventSize, r.settings.Logger, consumer)
+		accepted, streamErrs := handleStream(ctx, req, consumer)
 
 		result.Accepted = accepted
 		result.Errors = make([]string, 0, len(streamErrs)+2)
@@ -250,8 +350,14 @@ func intakeStatusCodeFromErr(err error, isRequestContextErr bool) int {
 	var validErr ndjsondecoder.ValidationError
 	if errors.As(err, &jsonErr) || errors.As(err, &validErr) {
 		code = http.StatusBadRequest
-	} else if errors.Is(err, ndjsondecoder.ErrLineTooLong) {
+	} else if errors.Is(err, ndjsondecoder.ErrLineTooLong) || errors.Is(err, ndjsondecoder.ErrRequestTooLarge) {
 		code = http.StatusRequestEntityTooLarge
+	} else if errors.Is(err, errRateLimitExceeded) {
+		code = http.StatusTooManyRequests
+	} else if errors.Is(err, errAnonymousNotAllowed) {
+		code = http.StatusForbidden
+	} else if errors.Is(err, errAsyncQueueFull) || errors.Is(err, errAsyncQueueClosed) {
+		code = http.StatusServiceUnavailable
 	}
 
 	// Evaluate final context/grpc outcome here (instead of early-returning above)
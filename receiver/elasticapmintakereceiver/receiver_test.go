@@ -19,6 +19,7 @@ package elasticapmintakereceiver
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -162,7 +163,7 @@ func TestRootHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 	body, err := io.ReadAll(res.Body)
 	require.NoError(t, err)
-	assert.JSONEq(t, `{"version":"8.9.0"}`, string(body))
+	assert.JSONEq(t, `{"publish_ready":true,"version":"8.9.0"}`, string(body))
 }
 
 func TestAgentCfgHandlerNoFetcher(t *testing.T) {
@@ -860,6 +861,37 @@ func TestEventsHandlerUsesConfiguredBatchSize(t *testing.T) {
 	})
 }
 
+func TestEventsHandlerSniffsCompressedBody(t *testing.T) {
+	rcvr, err := newElasticAPMIntakeReceiver(
+		func(context.Context, component.Host) (agentcfg.Fetcher, error) { return nil, nil },
+		createDefaultConfig().(*Config),
+		receivertest.NewNopSettings(metadata.Type),
+	)
+	require.NoError(t, err)
+
+	nextTraces := new(consumertest.TracesSink)
+	rcvr.nextTraces = nextTraces
+
+	handler := rcvr.newElasticAPMEventsHandler(func(req *http.Request) context.Context {
+		return withECSMappingMode(req.Context(), false)
+	})
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err = gw.Write(generateTransactionPayload(2))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	// A proxy dropped the Content-Encoding header.
+	req := httptest.NewRequest(http.MethodPost, intakeV2EventsPath, &gzipped)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.Equal(t, 2, nextTraces.SpanCount())
+}
+
 func TestEventsHandlerZeroMaxConcurrentDecodersDisablesLimit(t *testing.T) {
 	cfg := createDefaultConfig().(*Config)
 	cfg.MaxConcurrentDecoders = 0
@@ -0,0 +1,300 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver"
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/ndjsondecoder"
+)
+
+const (
+	intakeRUMV2EventsPath = "/intake/v2/rum/events"
+	intakeRUMV3EventsPath = "/intake/v3/rum/events"
+
+	// rumBurstMultiplier matches apm-server: clients may send up to three
+	// seconds worth of events at once.
+	rumBurstMultiplier = 3
+)
+
+// http header keys used by the RUM endpoints
+const (
+	AccessControlAllowHeaders = "Access-Control-Allow-Headers"
+	AccessControlAllowMethods = "Access-Control-Allow-Methods"
+	AccessControlAllowOrigin  = "Access-Control-Allow-Origin"
+	AccessControlMaxAge       = "Access-Control-Max-Age"
+	Origin                    = "Origin"
+	Vary                      = "Vary"
+)
+
+var (
+	rumDefaultAllowHeaders = []string{"Content-Type", "Content-Encoding", "Accept"}
+
+	errRateLimitExceeded = errors.New("rate limit exceeded")
+)
+
+// registerRUMHandlers registers the RUM v2 and v3 intake endpoints, if enabled.
+func (r *elasticAPMIntakeReceiver) registerRUMHandlers(mux *http.ServeMux) {
+	if !r.cfg.RUM.Enabled {
+		return
+	}
+	cors := newCORSMiddleware(r.cfg.RUM.AllowOrigins, r.cfg.RUM.AllowHeaders)
+	limiters := newRUMRateLimiters(r.cfg.RUM.RateLimit)
+	// The trusted proxies have been validated with the configuration.
+	trustedProxies, _ := parseTrustedProxies(r.cfg.RUM.TrustedProxies)
+	mux.Handle(intakeRUMV2EventsPath, cors(r.auth.middleware(r.newRUMEventsHandler(limiters, trustedProxies, false), true)))
+	mux.Handle(intakeRUMV3EventsPath, cors(r.auth.middleware(r.newRUMEventsHandler(limiters, trustedProxies, true), true)))
+}
+
+func (r *elasticAPMIntakeReceiver) newRUMEventsHandler(limiters *rumRateLimiters, trustedProxies []netip.Prefix, v3 bool) http.HandlerFunc {
+	ctxFunc := func(req *http.Request) context.Context {
+		return withECSMappingMode(req.Context(), r.cfg.IncludeMetadata)
+	}
+	return r.newEventsHandler(ctxFunc, func(ctx context.Context, req *http.Request, consumer ndjsondecoder.BatchConsumer) (int, []error) {
+		rum := r.rumRequest(req, trustedProxies)
+		if isAnonymous(ctx) {
+			consumer = r.auth.anonymousConsumer(consumer)
+		}
+		if limiter := limiters.get(rum.ClientIP); limiter != nil {
+			// Reject clients that are already over their limit before
+			// decoding; the events themselves are counted per batch.
+			if limiter.Tokens() < 1 {
+				return 0, []error{errRateLimitExceeded}
+			}
+			next := consumer
+			consumer = func(ctx context.Context, ld *plog.Logs, md *pmetric.Metrics, td *ptrace.Traces) error {
+				if !allowEvents(limiter, batchEventCount(ld, md, td)) {
+					return errRateLimitExceeded
+				}
+				return next(ctx, ld, md, td)
+			}
+		}
+		var body io.Reader = req.Body
+		if v3 {
+			body = ndjsondecoder.NewRUMV3Reader(body, r.cfg.MaxEventSize)
+		}
+		return ndjsondecoder.HandleRUMStream(ctx, body, rum, r.cfg.BatchSize, r.cfg.MaxEventSize, r.settings.Logger, consumer)
+	})
+}
+
+// rumRequest extracts the client information recorded with RUM events.
+func (r *elasticAPMIntakeReceiver) rumRequest(req *http.Request, trustedProxies []netip.Prefix) ndjsondecoder.RUMRequest {
+	headers := r.cfg.RUM.ClientGeoHeaders
+	geoHeader := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return req.Header.Get(name)
+	}
+	return ndjsondecoder.RUMRequest{
+		Time:      time.Now(),
+		ClientIP:  clientIP(req, trustedProxies),
+		UserAgent: req.UserAgent(),
+		Geo: ndjsondecoder.ClientGeo{
+			CountryISOCode: geoHeader(headers.CountryISOCode),
+			RegionISOCode:  geoHeader(headers.RegionISOCode),
+			RegionName:     geoHeader(headers.RegionName),
+			CityName:       geoHeader(headers.CityName),
+		},
+	}
+}
+
+// clientIP returns the IP of the client that sent req. If the peer is one
+// of the trusted proxies, the X-Real-IP header set by it is preferred,
+// followed by the rightmost X-Forwarded-For address not belonging to a
+// trusted proxy. The headers of other peers are ignored, as any client can
+// set them.
+func clientIP(req *http.Request, trustedProxies []netip.Prefix) string {
+	peer, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		peer = req.RemoteAddr
+	}
+	if !isTrustedProxy(peer, trustedProxies) {
+		return peer
+	}
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	if xff := req.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		// Each proxy appends the address it received the request from, so
+		// only the addresses after the last untrusted one can be spoofed.
+		ips := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if net.ParseIP(ip) == nil {
+				break
+			}
+			if i == 0 || !isTrustedProxy(ip, trustedProxies) {
+				return ip
+			}
+		}
+	}
+	return peer
+}
+
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// batchEventCount approximates the number of events in a decoded batch.
+func batchEventCount(ld *plog.Logs, md *pmetric.Metrics, td *ptrace.Traces) int {
+	var n int
+	if ld != nil {
+		n += ld.LogRecordCount()
+	}
+	if md != nil {
+		n += md.DataPointCount()
+	}
+	if td != nil {
+		n += td.SpanCount()
+	}
+	return n
+}
+
+// allowEvents takes n tokens from limiter. rate.Limiter never allows more
+// than its burst at once, so larger batches are charged in chunks of at
+// most the burst: only the first chunk must be available, the remaining
+// ones put the limiter into debt so that the client is rejected until its
+// tokens have been refilled.
+func allowEvents(limiter *rate.Limiter, n int) bool {
+	now := time.Now()
+	burst := limiter.Burst()
+	chunk := min(n, burst)
+	if !limiter.AllowN(now, chunk) {
+		return false
+	}
+	for n -= chunk; n > 0; n -= chunk {
+		chunk = min(n, burst)
+		limiter.ReserveN(now, chunk)
+	}
+	return true
+}
+
+// newCORSMiddleware returns a middleware that answers CORS preflight requests
+// and rejects requests from origins not matching allowOrigins.
+func newCORSMiddleware(allowOrigins, allowHeaders []string) func(http.Handler) http.Handler {
+	patterns := make([]string, len(allowOrigins))
+	for i, origin := range allowOrigins {
+		patterns[i] = strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, `.*`)
+	}
+	originRegexp := regexp.MustCompile("^(" + strings.Join(patterns, "|") + ")$")
+	headers := strings.Join(append(append([]string{}, rumDefaultAllowHeaders...), allowHeaders...), ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get(Origin)
+			if len(allowOrigins) == 0 || !originRegexp.MatchString(origin) {
+				w.Header().Set(ContentType, "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"error": fmt.Sprintf("origin: '%s' is not allowed", origin),
+				})
+				return
+			}
+			w.Header().Set(AccessControlAllowOrigin, origin)
+			w.Header().Add(Vary, Origin)
+
+			if req.Method == http.MethodOptions {
+				w.Header().Set(AccessControlAllowMethods, "POST, OPTIONS")
+				w.Header().Set(AccessControlAllowHeaders, headers)
+				w.Header().Set(AccessControlExposeHeaders, Etag)
+				w.Header().Set(AccessControlMaxAge, strconv.Itoa(int(time.Hour.Seconds())))
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// rumRateLimiters holds a token-bucket rate limiter per client IP, evicting
+// the least recently used limiter once the IP limit is reached.
+type rumRateLimiters struct {
+	limit rate.Limit
+	burst int
+	size  int
+
+	mu      sync.Mutex
+	lru     *list.List // of *rumRateLimiter, most recently used first
+	entries map[string]*list.Element
+}
+
+type rumRateLimiter struct {
+	ip      string
+	limiter *rate.Limiter
+}
+
+func newRUMRateLimiters(cfg RUMRateLimitConfig) *rumRateLimiters {
+	if cfg.EventLimit <= 0 {
+		return nil
+	}
+	return &rumRateLimiters{
+		limit:   rate.Limit(cfg.EventLimit),
+		burst:   cfg.EventLimit * rumBurstMultiplier,
+		size:    cfg.IPLimit,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the rate limiter for ip, or nil if rate limiting is disabled.
+func (l *rumRateLimiters) get(ip string) *rate.Limiter {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.entries[ip]; ok {
+		l.lru.MoveToFront(elem)
+		return elem.Value.(*rumRateLimiter).limiter
+	}
+	if l.lru.Len() >= l.size {
+		delete(l.entries, l.lru.Remove(l.lru.Back()).(*rumRateLimiter).ip)
+	}
+	entry := &rumRateLimiter{ip: ip, limiter: rate.NewLimiter(l.limit, l.burst)}
+	l.entries[ip] = l.lru.PushFront(entry)
+	return entry.limiter
+}
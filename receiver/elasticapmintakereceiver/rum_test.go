@@ -0,0 +1,310 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/golden"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/plogtest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/ptracetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/elasticattr"
+	"github.com/elastic/opentelemetry-collector-components/internal/testutil"
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/metadata"
+)
+
+func startRUMReceiver(t *testing.T, configure func(*Config)) (string, *consumertest.TracesSink, *consumertest.LogsSink) {
+	t.Helper()
+	factory := NewFactory()
+	testEndpoint := testutil.GetAvailableLocalAddress(t)
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = testEndpoint
+	cfg.RUM.Enabled = true
+	cfg.RUM.ClientGeoHeaders.CountryISOCode = "CloudFront-Viewer-Country"
+	cfg.RUM.TrustedProxies = []string{"127.0.0.1", "::1", "10.0.0.0/8"}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	set := receivertest.NewNopSettings(metadata.Type)
+	nextTraces := new(consumertest.TracesSink)
+	nextLogs := new(consumertest.LogsSink)
+	tracesReceiver, err := factory.CreateTraces(context.Background(), set, cfg, nextTraces)
+	require.NoError(t, err)
+	_, err = factory.CreateLogs(context.Background(), set, cfg, nextLogs)
+	require.NoError(t, err)
+
+	require.NoError(t, tracesReceiver.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, tracesReceiver.Shutdown(context.Background()))
+	})
+	return testEndpoint, nextTraces, nextLogs
+}
+
+func postRUM(t *testing.T, url string, body io.Reader, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	require.NoError(t, err)
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func rumHeader() http.Header {
+	return http.Header{
+		"Content-Type":              {"application/x-ndjson"},
+		"Origin":                    {"http://localhost:8000"},
+		"User-Agent":                {"Mozilla/5.0 (X11; Linux x86_64)"},
+		"X-Forwarded-For":           {"192.0.2.10, 10.0.0.1"},
+		"Cloudfront-Viewer-Country": {"SE"},
+	}
+}
+
+func TestRUMEvents(t *testing.T) {
+	testEndpoint, nextTraces, nextLogs := startRUMReceiver(t, nil)
+
+	for _, tt := range []struct {
+		path     string
+		input    string
+		expected string
+	}{
+		{path: intakeRUMV3EventsPath, input: "rumv3_events.ndjson", expected: "rumv3_events_expected.yaml"},
+		{path: intakeRUMV3EventsPath, input: "rumv3_errors.ndjson", expected: "rumv3_errors_expected.yaml"},
+	} {
+		t.Run(tt.path+"/"+tt.input, func(t *testing.T) {
+			nextTraces.Reset()
+			nextLogs.Reset()
+
+			data, err := os.ReadFile(filepath.Join(testData, tt.input))
+			require.NoError(t, err)
+			resp := postRUM(t, "http://"+testEndpoint+tt.path, bytes.NewReader(data), rumHeader())
+			body, _ := io.ReadAll(resp.Body)
+			require.Equal(t, http.StatusAccepted, resp.StatusCode, string(body))
+			assert.Equal(t, "http://localhost:8000", resp.Header.Get(AccessControlAllowOrigin))
+
+			expectedFile := filepath.Join(testData, tt.expected)
+			if len(nextLogs.AllLogs()) > 0 {
+				actualLogs := plog.NewLogs()
+				for _, l := range nextLogs.AllLogs() {
+					l.ResourceLogs().MoveAndAppendTo(actualLogs.ResourceLogs())
+				}
+				if *update {
+					require.NoError(t, golden.WriteLogs(t, expectedFile, actualLogs))
+				}
+				expectedLogs, err := golden.ReadLogs(expectedFile)
+				require.NoError(t, err)
+				require.NoError(t, plogtest.CompareLogs(expectedLogs, actualLogs,
+					plogtest.IgnoreTimestamp(),
+					plogtest.IgnoreLogRecordAttributeValue(elasticattr.TimestampUs),
+					plogtest.IgnoreLogRecordsOrder(),
+				))
+				return
+			}
+
+			actualTraces := ptrace.NewTraces()
+			for _, tr := range nextTraces.AllTraces() {
+				tr.ResourceSpans().MoveAndAppendTo(actualTraces.ResourceSpans())
+			}
+			if *update {
+				require.NoError(t, golden.WriteTraces(t, expectedFile, actualTraces))
+			}
+			expectedTraces, err := golden.ReadTraces(expectedFile)
+			require.NoError(t, err)
+			require.NoError(t, ptracetest.CompareTraces(expectedTraces, actualTraces,
+				ptracetest.IgnoreStartTimestamp(),
+				ptracetest.IgnoreEndTimestamp(),
+				ptracetest.IgnoreSpanAttributeValue(elasticattr.TimestampUs),
+				ptracetest.IgnoreResourceSpansOrder(),
+			))
+		})
+	}
+}
+
+func TestRUMDisabled(t *testing.T) {
+	testEndpoint, _, _ := startRUMReceiver(t, func(cfg *Config) { cfg.RUM.Enabled = false })
+
+	resp := postRUM(t, "http://"+testEndpoint+intakeRUMV3EventsPath, strings.NewReader(""), rumHeader())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRUMCORS(t *testing.T) {
+	testEndpoint, _, _ := startRUMReceiver(t, func(cfg *Config) {
+		cfg.RUM.AllowOrigins = []string{"https://*.example.com"}
+		cfg.RUM.AllowHeaders = []string{"X-Custom"}
+	})
+	url := "http://" + testEndpoint + intakeRUMV2EventsPath
+
+	t.Run("preflight", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodOptions, url, nil)
+		require.NoError(t, err)
+		req.Header.Set(Origin, "https://app.example.com")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "https://app.example.com", resp.Header.Get(AccessControlAllowOrigin))
+		assert.Equal(t, "POST, OPTIONS", resp.Header.Get(AccessControlAllowMethods))
+		assert.Equal(t, "Content-Type, Content-Encoding, Accept, X-Custom", resp.Header.Get(AccessControlAllowHeaders))
+		assert.Equal(t, Origin, resp.Header.Get(Vary))
+	})
+
+	t.Run("origin not allowed", func(t *testing.T) {
+		header := rumHeader()
+		header.Set(Origin, "https://example.org")
+		resp := postRUM(t, url, strings.NewReader(""), header)
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.JSONEq(t, `{"error":"origin: 'https://example.org' is not allowed"}`, string(body))
+		assert.Empty(t, resp.Header.Get(AccessControlAllowOrigin))
+	})
+}
+
+func TestRUMRateLimit(t *testing.T) {
+	testEndpoint, nextTraces, _ := startRUMReceiver(t, func(cfg *Config) {
+		cfg.RUM.RateLimit.EventLimit = 1
+	})
+	url := "http://" + testEndpoint + intakeRUMV2EventsPath
+	payload := generateTransactionPayload(1)
+
+	// The burst allows three events per client.
+	for i := 0; i < 3; i++ {
+		resp := postRUM(t, url, bytes.NewReader(payload), rumHeader())
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+	resp := postRUM(t, url, bytes.NewReader(payload), rumHeader())
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Contains(t, string(body), errRateLimitExceeded.Error())
+
+	// Other clients have their own limit.
+	header := rumHeader()
+	header.Set("X-Forwarded-For", "192.0.2.20")
+	resp = postRUM(t, url, bytes.NewReader(payload), header)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, 4, nextTraces.SpanCount())
+
+	// A batch larger than the burst is accepted, but the client is
+	// rejected until the tokens it borrowed have been refilled.
+	header.Set("X-Forwarded-For", "192.0.2.30")
+	resp = postRUM(t, url, bytes.NewReader(generateTransactionPayload(5)), header)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, 9, nextTraces.SpanCount())
+	resp = postRUM(t, url, bytes.NewReader(payload), header)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
+
+func TestAllowEvents(t *testing.T) {
+	limiter := rate.NewLimiter(1, 3)
+	// Only the first chunk of a batch larger than the burst must be
+	// available, the rest is borrowed.
+	assert.True(t, allowEvents(limiter, 10))
+	assert.Less(t, limiter.Tokens(), float64(-6))
+	assert.False(t, allowEvents(limiter, 1))
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.1", "172.16.0.0/12"})
+	require.NoError(t, err)
+	for _, tt := range []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		{
+			name:       "untrusted peer",
+			remoteAddr: "192.0.2.1:1234",
+			header:     http.Header{"X-Real-Ip": {"192.0.2.2"}, "X-Forwarded-For": {"192.0.2.3"}},
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "trusted peer with x-real-ip",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Real-Ip": {"192.0.2.2"}, "X-Forwarded-For": {"192.0.2.3"}},
+			expected:   "192.0.2.2",
+		},
+		{
+			name:       "trusted peer with x-forwarded-for",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"192.0.2.4, 192.0.2.3, 172.16.0.1"}},
+			expected:   "192.0.2.3",
+		},
+		{
+			name:       "trusted peer with only trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"172.16.0.2", "172.16.0.1"}},
+			expected:   "172.16.0.2",
+		},
+		{
+			name:       "trusted peer with invalid x-forwarded-for",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"unknown"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "trusted peer without headers",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, intakeRUMV2EventsPath, nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header = tt.header
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			assert.Equal(t, tt.expected, clientIP(req, trustedProxies))
+		})
+	}
+}
+
+func TestRUMRateLimitersEviction(t *testing.T) {
+	limiters := newRUMRateLimiters(RUMRateLimitConfig{EventLimit: 1, IPLimit: 2})
+	a := limiters.get("192.0.2.1")
+	b := limiters.get("192.0.2.2")
+	assert.Same(t, a, limiters.get("192.0.2.1"))
+
+	// 192.0.2.2 is the least recently used, so it is evicted.
+	limiters.get("192.0.2.3")
+	assert.Same(t, a, limiters.get("192.0.2.1"))
+	assert.NotSame(t, b, limiters.get("192.0.2.2"))
+
+	assert.Nil(t, newRUMRateLimiters(RUMRateLimitConfig{}).get("192.0.2.1"))
+}
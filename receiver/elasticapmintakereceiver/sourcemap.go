@@ -0,0 +1,123 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver // import "github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+)
+
+const (
+	sourcemapUploadPath = "/assets/v1/sourcemaps"
+
+	// maxSourcemapUploadSize is the maximum size of a source map upload
+	// request, in bytes.
+	maxSourcemapUploadSize = 30 << 20
+)
+
+// registerSourcemapHandler registers the source map upload endpoint, if a
+// source map store is configured.
+func (r *elasticAPMIntakeReceiver) registerSourcemapHandler(ctx context.Context, host component.Host, mux *http.ServeMux) error {
+	if !r.cfg.SourceMap.Enabled() {
+		return nil
+	}
+	store, err := sourcemap.NewStore(ctx, host, r.cfg.SourceMap)
+	if err != nil {
+		return fmt.Errorf("failed to create source map store: %w", err)
+	}
+	r.sourcemapStore = store
+	mux.Handle(sourcemapUploadPath, r.auth.middleware(r.newSourcemapUploadHandler(store), false))
+	return nil
+}
+
+// newSourcemapUploadHandler returns a handler accepting source maps in the
+// multipart/form-data format of the APM Server source map upload API: the
+// service_name, service_version and bundle_filepath fields identify the
+// minified bundle, and the sourcemap file holds its source map.
+func (r *elasticAPMIntakeReceiver) newSourcemapUploadHandler(store sourcemap.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeSourcemapError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not supported: %s", req.Method))
+			return
+		}
+		req.Body = http.MaxBytesReader(w, req.Body, maxSourcemapUploadSize)
+
+		key, data, err := readSourcemapUpload(req)
+		if err != nil {
+			statusCode := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				statusCode = http.StatusRequestEntityTooLarge
+			}
+			writeSourcemapError(w, statusCode, err)
+			return
+		}
+		if err := store.Put(req.Context(), key, data); err != nil {
+			r.settings.Logger.Error("failed to store source map", zap.Stringer("key", key), zap.Error(err))
+			writeSourcemapError(w, http.StatusInternalServerError, errors.New("failed to store source map"))
+			return
+		}
+		r.settings.Logger.Debug("stored source map", zap.Stringer("key", key))
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func readSourcemapUpload(req *http.Request) (sourcemap.Key, []byte, error) {
+	if err := req.ParseMultipartForm(maxSourcemapUploadSize); err != nil {
+		return sourcemap.Key{}, nil, fmt.Errorf("failed to parse form: %w", err)
+	}
+	defer func() { _ = req.MultipartForm.RemoveAll() }()
+
+	key := sourcemap.NewKey(
+		req.FormValue("service_name"),
+		req.FormValue("service_version"),
+		req.FormValue("bundle_filepath"),
+	)
+	if err := key.Validate(); err != nil {
+		return sourcemap.Key{}, nil, err
+	}
+
+	f, _, err := req.FormFile("sourcemap")
+	if err != nil {
+		return sourcemap.Key{}, nil, fmt.Errorf("sourcemap: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return sourcemap.Key{}, nil, fmt.Errorf("failed to read source map: %w", err)
+	}
+	if err := sourcemap.Validate(data); err != nil {
+		return sourcemap.Key{}, nil, fmt.Errorf("invalid source map: %w", err)
+	}
+	return key, data, nil
+}
+
+func writeSourcemapError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Set(ContentType, "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
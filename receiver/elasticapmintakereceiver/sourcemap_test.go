@@ -0,0 +1,144 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticapmintakereceiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	"github.com/elastic/opentelemetry-collector-components/internal/sourcemap"
+	"github.com/elastic/opentelemetry-collector-components/internal/testutil"
+	"github.com/elastic/opentelemetry-collector-components/receiver/elasticapmintakereceiver/internal/metadata"
+)
+
+func newSourcemapUpload(t *testing.T, fields map[string]string, sourcemapData []byte) (io.Reader, string) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for k, v := range fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	if sourcemapData != nil {
+		fw, err := w.CreateFormFile("sourcemap", "bundle.min.js.map")
+		require.NoError(t, err)
+		_, err = fw.Write(sourcemapData)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return &body, w.FormDataContentType()
+}
+
+func TestSourcemapUpload(t *testing.T) {
+	factory := NewFactory()
+	testEndpoint := testutil.GetAvailableLocalAddress(t)
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = testEndpoint
+	cfg.SourceMap.Directory = t.TempDir()
+
+	tracesReceiver, err := factory.CreateTraces(context.Background(), receivertest.NewNopSettings(metadata.Type), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, tracesReceiver.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, tracesReceiver.Shutdown(context.Background()))
+	})
+
+	sourcemapData, err := os.ReadFile(filepath.Join(testData, "bundle.min.js.map"))
+	require.NoError(t, err)
+	fields := map[string]string{
+		"service_name":    "frontend",
+		"service_version": "1.0.0",
+		"bundle_filepath": "http://localhost:8000/js/bundle.min.js?v=1",
+	}
+	url := "http://" + testEndpoint + sourcemapUploadPath
+
+	for name, tc := range map[string]struct {
+		fields        map[string]string
+		sourcemap     []byte
+		expectedCode  int
+		expectedError string
+	}{
+		"valid": {
+			fields:       fields,
+			sourcemap:    sourcemapData,
+			expectedCode: http.StatusAccepted,
+		},
+		"missing service name": {
+			fields:        map[string]string{"service_version": "1.0.0", "bundle_filepath": "/bundle.min.js"},
+			sourcemap:     sourcemapData,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "service_name must be set",
+		},
+		"missing sourcemap": {
+			fields:        fields,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "sourcemap: http: no such file",
+		},
+		"invalid sourcemap": {
+			fields:        fields,
+			sourcemap:     []byte(`{"version":2}`),
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid source map: sourcemap: got version=2, but only 3rd version is supported",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			body, contentType := newSourcemapUpload(t, tc.fields, tc.sourcemap)
+			resp, err := http.Post(url, contentType, body)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectedCode, resp.StatusCode)
+			if tc.expectedError != "" {
+				var result map[string]string
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+				assert.Equal(t, tc.expectedError, result["error"])
+			}
+		})
+	}
+
+	stored, err := sourcemap.NewDirectoryStore(cfg.SourceMap.Directory).Get(
+		context.Background(), sourcemap.NewKey("frontend", "1.0.0", "http://localhost:8000/js/bundle.min.js"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, sourcemapData, stored)
+}
+
+func TestSourcemapStorageNotFound(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = testutil.GetAvailableLocalAddress(t)
+	storageID := component.MustNewID("file_storage")
+	cfg.SourceMap.StorageID = &storageID
+
+	tracesReceiver, err := factory.CreateTraces(context.Background(), receivertest.NewNopSettings(metadata.Type), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	err = tracesReceiver.Start(context.Background(), componenttest.NewNopHost())
+	assert.EqualError(t, err, `failed to create source map store: storage extension "file_storage" not found`)
+}
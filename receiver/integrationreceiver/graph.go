@@ -0,0 +1,479 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integrationreceiver // import "github.com/elastic/opentelemetry-collector-components/receiver/integrationreceiver"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pipeline"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
+)
+
+// graphBuilder creates the components of the pipelines of an integration. Pipelines can be
+// connected between them with connectors, forming a graph. Pipelines that don't define exporters
+// send their data to the consumer of the integration receiver.
+type graphBuilder struct {
+	r      *integrationReceiver
+	host   factoryGetter
+	config integrations.Config
+
+	// heads contains the first consumer of the pipelines that receive data from connectors.
+	heads map[pipeline.ID]any
+
+	// exporters and connectors contain the instances already created, so they can be
+	// shared by multiple pipelines.
+	exporters  map[exporterKey]component.Component
+	connectors map[connectorKey]component.Component
+
+	// components contains all the created components, in the order they should be started.
+	components []component.Component
+}
+
+type exporterKey struct {
+	id     component.ID
+	signal pipeline.Signal
+}
+
+type connectorKey struct {
+	id   component.ID
+	from pipeline.Signal
+	to   pipeline.Signal
+}
+
+func newGraphBuilder(r *integrationReceiver, host factoryGetter, config integrations.Config) *graphBuilder {
+	return &graphBuilder{
+		r:          r,
+		host:       host,
+		config:     config,
+		heads:      make(map[pipeline.ID]any),
+		exporters:  make(map[exporterKey]component.Component),
+		connectors: make(map[connectorKey]component.Component),
+	}
+}
+
+// build creates the components of all the pipelines in the configuration. Pipelines are created
+// after the pipelines they export to through connectors, so their consumers are available.
+func (b *graphBuilder) build(ctx context.Context) ([]component.Component, error) {
+	order, err := sortPipelines(b.config)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range order {
+		if err := b.buildPipeline(ctx, id, b.config.Pipelines[id]); err != nil {
+			return b.components, fmt.Errorf("failed to start pipeline %q: %w", id, err)
+		}
+	}
+	return b.components, nil
+}
+
+func (b *graphBuilder) buildPipeline(ctx context.Context, pipelineID pipeline.ID, pipe integrations.PipelineConfig) error {
+	if pipe.Receiver == nil {
+		return errors.New("no receiver in pipeline configuration")
+	}
+
+	next, err := b.pipelineExporters(ctx, pipelineID, pipe)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		// Nothing consumes the data of this pipeline.
+		return nil
+	}
+
+	var components []component.Component
+	processors := slices.Clone(pipe.Processors)
+	slices.Reverse(processors)
+	for i, id := range processors {
+		processorConfig, found := b.config.Processors[id]
+		if !found {
+			return fmt.Errorf("processor %q not found", id)
+		}
+
+		factory, ok := b.host.GetFactory(component.KindProcessor, id.Type()).(processor.Factory)
+		if !ok {
+			return fmt.Errorf("could not find processor factory for %q", id.Type())
+		}
+
+		config, err := convertComponentConfig(factory.CreateDefaultConfig, processorConfig)
+		if err != nil {
+			return fmt.Errorf("could not compose processor config for %s: %w", id.String(), err)
+		}
+
+		params := processor.Settings{
+			TelemetrySettings: b.r.params.TelemetrySettings,
+			BuildInfo:         b.r.params.BuildInfo,
+		}
+		params.ID = component.NewIDWithName(factory.Type(), fmt.Sprintf("%s-%s-%d", b.r.params.ID, pipelineID, i))
+		params.Logger = params.Logger.With(zap.String("name", params.ID.String()))
+		created, err := createProcessor(ctx, factory, params, config, pipelineID.Signal(), next)
+		if err != nil {
+			return fmt.Errorf("failed to create %s processor %s: %w", pipelineID.Signal(), params.ID, err)
+		}
+		next = created
+		components = append(components, created)
+	}
+
+	if _, isConnector := b.config.Connectors[*pipe.Receiver]; isConnector {
+		// The connector is created when building the pipelines exporting to it.
+		b.heads[pipelineID] = next
+		b.components = append(b.components, components...)
+		return nil
+	}
+
+	receiverConfig, found := b.config.Receivers[*pipe.Receiver]
+	if !found {
+		return fmt.Errorf("receiver %q not found", pipe.Receiver)
+	}
+
+	receiverFactory, ok := b.host.GetFactory(component.KindReceiver, pipe.Receiver.Type()).(receiver.Factory)
+	if !ok {
+		return fmt.Errorf("could not find receiver factory for %q", pipe.Receiver.Type())
+	}
+
+	preparedConfig, err := convertComponentConfig(receiverFactory.CreateDefaultConfig, receiverConfig)
+	if err != nil {
+		return fmt.Errorf("could not compose receiver config for %s: %w", pipe.Receiver.String(), err)
+	}
+
+	params := b.r.params
+	params.ID = component.NewIDWithName(receiverFactory.Type(), fmt.Sprintf("%s-receiver", pipelineID))
+	params.Logger = params.Logger.With(zap.String("name", params.ID.String()))
+	created, err := createReceiver(ctx, receiverFactory, params, preparedConfig, pipelineID.Signal(), next)
+	switch {
+	case err == nil:
+		components = append(components, created)
+	case errors.Is(err, pipeline.ErrSignalNotSupported):
+		b.r.params.Logger.Debug(fmt.Sprintf("receiver does not support %s telemetry type", pipelineID.Signal()),
+			zap.String("integration", b.r.params.ID.String()),
+			zap.String("receiver", params.ID.String()))
+
+		// If no receiver has been created the rest of the pipeline won't be used, so don't keep it.
+		// Shutting down created components out of kindness, because they haven't been started yet.
+		if err := shutdownComponents(ctx, components); err != nil {
+			b.r.params.Logger.Error("failed to cleanup processors after receiver was not created",
+				zap.String("integration", b.r.params.ID.String()),
+				zap.String("receiver", params.ID.String()))
+		}
+		return nil
+	default:
+		return fmt.Errorf("failed to create %s receiver %s: %w", pipelineID.Signal(), params.ID, err)
+	}
+
+	b.components = append(b.components, components...)
+	return nil
+}
+
+// pipelineExporters returns the consumer for the data of the pipeline, creating the exporters and
+// connectors it needs. It returns nil if there is nothing to consume this data.
+func (b *graphBuilder) pipelineExporters(ctx context.Context, pipelineID pipeline.ID, pipe integrations.PipelineConfig) (any, error) {
+	signal := pipelineID.Signal()
+	if len(pipe.Exporters) == 0 {
+		return b.r.nextConsumer(signal), nil
+	}
+
+	// Keys are only used to identify consumers in the fan-out.
+	consumers := make(map[pipeline.ID]any)
+	for _, id := range pipe.Exporters {
+		if _, isConnector := b.config.Connectors[id]; isConnector {
+			connectors, err := b.connectorsFrom(ctx, id, signal)
+			if err != nil {
+				return nil, err
+			}
+			for to, c := range connectors {
+				consumers[pipeline.NewIDWithName(signal, fmt.Sprintf("%s-%s", id, to))] = c
+			}
+			continue
+		}
+
+		e, err := b.exporter(ctx, id, signal)
+		if err != nil {
+			return nil, err
+		}
+		consumers[pipeline.NewIDWithName(signal, id.String())] = e
+	}
+
+	return fanout(signal, consumers), nil
+}
+
+// exporter returns the exporter with the given ID for the given signal, creating it if needed.
+func (b *graphBuilder) exporter(ctx context.Context, id component.ID, signal pipeline.Signal) (component.Component, error) {
+	key := exporterKey{id: id, signal: signal}
+	if created, found := b.exporters[key]; found {
+		return created, nil
+	}
+
+	exporterConfig, found := b.config.Exporters[id]
+	if !found {
+		return nil, fmt.Errorf("exporter %q not found", id)
+	}
+
+	factory, ok := b.host.GetFactory(component.KindExporter, id.Type()).(exporter.Factory)
+	if !ok {
+		return nil, fmt.Errorf("could not find exporter factory for %q", id.Type())
+	}
+
+	config, err := convertComponentConfig(factory.CreateDefaultConfig, exporterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not compose exporter config for %s: %w", id.String(), err)
+	}
+
+	params := exporter.Settings{
+		TelemetrySettings: b.r.params.TelemetrySettings,
+		BuildInfo:         b.r.params.BuildInfo,
+	}
+	params.ID = component.NewIDWithName(factory.Type(), fmt.Sprintf("%s-%s", b.r.params.ID, id))
+	params.Logger = params.Logger.With(zap.String("name", params.ID.String()))
+	created, err := createExporter(ctx, factory, params, config, signal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter %s: %w", signal, params.ID, err)
+	}
+
+	b.exporters[key] = created
+	b.components = append(b.components, created)
+	return created, nil
+}
+
+// connectorsFrom returns the instances of the connector with the given ID that consume data of the
+// given signal, one for each signal of the pipelines receiving from it, creating them if needed.
+func (b *graphBuilder) connectorsFrom(ctx context.Context, id component.ID, from pipeline.Signal) (map[pipeline.Signal]component.Component, error) {
+	receivers := make(map[pipeline.Signal]map[pipeline.ID]any)
+	for pipelineID, pipe := range b.config.Pipelines {
+		if pipe.Receiver == nil || *pipe.Receiver != id {
+			continue
+		}
+		head, found := b.heads[pipelineID]
+		if !found {
+			// Pipeline not used.
+			continue
+		}
+		if receivers[pipelineID.Signal()] == nil {
+			receivers[pipelineID.Signal()] = make(map[pipeline.ID]any)
+		}
+		receivers[pipelineID.Signal()][pipelineID] = head
+	}
+
+	connectors := make(map[pipeline.Signal]component.Component, len(receivers))
+	for to, consumers := range receivers {
+		key := connectorKey{id: id, from: from, to: to}
+		if created, found := b.connectors[key]; found {
+			connectors[to] = created
+			continue
+		}
+
+		created, err := b.createConnector(ctx, id, from, to, fanout(to, consumers))
+		if err != nil {
+			return nil, err
+		}
+		b.connectors[key] = created
+		b.components = append(b.components, created)
+		connectors[to] = created
+	}
+	return connectors, nil
+}
+
+func (b *graphBuilder) createConnector(ctx context.Context, id component.ID, from, to pipeline.Signal, next any) (component.Component, error) {
+	connectorConfig, found := b.config.Connectors[id]
+	if !found {
+		return nil, fmt.Errorf("connector %q not found", id)
+	}
+
+	factory, ok := b.host.GetFactory(component.KindConnector, id.Type()).(connector.Factory)
+	if !ok {
+		return nil, fmt.Errorf("could not find connector factory for %q", id.Type())
+	}
+
+	config, err := convertComponentConfig(factory.CreateDefaultConfig, connectorConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not compose connector config for %s: %w", id.String(), err)
+	}
+
+	params := connector.Settings{
+		TelemetrySettings: b.r.params.TelemetrySettings,
+		BuildInfo:         b.r.params.BuildInfo,
+	}
+	params.ID = component.NewIDWithName(factory.Type(), fmt.Sprintf("%s-%s", b.r.params.ID, id))
+	params.Logger = params.Logger.With(zap.String("name", params.ID.String()))
+	created, err := createConnector(ctx, factory, params, config, from, to, next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s to %s connector %s: %w", from, to, params.ID, err)
+	}
+	return created, nil
+}
+
+// sortPipelines returns the IDs of the pipelines in the order they have to be built, so pipelines
+// receiving from a connector are placed before the pipelines exporting to it.
+func sortPipelines(config integrations.Config) ([]pipeline.ID, error) {
+	ids := make([]pipeline.ID, 0, len(config.Pipelines))
+	for id := range config.Pipelines {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b pipeline.ID) int { return strings.Compare(a.String(), b.String()) })
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[pipeline.ID]int, len(ids))
+	order := make([]pipeline.ID, 0, len(ids))
+	var visit func(id pipeline.ID) error
+	visit = func(id pipeline.ID) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("cycle in pipelines detected at %q", id)
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		for _, exporter := range config.Pipelines[id].Exporters {
+			if _, isConnector := config.Connectors[exporter]; !isConnector {
+				continue
+			}
+			for _, next := range ids {
+				receiver := config.Pipelines[next].Receiver
+				if receiver != nil && *receiver == exporter {
+					if err := visit(next); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		state[id] = visited
+		order = append(order, id)
+		return nil
+	}
+	for _, id := range ids {
+		if err := visit(id); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// fanout returns a consumer of the given signal that sends data to all the consumers.
+func fanout(signal pipeline.Signal, consumers map[pipeline.ID]any) any {
+	if len(consumers) == 0 {
+		return nil
+	}
+	if len(consumers) == 1 {
+		for _, c := range consumers {
+			return c
+		}
+	}
+	switch signal {
+	case pipeline.SignalLogs:
+		return connector.NewLogsRouter(typedConsumers[consumer.Logs](consumers))
+	case pipeline.SignalMetrics:
+		return connector.NewMetricsRouter(typedConsumers[consumer.Metrics](consumers))
+	case pipeline.SignalTraces:
+		return connector.NewTracesRouter(typedConsumers[consumer.Traces](consumers))
+	default:
+		return nil
+	}
+}
+
+func typedConsumers[T any](consumers map[pipeline.ID]any) map[pipeline.ID]T {
+	typed := make(map[pipeline.ID]T, len(consumers))
+	for id, c := range consumers {
+		typed[id] = c.(T)
+	}
+	return typed
+}
+
+func createProcessor(ctx context.Context, factory processor.Factory, params processor.Settings, config component.Config, signal pipeline.Signal, next any) (component.Component, error) {
+	switch signal {
+	case pipeline.SignalLogs:
+		return asComponent(factory.CreateLogs(ctx, params, config, next.(consumer.Logs)))
+	case pipeline.SignalMetrics:
+		return asComponent(factory.CreateMetrics(ctx, params, config, next.(consumer.Metrics)))
+	case pipeline.SignalTraces:
+		return asComponent(factory.CreateTraces(ctx, params, config, next.(consumer.Traces)))
+	default:
+		return nil, pipeline.ErrSignalNotSupported
+	}
+}
+
+func createReceiver(ctx context.Context, factory receiver.Factory, params receiver.Settings, config component.Config, signal pipeline.Signal, next any) (component.Component, error) {
+	switch signal {
+	case pipeline.SignalLogs:
+		return asComponent(factory.CreateLogs(ctx, params, config, next.(consumer.Logs)))
+	case pipeline.SignalMetrics:
+		return asComponent(factory.CreateMetrics(ctx, params, config, next.(consumer.Metrics)))
+	case pipeline.SignalTraces:
+		return asComponent(factory.CreateTraces(ctx, params, config, next.(consumer.Traces)))
+	default:
+		return nil, pipeline.ErrSignalNotSupported
+	}
+}
+
+func createExporter(ctx context.Context, factory exporter.Factory, params exporter.Settings, config component.Config, signal pipeline.Signal) (component.Component, error) {
+	switch signal {
+	case pipeline.SignalLogs:
+		return asComponent(factory.CreateLogs(ctx, params, config))
+	case pipeline.SignalMetrics:
+		return asComponent(factory.CreateMetrics(ctx, params, config))
+	case pipeline.SignalTraces:
+		return asComponent(factory.CreateTraces(ctx, params, config))
+	default:
+		return nil, pipeline.ErrSignalNotSupported
+	}
+}
+
+func createConnector(ctx context.Context, factory connector.Factory, params connector.Settings, config component.Config, from, to pipeline.Signal, next any) (component.Component, error) {
+	switch {
+	case from == pipeline.SignalLogs && to == pipeline.SignalLogs:
+		return asComponent(factory.CreateLogsToLogs(ctx, params, config, next.(consumer.Logs)))
+	case from == pipeline.SignalLogs && to == pipeline.SignalMetrics:
+		return asComponent(factory.CreateLogsToMetrics(ctx, params, config, next.(consumer.Metrics)))
+	case from == pipeline.SignalLogs && to == pipeline.SignalTraces:
+		return asComponent(factory.CreateLogsToTraces(ctx, params, config, next.(consumer.Traces)))
+	case from == pipeline.SignalMetrics && to == pipeline.SignalLogs:
+		return asComponent(factory.CreateMetricsToLogs(ctx, params, config, next.(consumer.Logs)))
+	case from == pipeline.SignalMetrics && to == pipeline.SignalMetrics:
+		return asComponent(factory.CreateMetricsToMetrics(ctx, params, config, next.(consumer.Metrics)))
+	case from == pipeline.SignalMetrics && to == pipeline.SignalTraces:
+		return asComponent(factory.CreateMetricsToTraces(ctx, params, config, next.(consumer.Traces)))
+	case from == pipeline.SignalTraces && to == pipeline.SignalLogs:
+		return asComponent(factory.CreateTracesToLogs(ctx, params, config, next.(consumer.Logs)))
+	case from == pipeline.SignalTraces && to == pipeline.SignalMetrics:
+		return asComponent(factory.CreateTracesToMetrics(ctx, params, config, next.(consumer.Metrics)))
+	case from == pipeline.SignalTraces && to == pipeline.SignalTraces:
+		return asComponent(factory.CreateTracesToTraces(ctx, params, config, next.(consumer.Traces)))
+	default:
+		return nil, pipeline.ErrSignalNotSupported
+	}
+}
+
+func asComponent[C component.Component](c C, err error) (component.Component, error) {
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -22,6 +22,9 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
+	"sync"
+	"syscall"
 
 	"go.uber.org/zap"
 
@@ -29,7 +32,6 @@ import (
 	"go.opentelemetry.io/collector/confmap"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pipeline"
-	"go.opentelemetry.io/collector/processor"
 	"go.opentelemetry.io/collector/receiver"
 
 	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
@@ -38,10 +40,15 @@ import (
 type integrationReceiver struct {
 	params              receiver.Settings
 	config              *Config
-	components          []component.Component
 	nextMetricsConsumer consumer.Metrics
 	nextLogsConsumer    consumer.Logs
 	nextTracesConsumer  consumer.Traces
+	unsubscribe         func()
+
+	// mu protects the running components, that are replaced when the integration changes.
+	mu         sync.Mutex
+	components []component.Component
+	shutdown   bool
 }
 
 func newTemplateLogsReceiver(params receiver.Settings, config *Config, consumer consumer.Logs) *integrationReceiver {
@@ -76,218 +83,183 @@ type factoryGetter interface {
 }
 
 // Start creates and starts a receiver composed by other components. It is composed of at least one pipeline, each one
-// of them composed by one receiver, and optionally a set of processors to customize the received data.
+// of them composed by one receiver, and optionally a set of processors to customize the received data. Pipelines can
+// also send their data to exporters, or to other pipelines through connectors. Components are replaced when the
+// finder extensions notify that the integration has changed.
 func (r *integrationReceiver) Start(ctx context.Context, ch component.Host) error {
 	host, ok := ch.(factoryGetter)
 	if !ok {
 		return fmt.Errorf("integrationreceiver is not compatible with the provided component.Host")
 	}
 
-	integration, err := integrations.Find(ctx, r.params.Logger, host, r.config.Name)
+	components, err := r.startComponents(ctx, host)
 	if err != nil {
-		return fmt.Errorf("failed to find integration %q: %w", r.config.Name, err)
+		return err
 	}
 
-	config, err := integration.Resolve(ctx, r.config.Parameters, r.config.Pipelines)
-	if err != nil {
-		return fmt.Errorf("failed to build receiver pipelines for integration %q: %w", r.config.Name, err)
-	}
-
-	// Confidence check, we should have as many unique pipelines as requested.
-	if found, expected := len(config.Pipelines), len(r.config.Pipelines); expected != found {
-		return fmt.Errorf("unexpected number of pipelines configured, found %d, expected %d", found, expected)
-	}
-
-	for id, pipeline := range config.Pipelines {
-		if !r.hasConsumerForPipelineType(id) {
-			continue
-		}
-		err := r.startPipeline(ctx, host, *config, id, pipeline)
-		if err != nil {
-			// Shutdown components that had been already started for cleanup.
-			if err := r.Shutdown(ctx); err != nil {
-				r.params.Logger.Warn("Failed to shutdown all components on error while starting",
-					zap.String("error", err.Error()))
-			}
-			return fmt.Errorf("failed to start pipeline %q: %w", id, err)
-		}
-	}
+	r.mu.Lock()
+	r.components = components
+	r.mu.Unlock()
 
+	r.unsubscribe = integrations.SubscribeChanges(host, r.config.Name, func() {
+		r.reload(host)
+	})
 	return nil
 }
 
-func (r *integrationReceiver) hasConsumerForPipelineType(id pipeline.ID) bool {
-	switch id.Signal() {
-	case pipeline.SignalLogs:
-		return r.nextLogsConsumer != nil
-	case pipeline.SignalMetrics:
-		return r.nextMetricsConsumer != nil
-	case pipeline.SignalTraces:
-		return r.nextTracesConsumer != nil
-	default:
-		r.params.Logger.Warn("unexpected signal type in integration", zap.String("id", id.String()))
-		return false
+// startComponents finds and resolves the integration, and creates and starts its components.
+// Components are shut down if any of them fails to start.
+func (r *integrationReceiver) startComponents(ctx context.Context, host factoryGetter) ([]component.Component, error) {
+	components, err := r.buildComponents(ctx, host)
+	if err != nil {
+		return nil, err
 	}
+	return r.start(ctx, host, components)
 }
 
-func (r *integrationReceiver) startPipeline(ctx context.Context, host factoryGetter, config integrations.Config, pipelineID pipeline.ID, pipe integrations.PipelineConfig) error {
-	consumerChain := struct {
-		logs    consumer.Logs
-		metrics consumer.Metrics
-		traces  consumer.Traces
-	}{
-		logs:    r.nextLogsConsumer,
-		metrics: r.nextMetricsConsumer,
-		traces:  r.nextTracesConsumer,
-	}
-
-	if pipe.Receiver == nil {
-		return errors.New("no receiver in pipeline configuration")
-	}
-
-	receiverConfig, found := config.Receivers[*pipe.Receiver]
-	if !found {
-		return fmt.Errorf("receiver %q not found", pipe.Receiver)
-	}
-
-	receiverFactory, ok := host.GetFactory(component.KindReceiver, pipe.Receiver.Type()).(receiver.Factory)
-	if !ok {
-		return fmt.Errorf("could not find receiver factory for %q", pipe.Receiver.Type())
-	}
-
-	preparedConfig, err := convertComponentConfig(receiverFactory.CreateDefaultConfig, receiverConfig)
+// buildComponents finds and resolves the integration, and creates its components without starting them.
+func (r *integrationReceiver) buildComponents(ctx context.Context, host factoryGetter) ([]component.Component, error) {
+	integration, err := integrations.FindVersion(ctx, r.params.Logger, host, r.config.Name, r.config.Version)
 	if err != nil {
-		return fmt.Errorf("could not compose receiver config for %s: %w", pipe.Receiver.String(), err)
+		return nil, fmt.Errorf("failed to find integration %q: %w", r.config.Name, err)
 	}
 
-	var components []component.Component
-	processors := slices.Clone(pipe.Processors)
-	slices.Reverse(processors)
-	for i, id := range processors {
-		processorConfig, found := config.Processors[id]
-		if !found {
-			return fmt.Errorf("processor %q not found", id)
-		}
-
-		factory, ok := host.GetFactory(component.KindProcessor, id.Type()).(processor.Factory)
-		if !ok {
-			return fmt.Errorf("could not find processor factory for %q", id.Type())
-		}
-
-		config, err := convertComponentConfig(factory.CreateDefaultConfig, processorConfig)
-		if err != nil {
-			return fmt.Errorf("could not compose processor config for %s: %w", id.String(), err)
-		}
-
-		params := processor.Settings{
-			TelemetrySettings: r.params.TelemetrySettings,
-			BuildInfo:         r.params.BuildInfo,
-		}
-		params.ID = component.NewIDWithName(factory.Type(), fmt.Sprintf("%s-%s-%d", r.params.ID, pipelineID, i))
-		params.Logger = params.Logger.With(zap.String("name", params.ID.String()))
-		if consumerChain.logs != nil {
-			logs, err := factory.CreateLogs(ctx, params, config, consumerChain.logs)
-			if err != nil {
-				return fmt.Errorf("failed to create logs processor %s: %w", params.ID, err)
-			}
-			consumerChain.logs = logs
-			components = append(components, logs)
-		}
-		if consumerChain.metrics != nil {
-			metrics, err := factory.CreateMetrics(ctx, params, config, consumerChain.metrics)
-			if err != nil {
-				return fmt.Errorf("failed to create metrics processor %s: %w", params.ID, err)
-			}
-			consumerChain.metrics = metrics
-			components = append(components, metrics)
-		}
-		if consumerChain.traces != nil {
-			traces, err := factory.CreateTraces(ctx, params, config, consumerChain.traces)
-			if err != nil {
-				return fmt.Errorf("failed to create traces processor %s: %w", params.ID, err)
-			}
-			consumerChain.traces = traces
-			components = append(components, traces)
-		}
+	config, err := integration.Resolve(ctx, r.config.Parameters, r.config.Pipelines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build receiver pipelines for integration %q: %w", r.config.Name, err)
 	}
 
-	params := r.params
-	params.ID = component.NewIDWithName(receiverFactory.Type(), fmt.Sprintf("%s-receiver", pipelineID))
-	params.Logger = params.Logger.With(zap.String("name", params.ID.String()))
-	receiversCreated := 0
-	if consumerChain.logs != nil {
-		logs, err := receiverFactory.CreateLogs(ctx, params, preparedConfig, consumerChain.logs)
-		switch {
-		case err == nil:
-			components = append(components, logs)
-			receiversCreated += 1
-		case errors.Is(err, pipeline.ErrSignalNotSupported):
-			r.params.Logger.Debug("receiver does not support logs telemetry type",
-				zap.String("integration", r.params.ID.String()),
-				zap.String("receiver", params.ID.String()))
-		default:
-			return fmt.Errorf("failed to create logs receiver %s: %w", params.ID, err)
-		}
-	}
-	if consumerChain.metrics != nil {
-		metrics, err := receiverFactory.CreateMetrics(ctx, params, preparedConfig, consumerChain.metrics)
-		switch {
-		case err == nil:
-			components = append(components, metrics)
-			receiversCreated += 1
-		case errors.Is(err, pipeline.ErrSignalNotSupported):
-			r.params.Logger.Debug("receiver does not support metrics telemetry type",
-				zap.String("integration", r.params.ID.String()),
-				zap.String("receiver", params.ID.String()))
-		default:
-			return fmt.Errorf("failed to create metrics receiver %s: %w", params.ID, err)
-		}
-	}
-	if consumerChain.traces != nil {
-		traces, err := receiverFactory.CreateTraces(ctx, params, preparedConfig, consumerChain.traces)
-		switch {
-		case err == nil:
-			components = append(components, traces)
-			receiversCreated += 1
-		case errors.Is(err, pipeline.ErrSignalNotSupported):
-			r.params.Logger.Debug("receiver does not support traces telemetry type",
-				zap.String("integration", r.params.ID.String()),
-				zap.String("receiver", params.ID.String()))
-		default:
-			return fmt.Errorf("failed to create traces receiver %s: %w", params.ID, err)
-		}
+	// Confidence check, we should have as many unique pipelines as requested.
+	if found, expected := len(config.Pipelines), len(r.config.Pipelines); expected != found {
+		return nil, fmt.Errorf("unexpected number of pipelines configured, found %d, expected %d", found, expected)
 	}
 
-	// If no receiver has been created the rest of the pipeline won't be used, so don't keep it.
-	if receiversCreated == 0 {
+	components, err := newGraphBuilder(r, host, *config).build(ctx)
+	if err != nil {
 		// Shutting down created components out of kindness, because they haven't been started yet.
 		if err := shutdownComponents(ctx, components); err != nil {
-			r.params.Logger.Error("failed to cleanup processors after receiver was not created",
-				zap.String("integration", r.params.ID.String()),
-				zap.String("receiver", params.ID.String()))
+			r.params.Logger.Warn("Failed to shutdown all components on error while starting",
+				zap.String("error", err.Error()))
 		}
-		return nil
+		return nil, err
 	}
+	return components, nil
+}
 
+// start starts the components, shutting down the started ones if any of them fails to start.
+func (r *integrationReceiver) start(ctx context.Context, host factoryGetter, components []component.Component) ([]component.Component, error) {
+	var started []component.Component
 	for _, component := range components {
 		err := component.Start(ctx, host)
 		if err != nil {
-			if err := r.Shutdown(ctx); err != nil {
-				r.params.Logger.Error("failed to cleanup processors after a component failed to start",
+			if err := shutdownComponents(ctx, started); err != nil {
+				r.params.Logger.Error("failed to cleanup components after a component failed to start",
 					zap.String("integration", r.params.ID.String()),
-					zap.String("receiver", params.ID.String()),
 					zap.Error(err),
 				)
 			}
-			return fmt.Errorf("failed to start component %q: %w", component, err)
+			return nil, fmt.Errorf("failed to start component %q: %w", component, err)
 		}
-		r.components = append(r.components, component)
+		started = append(started, component)
+	}
+
+	return started, nil
+}
+
+// reload replaces the running components with new ones, created from the current definition of the
+// integration. New components are started before shutting down the previous ones, so no data is lost
+// while they are replaced. During that time the previous and new receivers run side by side, so
+// receivers that do not hold exclusive resources, such as scrapers, may produce duplicated data.
+// Previous components are kept if the new definition is invalid or its components fail to start.
+//
+// Some components cannot run side by side with their previous instances, such as receivers listening
+// on a fixed endpoint. If the new components fail to start because a resource is already in use, the
+// previous ones are shut down before starting the new ones again. If they still fail, the integration
+// is left without running pipelines until its definition changes again.
+func (r *integrationReceiver) reload(host factoryGetter) {
+	ctx := context.Background()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.shutdown {
+		return
 	}
 
+	components, err := r.buildComponents(ctx, host)
+	if err != nil {
+		r.params.Logger.Error("failed to reload integration, keeping previous pipelines",
+			zap.String("integration", r.config.Name),
+			zap.Error(err))
+		return
+	}
+
+	started, err := r.start(ctx, host, components)
+	switch {
+	case err == nil:
+		if err := shutdownComponents(ctx, r.components); err != nil {
+			r.params.Logger.Warn("failed to shutdown previous pipelines after reloading integration",
+				zap.String("integration", r.config.Name),
+				zap.Error(err))
+		}
+	case isAddrInUse(err):
+		r.params.Logger.Warn("failed to start integration while previous pipelines are running, restarting them",
+			zap.String("integration", r.config.Name),
+			zap.Error(err))
+		if err := shutdownComponents(ctx, r.components); err != nil {
+			r.params.Logger.Warn("failed to shutdown previous pipelines before reloading integration",
+				zap.String("integration", r.config.Name),
+				zap.Error(err))
+		}
+		r.components = nil
+		started, err = r.startComponents(ctx, host)
+		if err != nil {
+			r.params.Logger.Error("failed to reload integration, no pipelines are running",
+				zap.String("integration", r.config.Name),
+				zap.Error(err))
+			return
+		}
+	default:
+		// The new components that were started have already been shut down.
+		r.params.Logger.Error("failed to reload integration, keeping previous pipelines",
+			zap.String("integration", r.config.Name),
+			zap.Error(err))
+		return
+	}
+	r.components = started
+	r.params.Logger.Info("integration reloaded", zap.String("integration", r.config.Name))
+}
+
+// isAddrInUse returns true if err is caused by a resource, such as a listening address, that is
+// held by another component.
+func isAddrInUse(err error) bool {
+	// Not all components wrap the underlying error, so its text is checked too.
+	return errors.Is(err, syscall.EADDRINUSE) || strings.Contains(err.Error(), "address already in use")
+}
+
+// nextConsumer returns the consumer of the receiver for the given signal, or nil if the receiver
+// has not been created for it.
+func (r *integrationReceiver) nextConsumer(signal pipeline.Signal) any {
+	switch signal {
+	case pipeline.SignalLogs:
+		return r.nextLogsConsumer
+	case pipeline.SignalMetrics:
+		return r.nextMetricsConsumer
+	case pipeline.SignalTraces:
+		return r.nextTracesConsumer
+	default:
+		r.params.Logger.Warn("unexpected signal type in integration", zap.String("signal", signal.String()))
+	}
 	return nil
 }
 
 func (r *integrationReceiver) Shutdown(ctx context.Context) error {
+	if r.unsubscribe != nil {
+		r.unsubscribe()
+		r.unsubscribe = nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shutdown = true
 	err := shutdownComponents(ctx, r.components)
 	if err != nil {
 		return err
@@ -314,6 +286,10 @@ func shutdownComponents(ctx context.Context, components []component.Component) e
 // with the configuration object returned by `create`. `create` is expected to be the
 // `CreateDefaultConfig` of a component factory.
 func convertComponentConfig(create func() component.Config, config component.Config) (component.Config, error) {
+	if config == nil {
+		// Component defined without settings.
+		return create(), nil
+	}
 	received := confmap.New()
 	err := received.Marshal(config)
 	if err != nil {
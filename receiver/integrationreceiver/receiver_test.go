@@ -20,9 +20,12 @@ package integrationreceiver // import "github.com/elastic/opentelemetry-collecto
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -34,8 +37,12 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/transformprocessor"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/filelogreceiver"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/pipeline"
+	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/collector/receiver/receivertest"
 
 	"github.com/elastic/opentelemetry-collector-components/pkg/integrations"
@@ -83,6 +90,15 @@ func TestConsumeLogs(t *testing.T) {
 			},
 			startErr: `variable "resource" not found`,
 		},
+		{
+			title: "version not available",
+			setup: func(config *Config) {
+				config.Name = "filelog"
+				config.Version = "^1.0.0"
+				config.Pipelines = []pipeline.ID{pipeline.NewID(pipeline.SignalLogs)}
+			},
+			startErr: `failed to find integration "filelog": not found`,
+		},
 		{
 			title: "receiver without factory",
 			setup: func(config *Config) {
@@ -92,6 +108,31 @@ func TestConsumeLogs(t *testing.T) {
 			startErr: `could not find receiver factory for "undefined"`,
 		},
 
+		{
+			title: "pipeline receiving from connector",
+			setup: func(config *Config) {
+				config.Name = "connectors"
+				config.Pipelines = []pipeline.ID{
+					pipeline.NewIDWithName(pipeline.SignalLogs, "source"),
+					pipeline.NewIDWithName(pipeline.SignalLogs, "connected"),
+				}
+				config.Parameters = map[string]any{
+					"paths": filepath.Join("testdata", "logs", "test-simple.log"),
+				}
+			},
+			expectedFile: "logs-with-connector.yaml",
+		},
+		{
+			title: "cycle between pipelines",
+			setup: func(config *Config) {
+				config.Name = "connectors"
+				config.Pipelines = []pipeline.ID{
+					pipeline.NewIDWithName(pipeline.SignalLogs, "cycle-a"),
+					pipeline.NewIDWithName(pipeline.SignalLogs, "cycle-b"),
+				}
+			},
+			startErr: `cycle in pipelines detected`,
+		},
 		{
 			title: "no receiver in pipeline",
 			setup: func(config *Config) {
@@ -135,6 +176,173 @@ func TestConvertComponentConfig(t *testing.T) {
 	assert.EqualValues(t, expected, converted)
 }
 
+func TestExporters(t *testing.T) {
+	factory := NewFactory()
+	config := factory.CreateDefaultConfig().(*Config)
+	config.Name = "connectors"
+	config.Pipelines = []pipeline.ID{
+		pipeline.NewIDWithName(pipeline.SignalLogs, "source"),
+		pipeline.NewIDWithName(pipeline.SignalLogs, "connected"),
+	}
+	config.Parameters = map[string]any{
+		"paths": filepath.Join("testdata", "logs", "test-simple.log"),
+	}
+
+	sink := new(consumertest.LogsSink)
+	p, err := factory.CreateLogs(context.Background(), receivertest.NewNopSettings(metadata.Type), config, sink)
+	require.NoError(t, err)
+
+	host := newMockHost("testdata/templates")
+	require.NoError(t, p.Start(context.Background(), host))
+	t.Cleanup(func() { require.NoError(t, p.Shutdown(context.Background())) })
+
+	waitLogsSink(t, sink)
+	waitLogsSink(t, host.exporterSink)
+
+	// The exporter receives the logs before they are processed by the connected pipeline.
+	exported := host.exporterSink.AllLogs()
+	expected, err := golden.ReadLogs(filepath.Join("testdata", "expected-logs-no-processing.yaml"))
+	require.NoError(t, err)
+	assert.NoError(t, plogtest.CompareLogs(expected, exported[0], plogtest.IgnoreObservedTimestamp()))
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate := func(t *testing.T, value string) {
+		template := `
+receivers:
+  filelog:
+    include: ${var:paths}
+    start_at: beginning
+
+processors:
+  transform:
+    log_statements:
+      - set(log.attributes["version"], "` + value + `")
+
+pipelines:
+  logs:
+    receiver: filelog
+    processors: [transform]
+`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "reload.yaml"), []byte(template), 0o600))
+	}
+	writeTemplate(t, "original")
+
+	factory := NewFactory()
+	config := factory.CreateDefaultConfig().(*Config)
+	config.Name = "reload"
+	config.Pipelines = []pipeline.ID{pipeline.NewID(pipeline.SignalLogs)}
+	config.Parameters = map[string]any{
+		"paths": filepath.Join("testdata", "logs", "test-simple.log"),
+	}
+
+	sink := new(consumertest.LogsSink)
+	p, err := factory.CreateLogs(context.Background(), receivertest.NewNopSettings(metadata.Type), config, sink)
+	require.NoError(t, err)
+
+	host := newMockHost(dir)
+	require.NoError(t, p.Start(context.Background(), host))
+	t.Cleanup(func() { require.NoError(t, p.Shutdown(context.Background())) })
+	waitLogsWithAttribute(t, sink, "version", "original")
+
+	// Invalid definitions are ignored, and previous pipelines are kept.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reload.yaml"), []byte("invalid"), 0o600))
+	host.notifyChange("reload")
+
+	writeTemplate(t, "reloaded")
+	host.notifyChange("other")
+	host.notifyChange("reload")
+	waitLogsWithAttribute(t, sink, "version", "reloaded")
+}
+
+func TestReload_ExclusiveComponents(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate := func(t *testing.T, value string) {
+		template := `
+receivers:
+  filelog:
+    include: ${var:paths}
+    start_at: beginning
+  exclusive:
+
+processors:
+  transform:
+    log_statements:
+      - set(log.attributes["version"], "` + value + `")
+
+pipelines:
+  logs:
+    receiver: filelog
+    processors: [transform]
+  logs/exclusive:
+    receiver: exclusive
+`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "reload.yaml"), []byte(template), 0o600))
+	}
+	writeTemplate(t, "original")
+
+	factory := NewFactory()
+	config := factory.CreateDefaultConfig().(*Config)
+	config.Name = "reload"
+	config.Pipelines = []pipeline.ID{
+		pipeline.NewID(pipeline.SignalLogs),
+		pipeline.NewIDWithName(pipeline.SignalLogs, "exclusive"),
+	}
+	config.Parameters = map[string]any{
+		"paths": filepath.Join("testdata", "logs", "test-simple.log"),
+	}
+
+	sink := new(consumertest.LogsSink)
+	p, err := factory.CreateLogs(context.Background(), receivertest.NewNopSettings(metadata.Type), config, sink)
+	require.NoError(t, err)
+
+	host := newMockHost(dir)
+	require.NoError(t, p.Start(context.Background(), host))
+	t.Cleanup(func() { require.NoError(t, p.Shutdown(context.Background())) })
+	waitLogsWithAttribute(t, sink, "version", "original")
+
+	// The exclusive receiver of the new pipelines cannot start while the
+	// previous one runs, so the previous pipelines are shut down first.
+	writeTemplate(t, "reloaded")
+	host.notifyChange("reload")
+	waitLogsWithAttribute(t, sink, "version", "reloaded")
+	assert.True(t, host.exclusiveRunning.Load())
+}
+
+func TestReload_StartFailure(t *testing.T) {
+	dir := t.TempDir()
+	template := `
+receivers:
+  failing:
+
+pipelines:
+  logs:
+    receiver: failing
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reload.yaml"), []byte(template), 0o600))
+
+	factory := NewFactory()
+	config := factory.CreateDefaultConfig().(*Config)
+	config.Name = "reload"
+	config.Pipelines = []pipeline.ID{pipeline.NewID(pipeline.SignalLogs)}
+
+	p, err := factory.CreateLogs(context.Background(), receivertest.NewNopSettings(metadata.Type), config, new(consumertest.LogsSink))
+	require.NoError(t, err)
+
+	host := newMockHost(dir)
+	require.NoError(t, p.Start(context.Background(), host))
+	require.Equal(t, int64(1), host.failingRunning.Load())
+
+	// Errors other than resources being in use keep the previous pipelines running.
+	host.failStart.Store(true)
+	host.notifyChange("reload")
+	assert.Equal(t, int64(1), host.failingRunning.Load())
+
+	require.NoError(t, p.Shutdown(context.Background()))
+	assert.Equal(t, int64(0), host.failingRunning.Load())
+}
+
 func testConsumeLogs(t *testing.T, setup func(*Config), expectedFile, startErr string) {
 	factory := NewFactory()
 	config := factory.CreateDefaultConfig().(*Config)
@@ -183,12 +391,58 @@ func waitLogsSink(t *testing.T, sink *consumertest.LogsSink) {
 	}
 }
 
+func waitLogsWithAttribute(t *testing.T, sink *consumertest.LogsSink, key, value string) {
+	t.Helper()
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		found := false
+		for _, logs := range sink.AllLogs() {
+			for i := 0; i < logs.ResourceLogs().Len(); i++ {
+				scopeLogs := logs.ResourceLogs().At(i).ScopeLogs()
+				for j := 0; j < scopeLogs.Len(); j++ {
+					records := scopeLogs.At(j).LogRecords()
+					for k := 0; k < records.Len(); k++ {
+						if v, ok := records.At(k).Attributes().Get(key); ok && v.Str() == value {
+							found = true
+						}
+					}
+				}
+			}
+		}
+		assert.True(c, found, "no logs with %s=%s", key, value)
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
 func newMockHost(path string) *mockedHost {
-	return &mockedHost{path: path}
+	return &mockedHost{
+		path:         path,
+		exporterSink: new(consumertest.LogsSink),
+	}
 }
 
 type mockedHost struct {
-	path string
+	path         string
+	exporterSink *consumertest.LogsSink
+	subscribers  []func(string)
+
+	// exclusiveRunning is set while an exclusive receiver runs, like a
+	// receiver listening on a fixed endpoint.
+	exclusiveRunning atomic.Bool
+
+	// failStart makes failing receivers fail to start, and failingRunning
+	// counts the failing receivers that are running.
+	failStart      atomic.Bool
+	failingRunning atomic.Int64
+}
+
+func (m *mockedHost) SubscribeChanges(fn func(string)) func() {
+	m.subscribers = append(m.subscribers, fn)
+	return func() {}
+}
+
+func (m *mockedHost) notifyChange(name string) {
+	for _, fn := range m.subscribers {
+		fn(name)
+	}
 }
 
 func (m *mockedHost) GetExtensions() map[component.ID]component.Component {
@@ -203,10 +457,93 @@ func (m *mockedHost) GetFactory(kind component.Kind, ctype component.Type) compo
 		return transformprocessor.NewFactory()
 	case kind == component.KindReceiver && ctype.String() == "filelog":
 		return filelogreceiver.NewFactory()
+	case kind == component.KindConnector && ctype.String() == "forward":
+		return newForwardConnectorFactory()
+	case kind == component.KindExporter && ctype.String() == "sink":
+		return newSinkExporterFactory(m.exporterSink)
+	case kind == component.KindReceiver && ctype.String() == "exclusive":
+		return newExclusiveReceiverFactory(&m.exclusiveRunning)
+	case kind == component.KindReceiver && ctype.String() == "failing":
+		return newFailingReceiverFactory(&m.failStart, &m.failingRunning)
 	}
 	return nil
 }
 
+// testComponent is a component that passes logs to the embedded consumer.
+type testComponent struct {
+	component.StartFunc
+	component.ShutdownFunc
+	consumer.Logs
+}
+
+// newForwardConnectorFactory returns the factory of a connector that forwards logs to the next consumer.
+func newForwardConnectorFactory() connector.Factory {
+	return connector.NewFactory(
+		component.MustNewType("forward"),
+		func() component.Config { return &struct{}{} },
+		connector.WithLogsToLogs(func(_ context.Context, _ connector.Settings, _ component.Config, next consumer.Logs) (connector.Logs, error) {
+			return &testComponent{Logs: next}, nil
+		}, component.StabilityLevelDevelopment),
+	)
+}
+
+// newSinkExporterFactory returns the factory of an exporter that stores logs in the given sink.
+func newSinkExporterFactory(sink *consumertest.LogsSink) exporter.Factory {
+	return exporter.NewFactory(
+		component.MustNewType("sink"),
+		func() component.Config { return &struct{}{} },
+		exporter.WithLogs(func(_ context.Context, _ exporter.Settings, _ component.Config) (exporter.Logs, error) {
+			return &testComponent{Logs: sink}, nil
+		}, component.StabilityLevelDevelopment),
+	)
+}
+
+// newExclusiveReceiverFactory returns the factory of a receiver that fails to start while another
+// instance is running.
+func newExclusiveReceiverFactory(running *atomic.Bool) receiver.Factory {
+	return receiver.NewFactory(
+		component.MustNewType("exclusive"),
+		func() component.Config { return &struct{}{} },
+		receiver.WithLogs(func(context.Context, receiver.Settings, component.Config, consumer.Logs) (receiver.Logs, error) {
+			return &testComponent{
+				StartFunc: func(context.Context, component.Host) error {
+					if !running.CompareAndSwap(false, true) {
+						return fmt.Errorf("listen tcp 127.0.0.1:4317: bind: %w", syscall.EADDRINUSE)
+					}
+					return nil
+				},
+				ShutdownFunc: func(context.Context) error {
+					running.Store(false)
+					return nil
+				},
+			}, nil
+		}, component.StabilityLevelDevelopment),
+	)
+}
+
+// newFailingReceiverFactory returns the factory of a receiver that fails to start when fail is set.
+func newFailingReceiverFactory(fail *atomic.Bool, running *atomic.Int64) receiver.Factory {
+	return receiver.NewFactory(
+		component.MustNewType("failing"),
+		func() component.Config { return &struct{}{} },
+		receiver.WithLogs(func(context.Context, receiver.Settings, component.Config, consumer.Logs) (receiver.Logs, error) {
+			return &testComponent{
+				StartFunc: func(context.Context, component.Host) error {
+					if fail.Load() {
+						return errors.New("failed to start")
+					}
+					running.Add(1)
+					return nil
+				},
+				ShutdownFunc: func(context.Context) error {
+					running.Add(-1)
+					return nil
+				},
+			}, nil
+		}, component.StabilityLevelDevelopment),
+	)
+}
+
 func (m *mockedHost) Start(context.Context, component.Host) error {
 	return nil
 }